@@ -0,0 +1,48 @@
+package muxie
+
+import (
+	"net/http"
+)
+
+// AfterHook is the type of the functions that run after a request is served, see `Mux#After`.
+// The `LogEntry` holds the status code, the written bytes and the latency of the response.
+type AfterHook func(e *LogEntry)
+
+// After registers hooks that run after each request is served by the Mux, in the order they are registered,
+// with the status code, the written bytes, the latency and the matched route of the response, i.e for audit trails and metrics:
+// mux.After(func(e *muxie.LogEntry) { requests.WithLabelValues(e.Route, strconv.Itoa(e.Status)).Inc() })
+//
+// The hooks of the Mux which serves the requests, i.e the `NewMux` and the `Host` ones, run around the whole request dispatch,
+// even around the `Wrap` middlewares, so they see the not found requests and the responses of those middlewares too;
+// the hooks of a sub mux run for its routes that are registered after them, like the `Use` middlewares do.
+// The response is captured once for all of the hooks. They do not run for the requests that panic.
+func (m *Mux) After(hooks ...AfterHook) {
+	for _, hook := range hooks {
+		if hook == nil {
+			panic("muxie/Mux#After: empty hook")
+		}
+	}
+
+	if m.parent != nil && m.host == nil {
+		m.Use(afterHooksWrapper(hooks))
+		return
+	}
+
+	m.lock()
+	m.afterHooks = append(m.afterHooks, hooks...)
+	m.buildDispatch()
+	m.unlock()
+}
+
+func afterHooksWrapper(hooks []AfterHook) Wrapper {
+	hooks = append([]AfterHook(nil), hooks...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			e := serveEntry(next, w, r)
+			for _, hook := range hooks {
+				hook(e)
+			}
+		})
+	}
+}
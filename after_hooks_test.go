@@ -0,0 +1,88 @@
+package muxie
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMuxAfter(t *testing.T) {
+	var entries []LogEntry
+
+	mux := NewMux()
+	mux.After(func(e *LogEntry) {
+		entries = append(entries, *e)
+	})
+	mux.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("limited") != "" {
+				http.Error(w, "limited", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, RequestIDs(RequestIDGenerator(func() string { return "id" })))
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("user " + GetParam(w, "id")))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42").statusCode(http.StatusCreated).bodyEq("user 42")
+	testHandler(t, mux, http.MethodGet, "/missing").statusCode(http.StatusNotFound)
+	testHandler(t, mux, http.MethodGet, "/users/42?limited=1").statusCode(http.StatusTooManyRequests)
+
+	expected := []struct {
+		route     string
+		status    int
+		bytes     int64
+		requestID string
+	}{
+		{"/users/:id", http.StatusCreated, 7, "id"},
+		{"", http.StatusNotFound, 19, "id"},
+		{"", http.StatusTooManyRequests, 8, ""},
+	}
+
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries but got %d", len(expected), len(entries))
+	}
+
+	for i, e := range entries {
+		if e.Route != expected[i].route || e.Status != expected[i].status || e.Bytes != expected[i].bytes || e.RequestID != expected[i].requestID {
+			t.Fatalf("[%d] unexpected entry: %#+v", i, e)
+		}
+
+		if e.Latency < 0 || e.Time.IsZero() || e.Request == nil {
+			t.Fatalf("[%d] expected the latency, time and request of the entry", i)
+		}
+	}
+}
+
+func TestMuxAfterOrderAndSubMux(t *testing.T) {
+	var calls []string
+
+	mux := NewMux()
+	mux.After(func(e *LogEntry) { calls = append(calls, "first "+e.Route) })
+	mux.After(func(e *LogEntry) { calls = append(calls, "second "+e.Route) })
+
+	v1 := mux.Of("/v1")
+	v1.HandleFunc("/before", func(w http.ResponseWriter, r *http.Request) {})
+	v1.After(func(e *LogEntry) { calls = append(calls, "v1 "+e.Route) })
+	v1.HandleFunc("/after", func(w http.ResponseWriter, r *http.Request) {})
+
+	testHandler(t, mux, http.MethodGet, "/v1/before")
+	testHandler(t, mux, http.MethodGet, "/v1/after")
+
+	expected := []string{
+		"first /v1/before", "second /v1/before",
+		"v1 /v1/after", "first /v1/after", "second /v1/after",
+	}
+
+	if len(calls) != len(expected) {
+		t.Fatalf("expected calls: %q but got: %q", expected, calls)
+	}
+
+	for i := range calls {
+		if calls[i] != expected[i] {
+			t.Fatalf("expected calls: %q but got: %q", expected, calls)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package muxie
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// APIKeyHeader is the default header of the API keys, see `APIKeyAuth`.
+const APIKeyHeader = "X-API-Key"
+
+// APIKey is the metadata of a valid API key, the key itself is not kept.
+type APIKey struct {
+	// ID identifies the key without revealing it, i.e for the logs and the rate limits.
+	ID string
+	// Owner is the client that the key belongs to, i.e a user or a service.
+	Owner string
+	// Scopes are the permissions of the key, if any.
+	Scopes []string
+	// Metadata holds any other information of the key.
+	Metadata map[string]interface{}
+	// RateLimit, if not zero, is the rate limit hint of the key, see `RateLimitByAPIKey`.
+	RateLimit RateLimit
+}
+
+// HasScope reports whether the key has the "scope", see `Scopes`.
+func (k *APIKey) HasScope(scope string) bool {
+	return containsMethod(k.Scopes, scope)
+}
+
+// APIKeyLookup is the interface that the storages of the API keys implement, see `APIKeys`,
+// `APIKeyLookupFunc` and `CachedAPIKeys`.
+type APIKeyLookup interface {
+	// LookupAPIKey returns the metadata of the "key", nil if it is not valid,
+	// or an error if the lookup failed, i.e the database is down.
+	LookupAPIKey(r *http.Request, key string) (*APIKey, error)
+}
+
+// APIKeyLookupFunc is an `APIKeyLookup` of a function, i.e a database query.
+type APIKeyLookupFunc func(r *http.Request, key string) (*APIKey, error)
+
+// LookupAPIKey calls the function.
+func (fn APIKeyLookupFunc) LookupAPIKey(r *http.Request, key string) (*APIKey, error) {
+	return fn(r, key)
+}
+
+// APIKeys returns an `APIKeyLookup` of the fixed "keys", the API keys to their metadata.
+// The keys are looked up by their hashes, so their comparison does not depend on their contents.
+func APIKeys(keys map[string]*APIKey) APIKeyLookup {
+	hashed := make(map[[32]byte]*APIKey, len(keys))
+	for key, metadata := range keys {
+		if metadata == nil {
+			metadata = new(APIKey)
+		}
+		hashed[sha256.Sum256([]byte(key))] = metadata
+	}
+
+	return APIKeyLookupFunc(func(r *http.Request, key string) (*APIKey, error) {
+		return hashed[sha256.Sum256([]byte(key))], nil
+	})
+}
+
+// CachedAPIKeys returns an `APIKeyLookup` which caches the results of the "lookup", the valid and the invalid keys,
+// for the "ttl" duration, i.e to not query the database on each request. The failed lookups are not cached.
+func CachedAPIKeys(lookup APIKeyLookup, ttl time.Duration) APIKeyLookup {
+	if lookup == nil || ttl <= 0 {
+		panic("muxie/CachedAPIKeys: empty lookup or not positive ttl")
+	}
+
+	return &apiKeyCache{
+		lookup:  lookup,
+		ttl:     ttl,
+		entries: make(map[[32]byte]apiKeyCacheEntry),
+		now:     time.Now,
+	}
+}
+
+type apiKeyCacheEntry struct {
+	key     *APIKey
+	expires time.Time
+}
+
+type apiKeyCache struct {
+	lookup    APIKeyLookup
+	ttl       time.Duration
+	mu        sync.Mutex
+	entries   map[[32]byte]apiKeyCacheEntry
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func (c *apiKeyCache) LookupAPIKey(r *http.Request, key string) (*APIKey, error) {
+	hash := sha256.Sum256([]byte(key))
+
+	c.mu.Lock()
+	now := c.now()
+	if now.Sub(c.lastSweep) > c.ttl {
+		for h, entry := range c.entries {
+			if !entry.expires.After(now) {
+				delete(c.entries, h)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	entry, ok := c.entries[hash]
+	c.mu.Unlock()
+
+	if ok && entry.expires.After(now) {
+		return entry.key, nil
+	}
+
+	// the lookup runs without the lock, a key can be looked up twice at the same time.
+	metadata, err := c.lookup.LookupAPIKey(r, key)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[hash] = apiKeyCacheEntry{key: metadata, expires: now.Add(c.ttl)}
+	c.mu.Unlock()
+
+	return metadata, nil
+}
+
+// APIKeyOption is the type of the options that `APIKeyAuth` accepts.
+type APIKeyOption func(*apiKeyAuth)
+
+// APIKeyHeaderName is an `APIKeyOption` which sets the header of the API keys, an empty one disables it.
+// Defaults to the `APIKeyHeader`.
+func APIKeyHeaderName(header string) APIKeyOption {
+	return func(a *apiKeyAuth) {
+		a.header = header
+	}
+}
+
+// APIKeyQuery is an `APIKeyOption` which accepts the API keys from a query parameter too, i.e "api_key",
+// when the request has not the header. Note that the URLs are often logged, so the keys may leak through them.
+// Defaults to none.
+func APIKeyQuery(param string) APIKeyOption {
+	return func(a *apiKeyAuth) {
+		a.query = param
+	}
+}
+
+// APIKeyUnauthorized is an `APIKeyOption` which customizes the response of the requests without a valid API key.
+// Defaults to a plain text 401 Unauthorized response.
+func APIKeyUnauthorized(handler http.Handler) APIKeyOption {
+	return func(a *apiKeyAuth) {
+		a.unauthorized = handler
+	}
+}
+
+// APIKeyLookupFailed is an `APIKeyOption` which customizes the response of the requests that their key's lookup failed.
+// Defaults to a plain text 500 Internal Server Error response.
+func APIKeyLookupFailed(handler func(w http.ResponseWriter, r *http.Request, err error)) APIKeyOption {
+	return func(a *apiKeyAuth) {
+		a.failed = handler
+	}
+}
+
+type apiKeyAuth struct {
+	header       string
+	query        string
+	unauthorized http.Handler
+	failed       func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// APIKeyAuth returns a middleware which requires a valid API key on the "X-API-Key" header of the requests,
+// or on a query parameter through the `APIKeyQuery`, the keys are validated by the "lookup", i.e:
+// mux.Use(muxie.APIKeyAuth(muxie.APIKeys(map[string]*muxie.APIKey{os.Getenv("API_KEY"): {ID: "ci", Owner: "ci"}})))
+// mux.Use(muxie.APIKeyAuth(muxie.CachedAPIKeys(muxie.APIKeyLookupFunc(findKey), time.Minute)))
+//
+// The metadata of the key is retrieved by the `APIKeyFromContext`
+// and its rate limit hint is used by the `RateLimiter` through the `RateLimitByAPIKey`.
+func APIKeyAuth(lookup APIKeyLookup, options ...APIKeyOption) Wrapper {
+	if lookup == nil {
+		panic("muxie/APIKeyAuth: empty lookup")
+	}
+
+	a := &apiKeyAuth{header: APIKeyHeader}
+	for _, opt := range options {
+		opt(a)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := a.key(r)
+			if key == "" {
+				a.deny(w, r)
+				return
+			}
+
+			metadata, err := lookup.LookupAPIKey(r, key)
+			if err != nil {
+				if a.failed != nil {
+					a.failed(w, r, err)
+					return
+				}
+
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if metadata == nil {
+				a.deny(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, metadata)))
+		})
+	}
+}
+
+func (a *apiKeyAuth) key(r *http.Request) string {
+	if a.header != "" {
+		if key := r.Header.Get(a.header); key != "" {
+			return key
+		}
+	}
+
+	if a.query != "" {
+		return r.URL.Query().Get(a.query)
+	}
+
+	return ""
+}
+
+func (a *apiKeyAuth) deny(w http.ResponseWriter, r *http.Request) {
+	if a.unauthorized != nil {
+		a.unauthorized.ServeHTTP(w, r)
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+type apiKeyContextKey struct{}
+
+// APIKeyFromContext returns the metadata of the API key that the `APIKeyAuth` authenticated the request by, or nil.
+func APIKeyFromContext(r *http.Request) *APIKey {
+	key, _ := r.Context().Value(apiKeyContextKey{}).(*APIKey)
+	return key
+}
+
+// RateLimitByAPIKey is a `RateLimitOption` which limits the requests by the `APIKey#ID` of their `APIKeyAuth` key
+// and by its `APIKey#RateLimit`, if not zero, instead of the `RateLimiter`'s one, i.e:
+// mux.Use(muxie.APIKeyAuth(keys), muxie.RateLimiter(defaultLimit, muxie.RateLimitByAPIKey()))
+//
+// The requests without a key are limited by the client's IP.
+func RateLimitByAPIKey() RateLimitOption {
+	return func(l *rateLimiter) {
+		l.key = func(w http.ResponseWriter, r *http.Request) string {
+			if key := APIKeyFromContext(r); key != nil && key.ID != "" {
+				return "api-key:" + key.ID
+			}
+
+			return ""
+		}
+
+		l.limitOf = func(r *http.Request) (RateLimit, bool) {
+			if key := APIKeyFromContext(r); key != nil {
+				return key.RateLimit, true
+			}
+
+			return RateLimit{}, false
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package muxie
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	mux := NewMux()
+	mux.Use(APIKeyAuth(APIKeys(map[string]*APIKey{
+		"secret": {ID: "ci", Owner: "ci-bot", Scopes: []string{"read"}},
+	}), APIKeyQuery("api_key")))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		key := APIKeyFromContext(r)
+		if key.HasScope("write") {
+			t.Fatalf("unexpected scope")
+		}
+		w.Write([]byte(key.Owner))
+	})
+
+	serve := func(url, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("/", "secret"); rec.Code != http.StatusOK || rec.Body.String() != "ci-bot" {
+		t.Fatalf("expected the header key to be valid but got: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := serve("/?api_key=secret", ""); rec.Code != http.StatusOK || rec.Body.String() != "ci-bot" {
+		t.Fatalf("expected the query key to be valid but got: %d %s", rec.Code, rec.Body.String())
+	}
+
+	for _, tt := range []struct{ url, key string }{{"/", ""}, {"/", "wrong"}, {"/?api_key=wrong", ""}} {
+		if rec := serve(tt.url, tt.key); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s %q: expected status code: %d but got: %d", tt.url, tt.key, http.StatusUnauthorized, rec.Code)
+		}
+	}
+
+	// failed lookups.
+	failing := NewMux()
+	failing.Use(APIKeyAuth(APIKeyLookupFunc(func(r *http.Request, key string) (*APIKey, error) {
+		return nil, errors.New("db down")
+	}), APIKeyLookupFailed(func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	})))
+	failing.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(APIKeyHeader, "secret")
+	rec := httptest.NewRecorder()
+	failing.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "db down\n" {
+		t.Fatalf("expected the failed lookup response but got: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestCachedAPIKeys(t *testing.T) {
+	var (
+		lookups int
+		fail    bool
+	)
+
+	lookup := CachedAPIKeys(APIKeyLookupFunc(func(r *http.Request, key string) (*APIKey, error) {
+		lookups++
+		if fail {
+			return nil, errors.New("db down")
+		}
+
+		if key == "secret" {
+			return &APIKey{ID: "1"}, nil
+		}
+
+		return nil, nil
+	}), time.Minute)
+
+	now := time.Now()
+	lookup.(*apiKeyCache).now = func() time.Time { return now }
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for i := 0; i < 3; i++ {
+		if key, err := lookup.LookupAPIKey(r, "secret"); err != nil || key == nil || key.ID != "1" {
+			t.Fatalf("expected the valid key but got: %v %v", key, err)
+		}
+
+		if key, err := lookup.LookupAPIKey(r, "wrong"); err != nil || key != nil {
+			t.Fatalf("expected the invalid key but got: %v %v", key, err)
+		}
+	}
+
+	if expected := 2; lookups != expected {
+		t.Fatalf("expected %d lookups but got %d", expected, lookups)
+	}
+
+	// expired, the failures are not cached.
+	now = now.Add(time.Minute)
+	fail = true
+	if _, err := lookup.LookupAPIKey(r, "secret"); err == nil {
+		t.Fatalf("expected the lookup error")
+	}
+
+	fail = false
+	if key, _ := lookup.LookupAPIKey(r, "secret"); key == nil || lookups != 4 {
+		t.Fatalf("expected a new lookup but got: %v after %d lookups", key, lookups)
+	}
+}
+
+func TestRateLimitByAPIKey(t *testing.T) {
+	mux := NewMux()
+	mux.Use(APIKeyAuth(APIKeys(map[string]*APIKey{
+		"basic":   {ID: "basic"},
+		"premium": {ID: "premium", RateLimit: RateLimit{Requests: 3, Per: time.Minute}},
+	})), RateLimiter(RateLimit{Requests: 1, Per: time.Minute}, RateLimitByAPIKey()))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, expected := range []int{200, 429} {
+		if rec := serve("basic"); rec.Code != expected || rec.Header().Get("RateLimit-Limit") != "1" {
+			t.Fatalf("[%d] basic: expected status code: %d but got: %d", i, expected, rec.Code)
+		}
+	}
+
+	for i, expected := range []int{200, 200, 200, 429} {
+		if rec := serve("premium"); rec.Code != expected || rec.Header().Get("RateLimit-Limit") != "3" {
+			t.Fatalf("[%d] premium: expected status code: %d but got: %d", i, expected, rec.Code)
+		}
+	}
+}
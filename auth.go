@@ -0,0 +1,201 @@
+package muxie
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BasicValidator is the type of the functions that validate the credentials of the `BasicAuth`,
+// it returns the authenticated principal, i.e a user, and true if they are valid.
+type BasicValidator func(r *http.Request, username, password string) (interface{}, bool)
+
+// BearerValidator is the type of the functions that validate the tokens of the `BearerAuth`,
+// it returns the authenticated principal, i.e a user, and true if the "token" is valid.
+type BearerValidator func(r *http.Request, token string) (interface{}, bool)
+
+// BasicUsers returns a `BasicValidator` of the fixed "users", their usernames to their passwords,
+// the principal is the username. The credentials are compared in constant time.
+func BasicUsers(users map[string]string) BasicValidator {
+	hashed := make(map[string][32]byte, len(users))
+	for username, password := range users {
+		hashed[username] = sha256.Sum256([]byte(password))
+	}
+
+	return func(r *http.Request, username, password string) (interface{}, bool) {
+		expected, ok := hashed[username]
+		// compares the password even for the unknown users, so they can not be told apart by the response time.
+		given := sha256.Sum256([]byte(password))
+		if subtle.ConstantTimeCompare(expected[:], given[:]) != 1 || !ok {
+			return nil, false
+		}
+
+		return username, true
+	}
+}
+
+// BearerTokens returns a `BearerValidator` of the fixed "tokens", the principal is the token.
+// The tokens are compared in constant time.
+func BearerTokens(tokens ...string) BearerValidator {
+	hashed := make([][32]byte, len(tokens))
+	for i, token := range tokens {
+		hashed[i] = sha256.Sum256([]byte(token))
+	}
+
+	return func(r *http.Request, token string) (interface{}, bool) {
+		given := sha256.Sum256([]byte(token))
+
+		found := 0
+		for i := range hashed {
+			found |= subtle.ConstantTimeCompare(hashed[i][:], given[:])
+		}
+
+		if found != 1 {
+			return nil, false
+		}
+
+		return token, true
+	}
+}
+
+// AuthOption is the type of the options that `BasicAuth` and `BearerAuth` accept.
+type AuthOption func(*authenticator)
+
+// AuthRealm is an `AuthOption` which sets the realm of the "WWW-Authenticate" header. Defaults to "Restricted".
+func AuthRealm(realm string) AuthOption {
+	return func(a *authenticator) {
+		a.realm = realm
+	}
+}
+
+// AuthUnauthorized is an `AuthOption` which customizes the response of the unauthorized requests,
+// the "WWW-Authenticate" header is already set when it is called.
+// Defaults to a plain text 401 Unauthorized response.
+func AuthUnauthorized(handler http.Handler) AuthOption {
+	return func(a *authenticator) {
+		a.unauthorized = handler
+	}
+}
+
+// AuthStash is an `AuthOption` which customizes where the authenticated principal is stored,
+// i.e as a parameter through the `SetParam`, the returned request is passed to the next handler.
+// Defaults to the request's context, see `AuthPrincipal`.
+func AuthStash(stash func(w http.ResponseWriter, r *http.Request, principal interface{}) *http.Request) AuthOption {
+	return func(a *authenticator) {
+		a.stash = stash
+	}
+}
+
+type authenticator struct {
+	realm        string
+	unauthorized http.Handler
+	stash        func(w http.ResponseWriter, r *http.Request, principal interface{}) *http.Request
+}
+
+func newAuthenticator(options []AuthOption) *authenticator {
+	a := &authenticator{realm: "Restricted", stash: stashPrincipal}
+	for _, opt := range options {
+		opt(a)
+	}
+
+	return a
+}
+
+func (a *authenticator) deny(w http.ResponseWriter, r *http.Request, challenge string) {
+	w.Header().Set("WWW-Authenticate", challenge)
+
+	if a.unauthorized != nil {
+		a.unauthorized.ServeHTTP(w, r)
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// BasicAuth returns a middleware which requires the HTTP Basic authentication of the requests, i.e:
+// mux.Use(muxie.BasicAuth(muxie.BasicUsers(map[string]string{"admin": "secret"}), muxie.AuthRealm("Admin")))
+// The authenticated principal is retrieved by the `AuthPrincipal`, see `AuthStash` too.
+func BasicAuth(validator BasicValidator, options ...AuthOption) Wrapper {
+	if validator == nil {
+		panic("muxie/BasicAuth: empty validator")
+	}
+
+	a := newAuthenticator(options)
+	challenge := "Basic realm=" + strconv.Quote(a.realm) + `, charset="UTF-8"`
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			if !ok {
+				a.deny(w, r, challenge)
+				return
+			}
+
+			principal, ok := validator(r, username, password)
+			if !ok {
+				a.deny(w, r, challenge)
+				return
+			}
+
+			next.ServeHTTP(w, a.stash(w, r, principal))
+		})
+	}
+}
+
+// BearerAuth returns a middleware which requires a bearer token on the "Authorization" header of the requests, i.e:
+// mux.Use(muxie.BearerAuth(muxie.BearerTokens(os.Getenv("API_TOKEN"))))
+// The authenticated principal is retrieved by the `AuthPrincipal`, see `AuthStash` too.
+func BearerAuth(validator BearerValidator, options ...AuthOption) Wrapper {
+	if validator == nil {
+		panic("muxie/BearerAuth: empty validator")
+	}
+
+	a := newAuthenticator(options)
+	challenge := "Bearer realm=" + strconv.Quote(a.realm)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				a.deny(w, r, challenge)
+				return
+			}
+
+			principal, ok := validator(r, token)
+			if !ok {
+				a.deny(w, r, challenge+`, error="invalid_token"`)
+				return
+			}
+
+			next.ServeHTTP(w, a.stash(w, r, principal))
+		})
+	}
+}
+
+// bearerToken returns the token of the request's "Authorization: Bearer <token>" header, the scheme is case insensitive.
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "bearer "
+
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(auth[len(prefix):])
+	return token, token != ""
+}
+
+type principalContextKey struct{}
+
+func stashPrincipal(w http.ResponseWriter, r *http.Request, principal interface{}) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalContextKey{}, principal))
+}
+
+// AuthPrincipal returns the principal that the `BasicAuth` or the `BearerAuth` authenticated the request for,
+// i.e the username of the `BasicUsers`, or nil.
+func AuthPrincipal(r *http.Request) interface{} {
+	return r.Context().Value(principalContextKey{})
+}
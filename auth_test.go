@@ -0,0 +1,59 @@
+package muxie
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBasicAuth(t *testing.T) {
+	mux := NewMux()
+	mux.Use(BasicAuth(BasicUsers(map[string]string{"admin": "secret"}), AuthRealm("Admin")))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "hello %v", AuthPrincipal(r))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	auth := func(username, password string) func(*http.Request) {
+		return func(r *http.Request) {
+			r.SetBasicAuth(username, password)
+		}
+	}
+
+	expect(t, http.MethodGet, srv.URL).statusCode(http.StatusUnauthorized).bodyEq("Unauthorized\n").
+		headerEq("WWW-Authenticate", `Basic realm="Admin", charset="UTF-8"`)
+	expect(t, http.MethodGet, srv.URL, auth("admin", "wrong")).statusCode(http.StatusUnauthorized)
+	expect(t, http.MethodGet, srv.URL, auth("guest", "secret")).statusCode(http.StatusUnauthorized)
+	expect(t, http.MethodGet, srv.URL, auth("admin", "secret")).statusCode(http.StatusOK).bodyEq("hello admin")
+}
+
+func TestBearerAuth(t *testing.T) {
+	mux := NewMux()
+	mux.Use(BearerAuth(BearerTokens("token1", "token2"),
+		AuthStash(func(w http.ResponseWriter, r *http.Request, principal interface{}) *http.Request {
+			SetParam(w, "token", principal.(string))
+			return r
+		}),
+		AuthUnauthorized(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("login first"))
+		}))))
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s by %s", GetParam(w, "id"), GetParam(w, "token"))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL+"/users/42").statusCode(http.StatusUnauthorized).bodyEq("login first").
+		headerEq("WWW-Authenticate", `Bearer realm="Restricted"`)
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("Authorization", "Bearer token3")).
+		statusCode(http.StatusUnauthorized).headerEq("WWW-Authenticate", `Bearer realm="Restricted", error="invalid_token"`)
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("Authorization", "Basic token1")).
+		statusCode(http.StatusUnauthorized)
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("Authorization", "bearer token2")).
+		statusCode(http.StatusOK).bodyEq("42 by token2")
+}
@@ -0,0 +1,301 @@
+package muxie
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FormTag is the struct field tag that `BindBody` reads the form field names from.
+const FormTag = "form"
+
+// BindOption is the type of the options that `BindBody` accepts.
+type BindOption func(*bodyBinder)
+
+// BindMaxSize is a `BindOption` which sets the maximum size, in bytes, of the request bodies,
+// the larger ones fail with the `ErrBodyTooLarge`. Defaults to 10MB.
+func BindMaxSize(size int64) BindOption {
+	return func(b *bodyBinder) {
+		b.maxSize = size
+	}
+}
+
+// BindStrict is a `BindOption` which rejects the JSON bodies with fields that the destination has not
+// or with more data after their value.
+func BindStrict() BindOption {
+	return func(b *bodyBinder) {
+		b.strict = true
+	}
+}
+
+type bodyBinder struct {
+	maxSize int64
+	strict  bool
+}
+
+var (
+	// ErrUnsupportedMediaType is the error that `BindBody` returns for the requests that their Content-Type
+	// is missing or it is not one of the supported ones, see `ErrorStatus`.
+	ErrUnsupportedMediaType = errors.New("muxie: unsupported request content type")
+	// ErrEmptyBody is the error of the `BindError` that `BindBody` returns for the requests without a body.
+	ErrEmptyBody = errors.New("muxie: empty request body")
+	// ErrFieldRequired is the error of the `BindError` that `BindBody` returns for a missing form field
+	// which its `FormTag` has the "required" option.
+	ErrFieldRequired = errors.New("muxie: required field is missing")
+)
+
+// BindError is the error that `BindBody` returns for the request bodies that can not be decoded,
+// the `ErrorStatus` of it is 400 Bad Request.
+type BindError struct {
+	// Field is the name of the invalid field, if it is known, i.e "address.city".
+	Field string
+	// Err is the decoding error, i.e a *json.SyntaxError.
+	Err error
+}
+
+func (e *BindError) Error() string {
+	switch {
+	case e.Err == ErrEmptyBody:
+		return e.Err.Error()
+	case e.Err == ErrFieldRequired:
+		return "muxie: required field \"" + e.Field + "\" of the request body is missing"
+	case e.Field != "":
+		return "muxie: invalid field \"" + e.Field + "\" of the request body: " + e.Err.Error()
+	default:
+		return "muxie: invalid request body: " + e.Err.Error()
+	}
+}
+
+// Unwrap returns the decoding error.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the 400 Bad Request.
+func (e *BindError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// BindBody decodes the request body to the value that "ptr" points to, by the Content-Type of the request,
+// the JSON ones ("application/json" and "+json"), the XML ones ("application/xml", "text/xml" and "+xml"),
+// the "application/x-www-form-urlencoded" and the "multipart/form-data" ones, i.e:
+// mux.HandleE("/users", func(w http.ResponseWriter, r *http.Request) error {
+// var user User
+// if err := muxie.BindBody(r, &user, muxie.BindStrict()); err != nil {
+// return err // 400 Bad Request, 413 or 415 through the mux's `ErrorMapper`.
+// }
+// [...]
+//
+// The forms are bound to the fields of a struct by their `FormTag`, i.e `form:"email,required"`,
+// the fields can be of the types that `Param` supports, pointers or slices of them, and *multipart.FileHeader
+// or []*multipart.FileHeader for the uploaded files. A missing or empty form field leaves its field untouched.
+// It returns a `*BindError` for the invalid bodies, the `ErrUnsupportedMediaType` for the not supported Content-Types
+// and an error which wraps the `ErrBodyTooLarge` for the large ones, see `BindMaxSize` and `ErrorStatus`.
+//
+// The `Bind` decodes the body through a specific `Binder` instead.
+func BindBody(r *http.Request, ptr interface{}, options ...BindOption) error {
+	b := &bodyBinder{maxSize: 10 << 20}
+	for _, opt := range options {
+		opt(b)
+	}
+
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		panic("muxie/BindBody: expected a pointer but got " + reflect.TypeOf(ptr).String())
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ErrUnsupportedMediaType
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return &BindError{Err: ErrEmptyBody}
+	}
+
+	if b.maxSize > 0 {
+		if r.ContentLength > b.maxSize {
+			return ErrBodyTooLarge
+		}
+		r.Body = limitedBody{http.MaxBytesReader(nil, r.Body, b.maxSize)}
+	}
+
+	switch {
+	case contentType == "application/json" || strings.HasSuffix(contentType, "+json"):
+		return b.bindJSON(r.Body, ptr)
+	case contentType == "application/xml" || contentType == "text/xml" || strings.HasSuffix(contentType, "+xml"):
+		return bindError(xml.NewDecoder(r.Body).Decode(ptr))
+	case contentType == "application/x-www-form-urlencoded", contentType == "multipart/form-data":
+		if v.Elem().Kind() != reflect.Struct {
+			panic("muxie/BindBody: expected a pointer to a struct but got " + v.Type().String())
+		}
+
+		var files map[string][]*multipart.FileHeader
+		if contentType == "multipart/form-data" {
+			if err = r.ParseMultipartForm(32 << 20); err != nil {
+				return bindError(err)
+			}
+			files = r.MultipartForm.File
+		} else if err = r.ParseForm(); err != nil {
+			return bindError(err)
+		}
+
+		return bindForm(v.Elem(), r.PostForm, files)
+	default:
+		return ErrUnsupportedMediaType
+	}
+}
+
+func (b *bodyBinder) bindJSON(body io.Reader, ptr interface{}) error {
+	dec := json.NewDecoder(body)
+	if b.strict {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(ptr); err != nil {
+		return bindError(err)
+	}
+
+	if b.strict && dec.More() {
+		return &BindError{Err: errors.New("unexpected data after the JSON value")}
+	}
+
+	return nil
+}
+
+// bindError returns the "err" of a decoder as a `*BindError`, with its field if it is known,
+// the `ErrBodyTooLarge` errors are kept as they are.
+func bindError(err error) error {
+	if err == nil || errors.Is(err, ErrBodyTooLarge) {
+		return err
+	}
+
+	if err == io.EOF {
+		return &BindError{Err: ErrEmptyBody}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return &BindError{Field: typeErr.Field, Err: err}
+	}
+
+	// the json package has not a type for the errors of the unknown fields.
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if unquoted, unquoteErr := strconv.Unquote(field); unquoteErr == nil {
+			field = unquoted
+		}
+		return &BindError{Field: field, Err: err}
+	}
+
+	return &BindError{Err: err}
+}
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+func bindForm(v reflect.Value, form url.Values, files map[string][]*multipart.FileHeader) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, hasTag := field.Tag.Lookup(FormTag)
+
+		if !hasTag {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := bindForm(v.Field(i), form, files); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		key, opts := tag, ""
+		if idx := strings.IndexByte(tag, ','); idx != -1 {
+			key, opts = tag[:idx], tag[idx+1:]
+		}
+
+		if key == "-" || field.PkgPath != "" { // skipped or unexported.
+			continue
+		}
+
+		if key == "" {
+			key = field.Name
+		}
+
+		fieldValue := v.Field(i)
+		if fieldValue.Type() == fileHeaderType || fieldValue.Type() == fileHeadersType {
+			fileHeaders := files[key]
+			if len(fileHeaders) == 0 {
+				if opts == "required" {
+					return &BindError{Field: key, Err: ErrFieldRequired}
+				}
+				continue
+			}
+
+			if fieldValue.Type() == fileHeaderType {
+				fieldValue.Set(reflect.ValueOf(fileHeaders[0]))
+			} else {
+				fieldValue.Set(reflect.ValueOf(fileHeaders))
+			}
+			continue
+		}
+
+		values := form[key]
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			if opts == "required" {
+				return &BindError{Field: key, Err: ErrFieldRequired}
+			}
+			continue
+		}
+
+		if fieldValue.Kind() == reflect.Slice {
+			slice := reflect.MakeSlice(fieldValue.Type(), 0, len(values))
+			for _, value := range values {
+				elem := reflect.New(fieldValue.Type().Elem()).Elem()
+				if err := bindFormValue(elem, value); err != nil {
+					return &BindError{Field: key, Err: err}
+				}
+				slice = reflect.Append(slice, elem)
+			}
+			fieldValue.Set(slice)
+			continue
+		}
+
+		if err := bindFormValue(fieldValue, values[0]); err != nil {
+			return &BindError{Field: key, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func bindFormValue(v reflect.Value, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := bindFormValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	err := parseParam(v.Addr().Interface(), value)
+	if numErr, ok := err.(*strconv.NumError); ok {
+		err = numErr.Err
+	}
+
+	return err
+}
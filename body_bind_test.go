@@ -0,0 +1,137 @@
+package muxie
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindUser struct {
+	Name  string   `json:"name" xml:"name" form:"name,required"`
+	Age   int      `json:"age" xml:"age" form:"age"`
+	Admin *bool    `json:"admin" xml:"admin" form:"admin"`
+	Tags  []string `json:"tags" xml:"tag" form:"tag"`
+}
+
+func newBindRequest(contentType, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+func TestBindBody(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/json; charset=utf-8", `{"name":"kataras","age":25,"admin":true,"tags":["a","b"]}`},
+		{"application/vnd.api+json", `{"name":"kataras","age":25,"admin":true,"tags":["a","b"]}`},
+		{"application/xml", `<user><name>kataras</name><age>25</age><admin>true</admin><tag>a</tag><tag>b</tag></user>`},
+		{"text/xml", `<user><name>kataras</name><age>25</age><admin>true</admin><tag>a</tag><tag>b</tag></user>`},
+		{"application/x-www-form-urlencoded", `name=kataras&age=25&admin=true&tag=a&tag=b`},
+	}
+
+	for _, tt := range tests {
+		var user bindUser
+		if err := BindBody(newBindRequest(tt.contentType, tt.body), &user); err != nil {
+			t.Fatalf("[%s] %v", tt.contentType, err)
+		}
+
+		if user.Name != "kataras" || user.Age != 25 || user.Admin == nil || !*user.Admin || strings.Join(user.Tags, ",") != "a,b" {
+			t.Fatalf("[%s] unexpected value: %#+v", tt.contentType, user)
+		}
+	}
+}
+
+func TestBindBodyMultipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("name", "kataras")
+	file, _ := mw.CreateFormFile("avatar", "avatar.png")
+	file.Write([]byte("png"))
+	mw.Close()
+
+	var upload struct {
+		bindUser
+		Avatar *multipart.FileHeader `form:"avatar,required"`
+	}
+	if err := BindBody(newBindRequest(mw.FormDataContentType(), body.String()), &upload); err != nil {
+		t.Fatal(err)
+	}
+
+	if upload.Name != "kataras" || upload.Avatar == nil || upload.Avatar.Filename != "avatar.png" {
+		t.Fatalf("unexpected value: %#+v", upload)
+	}
+}
+
+func TestBindBodyErrors(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		options     []BindOption
+		field       string
+		expected    error
+		status      int
+	}{
+		{"", `{}`, nil, "", ErrUnsupportedMediaType, http.StatusUnsupportedMediaType},
+		{"text/csv", `name`, nil, "", ErrUnsupportedMediaType, http.StatusUnsupportedMediaType},
+		{"application/json", ``, nil, "", ErrEmptyBody, http.StatusBadRequest},
+		{"application/json", `{"name":`, nil, "", io.ErrUnexpectedEOF, http.StatusBadRequest},
+		{"application/json", `{"age":"old"}`, nil, "age", nil, http.StatusBadRequest},
+		{"application/json", `{"name":"kataras","email":"x"}`, []BindOption{BindStrict()}, "email", nil, http.StatusBadRequest},
+		{"application/json", `{"name":"kataras"} {}`, []BindOption{BindStrict()}, "", nil, http.StatusBadRequest},
+		{"application/json", `{"name":"` + strings.Repeat("a", 64) + `"}`, []BindOption{BindMaxSize(32)}, "", ErrBodyTooLarge, http.StatusRequestEntityTooLarge},
+		{"application/x-www-form-urlencoded", `age=25`, nil, "name", ErrFieldRequired, http.StatusBadRequest},
+		{"application/x-www-form-urlencoded", `name=kataras&age=old`, nil, "age", nil, http.StatusBadRequest},
+	}
+
+	for i, tt := range tests {
+		var user bindUser
+		err := BindBody(newBindRequest(tt.contentType, tt.body), &user, tt.options...)
+		if err == nil {
+			t.Fatalf("[%d] expected an error", i)
+		}
+
+		if tt.expected != nil && !errors.Is(err, tt.expected) {
+			t.Fatalf("[%d] expected error: %v but got: %v", i, tt.expected, err)
+		}
+
+		var bindErr *BindError
+		if errors.As(err, &bindErr) && bindErr.Field != tt.field {
+			t.Fatalf("[%d] expected field: %q but got: %q", i, tt.field, bindErr.Field)
+		}
+
+		if got := ErrorStatus(err); tt.status != got {
+			t.Fatalf("[%d] expected status: %d but got: %d (%v)", i, tt.status, got, err)
+		}
+	}
+}
+
+func TestBindBodyHandleE(t *testing.T) {
+	mux := NewMux()
+	mux.HandleE("/users", func(w http.ResponseWriter, r *http.Request) error {
+		var user bindUser
+		if err := BindBody(r, &user); err != nil {
+			return err
+		}
+
+		_, err := w.Write([]byte(user.Name))
+		return err
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, newBindRequest("application/x-www-form-urlencoded", "age=25"))
+	if expected, got := http.StatusBadRequest, rec.Code; expected != got {
+		t.Fatalf("expected status: %d but got: %d", expected, got)
+	}
+	if expected, got := "muxie: required field \"name\" of the request body is missing\n", rec.Body.String(); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+}
@@ -0,0 +1,63 @@
+package muxie
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrBodyTooLarge is the error that the request bodies return when they exceed the `Mux#MaxBodySize`
+// or the `Route#MaxBodySize`, it wraps the `*http.MaxBytesError` as well. Use `errors.Is` to check for it.
+var ErrBodyTooLarge = errors.New("muxie: request body too large")
+
+// MaxBodySize sets the maximum size, in bytes, of the route's request bodies, it overrides the `Mux#MaxBodySize`, i.e:
+// mux.MaxBodySize = 1 << 20
+// mux.HandleFunc("/upload", upload).MaxBodySize(100 << 20)
+//
+// A zero "limit" removes the limit of the route.
+// Returns this Route for further calls.
+func (r *Route) MaxBodySize(limit int64) *Route {
+	if limit < 0 {
+		panic("muxie/Route#MaxBodySize: negative limit for \"" + r.pattern + "\"")
+	}
+
+	r.mux.lock()
+	r.handler.bodyLimit = limit
+	r.handler.hasBodyLimit = true
+	r.mux.unlock()
+
+	return r
+}
+
+// limitBody limits the request body of "r" to "limit" bytes through the `http.MaxBytesReader`,
+// it responds to the `ErrBodyTooLarge` through the mux's `ErrorMapper`, 413 Request Entity Too Large by default,
+// and it returns false if the request's Content-Length exceeds it.
+func limitBody(m *Mux, w http.ResponseWriter, r *http.Request, limit int64) bool {
+	if r.ContentLength > limit {
+		m.errorMapper()(w, r, ErrBodyTooLarge)
+		return false
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = limitedBody{http.MaxBytesReader(w, r.Body, limit)}
+	}
+
+	return true
+}
+
+// limitedBody marks the errors of the `http.MaxBytesReader` with the `ErrBodyTooLarge`.
+type limitedBody struct {
+	io.ReadCloser
+}
+
+func (b limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		err = fmt.Errorf("%w: %w", ErrBodyTooLarge, err)
+	}
+
+	return n, err
+}
@@ -0,0 +1,73 @@
+package muxie
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	var handled bool
+
+	mux := NewMux()
+	mux.MaxBodySize = 8
+	read := func(w http.ResponseWriter, r *http.Request) {
+		handled = true
+		b, err := io.ReadAll(r.Body)
+		if errors.Is(err, ErrBodyTooLarge) {
+			http.Error(w, "too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		w.Write(b)
+	}
+	mux.HandleFunc("/small", read)
+	mux.HandleFunc("/upload", read).MaxBodySize(16)
+	mux.HandleFunc("/unlimited", read).MaxBodySize(0)
+	mux.Of("/api").HandleFunc("/small", read)
+
+	serve := func(path, body string, chunked bool) *httptest.ResponseRecorder {
+		handled = false
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if chunked {
+			r.ContentLength = -1
+		}
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	var tests = []struct {
+		path    string
+		body    string
+		chunked bool
+		status  int
+		handled bool
+	}{
+		{"/small", "12345678", false, http.StatusOK, true},
+		{"/small", "123456789", false, http.StatusRequestEntityTooLarge, false},
+		{"/small", "123456789", true, http.StatusRequestEntityTooLarge, true},
+		{"/api/small", "123456789", false, http.StatusRequestEntityTooLarge, false},
+		{"/upload", "123456789", false, http.StatusOK, true},
+		{"/upload", strings.Repeat("1", 17), false, http.StatusRequestEntityTooLarge, false},
+		{"/unlimited", strings.Repeat("1", 1024), true, http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		w := serve(tt.path, tt.body, tt.chunked)
+		if w.Code != tt.status {
+			t.Fatalf("%s [%d bytes]: expected status code: %d but got: %d", tt.path, len(tt.body), tt.status, w.Code)
+		}
+
+		if handled != tt.handled {
+			t.Fatalf("%s [%d bytes]: expected the handler to run: %v", tt.path, len(tt.body), tt.handled)
+		}
+
+		if tt.status == http.StatusOK && w.Body.String() != tt.body {
+			t.Fatalf("%s: expected body: '%s' but got: '%s'", tt.path, tt.body, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package muxie
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// BufferOption is the type of the options that `Buffer` accepts.
+type BufferOption func(*bufferer)
+
+// BufferMaxSize is a `BufferOption` which sets the maximum size of the buffered response bodies,
+// a larger response is sent as it is written, without the buffering. Defaults to 0, no limit.
+func BufferMaxSize(size int) BufferOption {
+	return func(b *bufferer) {
+		b.maxSize = size
+	}
+}
+
+// BufferTransform is a `BufferOption` which registers a function that transforms the whole buffered response
+// before it is sent, it can modify the "header" and it returns the status code and the body that are sent.
+// The transforms run in the order they are registered.
+func BufferTransform(transform func(r *http.Request, status int, header http.Header, body []byte) (int, []byte)) BufferOption {
+	return func(b *bufferer) {
+		b.transforms = append(b.transforms, transform)
+	}
+}
+
+type bufferer struct {
+	maxSize    int
+	transforms []func(r *http.Request, status int, header http.Header, body []byte) (int, []byte)
+}
+
+// Buffer returns a middleware which holds the status code, the headers and the body of the responses
+// until the handler returns, i.e:
+// mux.Use(muxie.Buffer())
+// mux.HandleFunc("/report", report).Use(muxie.Buffer(muxie.BufferTransform(minify)))
+//
+// So the handlers can still set headers after they write the body, the `BufferTransform`s can modify the whole response
+// and a failing handler can replace what it has written with an error response, see `ResetResponse`,
+// the errors of the `HandlerE`s replace it automatically. The buffered responses are sent with their Content-Length.
+// A `Flush` sends the buffered response and it stops the buffering, i.e for the streaming handlers.
+func Buffer(options ...BufferOption) Wrapper {
+	b := new(bufferer)
+	for _, opt := range options {
+		opt(b)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bw := &bufferWriter{ResponseWriter: w, bufferer: b, initial: w.Header().Clone()}
+			next.ServeHTTP(bw, r)
+			bw.finish(r)
+		})
+	}
+}
+
+// ContentLength returns a middleware which sends the responses of up to "maxSize" bytes with their Content-Length header,
+// instead of the chunked transfer encoding that the net/http uses for the responses which exceed its 2KB buffer, i.e:
+// mux.Use(muxie.ContentLength(64 << 10))
+//
+// It is the `Buffer` with the `BufferMaxSize`, the larger responses and the flushed ones are sent as they are written.
+// A zero "maxSize" defaults to 64KB. The HEAD requests which are served by the GET handlers are sent
+// with the Content-Length of their GET responses even without it.
+func ContentLength(maxSize int) Wrapper {
+	if maxSize <= 0 {
+		maxSize = 64 << 10
+	}
+
+	return Buffer(BufferMaxSize(maxSize))
+}
+
+// ResetResponse discards the status code, the body and the headers that the handler has set to a buffered response,
+// the headers of the middlewares before the `Buffer` are kept, so the handler can write a new response, i.e an error one.
+// It returns false if the response is not buffered or it is already sent.
+func ResetResponse(w http.ResponseWriter) bool {
+	bw := findBufferWriter(w)
+	if bw == nil || bw.passThrough {
+		return false
+	}
+
+	bw.status = 0
+	bw.buf.Reset()
+
+	h := bw.Header()
+	for key := range h {
+		delete(h, key)
+	}
+	for key, values := range bw.initial {
+		h[key] = append([]string(nil), values...)
+	}
+
+	return true
+}
+
+// bufferWriter holds a response until the handler returns, see `Buffer`.
+type bufferWriter struct {
+	http.ResponseWriter
+	bufferer *bufferer
+	// the headers before the handler, see `ResetResponse`.
+	initial http.Header
+
+	status      int
+	buf         bytes.Buffer
+	passThrough bool
+}
+
+var _ Unwrapper = (*bufferWriter)(nil)
+
+func (w *bufferWriter) WriteHeader(statusCode int) {
+	if w.passThrough || (statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols) {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	if w.status == 0 {
+		w.status = statusCode
+	}
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if w.passThrough {
+		return w.ResponseWriter.Write(b)
+	}
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if max := w.bufferer.maxSize; max > 0 && w.buf.Len()+len(b) > max {
+		if err := w.release(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	return w.buf.Write(b)
+}
+
+// release sends the buffered response as it is and it stops the buffering.
+func (w *bufferWriter) release() error {
+	w.passThrough = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// Flush sends the buffered response and the rest of it is sent as it is written.
+func (w *bufferWriter) Flush() {
+	if !w.passThrough {
+		w.release()
+	}
+
+	flushWriter(w.ResponseWriter)
+}
+
+// Unwrap returns the underline http.ResponseWriter.
+func (w *bufferWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *bufferWriter) finish(r *http.Request) {
+	if w.passThrough {
+		return
+	}
+
+	if w.status == 0 && w.buf.Len() == 0 && len(w.bufferer.transforms) == 0 {
+		// nothing is written, let the net/http send its default response.
+		return
+	}
+
+	status, body := w.status, w.buf.Bytes()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	h := w.Header()
+	for _, transform := range w.bufferer.transforms {
+		status, body = transform(r, status, h, body)
+	}
+
+	w.passThrough = true
+	setContentLength(h, status, int64(len(body)))
+
+	w.ResponseWriter.WriteHeader(status)
+	if len(body) > 0 {
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// bodyAllowed reports whether a response of the status code can have a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// findBufferWriter returns the first *bufferWriter of the "w"'s `Unwrapper` chain, if any.
+func findBufferWriter(w http.ResponseWriter) *bufferWriter {
+	for w != nil {
+		if bw, ok := w.(*bufferWriter); ok {
+			return bw
+		}
+
+		u, ok := w.(Unwrapper)
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+
+	return nil
+}
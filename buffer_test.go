@@ -0,0 +1,112 @@
+package muxie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Before", "kept")
+			next.ServeHTTP(w, r)
+		})
+	}, Buffer())
+	mux.HandleFunc("/late", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("body"))
+		w.Header().Set("X-Late", "set after the body")
+	})
+	mux.HandleE("/fails", func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("id,name\n1,"))
+		return errors.New("query failed")
+	})
+	mux.HandleFunc("/reset", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Handler", "discarded")
+		w.Write([]byte("partial"))
+		if !ResetResponse(w) {
+			t.Fatalf("expected the response to be reset")
+		}
+		w.WriteHeader(http.StatusConflict)
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		if ResetResponse(w) {
+			t.Fatalf("expected a flushed response to not be reset")
+		}
+		w.Write([]byte(" second"))
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+
+	testHandler(t, mux, http.MethodGet, "/late").statusCode(http.StatusAccepted).bodyEq("body").
+		headerEq("X-Late", "set after the body").headerEq("Content-Length", "4")
+	testHandler(t, mux, http.MethodGet, "/fails").statusCode(http.StatusInternalServerError).
+		bodyEq("Internal Server Error\n").headerEq("Content-Type", "text/plain; charset=utf-8").headerEq("X-Before", "kept")
+	testHandler(t, mux, http.MethodGet, "/reset").statusCode(http.StatusConflict).bodyEq("").
+		headerEq("X-Handler", "").headerEq("X-Before", "kept")
+	testHandler(t, mux, http.MethodGet, "/empty").statusCode(http.StatusOK).bodyEq("")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	if !rec.Flushed || rec.Body.String() != "first second" {
+		t.Fatalf("expected the flushed stream but got: %s", rec.Body.String())
+	}
+}
+
+func TestBufferTransformAndMaxSize(t *testing.T) {
+	mux := NewMux()
+	mux.Use(Buffer(
+		BufferMaxSize(16),
+		BufferTransform(func(r *http.Request, status int, header http.Header, body []byte) (int, []byte) {
+			header.Set("X-Original-Length", fmt.Sprint(len(body)))
+			return status, bytes.ToUpper(body)
+		}),
+		BufferTransform(func(r *http.Request, status int, header http.Header, body []byte) (int, []byte) {
+			if len(body) == 0 {
+				return http.StatusNoContent, nil
+			}
+			return status, append(body, '!')
+		}),
+	))
+	mux.HandleFunc("/hello/:name", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "hello %s", GetParam(w, "name"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/hello/muxie").bodyEq("HELLO MUXIE!").
+		headerEq("X-Original-Length", "11").headerEq("Content-Length", "12")
+	// larger than the max size, sent without the transforms.
+	testHandler(t, mux, http.MethodGet, "/hello/a-very-long-name").bodyEq("hello a-very-long-name").
+		headerEq("X-Original-Length", "")
+	// the transforms run for the empty responses too.
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+	testHandler(t, mux, http.MethodGet, "/empty").statusCode(http.StatusNoContent).headerEq("Content-Length", "")
+}
+
+func TestContentLength(t *testing.T) {
+	mux := NewMux()
+	mux.Use(ContentLength(16 << 10))
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), 8<<10))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), 32<<10))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for path, expected := range map[string]int64{"/small": 8 << 10, "/large": -1} {
+		resp := expect(t, http.MethodGet, srv.URL+path).statusCode(http.StatusOK).resp
+		if got := resp.ContentLength; expected != got {
+			t.Fatalf("%s: expected content length: %d but got: %d", path, expected, got)
+		}
+		resp.Body.Close()
+	}
+}
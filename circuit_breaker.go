@@ -0,0 +1,314 @@
+package muxie
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a `CircuitBreaker`.
+type CircuitState int
+
+const (
+	// CircuitClosed is the state of a healthy circuit, the requests are served.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen is the state of a failing circuit, the requests are rejected with 503 Service Unavailable.
+	CircuitOpen
+	// CircuitHalfOpen is the state after the `CircuitOpenTimeout`, a few probe requests are served
+	// to decide whether the circuit is closed or opened again, the rest ones are rejected.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerOption is the type of the options that `NewCircuitBreaker` accepts.
+type CircuitBreakerOption func(*CircuitBreaker)
+
+// CircuitFailureRate is a `CircuitBreakerOption` which sets the rate of the failed requests, from 0 to 1,
+// that opens the circuit. Defaults to 0.5.
+func CircuitFailureRate(rate float64) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.failureRate = rate
+	}
+}
+
+// CircuitMinRequests is a `CircuitBreakerOption` which sets the number of the requests of a `CircuitWindow`
+// before the failure rate is checked, so a few failures of a quiet route do not open its circuit. Defaults to 20.
+func CircuitMinRequests(n int) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.minRequests = n
+	}
+}
+
+// CircuitWindow is a `CircuitBreakerOption` which sets the duration that the requests are counted for,
+// the counts of a closed circuit are reset after it. Defaults to 10 seconds.
+func CircuitWindow(window time.Duration) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.window = window
+	}
+}
+
+// CircuitSlowCall is a `CircuitBreakerOption` which counts the requests that are served slower than the "threshold"
+// as failed too, i.e for a downstream service which does not fail but it stalls. Defaults to 0, disabled.
+func CircuitSlowCall(threshold time.Duration) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.slowCall = threshold
+	}
+}
+
+// CircuitOpenTimeout is a `CircuitBreakerOption` which sets the duration that the circuit stays open
+// before it becomes half-open. Defaults to 30 seconds.
+func CircuitOpenTimeout(timeout time.Duration) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.openTimeout = timeout
+	}
+}
+
+// CircuitHalfOpenRequests is a `CircuitBreakerOption` which sets the number of the probe requests of a half-open circuit,
+// the circuit is closed if all of them succeed. Defaults to 1.
+func CircuitHalfOpenRequests(n int) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.halfOpenRequests = n
+	}
+}
+
+// CircuitIsFailure is a `CircuitBreakerOption` which decides whether a response of the status code is a failure.
+// Defaults to the 5xx status codes. The panics are always failures.
+func CircuitIsFailure(isFailure func(statusCode int) bool) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.isFailure = isFailure
+	}
+}
+
+// CircuitResponse is a `CircuitBreakerOption` which customizes the response of the rejected requests,
+// the "Retry-After" header is already set when it is called.
+// Defaults to a plain text 503 Service Unavailable response.
+func CircuitResponse(handler http.Handler) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.handler = handler
+	}
+}
+
+// CircuitOnStateChange is a `CircuitBreakerOption` which registers a function that is called when the state changes,
+// i.e to log it or to update a metric. It is called while the breaker is locked, it should not call its methods.
+func CircuitOnStateChange(onChange func(from, to CircuitState)) CircuitBreakerOption {
+	return func(cb *CircuitBreaker) {
+		cb.onChange = onChange
+	}
+}
+
+// CircuitCounts are the counts of the requests of the current `CircuitWindow` or state, see `CircuitBreaker#Counts`.
+type CircuitCounts struct {
+	Requests  int
+	Failures  int
+	Successes int
+	// Rejected is the number of the rejected requests since the circuit was opened.
+	Rejected int
+}
+
+// CircuitBreaker stops serving the requests of the routes that it wraps for a while when too many of them fail,
+// i.e because of a downstream service, so it can recover and the clients get a fast 503 Service Unavailable response, i.e:
+// breaker := muxie.NewCircuitBreaker(muxie.CircuitFailureRate(0.3), muxie.CircuitSlowCall(2*time.Second))
+// mux.HandleFunc("/reports", reports).Use(breaker.Wrapper())
+//
+// A circuit is shared by all the routes that the same breaker wraps. Its state is exposed by the `State` and the `Counts`.
+type CircuitBreaker struct {
+	failureRate      float64
+	minRequests      int
+	window           time.Duration
+	slowCall         time.Duration
+	openTimeout      time.Duration
+	halfOpenRequests int
+	isFailure        func(statusCode int) bool
+	handler          http.Handler
+	onChange         func(from, to CircuitState)
+	now              func() time.Time
+
+	mu    sync.Mutex
+	state CircuitState
+	// generation is increased on each state change, the results of the requests of the previous ones are ignored.
+	generation uint64
+	counts     CircuitCounts
+	// the start time of the closed circuit's window or the time that the circuit was opened.
+	since time.Time
+	// the number of the probe requests that are allowed on the half-open state.
+	probes int
+}
+
+// NewCircuitBreaker returns a new, closed, `CircuitBreaker`.
+func NewCircuitBreaker(options ...CircuitBreakerOption) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		failureRate:      0.5,
+		minRequests:      20,
+		window:           10 * time.Second,
+		openTimeout:      30 * time.Second,
+		halfOpenRequests: 1,
+		isFailure:        func(statusCode int) bool { return statusCode >= http.StatusInternalServerError },
+		now:              time.Now,
+	}
+	for _, opt := range options {
+		opt(cb)
+	}
+
+	if cb.minRequests < 1 {
+		cb.minRequests = 1
+	}
+
+	if cb.halfOpenRequests < 1 {
+		cb.halfOpenRequests = 1
+	}
+
+	return cb
+}
+
+// State returns the current state of the circuit.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.advance(cb.now())
+	return cb.state
+}
+
+// Counts returns the counts of the requests of the current window or state.
+func (cb *CircuitBreaker) Counts() CircuitCounts {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.advance(cb.now())
+	return cb.counts
+}
+
+// Wrapper returns the middleware of the breaker, see `CircuitBreaker`.
+func (cb *CircuitBreaker) Wrapper() Wrapper {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			generation, retryAfter, ok := cb.allow()
+			if !ok {
+				w.Header().Set("Retry-After", seconds(retryAfter))
+				if cb.handler != nil {
+					cb.handler.ServeHTTP(w, r)
+					return
+				}
+
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+
+			lw := &logWriter{ResponseWriter: w}
+			start := cb.now()
+			failed := true
+			defer func() {
+				cb.record(generation, failed)
+			}()
+
+			next.ServeHTTP(lw, r)
+
+			if lw.status == 0 {
+				lw.status = http.StatusOK
+			}
+
+			failed = cb.isFailure(lw.status) || (cb.slowCall > 0 && cb.now().Sub(start) > cb.slowCall)
+		})
+	}
+}
+
+// allow reports whether a request can be served and the generation of its state,
+// or the time until the circuit is half-open.
+func (cb *CircuitBreaker) allow() (uint64, time.Duration, bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	cb.advance(now)
+
+	switch cb.state {
+	case CircuitOpen:
+		cb.counts.Rejected++
+		return 0, cb.since.Add(cb.openTimeout).Sub(now), false
+	case CircuitHalfOpen:
+		if cb.probes >= cb.halfOpenRequests {
+			cb.counts.Rejected++
+			return 0, 0, false
+		}
+		cb.probes++
+	}
+
+	cb.counts.Requests++
+	return cb.generation, 0, true
+}
+
+func (cb *CircuitBreaker) record(generation uint64, failed bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	cb.advance(now)
+	if generation != cb.generation {
+		return
+	}
+
+	if failed {
+		cb.counts.Failures++
+	} else {
+		cb.counts.Successes++
+	}
+
+	switch cb.state {
+	case CircuitClosed:
+		if cb.counts.Requests >= cb.minRequests &&
+			float64(cb.counts.Failures) >= cb.failureRate*float64(cb.counts.Requests) {
+			cb.setState(CircuitOpen, now)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			cb.setState(CircuitOpen, now)
+		} else if cb.counts.Successes >= cb.halfOpenRequests {
+			cb.setState(CircuitClosed, now)
+		}
+	}
+}
+
+// advance moves the circuit to the half-open state after the open timeout
+// and it resets the counts of the closed circuit after its window.
+func (cb *CircuitBreaker) advance(now time.Time) {
+	switch cb.state {
+	case CircuitClosed:
+		if cb.since.IsZero() {
+			cb.since = now
+		} else if cb.window > 0 && now.Sub(cb.since) >= cb.window {
+			cb.generation++
+			cb.counts = CircuitCounts{}
+			cb.since = now
+		}
+	case CircuitOpen:
+		if now.Sub(cb.since) >= cb.openTimeout {
+			cb.setState(CircuitHalfOpen, now)
+		}
+	}
+}
+
+func (cb *CircuitBreaker) setState(state CircuitState, now time.Time) {
+	from := cb.state
+
+	cb.state = state
+	cb.generation++
+	cb.counts = CircuitCounts{}
+	cb.since = now
+	cb.probes = 0
+
+	if cb.onChange != nil {
+		cb.onChange(from, state)
+	}
+}
@@ -0,0 +1,114 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var (
+		now         = time.Now()
+		transitions []string
+		status      = http.StatusOK
+	)
+
+	breaker := NewCircuitBreaker(
+		CircuitMinRequests(4),
+		CircuitFailureRate(0.5),
+		CircuitOpenTimeout(time.Minute),
+		CircuitHalfOpenRequests(2),
+		CircuitOnStateChange(func(from, to CircuitState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		}),
+	)
+	breaker.now = func() time.Time { return now }
+
+	mux := NewMux()
+	mux.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}).Use(breaker.Wrapper())
+
+	serve := func(expected int) {
+		t.Helper()
+		testHandler(t, mux, http.MethodGet, "/reports").statusCode(expected)
+	}
+
+	// 1 of 4 failed, below the rate.
+	serve(200)
+	status = http.StatusBadGateway
+	serve(502)
+	status = http.StatusOK
+	serve(200)
+	serve(200)
+	if state := breaker.State(); state != CircuitClosed {
+		t.Fatalf("expected closed circuit but got: %s", state)
+	}
+
+	// a new window.
+	now = now.Add(10 * time.Second)
+	if counts := breaker.Counts(); counts.Requests != 0 {
+		t.Fatalf("expected the counts to be reset but got: %#+v", counts)
+	}
+
+	status = http.StatusInternalServerError
+	serve(500)
+	serve(500)
+	status = http.StatusOK
+	serve(200)
+	serve(200)
+	if state := breaker.State(); state != CircuitOpen {
+		t.Fatalf("expected open circuit but got: %s", state)
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/reports", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected a rejected request but got: %d %s", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	if counts := breaker.Counts(); counts.Rejected != 1 {
+		t.Fatalf("expected 1 rejected request but got: %#+v", counts)
+	}
+
+	// half-open, a failed probe opens it again.
+	now = now.Add(time.Minute)
+	if state := breaker.State(); state != CircuitHalfOpen {
+		t.Fatalf("expected half-open circuit but got: %s", state)
+	}
+	status = http.StatusInternalServerError
+	serve(500)
+	serve(503)
+
+	// the probes succeed and close it.
+	now = now.Add(time.Minute)
+	status = http.StatusOK
+	serve(200)
+	serve(200)
+	if state := breaker.State(); state != CircuitClosed {
+		t.Fatalf("expected closed circuit but got: %s", state)
+	}
+
+	expected := "closed->open, open->half-open, half-open->open, open->half-open, half-open->closed"
+	if got := strings.Join(transitions, ", "); expected != got {
+		t.Fatalf("expected transitions: %s but got: %s", expected, got)
+	}
+}
+
+func TestCircuitBreakerSlowCalls(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(CircuitMinRequests(1), CircuitSlowCall(time.Second), CircuitResponse(ProblemHandler(http.StatusServiceUnavailable)))
+	breaker.now = func() time.Time { return now }
+
+	mux := NewMux()
+	mux.Use(breaker.Wrapper())
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		now = now.Add(2 * time.Second)
+	})
+
+	testHandler(t, mux, http.MethodGet, "/slow").statusCode(http.StatusOK)
+	testHandler(t, mux, http.MethodGet, "/slow").statusCode(http.StatusServiceUnavailable).
+		headerEq("Content-Type", ProblemContentType)
+}
@@ -0,0 +1,336 @@
+package muxie
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressOption is the type of the options that `Compress` accepts.
+type CompressOption func(*compressor)
+
+// CompressLevel is a `CompressOption` which sets the compression level of the gzip and deflate encodings,
+// i.e `gzip.BestSpeed`. Defaults to the `gzip.DefaultCompression`.
+func CompressLevel(level int) CompressOption {
+	return func(c *compressor) {
+		c.level = level
+	}
+}
+
+// CompressMinSize is a `CompressOption` which sets the minimum size of the responses, in bytes, that are compressed,
+// the smaller responses are sent as they are. Defaults to 1024.
+func CompressMinSize(size int) CompressOption {
+	return func(c *compressor) {
+		c.minSize = size
+	}
+}
+
+// CompressEncoder is a `CompressOption` which registers an encoding, i.e the "br" or "zstd" of a third-party package,
+// it has priority over the built-in gzip and deflate encodings when the client accepts them equally, i.e:
+// muxie.CompressEncoder("br", func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) })
+func CompressEncoder(encoding string, newWriter func(w io.Writer) io.WriteCloser) CompressOption {
+	return func(c *compressor) {
+		c.encoders = append([]compressEncoder{{name: strings.ToLower(encoding), newWriter: newWriter}}, c.encoders...)
+	}
+}
+
+// CompressSkipTypes is a `CompressOption` which adds content types, or their prefixes like "image/", that are not compressed,
+// after the ones that are already compressed, i.e the images, videos, audios and archives.
+func CompressSkipTypes(contentTypes ...string) CompressOption {
+	return func(c *compressor) {
+		c.skipTypes = append(c.skipTypes, contentTypes...)
+	}
+}
+
+type compressResetter interface {
+	Reset(w io.Writer)
+}
+
+type compressEncoder struct {
+	name      string
+	newWriter func(w io.Writer) io.WriteCloser
+	pool      *sync.Pool
+}
+
+type compressor struct {
+	level     int
+	minSize   int
+	encoders  []compressEncoder
+	skipTypes []string
+}
+
+// the content types that are already compressed.
+var compressedTypes = []string{
+	"image/", "video/", "audio/", "font/woff", "application/zip", "application/gzip", "application/x-gzip",
+	"application/x-bzip2", "application/x-7z-compressed", "application/x-rar-compressed", "application/zstd",
+	"application/pdf", "application/wasm",
+}
+
+// Compress returns a middleware which compresses the responses of the clients that accept one of its encodings,
+// through their "Accept-Encoding" header, the gzip and deflate are built-in, i.e:
+// mux.Use(muxie.Compress())
+// mux.Use(muxie.Compress(muxie.CompressLevel(gzip.BestSpeed), muxie.CompressMinSize(2048)))
+//
+// The responses that are already encoded, the ones of already compressed content types
+// and the ones smaller than the `CompressMinSize` are sent as they are.
+// The wrapped http.ResponseWriter implements the `Unwrapper`, so the `GetParam` works through it.
+// The not yet sent response of a panicking handler is dropped, so the outer `Recover` can write its own.
+func Compress(options ...CompressOption) Wrapper {
+	c := &compressor{level: gzip.DefaultCompression, minSize: 1024}
+	c.encoders = []compressEncoder{
+		{name: "gzip", newWriter: func(w io.Writer) io.WriteCloser {
+			gw, _ := gzip.NewWriterLevel(w, c.level)
+			return gw
+		}},
+		{name: "deflate", newWriter: func(w io.Writer) io.WriteCloser {
+			zw, _ := zlib.NewWriterLevel(w, c.level)
+			return zw
+		}},
+	}
+	c.skipTypes = append([]string(nil), compressedTypes...)
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	// the writers that can be reset are reused.
+	for i := range c.encoders {
+		newWriter := c.encoders[i].newWriter
+		if _, ok := newWriter(io.Discard).(compressResetter); ok {
+			c.encoders[i].pool = &sync.Pool{New: func() interface{} { return newWriter(io.Discard) }}
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoder := c.negotiate(r.Header.Get("Accept-Encoding"))
+			if encoder == nil || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, compressor: c, encoder: encoder}
+			defer func() {
+				if err := recover(); err != nil {
+					// let the outer middlewares, i.e the `Recover`, write the response.
+					cw.discard()
+					panic(err)
+				}
+
+				cw.close()
+			}()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiate returns the encoder of the highest quality that the "Accept-Encoding" accepts, if any.
+func (c *compressor) negotiate(acceptEncoding string) *compressEncoder {
+	if acceptEncoding == "" {
+		return nil
+	}
+
+	var (
+		best      *compressEncoder
+		bestQ     float64
+		anyQ      = -1.0
+		qualities = make(map[string]float64)
+	)
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := part, 1.0
+		if i := strings.IndexByte(part, ';'); i != -1 {
+			name = part[:i]
+			if param := strings.TrimSpace(part[i+1:]); strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		if name = strings.ToLower(strings.TrimSpace(name)); name == "*" {
+			anyQ = q
+		} else {
+			qualities[name] = q
+		}
+	}
+
+	for i := range c.encoders {
+		q, ok := qualities[c.encoders[i].name]
+		if !ok {
+			q = anyQ
+		}
+
+		if q > bestQ {
+			best, bestQ = &c.encoders[i], q
+		}
+	}
+
+	return best
+}
+
+func (c *compressor) isSkipped(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, skipped := range c.skipTypes {
+		if strings.HasPrefix(contentType, skipped) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compressWriter buffers the first bytes of a response, up to the `CompressMinSize`,
+// to decide if it should be compressed, see `Compress`.
+type compressWriter struct {
+	http.ResponseWriter
+	compressor *compressor
+	encoder    *compressEncoder
+
+	status  int
+	buf     []byte
+	decided bool
+	writer  io.WriteCloser // not nil when the response is compressed.
+}
+
+var _ Unwrapper = (*compressWriter)(nil)
+
+func (w *compressWriter) WriteHeader(statusCode int) {
+	// the informational responses and the superfluous calls are sent as they are.
+	if w.decided || (statusCode >= 100 && statusCode < 200) {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	if w.status == 0 {
+		w.status = statusCode
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.compressor.minSize {
+			return len(b), nil
+		}
+
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+
+		return len(b), nil
+	}
+
+	if w.writer != nil {
+		return w.writer.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// decide compresses the response if "enough" bytes are written and its headers allow it, then it writes the buffered bytes.
+func (w *compressWriter) decide(enough bool) error {
+	w.decided = true
+
+	h := w.Header()
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" && len(w.buf) > 0 {
+		contentType = http.DetectContentType(w.buf)
+		h.Set("Content-Type", contentType)
+	}
+
+	compressible := h.Get("Content-Encoding") == "" && !w.compressor.isSkipped(contentType) &&
+		status != http.StatusNoContent && status != http.StatusNotModified && status != http.StatusPartialContent
+	if compressible {
+		h.Add("Vary", "Accept-Encoding")
+
+		if enough && len(w.buf) > 0 {
+			h.Set("Content-Encoding", w.encoder.name)
+			h.Del("Content-Length")
+
+			if w.encoder.pool != nil {
+				w.writer = w.encoder.pool.Get().(io.WriteCloser)
+				w.writer.(compressResetter).Reset(w.ResponseWriter)
+			} else {
+				w.writer = w.encoder.newWriter(w.ResponseWriter)
+			}
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.writer != nil {
+		_, err = w.writer.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+// Flush sends the buffered bytes to the client, their compression is decided by the bytes written so far.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(len(w.buf) > 0)
+	}
+
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underline http.ResponseWriter.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// discard drops the buffered bytes and the encoder without writing the response.
+func (w *compressWriter) discard() {
+	w.buf = nil
+	w.releaseWriter()
+}
+
+func (w *compressWriter) releaseWriter() {
+	if w.writer != nil && w.encoder.pool != nil {
+		w.writer.(compressResetter).Reset(io.Discard)
+		w.encoder.pool.Put(w.writer)
+	}
+	w.writer = nil
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.status == 0 && len(w.buf) == 0 {
+			// nothing is written, let the net/http send its default response.
+			return
+		}
+
+		w.decide(false)
+	}
+
+	if w.writer != nil {
+		w.writer.Close()
+		w.releaseWriter()
+	}
+}
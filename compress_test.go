@@ -0,0 +1,172 @@
+package muxie
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("muxie ", 500)
+
+	mux := NewMux()
+	mux.Use(Compress(CompressMinSize(1024)))
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, "%s%s", GetParam(w, "id"), large)
+	})
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("small"))
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(large))
+	})
+	mux.HandleFunc("/encoded", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte(large))
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	serve := func(path, acceptEncoding string) *http.Response {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		mux.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	decode := func(resp *http.Response) string {
+		var (
+			reader io.Reader = resp.Body
+			err    error
+		)
+
+		switch resp.Header.Get("Content-Encoding") {
+		case "gzip":
+			reader, err = gzip.NewReader(resp.Body)
+		case "deflate":
+			reader, err = zlib.NewReader(resp.Body)
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	var tests = []struct {
+		path           string
+		acceptEncoding string
+		encoding       string
+		status         int
+		body           string
+	}{
+		{"/users/42", "gzip, deflate", "gzip", http.StatusCreated, "42" + large},
+		{"/users/42", "gzip;q=0.5, deflate", "deflate", http.StatusCreated, "42" + large},
+		{"/users/42", "br", "", http.StatusCreated, "42" + large},
+		{"/users/42", "gzip;q=0, *", "deflate", http.StatusCreated, "42" + large},
+		{"/users/42", "", "", http.StatusCreated, "42" + large},
+		{"/small", "gzip", "", http.StatusOK, "small"},
+		{"/image", "gzip", "", http.StatusOK, large},
+		{"/encoded", "gzip", "gzip", http.StatusOK, ""},
+		{"/empty", "gzip", "", http.StatusNoContent, ""},
+	}
+
+	for _, tt := range tests {
+		resp := serve(tt.path, tt.acceptEncoding)
+		if got := resp.Header.Get("Content-Encoding"); got != tt.encoding {
+			t.Fatalf("%s [%s]: expected encoding: '%s' but got: '%s'", tt.path, tt.acceptEncoding, tt.encoding, got)
+		}
+
+		if resp.StatusCode != tt.status {
+			t.Fatalf("%s [%s]: expected status code: %d but got: %d", tt.path, tt.acceptEncoding, tt.status, resp.StatusCode)
+		}
+
+		if tt.path == "/encoded" {
+			continue
+		}
+
+		if got := decode(resp); got != tt.body {
+			t.Fatalf("%s [%s]: unexpected body of %d bytes", tt.path, tt.acceptEncoding, len(got))
+		}
+	}
+
+	if resp := serve("/small", "gzip"); resp.Header.Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expected the Vary header for the compressible responses but got: '%s'", resp.Header.Get("Vary"))
+	}
+}
+
+func TestCompressEncoder(t *testing.T) {
+	mux := NewMux()
+	mux.Use(Compress(CompressMinSize(0), CompressEncoder("x-upper", func(w io.Writer) io.WriteCloser {
+		return &upperWriter{w: w}
+	})))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip, x-upper")
+	mux.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Content-Encoding"); got != "x-upper" {
+		t.Fatalf("expected the registered encoding to have priority but got: '%s'", got)
+	}
+
+	if got := w.Body.String(); got != "HELLO" {
+		t.Fatalf("expected body: HELLO but got: %s", got)
+	}
+}
+
+type upperWriter struct {
+	w io.Writer
+}
+
+func (w *upperWriter) Write(b []byte) (int, error) {
+	return w.w.Write(bytes.ToUpper(b))
+}
+
+func (w *upperWriter) Close() error {
+	return nil
+}
+
+func TestCompressRecover(t *testing.T) {
+	mux := NewMux()
+	mux.Use(Recover(RecoverLogger(nil)), Compress(CompressMinSize(1024)))
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("oops")
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	mux.ServeHTTP(w, r)
+
+	if expected, got := http.StatusInternalServerError, w.Code; expected != got {
+		t.Fatalf("expected status code: %d but got: %d", expected, got)
+	}
+
+	if got := w.Body.String(); strings.Contains(got, "partial") {
+		t.Fatalf("expected the buffered body to be dropped but got: %s", got)
+	}
+}
@@ -0,0 +1,219 @@
+package muxie
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ConflictError is the error that `Mux#HandleErr` returns when a path pattern
+// can match the same request paths as an already registered one.
+//
+// Use `errors.As` to retrieve it.
+type ConflictError struct {
+	// Pattern is the path pattern that was going to be registered.
+	Pattern string
+	// Existing is the registered path pattern that the "Pattern" conflicts with.
+	Existing string
+	// Precedence is the path pattern which would serve the request paths that both of them can match.
+	Precedence string
+	// Reason describes why the "Precedence" path pattern is selected.
+	Reason string
+}
+
+func (e *ConflictError) Error() string {
+	return "muxie/Mux#HandleErr: \"" + e.Pattern + "\" conflicts with \"" + e.Existing +
+		"\", the \"" + e.Precedence + "\" would be selected because " + e.Reason
+}
+
+// HandleErr registers a route handler for a path pattern, like `Handle` does,
+// but it returns a `*ConflictError` instead, without registering it, when the "pattern"
+// can match the same request paths as an already registered one, i.e
+// "/users/:id" and "/users/new" or "/users/*rest", the error holds the precedence that would apply.
+//
+// Named parameters of different types, i.e "/users/:id:int" and "/users/:name:alphabetical",
+// are not checked against each other. A malformed "pattern", see `PatternError`,
+// or one with more path parameters than the `MaxParams` is returned as an error too.
+func (m *Mux) HandleErr(pattern string, handler http.Handler) (*Route, error) {
+	m.lock()
+	defer m.unlock()
+
+	if err := validatePattern(m.pattern(pattern)); err != nil {
+		return nil, err
+	}
+
+	if err := m.checkMaxParams(m.pattern(pattern)); err != nil {
+		return nil, err
+	}
+
+	if err := m.Routes.conflict(m.pattern(pattern)); err != nil {
+		return nil, err
+	}
+
+	return m.handle(pattern, handler), nil
+}
+
+// conflict returns the first conflict of the "pattern" with the inserted path patterns, if any.
+func (t *TrieOf[T]) conflict(pattern string) error {
+	var segments [][]conflictSegment
+	for _, p := range expandOptionalParams(pattern) {
+		s, err := t.conflictSegments(p)
+		if err != nil {
+			return err
+		}
+		segments = append(segments, s)
+	}
+
+	var err error
+	t.walk(func(n *NodeOf[T]) bool {
+		for _, existing := range expandOptionalParams(n.key) {
+			b, _ := t.conflictSegments(existing)
+			for _, a := range segments {
+				if !overlap(a, b) {
+					continue
+				}
+
+				err = newConflictError(pattern, n.key, a, b)
+				return false
+			}
+		}
+
+		return true
+	})
+
+	return err
+}
+
+// the ranks of the path segments, the higher has the priority on search.
+const (
+	wildcardSegment = iota
+	paramSegment
+	typedParamSegment
+	staticSegment
+)
+
+type conflictSegment struct {
+	rank      int
+	key       string // the static segment or the child key of a dynamic one.
+	validator ParamValidator
+}
+
+func (t *TrieOf[T]) conflictSegments(pattern string) ([]conflictSegment, error) {
+	input := slowPathSplit(t.normalizePattern(pattern))
+	segments := make([]conflictSegment, 0, len(input))
+
+	for _, s := range input {
+		switch s[0] {
+		case ParamStart[0]:
+			segment := conflictSegment{rank: paramSegment, key: childKey(s)}
+			if _, constraint := splitParam(s[1:]); constraint != "" {
+				validator, err := compileParamConstraint(constraint)
+				if err != nil {
+					return nil, errors.New("muxie/Mux#HandleErr: " + err.Error() + " of \"" + pattern + "\"")
+				}
+
+				segment.rank = typedParamSegment
+				segment.validator = validator
+			}
+			segments = append(segments, segment)
+		case WildcardParamStart[0]:
+			segments = append(segments, conflictSegment{rank: wildcardSegment, key: WildcardParamStart})
+		default:
+			if t.CaseInsensitive {
+				s = strings.ToLower(s)
+			}
+			segments = append(segments, conflictSegment{rank: staticSegment, key: s})
+		}
+	}
+
+	return segments, nil
+}
+
+// overlap reports whether two path patterns, by their segments, can match the same request path.
+func overlap(a, b []conflictSegment) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+
+	// a wildcard consumes one or more path segments.
+	if a[0].rank == wildcardSegment || b[0].rank == wildcardSegment {
+		if b[0].rank == wildcardSegment && a[0].rank != wildcardSegment {
+			a, b = b, a
+		}
+
+		for i := 1; i <= len(b); i++ {
+			if overlap(a[1:], b[i:]) {
+				return true
+			}
+		}
+
+		if b[0].rank == wildcardSegment {
+			for i := 2; i <= len(a); i++ {
+				if overlap(a[i:], b[1:]) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	return segmentsOverlap(a[0], b[0]) && overlap(a[1:], b[1:])
+}
+
+func segmentsOverlap(a, b conflictSegment) bool {
+	if a.rank < b.rank {
+		a, b = b, a
+	}
+
+	switch {
+	case a.rank == staticSegment && b.rank == staticSegment:
+		return a.key == b.key
+	case a.rank == staticSegment && b.rank == typedParamSegment:
+		return b.validator(a.key)
+	case a.rank == typedParamSegment && b.rank == typedParamSegment:
+		// different types or expressions can not be compared.
+		return a.key == b.key
+	default:
+		// any segment is accepted by an untyped named parameter.
+		return true
+	}
+}
+
+func newConflictError(pattern, existing string, a, b []conflictSegment) *ConflictError {
+	err := &ConflictError{Pattern: pattern, Existing: existing}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].rank == b[i].rank {
+			continue
+		}
+
+		err.Precedence, err.Reason = existing, segmentRanks[b[i].rank]+" have priority over "+segmentRanks[a[i].rank]
+		if a[i].rank > b[i].rank {
+			err.Precedence, err.Reason = pattern, segmentRanks[a[i].rank]+" have priority over "+segmentRanks[b[i].rank]
+		}
+
+		return err
+	}
+
+	err.Precedence = pattern
+	if len(a) != len(b) {
+		if len(b) > len(a) {
+			err.Precedence = existing
+		}
+		err.Reason = "wildcards match the fewest path segments that let the rest of the path pattern match"
+	} else if pattern == existing {
+		err.Reason = "it is already registered and it would be replaced"
+	} else {
+		err.Reason = "the path patterns have the same path segments and the last registered one replaces the other"
+	}
+
+	return err
+}
+
+var segmentRanks = map[int]string{
+	wildcardSegment:   "wildcards",
+	paramSegment:      "named parameters",
+	typedParamSegment: "typed named parameters",
+	staticSegment:     "static path segments",
+}
@@ -0,0 +1,67 @@
+package muxie
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestMuxHandleErr(t *testing.T) {
+	var tests = []struct {
+		registered []string
+		pattern    string
+		// empty if no conflict.
+		existing   string
+		precedence string
+	}{
+		{[]string{"/users/new"}, "/users/:id", "/users/new", "/users/new"},
+		{[]string{"/users/:id"}, "/users/new", "/users/:id", "/users/new"},
+		{[]string{"/users/:id"}, "/users/*rest", "/users/:id", "/users/:id"},
+		{[]string{"/users/:id"}, "/users/:name", "/users/:id", "/users/:name"},
+		{[]string{"/users/:id"}, "/users/:id", "/users/:id", "/users/:id"},
+		{[]string{"/users/:id:int"}, "/users/:name", "/users/:id:int", "/users/:id:int"},
+		{[]string{"/posts/:year/:month?"}, "/posts/:year", "/posts/:year/:month?", "/posts/:year"},
+		{[]string{"/*path"}, "/users/:id/posts", "/*path", "/users/:id/posts"},
+		{[]string{"/files/*path/raw"}, "/files/*path", "/files/*path/raw", "/files/*path/raw"},
+		// no conflicts.
+		{[]string{"/users/:id"}, "/users/:id/posts", "", ""},
+		{[]string{"/users/:id:int"}, "/users/new", "", ""},
+		{[]string{"/users/:id:int"}, "/users/:name:alphabetical", "", ""},
+		{[]string{"/users/*rest"}, "/users", "", ""},
+		{[]string{"/about"}, "/contact", "", ""},
+	}
+
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	for i, tt := range tests {
+		mux := NewMux()
+		for _, pattern := range tt.registered {
+			mux.Handle(pattern, noop)
+		}
+
+		route, err := mux.HandleErr(tt.pattern, noop)
+		if tt.existing == "" {
+			if err != nil || route == nil {
+				t.Fatalf("[%d] %s: expected no conflict but got: %v", i, tt.pattern, err)
+			}
+			continue
+		}
+
+		var conflictErr *ConflictError
+		if !errors.As(err, &conflictErr) {
+			t.Fatalf("[%d] %s: expected a conflict error but got: %v", i, tt.pattern, err)
+		}
+
+		if route != nil {
+			t.Fatalf("[%d] %s: expected the route to not be registered", i, tt.pattern)
+		}
+
+		if conflictErr.Pattern != tt.pattern || conflictErr.Existing != tt.existing || conflictErr.Precedence != tt.precedence || conflictErr.Reason == "" {
+			t.Fatalf("[%d] %s: unexpected conflict: %#+v", i, tt.pattern, conflictErr)
+		}
+	}
+
+	if _, err := NewMux().HandleErr("/users/:id:integer", noop); err == nil {
+		t.Fatalf("expected an error for an unknown parameter type")
+	}
+}
@@ -0,0 +1,277 @@
+package muxie
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOption is the type of the options that `CORS` and `Mux#CORS` accept.
+type CORSOption func(*cors)
+
+// CORSOrigins is a `CORSOption` which sets the allowed origins, they can contain a wildcard,
+// i.e "https://example.com", "https://*.example.com" or "*" for any origin. Defaults to "*".
+func CORSOrigins(origins ...string) CORSOption {
+	return func(c *cors) {
+		c.origins = nil
+		c.allowAnyOrigin = false
+		for _, origin := range origins {
+			if origin = strings.ToLower(origin); origin == "*" {
+				c.allowAnyOrigin = true
+				continue
+			}
+
+			c.origins = append(c.origins, origin)
+		}
+	}
+}
+
+// CORSOriginFunc is a `CORSOption` which allows the origins that the "allow" reports, after the `CORSOrigins` ones.
+func CORSOriginFunc(allow func(origin string) bool) CORSOption {
+	return func(c *cors) {
+		c.allowOrigin = allow
+		if len(c.origins) == 0 {
+			c.allowAnyOrigin = false
+		}
+	}
+}
+
+// CORSMethods is a `CORSOption` which sets the allowed methods. Defaults to GET, HEAD, POST, PUT, PATCH and DELETE.
+func CORSMethods(methods ...string) CORSOption {
+	return func(c *cors) {
+		c.methods = parseMethods(strings.Join(methods, ","))
+	}
+}
+
+// CORSHeaders is a `CORSOption` which sets the allowed request headers, "*" allows any header.
+// Defaults to "Accept", "Content-Type" and "X-Requested-With".
+func CORSHeaders(headers ...string) CORSOption {
+	return func(c *cors) {
+		c.headers = nil
+		c.allowAnyHeader = false
+		for _, header := range headers {
+			if header == "*" {
+				c.allowAnyHeader = true
+				continue
+			}
+
+			c.headers = append(c.headers, http.CanonicalHeaderKey(header))
+		}
+	}
+}
+
+// CORSExposedHeaders is a `CORSOption` which sets the response headers that the clients can read.
+func CORSExposedHeaders(headers ...string) CORSOption {
+	return func(c *cors) {
+		c.exposedHeaders = strings.Join(headers, ", ")
+	}
+}
+
+// CORSCredentials is a `CORSOption` which allows the requests with credentials, i.e cookies.
+// The origin of the request is sent instead of the "*" then, so it requires the `CORSOrigins`
+// or the `CORSOriginFunc` to allow only the trusted origins, the `CORS` panics for any origin.
+func CORSCredentials() CORSOption {
+	return func(c *cors) {
+		c.credentials = true
+	}
+}
+
+// CORSMaxAge is a `CORSOption` which sets how long the results of a preflight request can be cached by the clients.
+func CORSMaxAge(maxAge time.Duration) CORSOption {
+	return func(c *cors) {
+		c.maxAge = strconv.Itoa(int(maxAge / time.Second))
+	}
+}
+
+type cors struct {
+	origins        []string
+	allowAnyOrigin bool
+	allowOrigin    func(origin string) bool
+	methods        []string
+	headers        []string
+	allowAnyHeader bool
+	exposedHeaders string
+	credentials    bool
+	maxAge         string
+
+	// not nil for the `Mux#CORS`, the preflight requests are answered only for the registered paths.
+	mux *Mux
+}
+
+func newCORS(options []CORSOption) *cors {
+	c := &cors{
+		allowAnyOrigin: true,
+		methods: []string{http.MethodGet, http.MethodHead, http.MethodPost,
+			http.MethodPut, http.MethodPatch, http.MethodDelete},
+		headers: []string{"Accept", "Content-Type", "X-Requested-With"},
+	}
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	if c.allowAnyOrigin && c.credentials {
+		panic("muxie/CORS: the credentials cannot be allowed for any origin, set the CORSOrigins or the CORSOriginFunc")
+	}
+
+	return c
+}
+
+// CORS returns a middleware which sets the Cross-Origin Resource Sharing headers of the requests
+// of the allowed origins and it answers their preflight requests with 204 No Content, i.e:
+// mux.Wrap(muxie.CORS(muxie.CORSOrigins("https://*.example.com"), muxie.CORSCredentials()))
+//
+// Register it through the `Mux#Wrap`, so the preflight requests are answered before their route is matched,
+// or use the `Mux#CORS` which answers them only for the registered paths and methods.
+func CORS(options ...CORSOption) Wrapper {
+	return newCORS(options).wrap
+}
+
+// CORS registers a `CORS` middleware around the whole request dispatch of the Mux, see `Wrap`,
+// the preflight requests of the registered paths are answered, even without an OPTIONS handler,
+// and the methods of the paths that are registered through the `HandleMethod`
+// are the only ones allowed of them, the preflight requests of the rest paths are not found.
+func (m *Mux) CORS(options ...CORSOption) {
+	c := newCORS(options)
+	c.mux = m
+	m.Wrap(c.wrap)
+}
+
+func (c *cors) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			c.preflight(w, r, next, origin)
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if c.isOriginAllowed(origin) {
+			c.setAllowOrigin(h, origin)
+			if c.exposedHeaders != "" {
+				h.Set("Access-Control-Expose-Headers", c.exposedHeaders)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request, next http.Handler, origin string) {
+	methods := c.methods
+	if c.mux != nil {
+		var found bool
+		if methods, found = c.routeMethods(r); !found {
+			next.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	requestedHeaders := r.Header.Get("Access-Control-Request-Headers")
+
+	if c.isOriginAllowed(origin) && containsMethod(methods, method) && c.areHeadersAllowed(requestedHeaders) {
+		c.setAllowOrigin(h, origin)
+		h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if requestedHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", requestedHeaders)
+		}
+		if c.maxAge != "" {
+			h.Set("Access-Control-Max-Age", c.maxAge)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// routeMethods returns the allowed methods of the request's path, the configured ones
+// or the ones that the path is registered for through the `HandleMethod`, and if the path is registered at all.
+func (c *cors) routeMethods(r *http.Request) ([]string, bool) {
+	c.mux.rlock()
+	defer c.mux.runlock()
+
+	n := c.mux.Routes.Search(r.URL.Path, discardParams{})
+	if n == nil {
+		return nil, false
+	}
+
+	if n.Handler != nil || len(n.conditionals) > 0 || len(n.methodsAllowed) == 0 {
+		return c.methods, true
+	}
+
+	var methods []string
+	for _, method := range c.methods {
+		if _, ok := n.methodHandlers[method]; ok || (method == http.MethodHead && containsMethod(n.methodsAllowed, http.MethodGet)) {
+			methods = append(methods, method)
+		}
+	}
+
+	return methods, true
+}
+
+func (c *cors) setAllowOrigin(h http.Header, origin string) {
+	if c.allowAnyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) isOriginAllowed(origin string) bool {
+	if c.allowAnyOrigin {
+		return true
+	}
+
+	lowered := strings.ToLower(origin)
+	for _, allowed := range c.origins {
+		if i := strings.IndexByte(allowed, '*'); i != -1 {
+			if prefix, suffix := allowed[:i], allowed[i+1:]; len(lowered) >= len(prefix)+len(suffix) &&
+				strings.HasPrefix(lowered, prefix) && strings.HasSuffix(lowered, suffix) {
+				return true
+			}
+		} else if lowered == allowed {
+			return true
+		}
+	}
+
+	return c.allowOrigin != nil && c.allowOrigin(origin)
+}
+
+func (c *cors) areHeadersAllowed(requestedHeaders string) bool {
+	if c.allowAnyHeader || requestedHeaders == "" {
+		return true
+	}
+
+	for _, header := range strings.Split(requestedHeaders, ",") {
+		if header = strings.TrimSpace(header); header == "" {
+			continue
+		}
+
+		if !containsKey(c.headers, http.CanonicalHeaderKey(header)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// discardParams is a `ParamsSetter` which discards the parameters, when only the found node is needed.
+type discardParams struct{}
+
+func (discardParams) Set(string, string) {}
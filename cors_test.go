@@ -0,0 +1,116 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	mux := NewMux()
+	mux.Wrap(CORS(CORSOrigins("https://example.com", "https://*.example.org"), CORSCredentials(),
+		CORSExposedHeaders("X-Total-Count"), CORSMaxAge(10*time.Minute)))
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL+"/users", withHeader("Origin", "https://api.example.org")).
+		statusCode(http.StatusOK).bodyEq("users").
+		headerEq("Access-Control-Allow-Origin", "https://api.example.org").
+		headerEq("Access-Control-Allow-Credentials", "true").
+		headerEq("Access-Control-Expose-Headers", "X-Total-Count").
+		headerEq("Vary", "Origin")
+
+	expect(t, http.MethodGet, srv.URL+"/users", withHeader("Origin", "https://evil.com")).
+		statusCode(http.StatusOK).headerEq("Access-Control-Allow-Origin", "")
+
+	expect(t, http.MethodGet, srv.URL+"/users").
+		statusCode(http.StatusOK).headerEq("Vary", "")
+
+	expect(t, http.MethodOptions, srv.URL+"/users",
+		withHeader("Origin", "https://example.com"),
+		withHeader("Access-Control-Request-Method", "PUT"),
+		withHeader("Access-Control-Request-Headers", "content-type, x-requested-with")).
+		statusCode(http.StatusNoContent).bodyEq("").
+		headerEq("Access-Control-Allow-Origin", "https://example.com").
+		headerEq("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE").
+		headerEq("Access-Control-Allow-Headers", "content-type, x-requested-with").
+		headerEq("Access-Control-Max-Age", "600")
+
+	expect(t, http.MethodOptions, srv.URL+"/users",
+		withHeader("Origin", "https://example.com"),
+		withHeader("Access-Control-Request-Method", "PUT"),
+		withHeader("Access-Control-Request-Headers", "Authorization")).
+		statusCode(http.StatusNoContent).headerEq("Access-Control-Allow-Origin", "")
+}
+
+func TestMuxCORS(t *testing.T) {
+	mux := NewMux()
+	mux.CORS(CORSHeaders("*"))
+	mux.GET("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	mux.DELETE("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	mux.HandleFunc("/posts", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the preflight request should not reach the handler")
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	preflight := func(path, method string) *testie {
+		return expect(t, http.MethodOptions, srv.URL+path,
+			withHeader("Origin", "https://example.com"),
+			withHeader("Access-Control-Request-Method", method),
+			withHeader("Access-Control-Request-Headers", "Authorization"))
+	}
+
+	preflight("/users/42", http.MethodDelete).statusCode(http.StatusNoContent).
+		headerEq("Access-Control-Allow-Origin", "*").
+		headerEq("Access-Control-Allow-Methods", "GET, HEAD, DELETE").
+		headerEq("Access-Control-Allow-Headers", "Authorization")
+	preflight("/users/42", http.MethodPost).statusCode(http.StatusNoContent).
+		headerEq("Access-Control-Allow-Origin", "")
+	preflight("/posts", http.MethodPatch).statusCode(http.StatusNoContent).
+		headerEq("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE")
+	preflight("/missing", http.MethodGet).statusCode(http.StatusNotFound)
+}
+
+func TestCORSCredentialsAnyOrigin(t *testing.T) {
+	for _, options := range [][]CORSOption{
+		{CORSCredentials()},
+		{CORSOrigins("https://example.com", "*"), CORSCredentials()},
+	} {
+		func() {
+			defer func() {
+				if expected, got := "muxie/CORS: the credentials cannot be allowed for any origin, set the CORSOrigins or the CORSOriginFunc", recover(); expected != got {
+					t.Fatalf("expected panic: %s but got: %v", expected, got)
+				}
+			}()
+
+			CORS(options...)
+		}()
+	}
+
+	// the origins of the func are allowed with the credentials.
+	mux := NewMux()
+	mux.Wrap(CORS(CORSCredentials(), CORSOriginFunc(func(origin string) bool { return origin == "https://example.com" })))
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL+"/users", withHeader("Origin", "https://example.com")).
+		statusCode(http.StatusOK).
+		headerEq("Access-Control-Allow-Origin", "https://example.com").
+		headerEq("Access-Control-Allow-Credentials", "true")
+
+	expect(t, http.MethodGet, srv.URL+"/users", withHeader("Origin", "https://evil.com")).
+		statusCode(http.StatusOK).
+		headerEq("Access-Control-Allow-Origin", "").
+		headerEq("Access-Control-Allow-Credentials", "")
+}
@@ -0,0 +1,156 @@
+package muxie
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// COWTrieOf is a copy-on-write `TrieOf`, its searches do not lock at all while it can be modified at any time.
+// Each modification is applied to a copy of the current trie which then replaces it atomically,
+// the searches that are in progress keep using the previous one.
+// It fits the tries that are searched much more often than they are modified, like the routing tables.
+//
+// Each modification copies the whole trie, so inserting n path patterns one by one costs O(n²),
+// the bulk modifications should be applied through one `Update` instead.
+// The `Mux` does not use it, its `ThreadSafe` routes are still guarded by a read-write mutex
+// which every request locks for reading, it is meant for the custom routers and lookup tables built on the trie.
+//
+// See `COWTrie` and `NewCOWTrieOf`.
+type COWTrieOf[T any] struct {
+	mu      sync.Mutex // serializes the modifications.
+	current atomic.Pointer[TrieOf[T]]
+}
+
+// COWTrie is the `COWTrieOf` the HTTP handlers.
+type COWTrie = COWTrieOf[http.Handler]
+
+// NewCOWTrie returns a new, empty, copy-on-write Trie.
+func NewCOWTrie() *COWTrie {
+	return NewCOWTrieOf[http.Handler]()
+}
+
+// NewCOWTrieOf returns a new, empty, copy-on-write Trie which stores values of type T, i.e:
+// routes := muxie.NewCOWTrieOf[*Upstream]()
+// routes.Insert("/api/*path", muxie.WithValue(upstream))
+// n := routes.Search(r.URL.Path, params) // no locks.
+func NewCOWTrieOf[T any]() *COWTrieOf[T] {
+	t := new(COWTrieOf[T])
+	t.current.Store(NewTrieOf[T]())
+	return t
+}
+
+// Load returns the current trie, a snapshot which is never modified, it should be used only for reading.
+func (t *COWTrieOf[T]) Load() *TrieOf[T] {
+	return t.current.Load()
+}
+
+// Search searches the current trie, see `TrieOf#Search`.
+func (t *COWTrieOf[T]) Search(q string, params ParamsSetter) *NodeOf[T] {
+	return t.current.Load().Search(q, params)
+}
+
+// SearchErr searches the current trie, see `TrieOf#SearchErr`.
+func (t *COWTrieOf[T]) SearchErr(q string, params ParamsSetter) (*NodeOf[T], error) {
+	return t.current.Load().SearchErr(q, params)
+}
+
+// SearchInto searches the current trie, see `TrieOf#SearchInto`.
+func (t *COWTrieOf[T]) SearchInto(q string, params ParamsSetter) *NodeOf[T] {
+	return t.current.Load().SearchInto(q, params)
+}
+
+// Insert adds a node to a copy of the current trie which then replaces it, see `TrieOf#Insert`.
+func (t *COWTrieOf[T]) Insert(pattern string, options ...InsertOptionOf[T]) {
+	if err := validatePattern(pattern); err != nil {
+		panic(err.Error())
+	}
+
+	t.Update(func(trie *TrieOf[T]) {
+		trie.insertPattern(pattern, options)
+	})
+}
+
+// InsertErr adds a node to a copy of the current trie which then replaces it, see `TrieOf#InsertErr`.
+func (t *COWTrieOf[T]) InsertErr(pattern string, options ...InsertOptionOf[T]) error {
+	if err := validatePattern(pattern); err != nil {
+		return err
+	}
+
+	t.Update(func(trie *TrieOf[T]) {
+		trie.insertPattern(pattern, options)
+	})
+	return nil
+}
+
+// Delete removes an inserted path pattern from a copy of the current trie which then replaces it,
+// see `TrieOf#Delete`. The current trie is kept if the "pattern" is not found.
+func (t *COWTrieOf[T]) Delete(pattern string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := t.current.Load()
+	if len(current.nodes(pattern)) == 0 {
+		return false
+	}
+
+	trie := current.Clone()
+	trie.Delete(pattern)
+	t.current.Store(trie)
+	return true
+}
+
+// Update calls the "fn" with a copy of the current trie which then replaces it,
+// many modifications can be applied through one copy, i.e:
+// routes.Update(func(trie *muxie.Trie) {
+// trie.Insert("/users", muxie.WithHandler(users))
+// trie.Delete("/legacy/users")
+// })
+//
+// The "fn" should not keep the "trie".
+func (t *COWTrieOf[T]) Update(fn func(trie *TrieOf[T])) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	trie := t.current.Load().Clone()
+	fn(trie)
+	t.current.Store(trie)
+}
+
+// Clone returns a deep copy of the trie, the handlers, the values, the data and the metadata values of its nodes are shared.
+func (t *TrieOf[T]) Clone() *TrieOf[T] {
+	clone := *t
+	clone.root = t.root.clone(nil, make(map[*nodeMeta]*nodeMeta))
+	return &clone
+}
+
+func (n *NodeOf[T]) clone(parent *NodeOf[T], metas map[*nodeMeta]*nodeMeta) *NodeOf[T] {
+	c := *n
+	c.parent = parent
+	c.meta = n.meta.clone(metas)
+
+	if n.children != nil {
+		c.children = make(map[string]*NodeOf[T], len(n.children))
+		for s, child := range n.children {
+			c.children[s] = child.clone(&c, metas)
+		}
+	}
+
+	if n.paramChildren != nil {
+		// keep the search order.
+		c.paramChildren = make([]*NodeOf[T], len(n.paramChildren))
+		for i, child := range n.paramChildren {
+			c.paramChildren[i] = c.children[child.segment]
+		}
+	}
+
+	if n.methodHandlers != nil {
+		c.methodHandlers = make(map[string]http.Handler, len(n.methodHandlers))
+		for method, handler := range n.methodHandlers {
+			c.methodHandlers[method] = handler
+		}
+	}
+
+	c.conditionals = append([]*conditionalHandler(nil), n.conditionals...)
+	return &c
+}
@@ -0,0 +1,84 @@
+package muxie
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestCOWTrie(t *testing.T) {
+	routes := NewCOWTrieOf[string]()
+	routes.Insert("/users/:id", WithValue("user"))
+	routes.Insert("/users/:id:int", WithValue("user by id"))
+
+	snapshot := routes.Load()
+	routes.Insert("/users/new", WithValue("new user"))
+
+	if n := routes.Search("/users/new", new(paramsWriter)); n == nil || n.Value() != "new user" {
+		t.Fatalf("expected the inserted path pattern to be found")
+	}
+
+	if n := snapshot.Search("/users/new", new(paramsWriter)); n == nil || n.Value() != "user" {
+		t.Fatalf("expected the previous trie to not be modified")
+	}
+
+	// the typed named parameters of the copies keep their search order.
+	if n := routes.Search("/users/42", new(paramsWriter)); n == nil || n.Value() != "user by id" {
+		t.Fatalf("expected the typed named parameter to be found first")
+	}
+
+	if err := routes.InsertErr("/users/:id/:id"); err == nil {
+		t.Fatalf("expected an error for a malformed pattern")
+	}
+
+	if routes.Delete("/posts") {
+		t.Fatalf("expected a not inserted pattern to not be deleted")
+	}
+
+	snapshot = routes.Load()
+	if !routes.Delete("/users/new") {
+		t.Fatalf("expected pattern to be deleted")
+	}
+
+	if n := routes.Search("/users/new", new(paramsWriter)); n == nil || n.Value() != "user" {
+		t.Fatalf("expected the deleted path pattern to not be found")
+	}
+	if n := snapshot.Search("/users/new", new(paramsWriter)); n == nil || n.Value() != "new user" {
+		t.Fatalf("expected the previous trie to not be modified")
+	}
+
+	routes.Update(func(trie *TrieOf[string]) {
+		trie.Insert("/a", WithValue("a"))
+		trie.Insert("/b", WithValue("b"))
+	})
+	if n := routes.Search("/b", new(paramsWriter)); n == nil || n.Value() != "b" {
+		t.Fatalf("expected the updated path patterns to be found")
+	}
+}
+
+func TestCOWTrieConcurrent(t *testing.T) {
+	routes := NewCOWTrie()
+	routes.Insert("/users/:id")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if n := routes.Search("/users/42", new(paramsWriter)); n == nil {
+					t.Error("expected the path pattern to be always found")
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		pattern := "/items/" + strconv.Itoa(i)
+		routes.Insert(pattern)
+		routes.Delete(pattern)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,239 @@
+package muxie
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+const csrfTokenLength = 32
+
+// CSRFOption is the type of the options that `CSRF` accepts.
+type CSRFOption func(*csrf)
+
+// CSRFCookie is a `CSRFOption` which customizes the cookie of the tokens, i.e its Name, Domain or MaxAge.
+// Defaults to an HttpOnly, SameSite=Lax "_csrf" cookie of the "/" path for 12 hours, which is Secure for the HTTPS requests.
+func CSRFCookie(configure func(cookie *http.Cookie)) CSRFOption {
+	return func(c *csrf) {
+		configure(&c.cookie)
+	}
+}
+
+// CSRFSameSite is a `CSRFOption` which sets the SameSite mode of the cookie of the tokens, see `CSRFCookie`.
+func CSRFSameSite(mode http.SameSite) CSRFOption {
+	return func(c *csrf) {
+		c.cookie.SameSite = mode
+	}
+}
+
+// CSRFHeader is a `CSRFOption` which sets the request header of the tokens. Defaults to "X-CSRF-Token".
+func CSRFHeader(header string) CSRFOption {
+	return func(c *csrf) {
+		c.header = header
+	}
+}
+
+// CSRFFormField is a `CSRFOption` which sets the form field of the tokens. Defaults to "_csrf".
+func CSRFFormField(field string) CSRFOption {
+	return func(c *csrf) {
+		c.formField = field
+	}
+}
+
+// CSRFExempt is a `CSRFOption` which exempts the requests of the given paths or route path patterns from the checks,
+// i.e "/webhooks/:provider". The route path patterns are known when the `CSRF` is registered through the `Mux#Use`.
+func CSRFExempt(paths ...string) CSRFOption {
+	return func(c *csrf) {
+		c.exempt = append(c.exempt, paths...)
+	}
+}
+
+// CSRFFailure is a `CSRFOption` which customizes the response of the requests that fail the checks.
+// Defaults to a plain text 403 Forbidden response.
+func CSRFFailure(handler http.Handler) CSRFOption {
+	return func(c *csrf) {
+		c.failure = handler
+	}
+}
+
+type csrf struct {
+	cookie    http.Cookie
+	secureSet bool
+	header    string
+	formField string
+	exempt    []string
+	failure   http.Handler
+}
+
+// CSRF returns a middleware which protects the requests of the unsafe methods, all except GET, HEAD, OPTIONS and TRACE,
+// against the cross-site request forgery through the double submit cookie pattern, i.e:
+// mux.Use(muxie.CSRF(muxie.CSRFExempt("/webhooks/:provider")))
+//
+// Each client gets a secret token through a cookie and the unsafe requests should send it back through
+// the "X-CSRF-Token" header or the "_csrf" form field, the handlers retrieve it by the `CSRFToken`,
+// or the `CSRFField` for the HTML forms of the templates. The sent tokens are masked per response,
+// so they can not be guessed through the compressed responses, and they are compared in constant time.
+func CSRF(options ...CSRFOption) Wrapper {
+	c := &csrf{
+		cookie: http.Cookie{
+			Name:     "_csrf",
+			Path:     "/",
+			MaxAge:   int((12 * time.Hour).Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		header:    "X-CSRF-Token",
+		formField: "_csrf",
+	}
+	for _, opt := range options {
+		secure := c.cookie.Secure
+		opt(c)
+		c.secureSet = c.secureSet || c.cookie.Secure != secure
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := c.token(r)
+			if token == nil {
+				token = make([]byte, csrfTokenLength)
+				if _, err := rand.Read(token); err != nil {
+					panic("muxie/CSRF: " + err.Error())
+				}
+
+				cookie := c.cookie
+				cookie.Value = base64.RawURLEncoding.EncodeToString(token)
+				if !c.secureSet {
+					cookie.Secure = isHTTPS(r)
+				}
+				http.SetCookie(w, &cookie)
+			}
+
+			w.Header().Add("Vary", "Cookie")
+			r = r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, csrfValue{token: token, field: c.formField}))
+
+			if !isSafeMethod(r.Method) && !c.isExempt(w, r) {
+				if sent := unmaskCSRFToken(c.sentToken(r)); sent == nil || subtle.ConstantTimeCompare(sent, token) != 1 {
+					if c.failure != nil {
+						c.failure.ServeHTTP(w, r)
+						return
+					}
+
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// token returns the token of the request's cookie, if it is valid.
+func (c *csrf) token(r *http.Request) []byte {
+	cookie, err := r.Cookie(c.cookie.Name)
+	if err != nil {
+		return nil
+	}
+
+	token, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(token) != csrfTokenLength {
+		return nil
+	}
+
+	return token
+}
+
+func (c *csrf) sentToken(r *http.Request) string {
+	if token := r.Header.Get(c.header); token != "" {
+		return token
+	}
+
+	if isFormRequest(r) {
+		return r.PostFormValue(c.formField)
+	}
+
+	return ""
+}
+
+func (c *csrf) isExempt(w http.ResponseWriter, r *http.Request) bool {
+	route := RoutePattern(w)
+	for _, path := range c.exempt {
+		if path == r.URL.Path || (route != "" && path == route) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// maskCSRFToken returns a random one-time pad and the "token" xored with it, base64 encoded.
+func maskCSRFToken(token []byte) string {
+	masked := make([]byte, 2*csrfTokenLength)
+	if _, err := rand.Read(masked[:csrfTokenLength]); err != nil {
+		panic("muxie/CSRFToken: " + err.Error())
+	}
+
+	for i := 0; i < csrfTokenLength; i++ {
+		masked[csrfTokenLength+i] = masked[i] ^ token[i]
+	}
+
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+func unmaskCSRFToken(s string) []byte {
+	masked, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(masked) != 2*csrfTokenLength {
+		return nil
+	}
+
+	token := make([]byte, csrfTokenLength)
+	for i := 0; i < csrfTokenLength; i++ {
+		token[i] = masked[i] ^ masked[csrfTokenLength+i]
+	}
+
+	return token
+}
+
+type csrfContextKey struct{}
+
+type csrfValue struct {
+	token []byte
+	field string
+}
+
+// CSRFToken returns a new masked token of the request, for the "X-CSRF-Token" header or the "_csrf" form field
+// of the next unsafe requests, or empty if the request is not served through the `CSRF`.
+func CSRFToken(r *http.Request) string {
+	v, ok := r.Context().Value(csrfContextKey{}).(csrfValue)
+	if !ok {
+		return ""
+	}
+
+	return maskCSRFToken(v.token)
+}
+
+// CSRFField returns the hidden input of the `CSRFToken` for the HTML forms of the templates, i.e:
+// tmpl.Execute(w, map[string]interface{}{"csrfField": muxie.CSRFField(r)})
+// and {{ .csrfField }} inside the form.
+func CSRFField(r *http.Request) template.HTML {
+	v, ok := r.Context().Value(csrfContextKey{}).(csrfValue)
+	if !ok {
+		return ""
+	}
+
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(v.field) +
+		`" value="` + maskCSRFToken(v.token) + `">`)
+}
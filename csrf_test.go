@@ -0,0 +1,96 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	mux := NewMux()
+	mux.Use(CSRF(CSRFExempt("/webhooks/:provider")))
+	mux.HandleFunc("/form", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(CSRFToken(r)))
+			return
+		}
+
+		w.Write([]byte("submitted"))
+	})
+	mux.HandleFunc("/field", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(CSRFField(r)))
+	})
+	mux.HandleFunc("/webhooks/:provider", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hook " + GetParam(w, "provider")))
+	})
+
+	serve := func(method, path string, cookie *http.Cookie, form url.Values, header string) *httptest.ResponseRecorder {
+		var r *http.Request
+		if form != nil {
+			r = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			r = httptest.NewRequest(method, path, nil)
+		}
+
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+
+		if header != "" {
+			r.Header.Set("X-CSRF-Token", header)
+		}
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/form", nil, nil, "")
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "_csrf" || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Fatalf("expected the token cookie but got: %v", cookies)
+	}
+	cookie, token := cookies[0], w.Body.String()
+
+	// the cookie is not sent again.
+	if w = serve(http.MethodGet, "/form", cookie, nil, ""); len(w.Result().Cookies()) != 0 {
+		t.Fatalf("expected the existing cookie to be kept")
+	}
+
+	// the tokens are masked per response.
+	if other := w.Body.String(); other == token || unmaskCSRFToken(other) == nil ||
+		string(unmaskCSRFToken(other)) != string(unmaskCSRFToken(token)) {
+		t.Fatalf("expected a different mask of the same token")
+	}
+
+	var tests = []struct {
+		name   string
+		path   string
+		cookie *http.Cookie
+		form   url.Values
+		header string
+		status int
+	}{
+		{"no token", "/form", cookie, nil, "", http.StatusForbidden},
+		{"no cookie", "/form", nil, nil, token, http.StatusForbidden},
+		{"header", "/form", cookie, nil, token, http.StatusOK},
+		{"form", "/form", cookie, url.Values{"_csrf": {token}}, "", http.StatusOK},
+		{"invalid", "/form", cookie, nil, "invalid", http.StatusForbidden},
+		{"other cookie", "/form", &http.Cookie{Name: "_csrf", Value: strings.Repeat("A", 43)}, nil, token, http.StatusForbidden},
+		{"exempt", "/webhooks/github", nil, nil, "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if w = serve(http.MethodPost, tt.path, tt.cookie, tt.form, tt.header); w.Code != tt.status {
+			t.Fatalf("%s: expected status code: %d but got: %d", tt.name, tt.status, w.Code)
+		}
+	}
+
+	w = serve(http.MethodGet, "/field", cookie, nil, "")
+	if field := w.Body.String(); !strings.HasPrefix(field, `<input type="hidden" name="_csrf" value="`) {
+		t.Fatalf("unexpected field: %s", field)
+	}
+}
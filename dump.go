@@ -0,0 +1,226 @@
+package muxie
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DumpOption is the type of the options that `Dump` accepts.
+type DumpOption func(*dumper)
+
+// DumpOutput is a `DumpOption` which writes the dumps to the "w". Defaults to the os.Stderr.
+func DumpOutput(w io.Writer) DumpOption {
+	return func(d *dumper) {
+		d.output = w
+	}
+}
+
+// DumpMaxBodySize is a `DumpOption` which sets the maximum size of the dumped request and response bodies,
+// the rest of them is not dumped but it is still served. Defaults to 64KB.
+func DumpMaxBodySize(size int) DumpOption {
+	return func(d *dumper) {
+		d.maxBodySize = size
+	}
+}
+
+// DumpRedact is a `DumpOption` which adds headers that their values are replaced by "[REDACTED]" in the dumps.
+// The "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key" and "X-CSRF-Token" are always redacted.
+func DumpRedact(headers ...string) DumpOption {
+	return func(d *dumper) {
+		for _, header := range headers {
+			d.redact[http.CanonicalHeaderKey(header)] = struct{}{}
+		}
+	}
+}
+
+// DumpEnabled is a `DumpOption` which makes the dumps toggleable at runtime, the requests are dumped
+// only while the "enabled" is true, i.e by an admin route or a signal. Defaults to always enabled.
+func DumpEnabled(enabled *atomic.Bool) DumpOption {
+	return func(d *dumper) {
+		d.enabled = enabled
+	}
+}
+
+type dumper struct {
+	mu          sync.Mutex
+	output      io.Writer
+	maxBodySize int
+	redact      map[string]struct{}
+	enabled     *atomic.Bool
+}
+
+// Dump returns a development middleware which writes the complete requests and responses, their headers and bodies,
+// to the os.Stderr, i.e:
+// mux.Wrap(muxie.Dump(muxie.DumpMaxBodySize(4 << 10), muxie.DumpRedact("X-Session")))
+//
+// The bodies are dumped up to the `DumpMaxBodySize` and the credentials headers are redacted, see `DumpRedact`.
+// The request body is read before the handler and it is served to it as it was.
+// It should not be enabled on production, the bodies may contain sensitive data, see `DumpEnabled`.
+func Dump(options ...DumpOption) Wrapper {
+	d := &dumper{
+		output:      os.Stderr,
+		maxBodySize: 64 << 10,
+		redact: map[string]struct{}{
+			"Authorization":       {},
+			"Proxy-Authorization": {},
+			"Cookie":              {},
+			"Set-Cookie":          {},
+			"X-Api-Key":           {},
+			"X-Csrf-Token":        {},
+		},
+	}
+	for _, opt := range options {
+		opt(d)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if d.enabled != nil && !d.enabled.Load() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var reqBody []byte
+			reqTruncated := false
+			if r.Body != nil && r.Body != http.NoBody {
+				body := r.Body
+				reqBody, _ = io.ReadAll(io.LimitReader(body, int64(d.maxBodySize)+1))
+				if len(reqBody) > d.maxBodySize {
+					reqTruncated = true
+				}
+
+				r.Body = dumpedBody{Reader: io.MultiReader(bytes.NewReader(reqBody), body), Closer: body}
+				if reqTruncated {
+					reqBody = reqBody[:d.maxBodySize]
+				}
+			}
+
+			var b strings.Builder
+			b.WriteString("> " + r.Method + " " + r.URL.RequestURI() + " " + r.Proto + "\n")
+			b.WriteString("> Host: " + r.Host + "\n")
+			d.writeHeaders(&b, "> ", r.Header)
+			d.writeBody(&b, reqBody, reqTruncated, 0)
+
+			dw := &dumpWriter{ResponseWriter: w, maxBodySize: d.maxBodySize}
+			start := time.Now()
+			next.ServeHTTP(dw, r)
+			latency := time.Since(start)
+
+			dw.snapshot(http.StatusOK)
+			b.WriteString("< " + r.Proto + " " + strconv.Itoa(dw.status) + " " + http.StatusText(dw.status) + " (" + latency.String() + ")\n")
+			d.writeHeaders(&b, "< ", dw.header)
+			d.writeBody(&b, dw.body, dw.bytes > int64(len(dw.body)), dw.bytes-int64(len(dw.body)))
+
+			d.mu.Lock()
+			io.WriteString(d.output, b.String())
+			d.mu.Unlock()
+		})
+	}
+}
+
+func (d *dumper) writeHeaders(b *strings.Builder, prefix string, h http.Header) {
+	keys := make([]string, 0, len(h))
+	for key := range h {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		_, redacted := d.redact[key]
+		for _, value := range h[key] {
+			if redacted {
+				value = "[REDACTED]"
+			}
+			b.WriteString(prefix + key + ": " + value + "\n")
+		}
+	}
+}
+
+func (d *dumper) writeBody(b *strings.Builder, body []byte, truncated bool, rest int64) {
+	if len(body) == 0 && !truncated {
+		b.WriteString("\n")
+		return
+	}
+
+	b.WriteString("\n")
+	b.Write(body)
+	if truncated {
+		if rest > 0 {
+			b.WriteString("\n[truncated " + strconv.FormatInt(rest, 10) + " bytes]")
+		} else {
+			b.WriteString("\n[truncated]")
+		}
+	}
+	b.WriteString("\n\n")
+}
+
+// dumpedBody serves the already read part of a request body and then the rest of it.
+type dumpedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// dumpWriter captures the status code, the headers and the body, up to a size, of a response, see `Dump`.
+type dumpWriter struct {
+	http.ResponseWriter
+	maxBodySize int
+
+	status int
+	header http.Header
+	body   []byte
+	bytes  int64
+}
+
+var _ Unwrapper = (*dumpWriter)(nil)
+
+// snapshot keeps the status code and a copy of the headers of the response when it is sent.
+func (w *dumpWriter) snapshot(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+		w.header = w.Header().Clone()
+	}
+}
+
+func (w *dumpWriter) WriteHeader(statusCode int) {
+	if statusCode >= 200 {
+		w.snapshot(statusCode)
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *dumpWriter) Write(b []byte) (int, error) {
+	w.snapshot(http.StatusOK)
+
+	n, err := w.ResponseWriter.Write(b)
+	if room := w.maxBodySize - len(w.body); room > 0 {
+		if room > n {
+			room = n
+		}
+		w.body = append(w.body, b[:room]...)
+	}
+	w.bytes += int64(n)
+
+	return n, err
+}
+
+// Flush sends any buffered data to the client, if the underline http.ResponseWriter supports it.
+func (w *dumpWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.snapshot(http.StatusOK)
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underline http.ResponseWriter.
+func (w *dumpWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,69 @@
+package muxie
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	var (
+		out     bytes.Buffer
+		enabled atomic.Bool
+	)
+	enabled.Store(true)
+
+	mux := NewMux()
+	mux.Wrap(Dump(DumpOutput(&out), DumpMaxBodySize(8), DumpRedact("x-session"), DumpEnabled(&enabled)))
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "sid=1")
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/echo?x=1", strings.NewReader("hello world"))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Session", "secret")
+	req.Header.Set("Accept", "text/plain")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "hello world" {
+		t.Fatalf("expected the whole body to be served but got: %d %s", rec.Code, rec.Body.String())
+	}
+
+	expected := "^> POST /echo\\?x=1 HTTP/1.1\n" +
+		"> Host: example.com\n" +
+		"> Accept: text/plain\n" +
+		"> Authorization: \\[REDACTED\\]\n" +
+		"> X-Session: \\[REDACTED\\]\n" +
+		"\nhello wo\n\\[truncated\\]\n\n" +
+		"< HTTP/1.1 201 Created \\([0-9.]+[µnm]?s\\)\n" +
+		"< Content-Type: text/plain\n" +
+		"< Set-Cookie: \\[REDACTED\\]\n" +
+		"\nhello wo\n\\[truncated 3 bytes\\]\n\n$"
+	if got := out.String(); !regexp.MustCompile(expected).MatchString(got) {
+		t.Fatalf("unexpected dump:\n%s", got)
+	}
+
+	// disabled at runtime.
+	out.Reset()
+	enabled.Store(false)
+	testHandler(t, mux, http.MethodGet, "/echo").statusCode(http.StatusCreated)
+	if out.Len() != 0 {
+		t.Fatalf("expected no dump but got:\n%s", out.String())
+	}
+
+	enabled.Store(true)
+	testHandler(t, mux, http.MethodGet, "/missing").statusCode(http.StatusNotFound)
+	if got := out.String(); !strings.HasPrefix(got, "> GET /missing HTTP/1.1\n> Host: example.com\n\n< HTTP/1.1 404 Not Found (") {
+		t.Fatalf("unexpected dump:\n%s", got)
+	}
+}
@@ -0,0 +1,188 @@
+package muxie
+
+import (
+	"errors"
+	"net/http"
+)
+
+// HandlerE is an http handler which returns an error instead of responding to it,
+// the returned error is converted to a response by the `ErrorMapper`, see `Mux#HandleE`.
+// It should return the error before it writes anything to the response,
+// unless the response is buffered by the `Buffer`, then what it has written is discarded, see `ResetResponse`.
+type HandlerE func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP calls the handler and it responds to its error through the `DefaultErrorMapper`,
+// so a `HandlerE` can be used as a standard http.Handler too, see `Mux#HandleE` for the custom mappers.
+func (h HandlerE) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h(w, r); err != nil {
+		ResetResponse(w)
+		DefaultErrorMapper(w, r, err)
+	}
+}
+
+// ErrorMapper converts an error of a `HandlerE` to a response, i.e to a JSON body, see `Mux#HandleError`.
+type ErrorMapper func(w http.ResponseWriter, r *http.Request, err error)
+
+// HTTPError is an error which carries the status code and the message of its response,
+// the handlers return it to respond with a specific status, i.e:
+// return muxie.NewHTTPError(http.StatusConflict, "user already exists")
+// return &muxie.HTTPError{Status: http.StatusServiceUnavailable, Err: err}
+type HTTPError struct {
+	// Status is the status code of the response. Defaults to 500 Internal Server Error.
+	Status int
+	// Message is the body of the response, if empty then the status text is sent.
+	Message string
+	// Err is the underline error, if any, it is not sent to the client.
+	Err error
+}
+
+// NewHTTPError returns a new `HTTPError` of the given status code and message.
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{Status: status, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+
+	return http.StatusText(e.StatusCode())
+}
+
+// Unwrap returns the underline error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the status code of the error's response.
+func (e *HTTPError) StatusCode() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+
+	return e.Status
+}
+
+// DefaultErrorMapper is the `ErrorMapper` of the muxes which have not a custom one, see `Mux#HandleError`.
+// It responds with the status code and the message of an `*HTTPError`, with 413 Request Entity Too Large
+// to the `ErrBodyTooLarge`, with 415 Unsupported Media Type to the `ErrUnsupportedMediaType`, with 406 Not Acceptable to the `ErrNotAcceptable`,
+// with 400 Bad Request to the `*ParamError`, the `ParamErrors` and the `*BindError` and with a plain 500 Internal Server Error to the rest ones, without their message.
+var DefaultErrorMapper ErrorMapper = func(w http.ResponseWriter, r *http.Request, err error) {
+	status := ErrorStatus(err)
+
+	message := errorMessage(err, status)
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	http.Error(w, message, status)
+}
+
+// errorMessage returns the message of an error which can be sent to the client, or empty, see `DefaultErrorMapper`.
+func errorMessage(err error, status int) string {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.Message != "" {
+		return httpErr.Message
+	}
+
+	if status == http.StatusBadRequest {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// ErrorStatus returns the status code of the response of an error, see `DefaultErrorMapper` and `Problem`,
+// useful for the custom `ErrorMapper`s.
+func ErrorStatus(err error) int {
+	var (
+		httpErr     *HTTPError
+		problem     *Problem
+		paramErr    *ParamError
+		paramErrors ParamErrors
+		bindErr     *BindError
+	)
+
+	switch {
+	case errors.As(err, &httpErr):
+		return httpErr.StatusCode()
+	case errors.As(err, &problem):
+		return problem.StatusCode()
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrNotAcceptable):
+		return http.StatusNotAcceptable
+	case errors.As(err, &paramErr), errors.As(err, &paramErrors), errors.As(err, &bindErr):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// HandleError sets the `ErrorMapper` of the errors of the `HandleE` and `HandleMethodE` routes
+// of this Mux and its sub muxes, i.e:
+// api := mux.Of("/api")
+// api.HandleError(func(w http.ResponseWriter, r *http.Request, err error) {
+// w.Header().Set("Content-Type", "application/json")
+// [...]
+//
+// The routes of a sub mux without a mapper use their parent's one or, at the end, the `DefaultErrorMapper`.
+// See the `ErrorMapper` field too.
+func (m *Mux) HandleError(mapper ErrorMapper) {
+	m.lock()
+	m.ErrorMapper = mapper
+	m.unlock()
+}
+
+// HandleE registers a route handler which returns an error for a path pattern,
+// the error is converted to a response by the mux's `ErrorMapper`, see `HandleError`, i.e:
+// mux.HandleE("/users/:id", func(w http.ResponseWriter, r *http.Request) error {
+// id, err := muxie.GetParamInt(w, "id")
+// if err != nil {
+// return err
+// }
+// [...]
+//
+// Returns the `Route` which can be used to give a name to it.
+func (m *Mux) HandleE(pattern string, handler HandlerE) *Route {
+	return m.Handle(pattern, m.handlerE("muxie/Mux#HandleE", handler))
+}
+
+// HandleMethodE registers a route handler which returns an error for a path pattern
+// which is responsible only for the given HTTP method(s), see `HandleE` and `HandleMethod`.
+func (m *Mux) HandleMethodE(method, pattern string, handler HandlerE) *Route {
+	return m.HandleMethod(method, pattern, m.handlerE("muxie/Mux#HandleMethodE", handler))
+}
+
+func (m *Mux) handlerE(caller string, handler HandlerE) http.Handler {
+	if handler == nil {
+		panic(caller + ": empty handler")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := handler(w, r); err != nil {
+			ResetResponse(w)
+			m.errorMapper()(w, r, err)
+		}
+	})
+}
+
+// errorMapper returns the `ErrorMapper` of this mux, or of its nearest parent, or the `DefaultErrorMapper`.
+func (m *Mux) errorMapper() ErrorMapper {
+	m.rlock()
+	defer m.runlock()
+
+	for mux := m; mux != nil; mux = mux.parent {
+		if mux.ErrorMapper != nil {
+			return mux.ErrorMapper
+		}
+	}
+
+	return DefaultErrorMapper
+}
@@ -0,0 +1,87 @@
+package muxie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMuxHandleE(t *testing.T) {
+	mux := NewMux()
+	mux.HandleE("/users/:id", func(w http.ResponseWriter, r *http.Request) error {
+		id, err := GetParamInt(w, "id")
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case 1:
+			return NewHTTPError(http.StatusConflict, "user already exists")
+		case 2:
+			return fmt.Errorf("loading user: %w", &HTTPError{Status: http.StatusServiceUnavailable, Err: errors.New("db down")})
+		case 3:
+			return errors.New("secret failure")
+		}
+
+		fmt.Fprintf(w, "user %d", id)
+		return nil
+	})
+	mux.HandleMethodE(http.MethodPost, "/upload", func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("upload: %w", ErrBodyTooLarge)
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42").statusCode(http.StatusOK).bodyEq("user 42")
+	testHandler(t, mux, http.MethodGet, "/users/1").statusCode(http.StatusConflict).bodyEq("user already exists\n")
+	testHandler(t, mux, http.MethodGet, "/users/2").statusCode(http.StatusServiceUnavailable).bodyEq("Service Unavailable\n")
+	testHandler(t, mux, http.MethodGet, "/users/3").statusCode(http.StatusInternalServerError).bodyEq("Internal Server Error\n")
+	testHandler(t, mux, http.MethodGet, "/users/abc").statusCode(http.StatusBadRequest).
+		bodyEq("muxie: invalid value \"abc\" of parameter \"id\": invalid syntax\n")
+	testHandler(t, mux, http.MethodPost, "/upload").statusCode(http.StatusRequestEntityTooLarge)
+	testHandler(t, mux, http.MethodGet, "/upload").statusCode(http.StatusNotFound)
+}
+
+func TestMuxHandleError(t *testing.T) {
+	var mapped []string
+
+	mux := NewMux()
+	mux.HandleError(func(w http.ResponseWriter, r *http.Request, err error) {
+		mapped = append(mapped, "root: "+err.Error())
+		w.WriteHeader(ErrorStatus(err))
+	})
+	mux.HandleE("/fail", func(w http.ResponseWriter, r *http.Request) error {
+		return NewHTTPError(http.StatusTeapot, "teapot")
+	})
+
+	api := mux.Of("/api")
+	api.HandleE("/inherited", func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("inherited")
+	})
+
+	v2 := api.Of("/v2")
+	v2.HandleError(func(w http.ResponseWriter, r *http.Request, err error) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(ErrorStatus(err))
+		fmt.Fprintf(w, `{"error":%q}`, err.Error())
+	})
+	v2.HandleE("/fail", func(w http.ResponseWriter, r *http.Request) error {
+		return NewHTTPError(http.StatusNotFound, "missing")
+	})
+
+	testHandler(t, mux, http.MethodGet, "/fail").statusCode(http.StatusTeapot)
+	testHandler(t, mux, http.MethodGet, "/api/inherited").statusCode(http.StatusInternalServerError)
+	testHandler(t, mux, http.MethodGet, "/api/v2/fail").statusCode(http.StatusNotFound).
+		headerEq("Content-Type", "application/json").bodyEq(`{"error":"missing"}`)
+
+	if expected, got := "root: teapot, root: inherited", strings.Join(mapped, ", "); expected != got {
+		t.Fatalf("expected mapped errors: %s but got: %s", expected, got)
+	}
+
+	// as a standard handler.
+	std := NewMux()
+	std.Handle("/std", HandlerE(func(w http.ResponseWriter, r *http.Request) error {
+		return NewHTTPError(http.StatusForbidden, "")
+	}))
+	testHandler(t, std, http.MethodGet, "/std").statusCode(http.StatusForbidden).bodyEq("Forbidden\n")
+}
@@ -0,0 +1,274 @@
+package muxie
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETagOption is the type of the options that `ETag` accepts.
+type ETagOption func(*etagger)
+
+// ETagWeak is an `ETagOption` which generates weak "ETag"s, i.e `W/"..."`,
+// for the responses that are semantically equivalent but not byte for byte, i.e after a compression.
+func ETagWeak() ETagOption {
+	return func(e *etagger) {
+		e.weak = true
+	}
+}
+
+// ETagContentTypes is an `ETagOption` which generates the "ETag"s only for the responses of the given content types,
+// or their prefixes, i.e "application/json" or "text/".
+func ETagContentTypes(contentTypes ...string) ETagOption {
+	return func(e *etagger) {
+		for _, contentType := range contentTypes {
+			e.contentTypes = append(e.contentTypes, strings.ToLower(contentType))
+		}
+	}
+}
+
+// ETagMaxSize is an `ETagOption` which sets the maximum size of the buffered responses,
+// the larger ones are sent without an "ETag". Defaults to 1MB.
+func ETagMaxSize(size int) ETagOption {
+	return func(e *etagger) {
+		e.maxSize = size
+	}
+}
+
+type etagger struct {
+	weak         bool
+	contentTypes []string
+	maxSize      int
+}
+
+// ETag returns a middleware which buffers the successful responses of the GET and HEAD requests to generate their "ETag"
+// from the hash of their body, unless the handler sets one, and it responds with 304 Not Modified
+// to the conditional requests of the "If-None-Match" and the "If-Modified-Since" headers, i.e:
+// mux.Use(muxie.ETag(muxie.ETagContentTypes("application/json")))
+// mux.HandleFunc("/report", report).Use(muxie.ETag(muxie.ETagWeak()))
+//
+// The "If-Modified-Since" is checked against the "Last-Modified" header of the handler, if any,
+// only when the request has not an "If-None-Match" header. A flushed response is not buffered anymore.
+// The options of a route's `ETag` replace the options of its mux's one.
+func ETag(options ...ETagOption) Wrapper {
+	e := &etagger{maxSize: 1 << 20}
+	for _, opt := range options {
+		opt(e)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// the route's middleware replaces the mux's one.
+			if outer := findETagWriter(w); outer != nil && !outer.passThrough && outer.status == 0 {
+				outer.passThrough = true
+			}
+
+			ew := &etagWriter{ResponseWriter: w, etagger: e}
+			next.ServeHTTP(ew, r)
+			ew.finish(r)
+		})
+	}
+}
+
+func (e *etagger) isAllowed(contentType string) bool {
+	if len(e.contentTypes) == 0 {
+		return true
+	}
+
+	contentType = strings.ToLower(contentType)
+	for _, allowed := range e.contentTypes {
+		if strings.HasPrefix(contentType, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// etagWriter buffers a response, up to the `ETagMaxSize`, to generate its "ETag", see `ETag`.
+type etagWriter struct {
+	http.ResponseWriter
+	etagger *etagger
+
+	status      int
+	buf         []byte
+	passThrough bool
+}
+
+var _ Unwrapper = (*etagWriter)(nil)
+
+func (w *etagWriter) WriteHeader(statusCode int) {
+	if w.passThrough || (statusCode >= 100 && statusCode < 200) {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	if w.status == 0 {
+		w.status = statusCode
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.passThrough {
+		return w.ResponseWriter.Write(b)
+	}
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if w.status != http.StatusOK || len(w.buf)+len(b) > w.etagger.maxSize {
+		if err := w.release(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	return len(b), nil
+}
+
+// release sends the buffered response as it is and it stops the buffering.
+func (w *etagWriter) release() error {
+	w.passThrough = true
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush sends the buffered response without an "ETag".
+func (w *etagWriter) Flush() {
+	if !w.passThrough {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		w.release()
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// findETagWriter returns the first *etagWriter of the "w"'s `Unwrapper` chain, if any.
+func findETagWriter(w http.ResponseWriter) *etagWriter {
+	for w != nil {
+		if ew, ok := w.(*etagWriter); ok {
+			return ew
+		}
+
+		u, ok := w.(Unwrapper)
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+
+	return nil
+}
+
+// Unwrap returns the underline http.ResponseWriter.
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *etagWriter) finish(r *http.Request) {
+	if w.passThrough {
+		return
+	}
+
+	if w.status == 0 {
+		// nothing is written, let the net/http send its default response.
+		return
+	}
+
+	h := w.Header()
+	if w.status != http.StatusOK {
+		w.release()
+		return
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" && len(w.buf) > 0 {
+		contentType = http.DetectContentType(w.buf)
+		h.Set("Content-Type", contentType)
+	}
+
+	if !w.etagger.isAllowed(contentType) {
+		w.release()
+		return
+	}
+
+	etag := h.Get("ETag")
+	if etag == "" {
+		sum := sha256.Sum256(w.buf)
+		etag = "\"" + hex.EncodeToString(sum[:16]) + "\""
+		if w.etagger.weak {
+			etag = "W/" + etag
+		}
+		h.Set("ETag", etag)
+	}
+
+	if isNotModified(r, etag, h.Get("Last-Modified")) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		w.passThrough = true
+		w.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.release()
+}
+
+// isNotModified reports whether the conditional request "r" can be responded with 304 Not Modified,
+// for the response of the "etag" and the "lastModified" headers.
+func isNotModified(r *http.Request, etag, lastModified string) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			if candidate = strings.TrimSpace(candidate); candidate == "*" || weakETag(candidate) == weakETag(etag) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// weakETag returns the "etag" without its weak indicator, the "If-None-Match" uses the weak comparison.
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
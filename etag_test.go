@@ -0,0 +1,100 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	lastModified := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mux := NewMux()
+	mux.Use(ETag(ETagContentTypes("application/json", "text/")))
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":` + GetParam(w, "id") + `}`))
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	})
+	mux.HandleFunc("/custom", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		w.Write([]byte("custom"))
+	})
+	mux.HandleFunc("/created", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 64)))
+	}).Use(ETag(ETagMaxSize(32), ETagWeak()))
+	mux.HandleFunc("/weak", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("weak"))
+	}).Use(ETag(ETagWeak()))
+
+	serve := func(method, path string, headers ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil)
+		for i := 0; i < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodGet, "/users/42")
+	etag := w.Header().Get("ETag")
+	if len(etag) != 34 || w.Body.String() != `{"id":42}` {
+		t.Fatalf("expected a strong ETag but got: '%s'", etag)
+	}
+
+	if other := serve(http.MethodGet, "/users/43").Header().Get("ETag"); other == etag {
+		t.Fatalf("expected a different ETag for a different body")
+	}
+
+	var tests = []struct {
+		name    string
+		method  string
+		path    string
+		headers []string
+		status  int
+		body    string
+	}{
+		{"match", http.MethodGet, "/users/42", []string{"If-None-Match", etag}, http.StatusNotModified, ""},
+		{"match list", http.MethodGet, "/users/42", []string{"If-None-Match", `"other", W/` + etag}, http.StatusNotModified, ""},
+		{"any", http.MethodHead, "/users/42", []string{"If-None-Match", "*"}, http.StatusNotModified, ""},
+		{"no match", http.MethodGet, "/users/42", []string{"If-None-Match", `"other"`}, http.StatusOK, `{"id":42}`},
+		{"not a GET", http.MethodPost, "/users/42", []string{"If-None-Match", etag}, http.StatusOK, `{"id":42}`},
+		{"content type", http.MethodGet, "/image", nil, http.StatusOK, "png"},
+		{"custom", http.MethodGet, "/custom", []string{"If-None-Match", `"v1"`}, http.StatusNotModified, ""},
+		{"since", http.MethodGet, "/custom", []string{"If-Modified-Since", lastModified.Format(http.TimeFormat)}, http.StatusNotModified, ""},
+		{"modified", http.MethodGet, "/custom", []string{"If-Modified-Since", lastModified.Add(-time.Hour).Format(http.TimeFormat)},
+			http.StatusOK, "custom"},
+		{"status", http.MethodGet, "/created", []string{"If-None-Match", "*"}, http.StatusCreated, "created"},
+		{"large", http.MethodGet, "/large", []string{"If-None-Match", "*"}, http.StatusOK, strings.Repeat("a", 64)},
+	}
+
+	for _, tt := range tests {
+		w := serve(tt.method, tt.path, tt.headers...)
+		if w.Code != tt.status {
+			t.Fatalf("%s: expected status code: %d but got: %d", tt.name, tt.status, w.Code)
+		}
+
+		if w.Body.String() != tt.body {
+			t.Fatalf("%s: expected body: '%s' but got: '%s'", tt.name, tt.body, w.Body.String())
+		}
+	}
+
+	if got := serve(http.MethodGet, "/image").Header().Get("ETag"); got != "" {
+		t.Fatalf("expected no ETag for the not allowed content types but got: '%s'", got)
+	}
+
+	if got := serve(http.MethodGet, "/weak").Header().Get("ETag"); !strings.HasPrefix(got, `W/"`) {
+		t.Fatalf("expected a weak ETag but got: '%s'", got)
+	}
+}
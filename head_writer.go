@@ -0,0 +1,127 @@
+package muxie
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// headWriter is the response writer which serves a HEAD request through a GET handler,
+// it keeps the headers and the status code but it discards the response body, it counts its bytes instead,
+// so the response is sent with the Content-Length of the GET one, see `serveHead`.
+//
+// It is a `ResponseWriter` too, so the handler can still use the path parameters.
+type headWriter struct {
+	ResponseWriter
+	status  int
+	written int64
+	// true when the header is sent, by a `Flush` or the `finish`.
+	sent bool
+}
+
+var (
+	_ ResponseWriter = (*headWriter)(nil)
+	_ Unwrapper      = (*headWriter)(nil)
+)
+
+func newHeadWriter(w http.ResponseWriter) *headWriter {
+	store, ok := w.(ResponseWriter)
+	if !ok {
+		pw := new(paramsWriter)
+		pw.reset(w)
+		for _, p := range GetParams(w) {
+			pw.Set(p.Key, p.Value)
+		}
+		store = pw
+	}
+
+	return &headWriter{ResponseWriter: store}
+}
+
+// serveHead serves the HEAD request "r" through the GET "handler".
+func serveHead(handler http.Handler, w http.ResponseWriter, r *http.Request) {
+	hw := newHeadWriter(w)
+	handler.ServeHTTP(hw, r)
+	hw.finish()
+}
+
+// WriteHeader holds the status code until the handler returns, so the Content-Length can be set, see `finish`.
+func (hw *headWriter) WriteHeader(statusCode int) {
+	if hw.sent || (statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols) {
+		hw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	if hw.status == 0 {
+		hw.status = statusCode
+		hw.commit()
+	}
+}
+
+// commit records the held status code, so the middlewares can read it before the header is sent, see `Status`.
+func (hw *headWriter) commit() {
+	if pw, ok := hw.ResponseWriter.(*paramsWriter); ok {
+		pw.commit(hw.status)
+	}
+}
+
+// Write discards the "b" and it reports it as written.
+func (hw *headWriter) Write(b []byte) (int, error) {
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+		hw.commit()
+	}
+
+	hw.written += int64(len(b))
+	return len(b), nil
+}
+
+// Flush sends the headers to the client, without a Content-Length, if the underline http.ResponseWriter supports it,
+// see `paramsWriter#Flush`.
+func (hw *headWriter) Flush() {
+	hw.send()
+	flushWriter(hw.ResponseWriter)
+}
+
+func (hw *headWriter) send() {
+	if hw.sent {
+		return
+	}
+
+	hw.sent = true
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+	hw.ResponseWriter.WriteHeader(hw.status)
+}
+
+// finish sends the held status code with the Content-Length of the discarded body, unless the handler has set one.
+func (hw *headWriter) finish() {
+	if hw.sent {
+		return
+	}
+
+	if hw.status == 0 {
+		hw.status = http.StatusOK
+	}
+
+	setContentLength(hw.Header(), hw.status, hw.written)
+	hw.send()
+}
+
+// Unwrap returns the underline `ResponseWriter`.
+func (hw *headWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
+// Written returns the number of the discarded body bytes.
+func (hw *headWriter) Written() int64 {
+	return hw.written
+}
+
+// setContentLength sets the Content-Length header of a response of "n" body bytes, unless it is already set,
+// the response is chunked or its status code does not allow a body.
+func setContentLength(h http.Header, status int, n int64) {
+	if h.Get("Content-Length") == "" && h.Get("Transfer-Encoding") == "" && bodyAllowed(status) {
+		h.Set("Content-Length", strconv.FormatInt(n, 10))
+	}
+}
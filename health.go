@@ -0,0 +1,192 @@
+package muxie
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Heartbeat registers a liveness route which responds with 200 "ok" to the GET and HEAD requests of the "pattern",
+// i.e for the load balancers and the liveness probes:
+// mux.Heartbeat("/healthz")
+//
+// It is registered as any other route, the `Use` middlewares that are registered before it wrap it too,
+// but it is served while the mux is in `Maintenance`.
+// Returns the `Route` for further calls.
+func (m *Mux) Heartbeat(pattern string) *Route {
+	return m.HandleMethod(http.MethodGet, pattern, heartbeat{})
+}
+
+type heartbeat struct{}
+
+func (heartbeat) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	h.Set("Cache-Control", "no-store")
+	w.Write([]byte("ok"))
+}
+
+// HealthCheck is the type of the functions that check a component of the server, i.e a database ping,
+// they should return when the "ctx" is done. A nil error means the component is healthy.
+type HealthCheck func(ctx context.Context) error
+
+// HealthOption is the type of the options that `NewHealth` accepts.
+type HealthOption func(*Health)
+
+// HealthTimeout is a `HealthOption` which sets the time limit of each check. Defaults to 5 seconds.
+func HealthTimeout(timeout time.Duration) HealthOption {
+	return func(h *Health) {
+		h.timeout = timeout
+	}
+}
+
+// Health is a registry of the `HealthCheck`s of the server components, it is an http.Handler
+// which runs them concurrently and responds with their JSON report, i.e for the readiness probes:
+// health := muxie.NewHealth()
+// health.Register("db", db.PingContext)
+// mux.Heartbeat("/healthz")
+// mux.Handle("/readyz", health)
+//
+// It responds with 200 OK if all the checks pass, or with 503 Service Unavailable, i.e
+// {"status":"fail","checks":{"db":{"status":"fail","latency":"5s","error":"context deadline exceeded"}}}.
+// Its routes are served while the mux is in `Maintenance`.
+type Health struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	names  []string
+	checks map[string]HealthCheck
+}
+
+var _ http.Handler = (*Health)(nil)
+
+// NewHealth returns a new, empty, `Health`.
+func NewHealth(options ...HealthOption) *Health {
+	h := &Health{timeout: 5 * time.Second, checks: make(map[string]HealthCheck)}
+	for _, opt := range options {
+		opt(h)
+	}
+
+	return h
+}
+
+// Register registers the "check" of a component by its name, a check of the same name is replaced.
+// It can be called while the Health serves requests, i.e when a component starts.
+func (h *Health) Register(name string, check HealthCheck) {
+	if name == "" || check == nil {
+		panic("muxie/Health#Register: empty name or check")
+	}
+
+	h.mu.Lock()
+	if _, exists := h.checks[name]; !exists {
+		h.names = append(h.names, name)
+	}
+	h.checks[name] = check
+	h.mu.Unlock()
+}
+
+// HealthReport is the result of a `Health#Check`.
+type HealthReport struct {
+	// Status is "ok" if all of the checks passed, otherwise "fail".
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks"`
+}
+
+// HealthCheckResult is the result of a single `HealthCheck`.
+type HealthCheckResult struct {
+	// Status is "ok" or "fail".
+	Status  string        `json:"status"`
+	Latency time.Duration `json:"-"`
+	// Error is the error of the failed check, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// MarshalJSON returns the JSON of the result, its latency is formatted as a duration, i.e "1.2ms".
+func (res HealthCheckResult) MarshalJSON() ([]byte, error) {
+	type result HealthCheckResult
+	return json.Marshal(struct {
+		result
+		Latency string `json:"latency"`
+	}{result(res), res.Latency.String()})
+}
+
+// Check runs all the registered checks concurrently and it returns their report.
+func (h *Health) Check(ctx context.Context) HealthReport {
+	h.mu.RLock()
+	names := append([]string(nil), h.names...)
+	checks := make([]HealthCheck, len(names))
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.RUnlock()
+
+	results := make([]HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = h.run(ctx, checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	report := HealthReport{Status: "ok", Checks: make(map[string]HealthCheckResult, len(names))}
+	for i, name := range names {
+		if results[i].Status != "ok" {
+			report.Status = "fail"
+		}
+		report.Checks[name] = results[i]
+	}
+
+	return report
+}
+
+func (h *Health) run(ctx context.Context, check HealthCheck) HealthCheckResult {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := check(ctx)
+	res := HealthCheckResult{Status: "ok", Latency: time.Since(start)}
+	if err != nil {
+		res.Status = "fail"
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// isHealthHandler reports whether the "handler" is a `Heartbeat` or a `Health` one, see `Maintenance`.
+func isHealthHandler(handler http.Handler) bool {
+	switch handler.(type) {
+	case heartbeat, *Health:
+		return true
+	default:
+		return false
+	}
+}
+
+// ServeHTTP runs the checks and it responds with their JSON report, see `Check`.
+func (h *Health) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.Check(r.Context())
+
+	hdr := w.Header()
+	hdr.Set("Content-Type", "application/json; charset=utf-8")
+	hdr.Set("Cache-Control", "no-store")
+
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	w.WriteHeader(status)
+
+	if r.Method != http.MethodHead {
+		json.NewEncoder(w).Encode(report)
+	}
+}
@@ -0,0 +1,84 @@
+package muxie
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMuxHeartbeat(t *testing.T) {
+	mux := NewMux()
+	mux.Heartbeat("/healthz")
+
+	testHandler(t, mux, http.MethodGet, "/healthz").statusCode(http.StatusOK).bodyEq("ok").
+		headerEq("Cache-Control", "no-store")
+	testHandler(t, mux, http.MethodHead, "/healthz").statusCode(http.StatusOK).bodyEq("")
+	testHandler(t, mux, http.MethodPost, "/healthz").statusCode(http.StatusNotFound)
+}
+
+func TestHealth(t *testing.T) {
+	health := NewHealth(HealthTimeout(20 * time.Millisecond))
+	health.Register("db", func(ctx context.Context) error { return nil })
+	health.Register("cache", func(ctx context.Context) error { return nil })
+
+	mux := NewMux()
+	mux.Handle("/readyz", health)
+
+	serve := func() (int, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		if expected, got := "application/json; charset=utf-8", rec.Header().Get("Content-Type"); expected != got {
+			t.Fatalf("expected content type: %s but got: %s", expected, got)
+		}
+
+		var body map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+
+		return rec.Code, body
+	}
+
+	status, body := serve()
+	if status != http.StatusOK || body["status"] != "ok" {
+		t.Fatalf("expected the healthy report but got: %d %v", status, body)
+	}
+
+	db := body["checks"].(map[string]interface{})["db"].(map[string]interface{})
+	if db["status"] != "ok" || db["latency"] == "" || db["error"] != nil {
+		t.Fatalf("unexpected db result: %v", db)
+	}
+
+	// a failed and a timed out check, a replaced one.
+	health.Register("cache", func(ctx context.Context) error { return errors.New("connection refused") })
+	health.Register("queue", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	status, body = serve()
+	if status != http.StatusServiceUnavailable || body["status"] != "fail" {
+		t.Fatalf("expected the failed report but got: %d %v", status, body)
+	}
+
+	checks := body["checks"].(map[string]interface{})
+	if len(checks) != 3 {
+		t.Fatalf("expected 3 checks but got: %v", checks)
+	}
+
+	for name, expected := range map[string]string{"db": "", "cache": "connection refused", "queue": "context deadline exceeded"} {
+		check := checks[name].(map[string]interface{})
+		if got, _ := check["error"].(string); got != expected {
+			t.Fatalf("%s: expected error: %q but got: %q", name, expected, got)
+		}
+	}
+
+	if report := health.Check(context.Background()); report.Checks["queue"].Latency < 20*time.Millisecond {
+		t.Fatalf("expected the queue check to time out but got: %s", report.Checks["queue"].Latency)
+	}
+}
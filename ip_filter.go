@@ -0,0 +1,121 @@
+package muxie
+
+import (
+	"net/http"
+	"net/netip"
+)
+
+// IPFilterOption is the type of the options that `IPFilter` accepts.
+type IPFilterOption func(*ipFilter)
+
+// IPAllow is an `IPFilterOption` which allows only the clients of the given CIDRs or IPs,
+// i.e "10.0.0.0/8", "2001:db8::/32" or "192.0.2.10". It panics if one of them is not valid.
+func IPAllow(cidrs ...string) IPFilterOption {
+	return func(f *ipFilter) {
+		f.allow = append(f.allow, parsePrefixes("muxie/IPAllow", cidrs)...)
+	}
+}
+
+// IPDeny is an `IPFilterOption` which rejects the clients of the given CIDRs or IPs, even if they are allowed by the `IPAllow`.
+// It panics if one of them is not valid.
+func IPDeny(cidrs ...string) IPFilterOption {
+	return func(f *ipFilter) {
+		f.deny = append(f.deny, parsePrefixes("muxie/IPDeny", cidrs)...)
+	}
+}
+
+// IPFilterAudit is an `IPFilterOption` which registers a function that is called for each rejected request
+// with the client IP, i.e to log the rejected attempts to the admin routes.
+func IPFilterAudit(audit func(r *http.Request, ip string)) IPFilterOption {
+	return func(f *ipFilter) {
+		f.audit = audit
+	}
+}
+
+// IPFilterResponse is an `IPFilterOption` which customizes the response of the rejected requests.
+// Defaults to a plain text 403 Forbidden response.
+func IPFilterResponse(handler http.Handler) IPFilterOption {
+	return func(f *ipFilter) {
+		f.handler = handler
+	}
+}
+
+type ipFilter struct {
+	allow   []netip.Prefix
+	deny    []netip.Prefix
+	audit   func(r *http.Request, ip string)
+	handler http.Handler
+}
+
+// IPFilter returns a middleware which allows or rejects the requests by their client IP, see `ClientIP`,
+// against the CIDRs of the `IPAllow` and the `IPDeny`, IPv4 and IPv6, i.e:
+// mux.Wrap(muxie.IPFilter(muxie.IPDeny("203.0.113.0/24")))
+// mux.Of("/admin").Use(muxie.IPFilter(muxie.IPAllow("10.0.0.0/8", "fd00::/8"), muxie.IPFilterAudit(auditRejected)))
+//
+// The denied IPs are always rejected, if there are allowed ones then the rest IPs are rejected too.
+// The requests that their client IP can not be resolved are rejected. The nested filters, i.e of the mux and of a group,
+// should all allow a request. Register the `RealIP` before it for the clients behind proxies.
+func IPFilter(options ...IPFilterOption) Wrapper {
+	f := new(ipFilter)
+	for _, opt := range options {
+		opt(f)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(r)
+			if !f.isAllowed(ip) {
+				if f.audit != nil {
+					f.audit(r, ip)
+				}
+
+				if f.handler != nil {
+					f.handler.ServeHTTP(w, r)
+					return
+				}
+
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (f *ipFilter) isAllowed(ip string) bool {
+	addr, ok := parseIP(ip)
+	if !ok {
+		return false
+	}
+
+	if containsAddr(f.deny, addr) {
+		return false
+	}
+
+	return len(f.allow) == 0 || containsAddr(f.allow, addr)
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parsePrefixes(caller string, cidrs []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		prefix, ok := parsePrefix(cidr)
+		if !ok {
+			panic(caller + ": invalid CIDR or IP \"" + cidr + "\"")
+		}
+
+		prefixes = append(prefixes, prefix)
+	}
+
+	return prefixes
+}
@@ -0,0 +1,93 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilter(t *testing.T) {
+	var rejected []string
+
+	mux := NewMux()
+	mux.Use(IPFilter(IPDeny("203.0.113.0/24", "2001:db8:bad::/48")))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("public"))
+	})
+
+	admin := mux.Of("/admin")
+	admin.Use(IPFilter(
+		IPAllow("10.0.0.0/8", "192.0.2.10", "2001:db8::/32"),
+		IPFilterAudit(func(r *http.Request, ip string) { rejected = append(rejected, ip) }),
+		IPFilterResponse(ProblemHandler(http.StatusForbidden)),
+	))
+	admin.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("admin"))
+	})
+
+	tests := []struct {
+		remoteAddr, path string
+		status           int
+	}{
+		{"198.51.100.1:1234", "/", http.StatusOK},
+		{"203.0.113.7:1234", "/", http.StatusForbidden},
+		{"[2001:db8:bad::1]:1234", "/", http.StatusForbidden},
+		{"[::ffff:203.0.113.7]:1234", "/", http.StatusForbidden},
+		{"10.1.2.3:1234", "/admin", http.StatusOK},
+		{"192.0.2.10:1234", "/admin", http.StatusOK},
+		{"[2001:db8::1]:1234", "/admin", http.StatusOK},
+		{"192.0.2.11:1234", "/admin", http.StatusForbidden},
+		// denied by the mux' filter.
+		{"[2001:db8:bad::1]:1234", "/admin", http.StatusForbidden},
+		{"unknown", "/admin", http.StatusForbidden},
+	}
+
+	for i, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.RemoteAddr = tt.remoteAddr
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Fatalf("[%d] %s %s: expected status code: %d but got: %d", i, tt.remoteAddr, tt.path, tt.status, rec.Code)
+		}
+	}
+
+	// the rest ones are rejected by the mux' filter.
+	expected := []string{"192.0.2.11"}
+	if len(rejected) != len(expected) || rejected[0] != expected[0] {
+		t.Fatalf("expected audited IPs: %q but got: %q", expected, rejected)
+	}
+}
+
+func TestIPFilterRealIP(t *testing.T) {
+	mux := NewMux()
+	mux.Wrap(RealIP(RealIPTrustedProxies("10.0.0.1")), IPFilter(IPAllow("192.0.2.0/24")))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "192.0.2.60")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the forwarded client to be allowed but got: %d", rec.Code)
+	}
+
+	req.Header.Del("X-Forwarded-For")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected the proxy itself to be rejected but got: %d", rec.Code)
+	}
+}
+
+func TestIPFilterInvalidCIDR(t *testing.T) {
+	defer func() {
+		if expected, got := `muxie/IPAllow: invalid CIDR or IP "10.0.0.0/33"`, recover(); expected != got {
+			t.Fatalf("expected panic: %s but got: %v", expected, got)
+		}
+	}()
+
+	IPFilter(IPAllow("10.0.0.0/33"))
+}
@@ -0,0 +1,424 @@
+package muxie
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	// the hash functions of the supported algorithms.
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+// JWTToken is a parsed JSON Web Token, which its signature is not verified yet when it is passed to a `JWTKeyFunc`.
+type JWTToken struct {
+	Raw    string
+	Header map[string]interface{}
+	Claims JWTClaims
+}
+
+// Algorithm returns the "alg" header of the token, i.e "HS256".
+func (t *JWTToken) Algorithm() string {
+	alg, _ := t.Header["alg"].(string)
+	return alg
+}
+
+// KeyID returns the "kid" header of the token, if any.
+func (t *JWTToken) KeyID() string {
+	kid, _ := t.Header["kid"].(string)
+	return kid
+}
+
+// JWTClaims are the claims of a JSON Web Token.
+type JWTClaims map[string]interface{}
+
+// String returns the string value of a claim, i.e "sub", or empty.
+func (c JWTClaims) String(key string) string {
+	s, _ := c[key].(string)
+	return s
+}
+
+// Subject returns the "sub" claim, if any.
+func (c JWTClaims) Subject() string {
+	return c.String("sub")
+}
+
+// Audience returns the "aud" claim, which can be a string or an array of strings.
+func (c JWTClaims) Audience() []string {
+	switch aud := c["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []interface{}:
+		audience := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				audience = append(audience, s)
+			}
+		}
+		return audience
+	default:
+		return nil
+	}
+}
+
+// Time returns the time of a numeric date claim, i.e "exp", and true if it exists and it is a valid numeric date.
+func (c JWTClaims) Time(key string) (time.Time, bool) {
+	t, ok, err := c.numericDate(key)
+	return t, ok && err == nil
+}
+
+// jwtMaxNumericDate is the last second of the year 9999, the later numeric dates are malformed.
+const jwtMaxNumericDate = 253402300799
+
+// numericDate returns the time of a numeric date claim, if it exists,
+// and the `ErrJWTMalformed` if it is not a number or it is out of range.
+func (c JWTClaims) numericDate(key string) (time.Time, bool, error) {
+	var f float64
+	switch v := c[key].(type) {
+	case nil:
+		if _, ok := c[key]; !ok {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, true, ErrJWTMalformed
+	case json.Number:
+		var err error
+		if f, err = v.Float64(); err != nil {
+			return time.Time{}, true, ErrJWTMalformed
+		}
+	case float64:
+		f = v
+	default:
+		return time.Time{}, true, ErrJWTMalformed
+	}
+
+	if math.IsNaN(f) || math.Abs(f) > jwtMaxNumericDate {
+		return time.Time{}, true, ErrJWTMalformed
+	}
+
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*float64(time.Second))), true, nil
+}
+
+// JWTKeyFunc is the type of the functions that return the key which verifies the signature of a token,
+// a []byte for the HMAC algorithms, i.e "HS256", a *rsa.PublicKey for the RSA ones, i.e "RS256" and "PS256",
+// and an *ecdsa.PublicKey for the ECDSA ones, i.e "ES256". The token's "kid" header can select the key.
+type JWTKeyFunc func(token *JWTToken) (interface{}, error)
+
+// JWTOption is the type of the options that `JWTAuth` accepts.
+type JWTOption func(*jwtValidator)
+
+// JWTAlgorithms is a `JWTOption` which restricts the algorithms of the accepted tokens,
+// it should be set when the keys are selected by the tokens' "kid" header.
+// Defaults to all the supported algorithms, the "none" is never accepted.
+func JWTAlgorithms(algorithms ...string) JWTOption {
+	return func(v *jwtValidator) {
+		v.algorithms = algorithms
+	}
+}
+
+// JWTAudience is a `JWTOption` which requires the "aud" claim of the tokens to contain one of the "audience".
+func JWTAudience(audience ...string) JWTOption {
+	return func(v *jwtValidator) {
+		v.audience = audience
+	}
+}
+
+// JWTIssuer is a `JWTOption` which requires the "iss" claim of the tokens to be one of the "issuers".
+func JWTIssuer(issuers ...string) JWTOption {
+	return func(v *jwtValidator) {
+		v.issuers = issuers
+	}
+}
+
+// JWTLeeway is a `JWTOption` which allows a clock skew on the "exp" and "nbf" claims.
+func JWTLeeway(leeway time.Duration) JWTOption {
+	return func(v *jwtValidator) {
+		v.leeway = leeway
+	}
+}
+
+// JWTRealm is a `JWTOption` which sets the realm of the "WWW-Authenticate" header. Defaults to "Restricted".
+func JWTRealm(realm string) JWTOption {
+	return func(v *jwtValidator) {
+		v.realm = realm
+	}
+}
+
+// JWTUnauthorized is a `JWTOption` which customizes the response of the unauthorized requests,
+// the "WWW-Authenticate" header is already set when it is called, the error is retrieved by the `JWTErrorFromContext`.
+// Defaults to a plain text 401 Unauthorized response.
+func JWTUnauthorized(handler http.Handler) JWTOption {
+	return func(v *jwtValidator) {
+		v.unauthorized = handler
+	}
+}
+
+type jwtValidator struct {
+	keyFunc      JWTKeyFunc
+	algorithms   []string
+	audience     []string
+	issuers      []string
+	leeway       time.Duration
+	realm        string
+	unauthorized http.Handler
+	now          func() time.Time
+}
+
+// JWTAuth returns a middleware which requires a valid JSON Web Token as a bearer token on the "Authorization" header, i.e:
+// mux.Use(muxie.JWTAuth(func(*muxie.JWTToken) (interface{}, error) { return secret, nil }, muxie.JWTIssuer("auth.example.com")))
+//
+// The signature is verified by the key of the "keyFunc" and the "exp" and "nbf" claims are checked, if they exist.
+// The claims of the valid tokens are retrieved by the `JWTClaimsFromContext`.
+// The unauthorized responses have the RFC 6750 "WWW-Authenticate" header, i.e
+// `Bearer realm="Restricted", error="invalid_token", error_description="the token is expired"`.
+func JWTAuth(keyFunc JWTKeyFunc, options ...JWTOption) Wrapper {
+	if keyFunc == nil {
+		panic("muxie/JWTAuth: empty key func")
+	}
+
+	v := &jwtValidator{keyFunc: keyFunc, realm: "Restricted", now: time.Now}
+	for _, opt := range options {
+		opt(v)
+	}
+
+	challenge := "Bearer realm=" + strconv.Quote(v.realm)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, ok := bearerToken(r)
+			if !ok {
+				v.deny(w, r, challenge, nil)
+				return
+			}
+
+			token, err := v.validate(raw)
+			if err != nil {
+				v.deny(w, r, challenge+`, error="invalid_token", error_description=`+strconv.Quote(err.Error()), err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), jwtContextKey{}, token.Claims)))
+		})
+	}
+}
+
+func (v *jwtValidator) deny(w http.ResponseWriter, r *http.Request, challenge string, err error) {
+	w.Header().Set("WWW-Authenticate", challenge)
+
+	if v.unauthorized != nil {
+		if err != nil {
+			r = r.WithContext(context.WithValue(r.Context(), jwtErrorContextKey{}, err))
+		}
+		v.unauthorized.ServeHTTP(w, r)
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// The errors of the invalid tokens, see `JWTErrorFromContext`.
+var (
+	ErrJWTMalformed       = errors.New("the token is malformed")
+	ErrJWTAlgorithm       = errors.New("the token's algorithm is not accepted")
+	ErrJWTSignature       = errors.New("the token's signature is invalid")
+	ErrJWTExpired         = errors.New("the token is expired")
+	ErrJWTNotValidYet     = errors.New("the token is not valid yet")
+	ErrJWTInvalidAudience = errors.New("the token's audience is not accepted")
+	ErrJWTInvalidIssuer   = errors.New("the token's issuer is not accepted")
+)
+
+var (
+	errJWTUnsupportedKey   = errors.New("the key is not supported by the token's algorithm")
+	errJWTUnsupportedCurve = errors.New("the key's curve is not supported by the token's algorithm")
+)
+
+func (v *jwtValidator) validate(raw string) (*JWTToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrJWTMalformed
+	}
+
+	token := &JWTToken{Raw: raw}
+	if err := decodeJWTPart(parts[0], &token.Header); err != nil {
+		return nil, err
+	}
+
+	if err := decodeJWTPart(parts[1], &token.Claims); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrJWTMalformed
+	}
+
+	alg := token.Algorithm()
+	if _, ok := jwtHashes[alg]; !ok || (len(v.algorithms) > 0 && !containsMethod(v.algorithms, alg)) {
+		return nil, ErrJWTAlgorithm
+	}
+
+	key, err := v.keyFunc(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = verifyJWT(alg, parts[0]+"."+parts[1], signature, key); err != nil {
+		return nil, err
+	}
+
+	exp, hasExp, err := token.Claims.numericDate("exp")
+	if err != nil {
+		return nil, err
+	}
+
+	nbf, hasNbf, err := token.Claims.numericDate("nbf")
+	if err != nil {
+		return nil, err
+	}
+
+	now := v.now()
+	if hasExp && !now.Before(exp.Add(v.leeway)) {
+		return nil, ErrJWTExpired
+	}
+
+	if hasNbf && now.Add(v.leeway).Before(nbf) {
+		return nil, ErrJWTNotValidYet
+	}
+
+	if len(v.audience) > 0 && !containsAny(token.Claims.Audience(), v.audience) {
+		return nil, ErrJWTInvalidAudience
+	}
+
+	if len(v.issuers) > 0 && !containsMethod(v.issuers, token.Claims.String("iss")) {
+		return nil, ErrJWTInvalidIssuer
+	}
+
+	return token, nil
+}
+
+func decodeJWTPart(part string, ptr interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return ErrJWTMalformed
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err = dec.Decode(ptr); err != nil {
+		return ErrJWTMalformed
+	}
+
+	return nil
+}
+
+func containsAny(values, expected []string) bool {
+	for _, v := range values {
+		if containsMethod(expected, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var jwtHashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// verifyJWT verifies the "signature" of the "signed" header and claims by the "key" of the "alg" algorithm.
+func verifyJWT(alg, signed string, signature []byte, key interface{}) error {
+	hash := jwtHashes[alg]
+
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return errJWTUnsupportedKey
+		}
+
+		mac := hmac.New(hash.New, secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrJWTSignature
+		}
+		return nil
+	}
+
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch alg[:2] {
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errJWTUnsupportedKey
+		}
+
+		var err error
+		if alg[0] == 'R' {
+			err = rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+		} else {
+			err = rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+		}
+
+		if err != nil {
+			return ErrJWTSignature
+		}
+		return nil
+	default: // "ES".
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errJWTUnsupportedKey
+		}
+
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if expected := map[string]int{"ES256": 32, "ES384": 48, "ES512": 66}[alg]; size != expected {
+			return errJWTUnsupportedCurve
+		}
+
+		if len(signature) != 2*size {
+			return ErrJWTSignature
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return ErrJWTSignature
+		}
+		return nil
+	}
+}
+
+type (
+	jwtContextKey      struct{}
+	jwtErrorContextKey struct{}
+)
+
+// JWTClaimsFromContext returns the claims of the token that the `JWTAuth` validated for the request, or nil.
+func JWTClaimsFromContext(r *http.Request) JWTClaims {
+	claims, _ := r.Context().Value(jwtContextKey{}).(JWTClaims)
+	return claims
+}
+
+// JWTErrorFromContext returns the reason that the `JWTAuth` rejected the request's token for,
+// to the `JWTUnauthorized` handler, i.e the `ErrJWTExpired`, or nil if the request had not a token.
+func JWTErrorFromContext(r *http.Request) error {
+	err, _ := r.Context().Value(jwtErrorContextKey{}).(error)
+	return err
+}
@@ -0,0 +1,166 @@
+package muxie
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func signTestJWT(t *testing.T, alg string, key interface{}, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]interface{}{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+
+	var (
+		signature []byte
+		err       error
+	)
+
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg == "PS256" {
+			signature, err = rsa.SignPSS(rand.Reader, k, crypto.SHA256, digest[:], nil)
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		}
+	case *ecdsa.PrivateKey:
+		r, s, signErr := ecdsa.Sign(rand.Reader, k, digest[:])
+		err = signErr
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTAuth(t *testing.T) {
+	now := time.Now()
+	secret := []byte("secret")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFunc := func(token *JWTToken) (interface{}, error) {
+		switch token.Algorithm() {
+		case "HS256":
+			return secret, nil
+		case "RS256", "PS256":
+			return &rsaKey.PublicKey, nil
+		case "ES256":
+			return &ecKey.PublicKey, nil
+		default:
+			return nil, errors.New("unknown key")
+		}
+	}
+
+	mux := NewMux()
+	mux.Use(JWTAuth(keyFunc, JWTIssuer("auth.example.com"), JWTAudience("api"), JWTLeeway(time.Minute)))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		claims := JWTClaimsFromContext(r)
+		fmt.Fprintf(w, "hello %s", claims.Subject())
+	})
+
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub": "kataras",
+			"iss": "auth.example.com",
+			"aud": []string{"web", "api"},
+			"exp": now.Add(time.Hour).Unix(),
+			"nbf": now.Add(-time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	var tests = []struct {
+		name   string
+		token  string
+		status int
+		header string
+	}{
+		{"HS256", signTestJWT(t, "HS256", secret, claims(nil)), http.StatusOK, ""},
+		{"RS256", signTestJWT(t, "RS256", rsaKey, claims(nil)), http.StatusOK, ""},
+		{"PS256", signTestJWT(t, "PS256", rsaKey, claims(nil)), http.StatusOK, ""},
+		{"ES256", signTestJWT(t, "ES256", ecKey, claims(nil)), http.StatusOK, ""},
+		{"missing", "", http.StatusUnauthorized, `Bearer realm="Restricted"`},
+		{"malformed", "abc.def", http.StatusUnauthorized,
+			`Bearer realm="Restricted", error="invalid_token", error_description="the token is malformed"`},
+		{"signature", signTestJWT(t, "HS256", []byte("other"), claims(nil)), http.StatusUnauthorized,
+			`Bearer realm="Restricted", error="invalid_token", error_description="the token's signature is invalid"`},
+		{"none", signTestJWT(t, "none", secret, claims(nil)), http.StatusUnauthorized,
+			`Bearer realm="Restricted", error="invalid_token", error_description="the token's algorithm is not accepted"`},
+		{"expired", signTestJWT(t, "HS256", secret, claims(map[string]interface{}{"exp": now.Add(-2 * time.Minute).Unix()})),
+			http.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token", error_description="the token is expired"`},
+		{"leeway", signTestJWT(t, "HS256", secret, claims(map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()})),
+			http.StatusOK, ""},
+		{"far future exp", signTestJWT(t, "HS256", secret, claims(map[string]interface{}{"exp": int64(1e10)})),
+			http.StatusOK, ""},
+		{"string exp", signTestJWT(t, "HS256", secret, claims(map[string]interface{}{"exp": "soon"})),
+			http.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token", error_description="the token is malformed"`},
+		{"out of range exp", signTestJWT(t, "HS256", secret, claims(map[string]interface{}{"exp": 1e19})),
+			http.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token", error_description="the token is malformed"`},
+		{"string nbf", signTestJWT(t, "HS256", secret, claims(map[string]interface{}{"nbf": "now"})),
+			http.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token", error_description="the token is malformed"`},
+		{"nbf", signTestJWT(t, "HS256", secret, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+			http.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token", error_description="the token is not valid yet"`},
+		{"aud", signTestJWT(t, "HS256", secret, claims(map[string]interface{}{"aud": "web"})),
+			http.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token", error_description="the token's audience is not accepted"`},
+		{"iss", signTestJWT(t, "HS256", secret, claims(map[string]interface{}{"iss": "evil.example.com"})),
+			http.StatusUnauthorized, `Bearer realm="Restricted", error="invalid_token", error_description="the token's issuer is not accepted"`},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		mux.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Fatalf("%s: expected status code: %d but got: %d", tt.name, tt.status, w.Code)
+		}
+
+		if got := w.Header().Get("WWW-Authenticate"); got != tt.header {
+			t.Fatalf("%s: expected WWW-Authenticate: '%s' but got: '%s'", tt.name, tt.header, got)
+		}
+
+		if tt.status == http.StatusOK && w.Body.String() != "hello kataras" {
+			t.Fatalf("%s: unexpected body: %s", tt.name, w.Body.String())
+		}
+	}
+}
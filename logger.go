@@ -0,0 +1,251 @@
+package muxie
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LogEntry holds the information of a served request, see `Logger`.
+type LogEntry struct {
+	Time    time.Time
+	Request *http.Request
+	// Route is the path pattern of the route that served the request, i.e "/users/:id", empty if not found.
+	Route   string
+	Status  int
+	Bytes   int64
+	Latency time.Duration
+	// RequestID is the ID that the `RequestIDs` gave to the request, if any.
+	RequestID string
+}
+
+// LogFormat returns the line of a `LogEntry`, without the new line, see `Logger`.
+type LogFormat func(e *LogEntry) string
+
+var (
+	// DefaultLogFormat is the `LogFormat` of the `Logger` by default,
+	// i.e "GET /users/42 /users/:id 200 12 1.2ms".
+	DefaultLogFormat LogFormat = func(e *LogEntry) string {
+		route := e.Route
+		if route == "" {
+			route = "-"
+		}
+
+		return e.Request.Method + " " + e.Request.URL.RequestURI() + " " + route + " " +
+			strconv.Itoa(e.Status) + " " + strconv.FormatInt(e.Bytes, 10) + " " + e.Latency.String()
+	}
+
+	// CommonLogFormat is the `LogFormat` of the Common Log Format,
+	// i.e `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`.
+	CommonLogFormat LogFormat = func(e *LogEntry) string {
+		user := "-"
+		if username, _, ok := e.Request.BasicAuth(); ok && username != "" {
+			user = username
+		}
+
+		bytes := "-"
+		if e.Bytes > 0 {
+			bytes = strconv.FormatInt(e.Bytes, 10)
+		}
+
+		return remoteHost(e.Request) + " - " + user + " [" + e.Time.Format("02/Jan/2006:15:04:05 -0700") + "] \"" +
+			e.Request.Method + " " + e.Request.URL.RequestURI() + " " + e.Request.Proto + "\" " +
+			strconv.Itoa(e.Status) + " " + bytes
+	}
+
+	// CombinedLogFormat is the `LogFormat` of the Combined Log Format, the `CommonLogFormat` with the "Referer" and the "User-Agent".
+	CombinedLogFormat LogFormat = func(e *LogEntry) string {
+		return CommonLogFormat(e) + " " + strconv.Quote(e.Request.Referer()) + " " + strconv.Quote(e.Request.UserAgent())
+	}
+)
+
+func remoteHost(r *http.Request) string {
+	host := r.RemoteAddr
+	for i := len(host) - 1; i >= 0; i-- {
+		if host[i] == ':' {
+			host = host[:i]
+			break
+		}
+	}
+
+	if host == "" {
+		return "-"
+	}
+
+	return host
+}
+
+// LoggerOption is the type of the options that `Logger` accepts.
+type LoggerOption func(*logger)
+
+// LoggerOutput is a `LoggerOption` which writes the log lines to the "w". Defaults to the os.Stdout.
+func LoggerOutput(w io.Writer) LoggerOption {
+	return func(l *logger) {
+		l.output = w
+	}
+}
+
+// LoggerFormat is a `LoggerOption` which sets the `LogFormat` of the log lines,
+// i.e the `CommonLogFormat` or the `CombinedLogFormat`. Defaults to the `DefaultLogFormat`.
+func LoggerFormat(format LogFormat) LoggerOption {
+	return func(l *logger) {
+		l.format = format
+	}
+}
+
+// LoggerSlog is a `LoggerOption` which logs the requests as structured records through the "logger",
+// of the info level, or the warn and error levels for the 4xx and 5xx responses, instead of the log lines.
+func LoggerSlog(structured *slog.Logger) LoggerOption {
+	return func(l *logger) {
+		l.slog = structured
+	}
+}
+
+type logger struct {
+	mu     sync.Mutex
+	output io.Writer
+	format LogFormat
+	slog   *slog.Logger
+}
+
+// Logger returns a middleware which logs the method, the path, the matched route's path pattern,
+// the status code, the written bytes and the latency of the requests, i.e:
+// mux.Wrap(muxie.Logger())
+// mux.Wrap(muxie.Logger(muxie.LoggerFormat(muxie.CombinedLogFormat), muxie.LoggerOutput(accessLog)))
+// mux.Wrap(muxie.Logger(muxie.LoggerSlog(slog.Default())))
+//
+// Register it through the `Mux#Wrap` to log the not found requests as well, or through the `Mux#Use`.
+// The ID of the `RequestIDs` is passed to the `LogFormat` and it is logged as the "request_id" attribute by the `LoggerSlog`.
+func Logger(options ...LoggerOption) Wrapper {
+	l := &logger{output: os.Stdout, format: DefaultLogFormat}
+	for _, opt := range options {
+		opt(l)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l.log(serveEntry(next, w, r))
+		})
+	}
+}
+
+// serveEntry serves the request by the "next" handler and it returns the `LogEntry` of its response.
+func serveEntry(next http.Handler, w http.ResponseWriter, r *http.Request) *LogEntry {
+	lw := &logWriter{ResponseWriter: w}
+	start := time.Now()
+
+	next.ServeHTTP(lw, r)
+
+	if lw.route == "" {
+		lw.route = RoutePattern(w)
+	}
+
+	if lw.status == 0 {
+		lw.status = http.StatusOK
+	}
+
+	if id := RequestID(r); id != "" {
+		lw.requestID = id
+	}
+
+	return &LogEntry{
+		Time:      start,
+		Request:   r,
+		Route:     lw.route,
+		Status:    lw.status,
+		Bytes:     lw.bytes,
+		Latency:   time.Since(start),
+		RequestID: lw.requestID,
+	}
+}
+
+func (l *logger) log(e *LogEntry) {
+	if l.slog != nil {
+		level := slog.LevelInfo
+		if e.Status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if e.Status >= http.StatusBadRequest {
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", e.Request.Method),
+			slog.String("path", e.Request.URL.Path),
+			slog.String("route", e.Route),
+			slog.Int("status", e.Status),
+			slog.Int64("bytes", e.Bytes),
+			slog.Duration("latency", e.Latency),
+		}
+
+		if e.RequestID != "" {
+			attrs = append(attrs, slog.String("request_id", e.RequestID))
+		}
+
+		l.slog.LogAttrs(context.Background(), level, "request", attrs...)
+		return
+	}
+
+	line := l.format(e) + "\n"
+
+	l.mu.Lock()
+	io.WriteString(l.output, line)
+	l.mu.Unlock()
+}
+
+// logWriter captures the status code and the written bytes of a response, see `Logger`.
+type logWriter struct {
+	http.ResponseWriter
+	status    int
+	bytes     int64
+	route     string
+	requestID string
+}
+
+var _ Unwrapper = (*logWriter)(nil)
+
+func (w *logWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the client, if the underline http.ResponseWriter supports it.
+func (w *logWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underline http.ResponseWriter.
+func (w *logWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *logWriter) recordRoute(pattern string) {
+	w.route = pattern
+}
+
+func (w *logWriter) recordRequestID(id string) {
+	w.requestID = id
+}
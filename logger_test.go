@@ -0,0 +1,95 @@
+package muxie
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger(t *testing.T) {
+	var logs bytes.Buffer
+
+	mux := NewMux()
+	mux.Wrap(Logger(LoggerOutput(&logs)))
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s", GetParam(w, "id"))
+	})
+	mux.HandleFunc("/teapot", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42?tab=posts").bodyEq("user 42")
+	testHandler(t, mux, http.MethodPost, "/teapot").statusCode(http.StatusTeapot)
+	testHandler(t, mux, http.MethodGet, "/missing").statusCode(http.StatusNotFound)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	expected := []string{
+		`^GET /users/42\?tab=posts /users/:id 200 7 [0-9.]+[µnm]?s$`,
+		`^POST /teapot /teapot 418 0 [0-9.]+[µnm]?s$`,
+		`^GET /missing - 404 19 [0-9.]+[µnm]?s$`,
+	}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d log lines but got: %q", len(expected), lines)
+	}
+
+	for i, line := range lines {
+		if !regexp.MustCompile(expected[i]).MatchString(line) {
+			t.Fatalf("expected log line to match: %s but got: %s", expected[i], line)
+		}
+	}
+
+	// through the Use.
+	logs.Reset()
+	routes := NewMux()
+	routes.Use(Logger(LoggerOutput(&logs)))
+	routes.HandleFunc("/posts/:slug", func(w http.ResponseWriter, r *http.Request) {})
+	testHandler(t, routes, http.MethodGet, "/posts/hello")
+	if line := logs.String(); !strings.HasPrefix(line, "GET /posts/hello /posts/:slug 200 0 ") {
+		t.Fatalf("unexpected log line: %s", line)
+	}
+}
+
+func TestLoggerFormats(t *testing.T) {
+	entry := &LogEntry{
+		Time:    time.Date(2000, time.October, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60)),
+		Request: httptest.NewRequest(http.MethodGet, "/apache_pb.gif", nil),
+		Route:   "/*file",
+		Status:  http.StatusOK,
+		Bytes:   2326,
+	}
+	entry.Request.RemoteAddr = "127.0.0.1:4242"
+	entry.Request.Proto = "HTTP/1.0"
+	entry.Request.SetBasicAuth("frank", "secret")
+	entry.Request.Header.Set("Referer", "http://www.example.com/start.html")
+	entry.Request.Header.Set("User-Agent", "Mozilla/4.08")
+
+	if expected, got := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`, CommonLogFormat(entry); got != expected {
+		t.Fatalf("expected: %s but got: %s", expected, got)
+	}
+
+	if expected, got := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`, CombinedLogFormat(entry); got != expected {
+		t.Fatalf("expected: %s but got: %s", expected, got)
+	}
+}
+
+func TestLoggerSlog(t *testing.T) {
+	var logs bytes.Buffer
+
+	mux := NewMux()
+	mux.Wrap(Logger(LoggerSlog(slog.New(slog.NewTextHandler(&logs, nil)))))
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failure", http.StatusInternalServerError)
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42")
+	if expected, got := `level=ERROR msg=request method=GET path=/users/42 route=/users/:id status=500 bytes=8 latency=`, logs.String(); !strings.Contains(got, expected) {
+		t.Fatalf("expected the record to contain: %s but got: %s", expected, got)
+	}
+}
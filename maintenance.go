@@ -0,0 +1,115 @@
+package muxie
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// MaintenanceOption is the type of the options that `Mux#Maintenance` accepts.
+type MaintenanceOption func(*Maintenance)
+
+// MaintenanceRetryAfter is a `MaintenanceOption` which sets the "Retry-After" header of the maintenance responses,
+// a zero "d" omits it. Defaults to 5 minutes.
+func MaintenanceRetryAfter(d time.Duration) MaintenanceOption {
+	return func(m *Maintenance) {
+		m.retryAfter = d
+	}
+}
+
+// MaintenanceResponse is a `MaintenanceOption` which customizes the maintenance responses, i.e to render a page,
+// the "Retry-After" header is already set when it is called.
+// Defaults to a plain text 503 Service Unavailable response.
+func MaintenanceResponse(handler http.Handler) MaintenanceOption {
+	return func(m *Maintenance) {
+		m.handler = handler
+	}
+}
+
+// Maintenance is the switch of the maintenance mode of a mux' routes, see `Mux#Maintenance`.
+// It can be switched at runtime, from any goroutine.
+type Maintenance struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+	handler    http.Handler
+}
+
+// Enable puts the routes in maintenance.
+func (m *Maintenance) Enable() {
+	m.enabled.Store(true)
+}
+
+// Disable puts the routes back in service.
+func (m *Maintenance) Disable() {
+	m.enabled.Store(false)
+}
+
+// Enabled reports whether the routes are in maintenance.
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// ServeHTTP responds with the maintenance response, 503 Service Unavailable by default.
+func (m *Maintenance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.retryAfter > 0 {
+		w.Header().Set("Retry-After", seconds(m.retryAfter))
+	}
+
+	if m.handler != nil {
+		m.handler.ServeHTTP(w, r)
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
+
+// Maintenance returns the maintenance switch of this Mux' routes and of its sub muxes' ones, i.e:
+// maintenance := mux.Maintenance(muxie.MaintenanceRetryAfter(10 * time.Minute))
+// maintenance.Enable() // all the routes respond with 503 Service Unavailable.
+// billing.Maintenance().Enable() // only the routes of the billing := mux.Of("/billing").
+//
+// It is disabled at first. The `Heartbeat` and the `Health` routes and the ones of the `Route#IgnoreMaintenance`
+// are always served. The not found requests are not affected.
+// It returns the same switch on each call, the options can be given only on the first one.
+func (m *Mux) Maintenance(options ...MaintenanceOption) *Maintenance {
+	m.lock()
+	defer m.unlock()
+
+	maintenance := m.maintenance.Load()
+	if maintenance != nil {
+		if len(options) > 0 {
+			panic("muxie/Mux#Maintenance: the options should be given on the first call")
+		}
+
+		return maintenance
+	}
+
+	maintenance = &Maintenance{retryAfter: 5 * time.Minute}
+	for _, opt := range options {
+		opt(maintenance)
+	}
+
+	m.maintenance.Store(maintenance)
+	return maintenance
+}
+
+// underMaintenance returns the enabled `Maintenance` of this mux or of one of its parents, if any.
+func (m *Mux) underMaintenance() *Maintenance {
+	for mux := m; mux != nil; mux = mux.parent {
+		if maintenance := mux.maintenance.Load(); maintenance != nil && maintenance.Enabled() {
+			return maintenance
+		}
+	}
+
+	return nil
+}
+
+// IgnoreMaintenance makes the route to be served while its mux is in `Mux#Maintenance`, i.e a status page.
+// Returns this Route for further calls.
+func (r *Route) IgnoreMaintenance() *Route {
+	r.mux.lock()
+	r.handler.ignoreMaintenance = true
+	r.mux.unlock()
+
+	return r
+}
@@ -0,0 +1,60 @@
+package muxie
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMuxMaintenance(t *testing.T) {
+	mux := NewMux()
+	maintenance := mux.Maintenance(MaintenanceRetryAfter(10 * time.Minute))
+	mux.Heartbeat("/healthz")
+	health := NewHealth()
+	health.Register("db", func(ctx context.Context) error { return nil })
+	mux.Handle("/readyz", health)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {}).IgnoreMaintenance()
+
+	billing := mux.Of("/billing")
+	billing.HandleFunc("/invoices", func(w http.ResponseWriter, r *http.Request) {})
+
+	if maintenance != mux.Maintenance() {
+		t.Fatalf("expected the same switch")
+	}
+
+	for _, path := range []string{"/", "/status", "/healthz", "/readyz", "/billing/invoices"} {
+		testHandler(t, mux, http.MethodGet, path).statusCode(http.StatusOK)
+	}
+
+	maintenance.Enable()
+	for _, path := range []string{"/", "/billing/invoices"} {
+		testHandler(t, mux, http.MethodGet, path).statusCode(http.StatusServiceUnavailable).headerEq("Retry-After", "600")
+	}
+	for _, path := range []string{"/status", "/healthz", "/readyz"} {
+		testHandler(t, mux, http.MethodGet, path).statusCode(http.StatusOK)
+	}
+	testHandler(t, mux, http.MethodGet, "/missing").statusCode(http.StatusNotFound)
+
+	maintenance.Disable()
+	testHandler(t, mux, http.MethodGet, "/").statusCode(http.StatusOK)
+
+	// only a group.
+	billing.Maintenance(MaintenanceResponse(ProblemHandler(http.StatusServiceUnavailable))).Enable()
+	testHandler(t, mux, http.MethodGet, "/").statusCode(http.StatusOK)
+	testHandler(t, mux, http.MethodGet, "/billing/invoices").statusCode(http.StatusServiceUnavailable).
+		headerEq("Content-Type", ProblemContentType).headerEq("Retry-After", "300")
+}
+
+func TestMuxMaintenanceOptionsAfterFirstCall(t *testing.T) {
+	defer func() {
+		if expected, got := "muxie/Mux#Maintenance: the options should be given on the first call", recover(); expected != got {
+			t.Fatalf("expected panic: %s but got: %v", expected, got)
+		}
+	}()
+
+	mux := NewMux()
+	mux.Maintenance()
+	mux.Maintenance(MaintenanceRetryAfter(time.Minute))
+}
@@ -40,10 +40,9 @@ func Methods() *MethodHandler {
 //
 // Look `Handle` and `HandleFunc`.
 type MethodHandler struct {
-	// origin *Mux
-
 	handlers map[string]http.Handler // method:handler
 
+	methodsAllowed    []string // in registration order.
 	methodsAllowedStr string
 }
 
@@ -69,6 +68,12 @@ func (m *MethodHandler) Handle(method string, handler http.Handler) *MethodHandl
 
 	method = strings.ToUpper(strings.TrimSpace(method))
 
+	if _, exists := m.handlers[method]; exists {
+		m.handlers[method] = handler
+		return m
+	}
+
+	m.methodsAllowed = append(m.methodsAllowed, method)
 	if m.methodsAllowedStr == "" {
 		m.methodsAllowedStr = method
 	} else {
@@ -87,16 +92,98 @@ func (m *MethodHandler) HandleFunc(method string, handlerFunc func(w http.Respon
 	return m
 }
 
+// AllowedMethods returns the HTTP methods that this MethodHandler has handlers for, in registration order.
+func (m *MethodHandler) AllowedMethods() []string {
+	return m.methodsAllowed
+}
+
+// ServeHTTP serves the request through the handler of its method. The HEAD requests are served by the GET handler,
+// if there is no HEAD one, and the OPTIONS requests are answered when it is served by a Mux with the `Mux#AutoOptions`,
+// the rest methods are responded with 405 Method Not Allowed.
 func (m *MethodHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if handler, ok := m.handlers[r.Method]; ok {
 		handler.ServeHTTP(w, r)
 		return
 	}
 
+	var mux *Mux
+	if pw := findParamsWriter(w); pw != nil && pw.mux != nil {
+		mux = pw.mux.origin()
+	}
+
+	handler, methodsAllowedStr := mux.methodFallback(m.handlers, m.methodsAllowedStr, r.Method)
+	if handler != nil {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
 	// RCF rfc2616 https://www.w3.org/Protocols/rfc2616/rfc2616-sec10.html
 	// The response MUST include an Allow header containing a list of valid methods for the requested resource.
 	//
 	// https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Allow#Examples
-	w.Header().Set("Allow", m.methodsAllowedStr)
+	w.Header().Set("Allow", methodsAllowedStr)
 	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
 }
+
+// methodHandler returns the handler of the node "n", which has per method handlers, for the request's "method".
+// It returns nil, which is a 404 Not Found, when the method is not registered and the `MethodNotAllowed` field is false.
+func (m *Mux) methodHandler(n *Node, method string) http.Handler {
+	mux := m.origin()
+
+	if handler, ok := n.methodHandlers[method]; ok {
+		return handler
+	}
+
+	if len(n.methodsAllowed) == 0 {
+		return nil
+	}
+
+	handler, methodsAllowedStr := mux.methodFallback(n.methodHandlers, n.methodsAllowedStr, method)
+	if handler != nil {
+		return handler
+	}
+
+	if !mux.MethodNotAllowed {
+		return nil
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.methodNotAllowed(w, r, methodsAllowedStr)
+	})
+}
+
+// methodFallback returns the handler of a HEAD or an automatic OPTIONS request, if the "method" is one of them
+// and the "handlers" do not have it, and the Allow header value of the "handlers" with these methods.
+// The OPTIONS requests are answered only for the `AutoOptions` of the "mux", which is the origin one and it can be nil.
+func (m *Mux) methodFallback(handlers map[string]http.Handler, methodsAllowedStr, method string) (http.Handler, string) {
+	// HEAD requests are served by the GET handler, if any, without the response body.
+	if handler, ok := handlers[http.MethodGet]; ok {
+		if _, hasHead := handlers[http.MethodHead]; !hasHead {
+			if method == http.MethodHead {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					serveHead(handler, w, r)
+				}), methodsAllowedStr + ", " + http.MethodHead
+			}
+
+			methodsAllowedStr += ", " + http.MethodHead
+		}
+	}
+
+	if _, hasOptions := handlers[http.MethodOptions]; m != nil && m.AutoOptions && !hasOptions {
+		methodsAllowedStr += ", " + http.MethodOptions
+
+		if method == http.MethodOptions {
+			allow, optionsHandler := methodsAllowedStr, m.OptionsHandler
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Allow", allow)
+				if optionsHandler != nil {
+					optionsHandler.ServeHTTP(w, r)
+				} else {
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}), methodsAllowedStr
+		}
+	}
+
+	return nil, methodsAllowedStr
+}
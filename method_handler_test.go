@@ -1,6 +1,7 @@
 package muxie
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -47,5 +48,153 @@ func TestMethodHandler(t *testing.T) {
 	expect(t, http.MethodDelete, srv.URL+"/user/42").statusCode(http.StatusOK).
 		bodyEq("DELETE: remove user with ID: 42\n")
 	expect(t, http.MethodPut, srv.URL+"/user/42").statusCode(http.StatusMethodNotAllowed).
-		bodyEq("Method Not Allowed\n").headerEq("Allow", "GET, POST, DELETE")
+		bodyEq("Method Not Allowed\n").headerEq("Allow", "GET, POST, DELETE, HEAD")
+}
+
+func TestMethodsHeadAndOptions(t *testing.T) {
+	mux := NewMux()
+	mux.Handle("/user/:id", Methods().
+		HandleFunc(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-User", GetParam(w, "id"))
+			fmt.Fprintf(w, "GET: User details by user ID: %s\n", GetParam(w, "id"))
+		}).
+		HandleFunc(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {}))
+
+	testHandler(t, mux, http.MethodHead, "/user/42").statusCode(http.StatusOK).
+		headerEq("X-User", "42").headerEq("Content-Length", "33").bodyEq("")
+	testHandler(t, mux, http.MethodOptions, "/user/42").statusCode(http.StatusMethodNotAllowed).
+		headerEq("Allow", "GET, POST, HEAD")
+
+	mux.AutoOptions = true
+	testHandler(t, mux, http.MethodOptions, "/user/42").statusCode(http.StatusNoContent).
+		headerEq("Allow", "GET, POST, HEAD, OPTIONS")
+	testHandler(t, mux, http.MethodPut, "/user/42").statusCode(http.StatusMethodNotAllowed).
+		headerEq("Allow", "GET, POST, HEAD, OPTIONS")
+
+	// the sub muxes answer them by the options of the root one.
+	mux.Of("/v1").Handle("/user/:id", Methods().HandleFunc(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {}))
+	testHandler(t, mux, http.MethodOptions, "/v1/user/42").statusCode(http.StatusNoContent).
+		headerEq("Allow", "GET, HEAD, OPTIONS")
+}
+
+func TestMuxHandleMethod(t *testing.T) {
+	mux := NewMux()
+
+	v1 := mux.Of("/v1")
+	v1.HandleMethodFunc(http.MethodGet, "/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "GET: User details by user ID: %s\n", GetParam(w, "id"))
+	})
+	v1.HandleMethodFunc("POST, PUT", "/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s: save user with ID: %s\n", r.Method, GetParam(w, "id"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/v1/user/42").statusCode(http.StatusOK).
+		bodyEq("GET: User details by user ID: 42\n")
+	testHandler(t, mux, http.MethodPut, "/v1/user/42").statusCode(http.StatusOK).
+		bodyEq("PUT: save user with ID: 42\n")
+	testHandler(t, mux, http.MethodDelete, "/v1/user/42").statusCode(http.StatusNotFound).
+		headerEq("Allow", "")
+
+	mux.MethodNotAllowed = true
+	testHandler(t, mux, http.MethodDelete, "/v1/user/42").statusCode(http.StatusMethodNotAllowed).
+		bodyEq("Method Not Allowed\n").headerEq("Allow", "GET, POST, PUT, HEAD")
+}
+
+func TestMuxAutoOptions(t *testing.T) {
+	mux := NewMux()
+	mux.HandleMethodFunc("GET, POST", "/users", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleMethodFunc(http.MethodOptions, "/custom", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("custom options"))
+	})
+	mux.HandleMethodFunc(http.MethodGet, "/custom", func(w http.ResponseWriter, r *http.Request) {})
+
+	testHandler(t, mux, http.MethodOptions, "/users").statusCode(http.StatusNotFound)
+
+	mux.AutoOptions = true
+	testHandler(t, mux, http.MethodOptions, "/users").statusCode(http.StatusNoContent).
+		headerEq("Allow", "GET, POST, HEAD, OPTIONS")
+	testHandler(t, mux, http.MethodOptions, "/custom").statusCode(http.StatusOK).
+		bodyEq("custom options")
+
+	mux.MethodNotAllowed = true
+	testHandler(t, mux, http.MethodDelete, "/users").statusCode(http.StatusMethodNotAllowed).
+		headerEq("Allow", "GET, POST, HEAD, OPTIONS")
+
+	mux.OptionsHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Methods", w.Header().Get("Allow"))
+		w.WriteHeader(http.StatusOK)
+	})
+	testHandler(t, mux, http.MethodOptions, "/users").statusCode(http.StatusOK).
+		headerEq("Access-Control-Allow-Methods", "GET, POST, HEAD, OPTIONS")
+}
+
+func TestMuxHeadFallback(t *testing.T) {
+	mux := NewMux()
+	mux.HandleMethodFunc(http.MethodGet, "/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-User", GetParam(w, "id"))
+		fmt.Fprintf(w, "GET: User details by user ID: %s\n", GetParam(w, "id"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/user/42").statusCode(http.StatusOK).
+		headerEq("X-User", "42").bodyEq("GET: User details by user ID: 42\n")
+	testHandler(t, mux, http.MethodHead, "/user/42").statusCode(http.StatusOK).
+		headerEq("X-User", "42").headerEq("Content-Length", "33").bodyEq("")
+
+	mux.HandleMethodFunc(http.MethodGet, "/large", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write(bytes.Repeat([]byte("a"), 8<<10))
+	})
+	mux.HandleMethodFunc(http.MethodGet, "/sized", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write(bytes.Repeat([]byte("a"), 100))
+	})
+	mux.HandleMethodFunc(http.MethodGet, "/no-content", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// the net/http can not know the length of the large ones.
+	resp := expect(t, http.MethodHead, srv.URL+"/large").statusCode(http.StatusAccepted).resp
+	if expected, got := int64(8<<10), resp.ContentLength; expected != got {
+		t.Fatalf("expected content length: %d but got: %d", expected, got)
+	}
+	expect(t, http.MethodHead, srv.URL+"/sized").headerEq("Content-Length", "100")
+	testHandler(t, mux, http.MethodHead, "/no-content").statusCode(http.StatusNoContent).headerEq("Content-Length", "")
+
+	mux.HandleMethodFunc(http.MethodHead, "/user/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Head", "true")
+	})
+	testHandler(t, mux, http.MethodHead, "/user/42").statusCode(http.StatusOK).
+		headerEq("X-Head", "true").headerEq("X-User", "")
+}
+
+func TestMuxMethodShortcuts(t *testing.T) {
+	methodHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Method, r.URL.Path)
+	})
+
+	mux := NewMux()
+	mux.MethodNotAllowed = true
+	users := mux.Of("/users")
+	users.GET("/:id", methodHandler)
+	users.POST("/:id", methodHandler)
+	users.PUT("/:id", methodHandler)
+	users.PATCH("/:id", methodHandler)
+	users.DELETE("/:id", methodHandler)
+	users.OPTIONS("/:id", methodHandler)
+	mux.HEAD("/ping", methodHandler)
+	mux.Match([]string{http.MethodGet, http.MethodPost}, "/search", methodHandler)
+	mux.Any("/any", methodHandler)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions} {
+		testHandler(t, mux, method, "/users/42").statusCode(http.StatusOK).bodyEq(method + " /users/42")
+	}
+
+	testHandler(t, mux, http.MethodHead, "/ping").statusCode(http.StatusOK)
+	testHandler(t, mux, http.MethodGet, "/ping").statusCode(http.StatusMethodNotAllowed).headerEq("Allow", "HEAD")
+	testHandler(t, mux, http.MethodPost, "/search").statusCode(http.StatusOK).bodyEq("POST /search")
+	testHandler(t, mux, http.MethodPut, "/search").statusCode(http.StatusMethodNotAllowed).headerEq("Allow", "GET, POST, HEAD")
+	testHandler(t, mux, "CUSTOM", "/any").statusCode(http.StatusOK).bodyEq("CUSTOM /any")
 }
@@ -0,0 +1,94 @@
+package muxie
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// MethodOverrideOption is the type of the options that `MethodOverride` accepts.
+type MethodOverrideOption func(*methodOverrider)
+
+// MethodOverrideMethods is a `MethodOverrideOption` which sets the methods that a POST request can be overridden to.
+// Defaults to PUT, PATCH and DELETE.
+func MethodOverrideMethods(methods ...string) MethodOverrideOption {
+	return func(o *methodOverrider) {
+		o.methods = parseMethods(strings.Join(methods, ","))
+	}
+}
+
+// MethodOverrideHeader is a `MethodOverrideOption` which sets the request header of the override method,
+// an empty "header" disables it. Defaults to "X-HTTP-Method-Override".
+func MethodOverrideHeader(header string) MethodOverrideOption {
+	return func(o *methodOverrider) {
+		o.header = header
+	}
+}
+
+// MethodOverrideFormField is a `MethodOverrideOption` which sets the form field of the override method,
+// an empty "field" disables it. Defaults to "_method".
+func MethodOverrideFormField(field string) MethodOverrideOption {
+	return func(o *methodOverrider) {
+		o.formField = field
+	}
+}
+
+type methodOverrider struct {
+	methods   []string
+	header    string
+	formField string
+}
+
+// MethodOverride returns a middleware which serves the POST requests by the method of their "X-HTTP-Method-Override" header
+// or "_method" form field, i.e for the HTML forms which can not send PUT or DELETE requests.
+// It should be registered through the `Mux#Wrap` so it runs before the routing, i.e:
+// mux.Wrap(muxie.MethodOverride())
+// mux.Handle("/users/:id", muxie.Methods().HandleFunc(http.MethodDelete, deleteUser))
+//
+// Only the POST requests are overridden and only to the `MethodOverrideMethods`,
+// the header has priority over the form field, which is read only from the form bodies.
+func MethodOverride(options ...MethodOverrideOption) Wrapper {
+	o := &methodOverrider{
+		methods:   []string{http.MethodPut, http.MethodPatch, http.MethodDelete},
+		header:    "X-HTTP-Method-Override",
+		formField: "_method",
+	}
+	for _, opt := range options {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost {
+				if method := o.method(r); method != "" {
+					r.Method = method
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// method returns the allowed override method of the POST request "r", if any.
+func (o *methodOverrider) method(r *http.Request) string {
+	method := ""
+	if o.header != "" {
+		method = r.Header.Get(o.header)
+	}
+
+	if method == "" && o.formField != "" && isFormRequest(r) {
+		method = r.PostFormValue(o.formField)
+	}
+
+	if method = strings.ToUpper(strings.TrimSpace(method)); !containsMethod(o.methods, method) {
+		return ""
+	}
+
+	return method
+}
+
+func isFormRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/x-www-form-urlencoded" || mediaType == "multipart/form-data")
+}
@@ -0,0 +1,52 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodOverride(t *testing.T) {
+	mux := NewMux()
+	mux.Wrap(MethodOverride())
+	mux.Handle("/users/:id", Methods().
+		HandleFunc(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("created " + GetParam(w, "id")))
+		}).
+		HandleFunc(http.MethodDelete, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("deleted " + GetParam(w, "id")))
+		}).
+		HandleFunc(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("got " + GetParam(w, "id")))
+		}))
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	expect(t, http.MethodPost, srv.URL+"/users/42", withHeader("X-HTTP-Method-Override", "DELETE")).
+		statusCode(http.StatusOK).bodyEq("deleted 42")
+	expect(t, http.MethodPost, srv.URL+"/users/42", withFormField("_method", "delete")).
+		statusCode(http.StatusOK).bodyEq("deleted 42")
+	// only the allowed methods.
+	expect(t, http.MethodPost, srv.URL+"/users/42", withFormField("_method", "GET")).
+		statusCode(http.StatusOK).bodyEq("created 42")
+	// only the POST requests.
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("X-HTTP-Method-Override", "DELETE")).
+		statusCode(http.StatusOK).bodyEq("got 42")
+	// the header has priority over the form field.
+	expect(t, http.MethodPost, srv.URL+"/users/42", withFormField("_method", "PUT"), withHeader("X-HTTP-Method-Override", "DELETE")).
+		statusCode(http.StatusOK).bodyEq("deleted 42")
+
+	custom := NewMux()
+	custom.Wrap(MethodOverride(MethodOverrideHeader(""), MethodOverrideFormField("verb"), MethodOverrideMethods("PATCH")))
+	custom.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method))
+	})
+
+	srv2 := httptest.NewServer(custom)
+	defer srv2.Close()
+
+	expect(t, http.MethodPost, srv2.URL, withHeader("X-HTTP-Method-Override", "PATCH")).bodyEq(http.MethodPost)
+	expect(t, http.MethodPost, srv2.URL, withFormField("verb", "patch")).bodyEq(http.MethodPatch)
+	expect(t, http.MethodPost, srv2.URL, withFormField("verb", "delete")).bodyEq(http.MethodPost)
+}
@@ -0,0 +1,81 @@
+package muxie
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// mountParamKey is the wildcard parameter key that a mounted handler's path is stored,
+// it is not visible to the mounted handler.
+const mountParamKey = "muxie.mount"
+
+// MountOption is the type of the options that `Mux#Mount` accepts.
+type MountOption func(*mountHandler)
+
+// StripPrefix is a `MountOption` which removes the mount prefix from the request's URL path
+// before dispatching it to the mounted handler, i.e a request to "/admin/users" on a handler mounted at "/admin"
+// is dispatched as "/users".
+func StripPrefix() MountOption {
+	return func(h *mountHandler) {
+		h.stripPrefix = true
+	}
+}
+
+type mountHandler struct {
+	handler     http.Handler
+	stripPrefix bool
+}
+
+// Mount attaches a whole http.Handler, i.e an independently-built Mux, under a "prefix",
+// the handler receives all requests of the "prefix" and its sub paths, i.e:
+// mux := NewMux()
+// mux.Mount("/admin", adminMux, muxie.StripPrefix())
+//
+// The path parameters of the "prefix", if any, are available to the mounted handler through the `GetParam`,
+// and to the mounted Mux's handlers as well, i.e "/tenants/:tenant".
+func (m *Mux) Mount(prefix string, handler http.Handler, options ...MountOption) {
+	if handler == nil {
+		panic("muxie/Mux#Mount: empty handler")
+	}
+
+	h := &mountHandler{handler: handler}
+	for _, opt := range options {
+		opt(h)
+	}
+
+	// remove last slash "/", if any.
+	if lidx := len(prefix) - 1; lidx >= 0 && prefix[lidx] == pathSepB {
+		prefix = prefix[0:lidx]
+	}
+
+	if prefix == "" {
+		m.Handle(pathSep, h)
+	} else {
+		m.Handle(prefix, h)
+	}
+
+	m.Handle(prefix+pathSep+WildcardParamStart+mountParamKey, h)
+}
+
+func (h *mountHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// params of the mount prefix, without the internal one.
+	pw := new(paramsWriter)
+	pw.reset(w)
+	for _, p := range GetParams(w) {
+		if p.Key != mountParamKey {
+			pw.Set(p.Key, p.Value)
+		}
+	}
+
+	if h.stripPrefix {
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = pathSep + GetParam(w, mountParamKey)
+		r2.URL.RawPath = ""
+		r = r2
+	}
+
+	h.handler.ServeHTTP(pw, r)
+}
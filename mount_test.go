@@ -0,0 +1,39 @@
+package muxie
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestMuxMount(t *testing.T) {
+	printPathHandler := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Handler of %s", r.URL.Path)
+	}
+
+	admin := NewMux()
+	admin.HandleFunc("/", printPathHandler)
+	admin.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "User %s of tenant %s", GetParam(w, "id"), GetParam(w, "tenant"))
+	})
+
+	mux := NewMux()
+	mux.Mount("/admin", admin, StripPrefix())
+	mux.Mount("/tenants/:tenant/admin", admin, StripPrefix())
+	mux.Mount("/raw/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Raw %s with %d params", r.URL.Path, len(GetParams(w)))
+	}))
+
+	testHandler(t, mux, http.MethodGet, "/admin").
+		statusCode(http.StatusOK).bodyEq("Handler of /")
+	testHandler(t, mux, http.MethodGet, "/admin/").
+		statusCode(http.StatusOK).bodyEq("Handler of /")
+	testHandler(t, mux, http.MethodGet, "/admin/users/42").
+		statusCode(http.StatusOK).bodyEq("User 42 of tenant ")
+	testHandler(t, mux, http.MethodGet, "/tenants/acme/admin/users/42").
+		statusCode(http.StatusOK).bodyEq("User 42 of tenant acme")
+	testHandler(t, mux, http.MethodGet, "/admin/other").
+		statusCode(http.StatusNotFound)
+	testHandler(t, mux, http.MethodGet, "/raw/files/a.txt").
+		statusCode(http.StatusOK).bodyEq("Raw /raw/files/a.txt with 0 params")
+}
@@ -1,9 +1,14 @@
 package muxie
 
 import (
+	"errors"
+	"io/fs"
 	"net/http"
+	"net/url"
+	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // Mux is an HTTP request multiplexer.
@@ -21,29 +26,301 @@ import (
 //
 // See `NewMux`.
 type Mux struct {
+	// PatternSyntax is the syntax of the named parameters of the path patterns,
+	// see `ColonSyntax`, `BraceSyntax` and `ServeMuxSyntax`.
+	// It should be set before any route registration.
+	// Defaults to `ColonSyntax`.
+	PatternSyntax PatternSyntax
+	// PathCorrection, if true, redirects the requests with a trailing slash to their path without it,
+	// it is the same as the `TrailingSlashRedirect` policy and it has priority over the `TrailingSlash` field.
 	PathCorrection bool
-	Routes         *Trie
+	// TrailingSlash is the policy for the requests with a trailing slash, i.e "/about/",
+	// see `TrailingSlashStrict`, `TrailingSlashRedirect` and `TrailingSlashMatch`.
+	// Defaults to `TrailingSlashStrict`.
+	TrailingSlash TrailingSlashPolicy
+	// CleanPath, if true, redirects the requests with double slashes, "." or ".." path elements
+	// to their cleaned path, like the `http.ServeMux` does, i.e "/a//b/../c" to "/a/c".
+	// Keep it false for proxies that need the raw request paths.
+	// Defaults to false.
+	CleanPath bool
+	// EncodedSlashes is the policy for the encoded slashes ("%2F") of the request paths,
+	// the `http.Request#URL.Path` is already decoded by the net/http, so they split the path segments by default,
+	// see `EncodedSlashSplit`, `EncodedSlashDecode`, `EncodedSlashKeep` and `EncodedSlashReject`.
+	// Defaults to `EncodedSlashSplit`.
+	EncodedSlashes EncodedSlashPolicy
+	// CaseCorrection, if true, redirects the requests that their path differs only in casing
+	// from their registered path pattern to the registered casing, i.e "/Users/42" to "/users/42".
+	// The `Routes.CaseInsensitive` should be true for these requests to be matched at the first place.
+	// Defaults to false.
+	CaseCorrection bool
+	// MethodNotAllowed, if true, responds with 405 Method Not Allowed and the "Allow" header
+	// when the requested path is registered through `HandleMethod` but only for different HTTP methods,
+	// otherwise it responds with 404 Not Found as if the path was not registered at all.
+	// Defaults to false.
+	MethodNotAllowed bool
+	// AutoOptions, if true, responds to the OPTIONS requests of the paths that are registered through `HandleMethod` or a `Methods` handler,
+	// but not for the OPTIONS method itself, with the "Allow" header set to their registered methods.
+	// Defaults to false.
+	AutoOptions bool
+	// OptionsHandler can be used to customize the automatic OPTIONS responses, i.e to add CORS headers.
+	// The "Allow" header is already set when it is executed.
+	// If nil then the response is a 204 No Content.
+	OptionsHandler http.Handler
+	// NotFoundHandler can be used to customize the 404 Not Found responses of the requests
+	// that are not matched by any route, i.e to render an HTML page.
+	// The sub muxes can have their own, see `HandleNotFound`.
+	// If nil then the parent's one is used or, at the end, the `http.NotFound`.
+	NotFoundHandler http.Handler
+	// MethodNotAllowedHandler can be used to customize the 405 Method Not Allowed responses, see `MethodNotAllowed`.
+	// The "Allow" header is already set when it is executed.
+	// The sub muxes can have their own, see `HandleMethodNotAllowed`.
+	// If nil then the parent's one is used or, at the end, a plain text 405 response.
+	MethodNotAllowedHandler http.Handler
+	// ErrorMapper can be used to customize the responses of the errors that the `HandleE` routes return.
+	// The sub muxes can have their own, see `HandleError`.
+	// If nil then the parent's one is used or, at the end, the `DefaultErrorMapper`.
+	ErrorMapper ErrorMapper
+	// VersionResolver resolves the API version of the requests for the routes of the `Version` sub muxes,
+	// i.e `HeaderVersion("X-API-Version")` or `AcceptVersion("vnd.api")`.
+	// If nil then the versions are resolved by their path prefix, i.e "/v2/users".
+	// It should be set before the `Version` calls.
+	VersionResolver VersionResolver
+	// ContextParams, if true, stores the path parameters to the request's context as well,
+	// so they can be retrieved by the `GetParamFromRequest` and `ParamsFromContext`
+	// even if a middleware has wrapped the http.ResponseWriter.
+	// It costs an allocation per request.
+	// Defaults to false.
+	ContextParams bool
+	// MaxParams, if not zero, is the maximum number of the path parameters that a path pattern can have,
+	// i.e 2 for the "/users/:id/posts/:slug". The registration of a path pattern with more panics,
+	// or returns an error on `HandleErr`.
+	// It should be set before any route registration.
+	// Defaults to 0, no limit.
+	MaxParams int
+	// ParamsCapacity is the number of the path parameters that the parameters storage of each request is preallocated for,
+	// set it to the expected number of the path parameters per route so the first requests do not grow it.
+	// It should be set before the Mux is served.
+	// Defaults to 0, the storage grows on demand and it is reused between the requests.
+	ParamsCapacity int
+	// MaxBodySize, if not zero, is the maximum size, in bytes, of the request bodies of the routes,
+	// the requests which their Content-Length exceeds it are responded with 413 Request Entity Too Large
+	// before the route's middlewares and handler run and the reads of the rest ones fail with the `ErrBodyTooLarge`
+	// when they exceed it. The routes can override it through the `Route#MaxBodySize`.
+	// Defaults to 0, no limit.
+	MaxBodySize int64
+	// ThreadSafe, if true, allows routes to be registered and removed, i.e `Handle` and `Unhandle`,
+	// while the Mux serves requests, the routes are guarded by a read-write mutex, which every request locks for reading,
+	// the lock-free searches of the `COWTrie` are not used by the Mux.
+	// It should be set before the Mux is served.
+	// Defaults to false.
+	ThreadSafe bool
+	Routes     *Trie
 
 	paramsPool *sync.Pool
+	// shared with the sub muxes, see `ThreadSafe`.
+	mu *sync.RWMutex
 
 	// per mux
+	parent          *Mux
 	root            string
 	requestHandlers []RequestHandler
 	beginHandlers   []Wrapper
+	// the middlewares of the `Wrap` and the request dispatch that they wrap.
+	wrappers []Wrapper
+	dispatch http.Handler
+	// the hooks that run after the requests are served, see `After`.
+	afterHooks []AfterHook
+	// the switch of the maintenance mode of this mux' routes, see `Maintenance`.
+	maintenance atomic.Pointer[Maintenance]
+	// the response encoders by their content type, see `RegisterEncoder`.
+	encoders map[string]Dispatcher
+	// the sub muxes of `Of` and `Group`, see `HandleNotFound`.
+	children []*Mux
+	// not nil when it is created by the `Version`, its routes are matched by the version of the request.
+	version *versionMatcher
+
+	// shared with the sub muxes, route name:route.
+	namedRoutes map[string]*Route
+
+	// not nil when it is created by the `Host` or `Subdomain`.
+	host *hostMatcher
 }
 
 // NewMux returns a new HTTP multiplexer which uses a fast, if not the fastest
 // implementation of the trie data structure that is designed especially for path segments.
 func NewMux() *Mux {
-	return &Mux{
-		Routes: NewTrie(),
-		paramsPool: &sync.Pool{
-			New: func() interface{} {
-				return &paramsWriter{}
-			},
+	m := &Mux{
+		Routes:      NewTrie(),
+		mu:          new(sync.RWMutex),
+		root:        "",
+		namedRoutes: make(map[string]*Route),
+	}
+
+	m.paramsPool = &sync.Pool{
+		New: func() interface{} {
+			return &paramsWriter{params: make([]ParamEntry, 0, m.ParamsCapacity)}
 		},
-		root: "",
 	}
+
+	return m
+}
+
+// TrailingSlashPolicy is the type of the `Mux#TrailingSlash` field,
+// it declares how the requests with a trailing slash are handled.
+type TrailingSlashPolicy uint8
+
+const (
+	// TrailingSlashStrict matches the requests with a trailing slash only against
+	// the path patterns that can accept an empty last path segment, i.e "/about/" is not found for the "/about".
+	TrailingSlashStrict TrailingSlashPolicy = iota
+	// TrailingSlashRedirect redirects the requests with a trailing slash to their path without it,
+	// i.e "/about/" to "/about". The GET requests are permanently (301) redirected, the POST and PUT are temporarily (307).
+	TrailingSlashRedirect
+	// TrailingSlashMatch matches the requests with a trailing slash as they had not,
+	// i.e "/about/" is handled by the "/about".
+	TrailingSlashMatch
+)
+
+// EncodedSlashPolicy is the type of the `Mux#EncodedSlashes` field,
+// it declares how the encoded slashes ("%2F") of the request paths are handled.
+type EncodedSlashPolicy uint8
+
+const (
+	// EncodedSlashSplit matches the requests by their decoded path, an encoded slash separates the path segments
+	// like a slash does, i.e "/files/a%2Fb" is matched as "/files/a/b".
+	EncodedSlashSplit EncodedSlashPolicy = iota
+	// EncodedSlashDecode matches the requests by their escaped path, an encoded slash is part of its path segment
+	// and it is decoded on the parameter values, i.e "/files/:name" for the "/files/a%2Fb" gives "a/b".
+	EncodedSlashDecode
+	// EncodedSlashKeep is like the `EncodedSlashDecode` but the encoded slashes and percent signs ("%25")
+	// are kept on the parameter values, i.e "/proxy/:name" for the "/proxy/a%2Fb" gives "a%2Fb".
+	// Useful for proxies that need to pass the path segments as they were sent.
+	EncodedSlashKeep
+	// EncodedSlashReject responds with 400 Bad Request to the requests which their path contains an encoded slash.
+	EncodedSlashReject
+)
+
+func (m *Mux) trailingSlash() TrailingSlashPolicy {
+	if m.PathCorrection {
+		return TrailingSlashRedirect
+	}
+
+	return m.TrailingSlash
+}
+
+func (m *Mux) lock() {
+	if m.origin().ThreadSafe {
+		m.mu.Lock()
+	}
+}
+
+func (m *Mux) unlock() {
+	if m.origin().ThreadSafe {
+		m.mu.Unlock()
+	}
+}
+
+func (m *Mux) rlock() {
+	if m.origin().ThreadSafe {
+		m.mu.RLock()
+	}
+}
+
+func (m *Mux) runlock() {
+	if m.origin().ThreadSafe {
+		m.mu.RUnlock()
+	}
+}
+
+// origin returns the main Mux that this (sub) Mux belongs to, the one which serves the requests.
+func (m *Mux) origin() *Mux {
+	for m.parent != nil {
+		m = m.parent
+	}
+
+	return m
+}
+
+// HandleNotFound sets the handler of the 404 Not Found responses of the requests
+// that are not matched by any route and their path belongs to this Mux' path prefix, i.e:
+// mux := NewMux()
+// mux.HandleNotFound(htmlNotFoundHandler)
+// api := mux.Of("/api")
+// api.HandleNotFound(jsonNotFoundHandler)
+//
+// The requests of a sub mux without a handler are served by its parent's one.
+// See the `NotFoundHandler` field too.
+func (m *Mux) HandleNotFound(handler http.Handler) {
+	m.lock()
+	m.NotFoundHandler = handler
+	m.unlock()
+}
+
+// HandleMethodNotAllowed sets the handler of the 405 Method Not Allowed responses
+// of the requests that their path belongs to this Mux' path prefix, like `HandleNotFound` does.
+// The responses are enabled through the `MethodNotAllowed` field.
+func (m *Mux) HandleMethodNotAllowed(handler http.Handler) {
+	m.lock()
+	m.MethodNotAllowedHandler = handler
+	m.unlock()
+}
+
+// notFound serves a request that is not matched by any route.
+func (m *Mux) notFound(w http.ResponseWriter, r *http.Request) {
+	m.rlock()
+	var handler http.Handler
+	for mux := m.subMux(r.URL.Path); mux != nil && handler == nil; mux = mux.parent {
+		handler = mux.NotFoundHandler
+	}
+	m.runlock()
+
+	if handler != nil {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// methodNotAllowed serves a request that its path is registered only for different HTTP methods.
+func (m *Mux) methodNotAllowed(w http.ResponseWriter, r *http.Request, methodsAllowedStr string) {
+	m.rlock()
+	var handler http.Handler
+	for mux := m.subMux(r.URL.Path); mux != nil && handler == nil; mux = mux.parent {
+		handler = mux.MethodNotAllowedHandler
+	}
+	m.runlock()
+
+	// RCF rfc2616 https://www.w3.org/Protocols/rfc2616/rfc2616-sec10.html
+	// The response MUST include an Allow header containing a list of valid methods for the requested resource.
+	w.Header().Set("Allow", methodsAllowedStr)
+	if handler != nil {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// subMux returns the sub mux, of `Of` and `Group`, with the longest path prefix of the "path", or "m" itself.
+func (m *Mux) subMux(path string) *Mux {
+	var sub *Mux
+	for _, child := range m.children {
+		if sub != nil && len(child.root) <= len(sub.root) {
+			continue
+		}
+
+		if root := child.root; len(path) >= len(root) && (len(path) == len(root) || path[len(root)] == pathSepB) &&
+			(path[:len(root)] == root || (m.Routes.CaseInsensitive && strings.EqualFold(path[:len(root)], root))) {
+			sub = child
+		}
+	}
+
+	if sub == nil {
+		return m
+	}
+
+	return sub.subMux(path)
 }
 
 // AddRequestHandler adds a full `RequestHandler` which is responsible
@@ -58,7 +335,9 @@ func NewMux() *Mux {
 // middlewares then you have to use the `muxie.Pre` to declare
 // the shared middlewares and register them via the `Mux#Use` function.
 func (m *Mux) AddRequestHandler(requestHandler RequestHandler) {
+	m.lock()
 	m.requestHandlers = append(m.requestHandlers, requestHandler)
+	m.unlock()
 }
 
 // HandleRequest adds a matcher and a (conditional) handler to be executed when "matcher" passed.
@@ -83,10 +362,43 @@ func (m *Mux) HandleRequest(matcher Matcher, handler http.Handler) {
 // use the `Handle/HandleFunc` with the package-level `muxie.Pre` function instead.
 // Functionality of `Use` is pretty self-explained but new gophers should
 // take a look of the examples for further details.
+//
+// The middlewares run after the route is matched, so they can read its parameters,
+// to wrap the whole request dispatch, including the not found requests, use the `Wrap` instead.
 func (m *Mux) Use(middlewares ...Wrapper) {
 	m.beginHandlers = append(m.beginHandlers, middlewares...)
 }
 
+// Wrap adds middlewares around the whole request dispatch of the Mux, in the order they are registered,
+// unlike the `Use` ones which wrap only the routes that are registered after them, i.e:
+// mux := NewMux()
+// mux.Wrap(requestIDMiddleware, loggerMiddleware)
+//
+// They run for every request, including the not found ones and the redirects,
+// before the route is matched, so the `GetParam` returns empty values inside them
+// but the handlers can still retrieve the parameters through the http.ResponseWriter wrappers of them, see `Unwrapper`.
+// It can be called at any time, but only by the Mux which serves the requests, i.e the `NewMux` and the `Host` ones.
+func (m *Mux) Wrap(middlewares ...Wrapper) {
+	if m.parent != nil && m.host == nil {
+		panic("muxie/Mux#Wrap: a sub mux does not serve the requests itself, use the Use instead")
+	}
+
+	m.lock()
+	m.wrappers = append(m.wrappers, middlewares...)
+	m.buildDispatch()
+	m.unlock()
+}
+
+// buildDispatch wraps the request dispatch with the `After` hooks and the `Wrap` middlewares, the "mu" should be locked.
+func (m *Mux) buildDispatch() {
+	wrappers := m.wrappers
+	if len(m.afterHooks) > 0 {
+		wrappers = append(Wrappers{afterHooksWrapper(m.afterHooks)}, wrappers...)
+	}
+
+	m.dispatch = Wrappers(wrappers).For(http.HandlerFunc(m.serveHTTP))
+}
+
 type (
 	// Wrapper is just a type of `func(http.Handler) http.Handler`
 	// which is a common type definition for net/http middlewares.
@@ -127,20 +439,182 @@ func Pre(middleware ...Wrapper) Wrappers {
 }
 
 // Handle registers a route handler for a path pattern.
-func (m *Mux) Handle(pattern string, handler http.Handler) {
-	m.Routes.Insert(m.root+pattern,
-		WithHandler(
-			Pre(m.beginHandlers...).For(handler)))
+// The path patterns of the `ServeMuxSyntax` can start with a method, i.e "GET /users/{id}", see `HandleMethod`.
+// Returns the `Route` which can be used to give a name to it.
+func (m *Mux) Handle(pattern string, handler http.Handler) *Route {
+	m.lock()
+	defer m.unlock()
+
+	return m.handle(pattern, handler)
+}
+
+func (m *Mux) handle(pattern string, handler http.Handler) *Route {
+	if method, _ := m.origin().PatternSyntax.splitMethod(pattern); method != "" {
+		return m.handleMethod(method, pattern, handler)
+	}
+
+	route := m.newRoute(m.pattern(pattern), nil, handler)
+
+	// any previous `HandleMethod` registrations for that path are overridden, see `WithHandler`.
+	m.Routes.Insert(route.pattern, WithHandler(route.handler))
+	if m.version != nil {
+		route.when(m.version)
+	}
+
+	m.tagRoutes(route.pattern)
+	return route
+}
+
+// tagRoutes restores the names of the routes of a re-inserted "pattern" to its nodes.
+func (m *Mux) tagRoutes(pattern string) {
+	for _, route := range m.namedRoutes {
+		if route.pattern == pattern {
+			route.tag()
+		}
+	}
 }
 
 // HandleFunc registers a route handler function for a path pattern.
-func (m *Mux) HandleFunc(pattern string, handlerFunc func(http.ResponseWriter, *http.Request)) {
-	m.Handle(pattern, http.HandlerFunc(handlerFunc))
+// Returns the `Route` which can be used to give a name to it.
+func (m *Mux) HandleFunc(pattern string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return m.Handle(pattern, http.HandlerFunc(handlerFunc))
+}
+
+// HandleMethod registers a route handler for a path pattern which is responsible only for the given HTTP method(s),
+// the "method" can accept many methods separated by comma, i.e "POST, PUT".
+// It can be called many times for the same path pattern to register handlers for different methods.
+//
+// Requests with a method that is not registered for their path
+// are responding with 404 Not Found, unless the `MethodNotAllowed` field is true.
+// HEAD requests are served by the GET handler without the response body, unless a HEAD handler is registered.
+// Returns the `Route` which can be used to give a name to it.
+func (m *Mux) HandleMethod(method, pattern string, handler http.Handler) *Route {
+	m.lock()
+	defer m.unlock()
+
+	return m.handleMethod(method, pattern, handler)
+}
+
+func (m *Mux) handleMethod(method, pattern string, handler http.Handler) *Route {
+	route := m.newRoute(m.pattern(pattern), parseMethods(method), handler)
+
+	// the handlers of the rest methods are kept by the node and its priority too, see `Route#Priority`.
+	options := []InsertOption{WithMethodHandler(method, route.handler)}
+	if nodes := m.Routes.nodes(route.pattern); len(nodes) > 0 && nodes[0].priority != 0 {
+		options = append(options, WithPriority(nodes[0].priority))
+	}
+	m.Routes.Insert(route.pattern, options...)
+	if m.version != nil {
+		route.when(m.version)
+	}
+
+	m.tagRoutes(route.pattern)
+	return route
 }
 
-// ServeHTTP exposes and serves the registered routes.
+// HandleMethodFunc registers a route handler function for a path pattern which is responsible only for the given HTTP method(s).
+// See `HandleMethod` too.
+func (m *Mux) HandleMethodFunc(method, pattern string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route {
+	return m.HandleMethod(method, pattern, http.HandlerFunc(handlerFunc))
+}
+
+// GET registers a route handler for a path pattern which is responsible only for the GET (and HEAD) HTTP method.
+// See `HandleMethod` too.
+func (m *Mux) GET(pattern string, handler http.Handler) *Route {
+	return m.HandleMethod(http.MethodGet, pattern, handler)
+}
+
+// POST registers a route handler for a path pattern which is responsible only for the POST HTTP method.
+// See `HandleMethod` too.
+func (m *Mux) POST(pattern string, handler http.Handler) *Route {
+	return m.HandleMethod(http.MethodPost, pattern, handler)
+}
+
+// PUT registers a route handler for a path pattern which is responsible only for the PUT HTTP method.
+// See `HandleMethod` too.
+func (m *Mux) PUT(pattern string, handler http.Handler) *Route {
+	return m.HandleMethod(http.MethodPut, pattern, handler)
+}
+
+// PATCH registers a route handler for a path pattern which is responsible only for the PATCH HTTP method.
+// See `HandleMethod` too.
+func (m *Mux) PATCH(pattern string, handler http.Handler) *Route {
+	return m.HandleMethod(http.MethodPatch, pattern, handler)
+}
+
+// DELETE registers a route handler for a path pattern which is responsible only for the DELETE HTTP method.
+// See `HandleMethod` too.
+func (m *Mux) DELETE(pattern string, handler http.Handler) *Route {
+	return m.HandleMethod(http.MethodDelete, pattern, handler)
+}
+
+// HEAD registers a route handler for a path pattern which is responsible only for the HEAD HTTP method.
+// See `HandleMethod` too.
+func (m *Mux) HEAD(pattern string, handler http.Handler) *Route {
+	return m.HandleMethod(http.MethodHead, pattern, handler)
+}
+
+// OPTIONS registers a route handler for a path pattern which is responsible only for the OPTIONS HTTP method.
+// See `HandleMethod` too.
+func (m *Mux) OPTIONS(pattern string, handler http.Handler) *Route {
+	return m.HandleMethod(http.MethodOptions, pattern, handler)
+}
+
+// Any registers a route handler for a path pattern which is responsible for any HTTP method,
+// it is the same as `Handle`, so it overrides any previous method registrations for that path pattern.
+func (m *Mux) Any(pattern string, handler http.Handler) *Route {
+	return m.Handle(pattern, handler)
+}
+
+// Match registers a route handler for a path pattern which is responsible only for the given HTTP methods, i.e:
+// mux.Match([]string{"GET", "POST"}, "/users", usersHandler)
+// See `HandleMethod` too.
+func (m *Mux) Match(methods []string, pattern string, handler http.Handler) *Route {
+	if len(methods) == 0 {
+		panic("muxie/Mux#Match: empty methods")
+	}
+
+	return m.HandleMethod(strings.Join(methods, ","), pattern, handler)
+}
+
+// Unhandle removes a registered route, by its path pattern, at any time.
+// Useful for plugin systems that register and unregister endpoints dynamically.
+// It reports whether the route was registered.
+func (m *Mux) Unhandle(pattern string) bool {
+	m.lock()
+	defer m.unlock()
+
+	pattern = m.pattern(pattern)
+
+	for name, route := range m.namedRoutes {
+		if route.pattern == pattern {
+			delete(m.namedRoutes, name)
+		}
+	}
+
+	return m.Routes.Delete(pattern)
+}
+
+// ServeHTTP exposes and serves the registered routes, through the `Wrap` middlewares, if any.
 func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	for _, h := range m.requestHandlers {
+	m.rlock()
+	dispatch := m.dispatch
+	m.runlock()
+
+	if dispatch != nil {
+		dispatch.ServeHTTP(w, r)
+		return
+	}
+
+	m.serveHTTP(w, r)
+}
+
+func (m *Mux) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	m.rlock()
+	requestHandlers := m.requestHandlers
+	m.runlock()
+
+	for _, h := range requestHandlers {
 		if h.Match(r) {
 			h.ServeHTTP(w, r)
 			return
@@ -148,27 +622,41 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	path := r.URL.Path
+	mux := m.origin()
 
-	if m.PathCorrection {
-		if len(path) > 1 && strings.HasSuffix(path, "/") {
+	// the request's path is partially unescaped so the encoded slashes can not split the path segments, see `EncodedSlashes`.
+	escaped := false
+	if mux.EncodedSlashes != EncodedSlashSplit && r.URL.RawPath != "" && hasEncodedSlash(r.URL.RawPath) {
+		if mux.EncodedSlashes == EncodedSlashReject {
+			http.Error(w, "Bad Request: encoded slash in path", http.StatusBadRequest)
+			return
+		}
+
+		path, escaped = unescapePath(r.URL.EscapedPath()), true
+	}
+
+	if mux.CleanPath && r.Method != http.MethodConnect {
+		if cleanedPath := cleanPath(path); cleanedPath != path {
+			redirectPath(w, r, cleanedPath, escaped)
+			return
+		}
+	}
+
+	if len(path) > 1 && path[len(path)-1] == pathSepB {
+		switch mux.trailingSlash() {
+		case TrailingSlashRedirect:
 			// Remove trailing slash and client-permanent rule for redirection,
 			// if confgiuration allows that and path has an extra slash.
 
 			// update the new path and redirect.
 			// use Trim to ensure there is no open redirect due to two leading slashes
-			r.URL.Path = pathSep + strings.Trim(path, pathSep)
-			url := r.URL.String()
-			method := r.Method
-			// Fixes https://github.com/kataras/iris/issues/921
-			// This is caused for security reasons, imagine a payment shop,
-			// you can't just permantly redirect a POST request, so just 307 (RFC 7231, 6.4.7).
-			if method == http.MethodPost || method == http.MethodPut {
-				http.Redirect(w, r, url, http.StatusTemporaryRedirect)
-				return
-			}
-
-			http.Redirect(w, r, url, http.StatusMovedPermanently)
+			redirectPath(w, r, pathSep+strings.Trim(path, pathSep), escaped)
 			return
+		case TrailingSlashMatch:
+			// search for the path without the trailing slash(es), the request's path is kept as it is.
+			if path = strings.TrimRight(path, pathSep); path == "" {
+				path = pathSep
+			}
 		}
 	}
 
@@ -181,11 +669,81 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	pw := m.paramsPool.Get().(*paramsWriter)
 	pw.reset(w)
-	n := m.Routes.Search(path, pw)
+	pw.mux = m
+	// when this Mux is a handler of another Mux, i.e `Mount`, the parent's path parameters are kept.
+	if store, ok := paramsStore(w); ok {
+		for _, p := range store.GetAll() {
+			pw.Set(p.Key, p.Value)
+		}
+	}
+
+	if m.host != nil && m.host.wildcard {
+		subdomain, _ := m.host.capture(r)
+		pw.Set(m.host.paramKey, subdomain)
+	}
+
+	var (
+		handler    http.Handler
+		redirectTo string
+	)
+
+	paramsStart := len(pw.params)
+
+	m.rlock()
+	n, err := m.Routes.SearchErr(path, pw)
 	if n != nil {
-		n.Handler.ServeHTTP(pw, r)
+		pw.route = n.key
+		pw.meta = n.meta
+		recordRoute(w, n.key)
+
+		if escaped {
+			unescapeParams(pw.params[paramsStart:], mux.EncodedSlashes == EncodedSlashKeep)
+		}
+
+		if len(n.conditionals) > 0 {
+			var negotiated bool
+			if handler, negotiated = n.conditionalHandler(r); negotiated {
+				w.Header().Add("Vary", "Accept")
+				if _, ok := n.methodHandlers[r.Method]; handler == nil && n.Handler == nil && !ok {
+					handler = notAcceptableHandler
+				}
+			}
+		}
+
+		if handler == nil {
+			handler = n.Handler
+		}
+
+		if handler == nil {
+			handler = m.methodHandler(n, r.Method)
+		}
+
+		if mux.CaseCorrection && m.Routes.CaseInsensitive {
+			if canonicalPath := canonicalPath(n, pw.params); canonicalPath != path && strings.EqualFold(canonicalPath, path) {
+				redirectTo = canonicalPath
+			}
+		}
+	}
+	m.runlock()
+
+	if err != nil {
+		// the path exceeds the search budget of the routes, see `Trie#MaxPathLength`.
+		if errors.Is(err, ErrPathTooLong) {
+			http.Error(w, "Request URI Too Long", http.StatusRequestURITooLong)
+		} else {
+			http.Error(w, "Bad Request: path too deep", http.StatusBadRequest)
+		}
+	} else if redirectTo != "" {
+		redirectPath(w, r, redirectTo, escaped)
+	} else if handler != nil {
+		if mux.ContextParams {
+			r = withParams(r, pw.params)
+		}
+
+		handler.ServeHTTP(pw, r)
+		pw.finish()
 	} else {
-		http.NotFound(w, r)
+		m.notFound(w, r)
 		// or...
 		// http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 		// w.WriteHeader(http.StatusNotFound)
@@ -193,16 +751,263 @@ func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// which will be fired if no other requested path's closest wildcard is found.
 	}
 
-	m.paramsPool.Put(pw)
+	if pw.release(m.ParamsCapacity) {
+		m.paramsPool.Put(pw)
+	}
+}
+
+// Host returns a new Mux which its routes are matching only the requests of the given host pattern, i.e:
+// mux := NewMux()
+// api := mux.Host("api.example.com")
+// api.HandleFunc("/users", myHandler)
+//
+// The host pattern can start with a wildcard to match any subdomain of a domain, i.e "*.example.com",
+// the subdomain is stored as a parameter which can be retrieved by `GetParam(w, muxie.SubdomainParamKey)`.
+// Give a name to the wildcard to store it as a different parameter, i.e "*tenant.example.com".
+//
+// If the host pattern does not contain a port then the request's port is ignored.
+// The host routes have priority over the rest routes, see `AddRequestHandler`.
+// The returned Mux inherits the `MaxParams`, `ParamsCapacity` and `ThreadSafe` of the main Mux, set them before it.
+func (m *Mux) Host(pattern string) SubMux {
+	return m.hostMux(newHostMatcher(pattern))
+}
+
+// Subdomain returns a new Mux which its routes are matching only the requests
+// which their host starts with the given "subdomain", i.e:
+// mux := NewMux()
+// admin := mux.Subdomain("admin.")
+// admin.HandleFunc("/", myHandler)
+//
+// See `Host` too.
+func (m *Mux) Subdomain(subdomain string) SubMux {
+	if subdomain == "" {
+		panic("muxie/Mux#Subdomain: empty subdomain")
+	}
+
+	return m.hostMux(&hostMatcher{host: subdomain, subdomain: true})
+}
+
+func (m *Mux) hostMux(matcher *hostMatcher) *Mux {
+	origin := m.origin()
+
+	hostMux := NewMux()
+	// the options of the parameters storage and the locking of the main Mux are inherited.
+	hostMux.MaxParams = origin.MaxParams
+	hostMux.ParamsCapacity = origin.ParamsCapacity
+	hostMux.ThreadSafe = origin.ThreadSafe
+	hostMux.mu = origin.mu
+	hostMux.paramsPool = origin.paramsPool
+	hostMux.parent = m
+	hostMux.root = m.root
+	hostMux.beginHandlers = m.beginHandlers[0:]
+	hostMux.host = matcher
+	hostMux.namedRoutes = m.namedRoutes
+
+	// register it to the main Mux, it is the one which serves the requests.
+	origin.HandleRequest(matcher, hostMux)
+	return hostMux
+}
+
+// cleanPath returns the canonical path for "p", eliminating . and .. elements and double slashes,
+// the trailing slash, if any, is kept.
+func cleanPath(p string) string {
+	if p == "" {
+		return pathSep
+	}
+
+	if p[0] != pathSepB {
+		p = pathSep + p
+	}
+
+	np := path.Clean(p)
+	if p[len(p)-1] == pathSepB && np != pathSep {
+		np += pathSep
+	}
+
+	return np
+}
+
+// canonicalPath returns the path of the found node's path pattern
+// with its dynamic path segments replaced by the request's parameters, which are the last ones of the "params".
+func canonicalPath(n *Node, params []ParamEntry) string {
+	if ln := len(params) - len(n.paramKeys); ln > 0 {
+		params = params[ln:]
+	}
+
+	values := make([]string, len(params))
+	for i := range params {
+		values[i] = params[i].Value
+	}
+
+	return fillPattern(n.key, values)
+}
+
+// hasEncodedSlash reports whether an escaped path contains an encoded slash.
+func hasEncodedSlash(rawPath string) bool {
+	return strings.Contains(rawPath, "%2F") || strings.Contains(rawPath, "%2f")
+}
+
+// isKeptEscape reports whether the escape sequence at the start of "s" is an encoded slash or percent sign,
+// the ones that `unescapePath` keeps.
+func isKeptEscape(s string) bool {
+	return len(s) >= 3 && s[0] == '%' && s[1] == '2' && (s[2] == 'F' || s[2] == 'f' || s[2] == '5')
+}
+
+// unescapePath unescapes an escaped path except its encoded slashes and percent signs,
+// so they are kept as "%2F" and "%25" and the "%" in the result is always the start of one of them.
+func unescapePath(rawPath string) string {
+	var b strings.Builder
+	b.Grow(len(rawPath))
+
+	for i := 0; i < len(rawPath); i++ {
+		if c := rawPath[i]; c == '%' && i+2 < len(rawPath) {
+			if isKeptEscape(rawPath[i:]) {
+				b.WriteString("%2")
+				b.WriteByte(upperHex(rawPath[i+2]))
+				i += 2
+				continue
+			}
+
+			if hi, lo := unhex(rawPath[i+1]), unhex(rawPath[i+2]); hi >= 0 && lo >= 0 {
+				b.WriteByte(byte(hi<<4 | lo))
+				i += 2
+				continue
+			}
+		}
+
+		b.WriteByte(rawPath[i])
+	}
+
+	return b.String()
+}
+
+// unescapeParams decodes the encoded slashes and percent signs that `unescapePath` kept on the parameter values,
+// unless "keep" is true.
+func unescapeParams(params []ParamEntry, keep bool) {
+	if keep {
+		return
+	}
+
+	for i := range params {
+		if value := params[i].Value; strings.IndexByte(value, '%') != -1 {
+			params[i].Value, _ = url.PathUnescape(value)
+		}
+	}
+}
+
+func unhex(c byte) int {
+	switch {
+	case '0' <= c && c <= '9':
+		return int(c - '0')
+	case 'a' <= c && c <= 'f':
+		return int(c - 'a' + 10)
+	case 'A' <= c && c <= 'F':
+		return int(c - 'A' + 10)
+	}
+
+	return -1
+}
+
+func upperHex(c byte) byte {
+	if 'a' <= c && c <= 'f' {
+		return c - 'a' + 'A'
+	}
+
+	return c
+}
+
+// redirectPath redirects the client to the "path", which is partially unescaped by the `unescapePath`
+// when "escaped" is true, so its encoded slashes are kept on the redirect location.
+func redirectPath(w http.ResponseWriter, r *http.Request, path string, escaped bool) {
+	if !escaped {
+		redirect(w, r, path)
+		return
+	}
+
+	var rawPath strings.Builder
+	for i := 0; i < len(path); {
+		if isKeptEscape(path[i:]) {
+			rawPath.WriteString(path[i : i+3])
+			i += 3
+			continue
+		}
+
+		end := strings.IndexByte(path[i+1:], '%')
+		if end == -1 {
+			end = len(path)
+		} else {
+			end += i + 1
+		}
+
+		rawPath.WriteString((&url.URL{Path: path[i:end]}).EscapedPath())
+		i = end
+	}
+
+	r.URL.Path, _ = url.PathUnescape(path)
+	r.URL.RawPath = rawPath.String()
+	redirectURL(w, r)
+}
+
+// redirect redirects the client to the "path" of the same request, including its query.
+func redirect(w http.ResponseWriter, r *http.Request, path string) {
+	r.URL.Path = path
+	redirectURL(w, r)
+}
+
+// redirectURL redirects the client to the request's URL.
+func redirectURL(w http.ResponseWriter, r *http.Request) {
+	location := r.URL.String()
+	method := r.Method
+	// Fixes https://github.com/kataras/iris/issues/921
+	// This is caused for security reasons, imagine a payment shop,
+	// you can't just permantly redirect a POST request, so just 307 (RFC 7231, 6.4.7).
+	if method == http.MethodPost || method == http.MethodPut {
+		http.Redirect(w, r, location, http.StatusTemporaryRedirect)
+		return
+	}
+
+	http.Redirect(w, r, location, http.StatusMovedPermanently)
 }
 
 // SubMux is the child of a main Mux.
 type SubMux interface {
 	Of(prefix string) SubMux
+	Group(prefix string, middlewares ...Wrapper) SubMux
+	With(middlewares ...Wrapper) SubMux
+	Host(pattern string) SubMux
+	Subdomain(subdomain string) SubMux
 	Unlink() SubMux
 	Use(middlewares ...Wrapper)
-	Handle(pattern string, handler http.Handler)
-	HandleFunc(pattern string, handlerFunc func(http.ResponseWriter, *http.Request))
+	After(hooks ...AfterHook)
+	Handle(pattern string, handler http.Handler) *Route
+	HandleErr(pattern string, handler http.Handler) (*Route, error)
+	HandleFunc(pattern string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route
+	HandleE(pattern string, handler HandlerE) *Route
+	HandleMethod(method, pattern string, handler http.Handler) *Route
+	HandleMethodFunc(method, pattern string, handlerFunc func(http.ResponseWriter, *http.Request)) *Route
+	HandleMethodE(method, pattern string, handler HandlerE) *Route
+	GET(pattern string, handler http.Handler) *Route
+	POST(pattern string, handler http.Handler) *Route
+	PUT(pattern string, handler http.Handler) *Route
+	PATCH(pattern string, handler http.Handler) *Route
+	DELETE(pattern string, handler http.Handler) *Route
+	HEAD(pattern string, handler http.Handler) *Route
+	OPTIONS(pattern string, handler http.Handler) *Route
+	Any(pattern string, handler http.Handler) *Route
+	Match(methods []string, pattern string, handler http.Handler) *Route
+	Mount(prefix string, handler http.Handler, options ...MountOption)
+	Static(pattern string, fs http.FileSystem) *Route
+	StaticFS(pattern string, fsys fs.FS, options ...StaticOption) *Route
+	SPA(pattern string, fs http.FileSystem, index string) *Route
+	Unhandle(pattern string) bool
+	Version(version string, options ...VersionOption) SubMux
+	HandleNotFound(handler http.Handler)
+	HandleMethodNotAllowed(handler http.Handler)
+	HandleError(mapper ErrorMapper)
+	Problems()
+	Heartbeat(pattern string) *Route
+	Maintenance(options ...MaintenanceOption) *Maintenance
+	RegisterEncoder(contentType string, encoder Dispatcher)
 	AbsPath() string
 }
 
@@ -216,6 +1021,8 @@ func (m *Mux) Of(prefix string) SubMux {
 		return m
 	}
 
+	prefix = m.origin().PatternSyntax.translate(prefix)
+
 	if prefix == m.root {
 		return m
 	}
@@ -233,13 +1040,59 @@ func (m *Mux) Of(prefix string) SubMux {
 	// remove any duplication of slashes "/".
 	prefix = pathSep + strings.Trim(m.root+prefix, pathSep)
 
-	return &Mux{
+	return m.child(prefix)
+}
+
+// child returns a new sub Mux of "m" which registers its routes under the "root" prefix,
+// it inherits a copy of the parent's request handlers and middlewares.
+func (m *Mux) child(root string) *Mux {
+	child := &Mux{
 		Routes: m.Routes,
 
-		root:            prefix,
-		requestHandlers: m.requestHandlers[0:],
-		beginHandlers:   m.beginHandlers[0:],
+		mu:              m.mu,
+		parent:          m,
+		root:            root,
+		requestHandlers: append([]RequestHandler(nil), m.requestHandlers...),
+		beginHandlers:   append([]Wrapper(nil), m.beginHandlers...),
+		namedRoutes:     m.namedRoutes,
+		version:         m.version,
 	}
+
+	m.lock()
+	m.children = append(m.children, child)
+	m.unlock()
+
+	return child
+}
+
+// Group returns a new Mux which registers its routes under the given "prefix", like `Of` does,
+// and wraps them with the given "middlewares" after the inherited ones, like `Use` does, i.e:
+// mux := NewMux()
+// api := mux.Group("/api/v1", authMiddleware)
+// users := api.Group("/users", loggerMiddleware)
+// users.HandleFunc("/:id", myHandler)
+// The above will register the "myHandler" to the "/api/v1/users/:id" path pattern
+// wrapped by the "authMiddleware" and the "loggerMiddleware", in that order.
+func (m *Mux) Group(prefix string, middlewares ...Wrapper) SubMux {
+	group, ok := m.Of(prefix).(*Mux)
+	if !ok || group == m {
+		group = m.child(m.root)
+	}
+
+	group.Use(middlewares...)
+	return group
+}
+
+// With returns a new Mux which registers its routes under the same prefix as this one,
+// wrapped by the inherited middlewares and the given "middlewares", like the `Group("/", middlewares...)`,
+// so individual routes can have their own middlewares, i.e:
+// mux.With(authMiddleware, auditMiddleware).HandleFunc("/admin", adminHandler)
+//
+// See `Route#Use` too.
+func (m *Mux) With(middlewares ...Wrapper) SubMux {
+	inline := m.child(m.root)
+	inline.Use(middlewares...)
+	return inline
 }
 
 // AbsPath returns the absolute path of the router for this Mux group.
@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -154,3 +155,335 @@ func TestMuxOf(t *testing.T) {
 	expect(t, http.MethodGet, srv.URL+"/v1").bodyEq("Handler of /v1")
 	expect(t, http.MethodGet, srv.URL+"/v1/hello").bodyEq("Handler of /v1/hello")
 }
+
+func TestMuxCaseInsensitive(t *testing.T) {
+	mux := NewMux()
+	mux.Routes.CaseInsensitive = true
+
+	mux.HandleFunc("/users/:name", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "User %s", GetParam(w, "name"))
+	})
+	mux.HandleFunc("/About/*path", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "About %s", GetParam(w, "path"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/Users/Kataras").
+		statusCode(http.StatusOK).bodyEq("User Kataras")
+	testHandler(t, mux, http.MethodGet, "/about/Us").
+		statusCode(http.StatusOK).bodyEq("About Us")
+
+	mux.CaseCorrection = true
+	testHandler(t, mux, http.MethodGet, "/USERS/Kataras?q=1").
+		statusCode(http.StatusMovedPermanently).headerEq("Location", "/users/Kataras?q=1")
+	testHandler(t, mux, http.MethodGet, "/about/Us").
+		statusCode(http.StatusMovedPermanently).headerEq("Location", "/About/Us")
+	testHandler(t, mux, http.MethodGet, "/users/Kataras").
+		statusCode(http.StatusOK).bodyEq("User Kataras")
+}
+
+func TestMuxTrailingSlash(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "About of %s", r.URL.Path)
+	})
+
+	testHandler(t, mux, http.MethodGet, "/about/").statusCode(http.StatusNotFound)
+
+	mux.TrailingSlash = TrailingSlashRedirect
+	testHandler(t, mux, http.MethodGet, "/about/").
+		statusCode(http.StatusMovedPermanently).headerEq("Location", "/about")
+	testHandler(t, mux, http.MethodPost, "/about/").
+		statusCode(http.StatusTemporaryRedirect).headerEq("Location", "/about")
+
+	mux.TrailingSlash = TrailingSlashMatch
+	testHandler(t, mux, http.MethodGet, "/about/").
+		statusCode(http.StatusOK).bodyEq("About of /about/")
+	testHandler(t, mux, http.MethodGet, "/about").
+		statusCode(http.StatusOK).bodyEq("About of /about")
+}
+
+func TestMuxCleanPath(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/a/c", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Handler of %s", r.URL.Path)
+	})
+
+	testHandler(t, mux, http.MethodGet, "/a//c").statusCode(http.StatusNotFound)
+
+	mux.CleanPath = true
+	for _, p := range []string{"/a//c", "/a/./c", "/a/b/../c", "//a/c"} {
+		testHandler(t, mux, http.MethodGet, p).
+			statusCode(http.StatusMovedPermanently).headerEq("Location", "/a/c")
+	}
+
+	testHandler(t, mux, http.MethodGet, "/a/b/../c/?q=1").
+		statusCode(http.StatusMovedPermanently).headerEq("Location", "/a/c/?q=1")
+	testHandler(t, mux, http.MethodGet, "/a/c").
+		statusCode(http.StatusOK).bodyEq("Handler of /a/c")
+}
+
+func TestMuxEncodedSlashes(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/files/:name", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "file %s", GetParam(w, "name"))
+	})
+	mux.HandleFunc("/files/:dir/:name", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "file %s of %s", GetParam(w, "name"), GetParam(w, "dir"))
+	})
+	mux.HandleFunc("/café/*path", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "café %s", GetParam(w, "path"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/files/john%20doe").
+		statusCode(http.StatusOK).bodyEq("file john doe")
+	testHandler(t, mux, http.MethodGet, "/files/a%2Fb").
+		statusCode(http.StatusOK).bodyEq("file b of a")
+
+	mux.EncodedSlashes = EncodedSlashDecode
+	testHandler(t, mux, http.MethodGet, "/files/a%2Fb%20c%25").
+		statusCode(http.StatusOK).bodyEq("file a/b c%")
+	testHandler(t, mux, http.MethodGet, "/files/a%2fb/c").
+		statusCode(http.StatusOK).bodyEq("file c of a/b")
+	testHandler(t, mux, http.MethodGet, "/caf%C3%A9/a%2Fb/c").
+		statusCode(http.StatusOK).bodyEq("café a/b/c")
+
+	mux.EncodedSlashes = EncodedSlashKeep
+	testHandler(t, mux, http.MethodGet, "/files/a%2Fb%20c%25").
+		statusCode(http.StatusOK).bodyEq("file a%2Fb c%25")
+
+	mux.TrailingSlash = TrailingSlashRedirect
+	testHandler(t, mux, http.MethodGet, "/files/a%2Fb%20c/").
+		statusCode(http.StatusMovedPermanently).headerEq("Location", "/files/a%2Fb%20c")
+
+	mux.EncodedSlashes = EncodedSlashReject
+	testHandler(t, mux, http.MethodGet, "/files/a%2Fb").
+		statusCode(http.StatusBadRequest)
+	testHandler(t, mux, http.MethodGet, "/files/john%20doe").
+		statusCode(http.StatusOK).bodyEq("file john doe")
+}
+
+func TestMuxPathBudget(t *testing.T) {
+	mux := NewMux()
+	mux.Routes.MaxPathLength = 32
+	mux.Routes.MaxPathDepth = 4
+	mux.HandleFunc("/files/*path", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("file " + GetParam(w, "path")))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/files/a/b/c").
+		statusCode(http.StatusOK).bodyEq("file a/b/c")
+	testHandler(t, mux, http.MethodGet, "/files/a/b/c/d").
+		statusCode(http.StatusBadRequest)
+	testHandler(t, mux, http.MethodGet, "/files/"+strings.Repeat("a", 32)).
+		statusCode(http.StatusRequestURITooLong)
+	testHandler(t, mux, http.MethodGet, "/"+strings.Repeat("/", 1000)).
+		statusCode(http.StatusRequestURITooLong)
+}
+
+func TestMuxWrap(t *testing.T) {
+	withHeaderValue := func(value string) Wrapper {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Chain", value)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	mux := NewMux()
+	mux.Use(withHeaderValue("use"))
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s %s", GetParam(w, "id"), strings.Join(w.Header()["X-Chain"], ","))
+	})
+
+	// registered after the routes.
+	mux.Wrap(withHeaderValue("first"), withHeaderValue("second"))
+	mux.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&unwrapWriter{w}, r)
+		})
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42").
+		statusCode(http.StatusOK).bodyEq("user 42 first,second,use")
+	testHandler(t, mux, http.MethodGet, "/missing").
+		statusCode(http.StatusNotFound).headerEq("X-Chain", "first")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a sub mux")
+		}
+	}()
+
+	mux.Of("/api").(*Mux).Wrap(withHeaderValue("api"))
+}
+
+func TestMuxGroup(t *testing.T) {
+	withHeaderValue := func(value string) Wrapper {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Chain", value)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	printPathHandler := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Handler of %s with %s", r.URL.Path, strings.Join(w.Header()["X-Chain"], ","))
+	}
+
+	mux := NewMux()
+	mux.Use(withHeaderValue("global"))
+
+	api := mux.Group("/api/v1", withHeaderValue("api"))
+	users := api.Group("/users", withHeaderValue("users"))
+	users.HandleFunc("/:id", printPathHandler)
+	posts := api.Group("/posts", withHeaderValue("posts"))
+	posts.HandleFunc("/", printPathHandler)
+	api.HandleFunc("/status", printPathHandler)
+
+	root := mux.Group("", withHeaderValue("root"))
+	root.HandleFunc("/", printPathHandler)
+	mux.HandleFunc("/about", printPathHandler)
+
+	testHandler(t, mux, http.MethodGet, "/api/v1/users/42").
+		bodyEq("Handler of /api/v1/users/42 with global,api,users")
+	testHandler(t, mux, http.MethodGet, "/api/v1/posts").
+		bodyEq("Handler of /api/v1/posts with global,api,posts")
+	testHandler(t, mux, http.MethodGet, "/api/v1/status").
+		bodyEq("Handler of /api/v1/status with global,api")
+	testHandler(t, mux, http.MethodGet, "/").
+		bodyEq("Handler of / with global,root")
+	testHandler(t, mux, http.MethodGet, "/about").
+		bodyEq("Handler of /about with global")
+}
+
+func TestMuxUnhandle(t *testing.T) {
+	mux := NewMux()
+	v1 := mux.Of("/v1")
+	v1.HandleMethodFunc(http.MethodGet, "/plugin", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("plugin"))
+	}).Name("plugin")
+
+	testHandler(t, mux, http.MethodGet, "/v1/plugin").statusCode(http.StatusOK).bodyEq("plugin")
+
+	if !v1.Unhandle("/plugin") {
+		t.Fatalf("expected route to be removed")
+	}
+
+	testHandler(t, mux, http.MethodGet, "/v1/plugin").statusCode(http.StatusNotFound)
+
+	if _, err := mux.URL("plugin"); err == nil {
+		t.Fatalf("expected the name of a removed route to be removed too")
+	}
+
+	if v1.Unhandle("/plugin") {
+		t.Fatalf("expected a removed route to not be found")
+	}
+}
+
+func TestMuxThreadSafe(t *testing.T) {
+	mux := NewMux()
+	mux.ThreadSafe = true
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("index"))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				pattern := fmt.Sprintf("/plugins/%d/%d", i, j)
+				mux.HandleMethodFunc(http.MethodGet, pattern, func(w http.ResponseWriter, r *http.Request) {})
+				mux.HandleMethodFunc(http.MethodPost, pattern, func(w http.ResponseWriter, r *http.Request) {}).Name(pattern)
+				if j%2 == 0 {
+					mux.Unhandle(pattern)
+				}
+			}
+		}(i)
+
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w := httptest.NewRecorder()
+				mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/plugins/%d/%d", i, j), nil))
+				mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+				mux.URL(fmt.Sprintf("/plugins/%d/%d", i, j))
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	testHandler(t, mux, http.MethodGet, "/").statusCode(http.StatusOK).bodyEq("index")
+	testHandler(t, mux, http.MethodPost, "/plugins/0/1").statusCode(http.StatusOK)
+	testHandler(t, mux, http.MethodPost, "/plugins/0/2").statusCode(http.StatusNotFound)
+
+	// the route middlewares can be added while the route is served.
+	route := mux.HandleFunc("/admin", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("admin"))
+	})
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			route.Use(func(next http.Handler) http.Handler { return next })
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin", nil))
+		}
+	}()
+	wg.Wait()
+
+	testHandler(t, mux, http.MethodGet, "/admin").statusCode(http.StatusOK).bodyEq("admin")
+}
+
+func TestMuxSubMuxErrorHandlers(t *testing.T) {
+	writeError := func(format string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, format, r.URL.Path)
+		})
+	}
+
+	mux := NewMux()
+	mux.MethodNotAllowed = true
+	mux.HandleNotFound(writeError("<h1>%s not found</h1>"))
+	mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {})
+
+	api := mux.Of("/api")
+	api.HandleNotFound(writeError(`{"error":"%s not found"}`))
+	api.HandleMethodNotAllowed(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		fmt.Fprintf(w, `{"error":"%s not allowed"}`, r.Method)
+	}))
+	api.HandleMethodFunc(http.MethodGet, "/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	// inherits the "/api" ones.
+	v1 := api.Of("/v1")
+	v1.HandleMethodFunc(http.MethodGet, "/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	mux.HandleMethodFunc(http.MethodGet, "/contact", func(w http.ResponseWriter, r *http.Request) {})
+
+	testHandler(t, mux, http.MethodGet, "/missing").
+		statusCode(http.StatusNotFound).bodyEq("<h1>/missing not found</h1>")
+	testHandler(t, mux, http.MethodGet, "/apis").
+		statusCode(http.StatusNotFound).bodyEq("<h1>/apis not found</h1>")
+	testHandler(t, mux, http.MethodGet, "/api/missing").
+		statusCode(http.StatusNotFound).bodyEq(`{"error":"/api/missing not found"}`)
+	testHandler(t, mux, http.MethodGet, "/api/v1/missing").
+		statusCode(http.StatusNotFound).bodyEq(`{"error":"/api/v1/missing not found"}`)
+	testHandler(t, mux, http.MethodPost, "/api/users").
+		statusCode(http.StatusMethodNotAllowed).headerEq("Allow", "GET, HEAD").bodyEq(`{"error":"POST not allowed"}`)
+	testHandler(t, mux, http.MethodDelete, "/api/v1/users").
+		statusCode(http.StatusMethodNotAllowed).headerEq("Allow", "GET, HEAD").bodyEq(`{"error":"DELETE not allowed"}`)
+	testHandler(t, mux, http.MethodPost, "/contact").
+		statusCode(http.StatusMethodNotAllowed).headerEq("Allow", "GET, HEAD").bodyEq("Method Not Allowed\n")
+}
@@ -0,0 +1,181 @@
+package muxie
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Accepts makes the route to respond only to the requests that accept one of the given media types,
+// through their "Accept" header, i.e:
+// mux.HandleFunc("/report", jsonReport).Accepts("application/json")
+// mux.HandleFunc("/report", csvReport).Accepts("text/csv")
+// mux.HandleFunc("/report", htmlReport).Accepts("text/html", "application/xhtml+xml")
+//
+// The route with the highest quality value ("q") of the "Accept" header is selected,
+// on ties the most specific media range wins and then the first registered route,
+// so the first one is selected for the requests without an "Accept" header.
+// The requests that do not accept any of them are served by the route, if any, which was registered for the same path
+// before the negotiated ones, otherwise they are responding with 406 Not Acceptable.
+// The "Vary: Accept" header is added to the negotiated responses.
+//
+// See `When` too.
+// Returns this Route for further calls.
+func (r *Route) Accepts(mediaTypes ...string) *Route {
+	if len(mediaTypes) == 0 {
+		panic("muxie/Route#Accepts: empty media types for \"" + r.pattern + "\"")
+	}
+
+	for _, mediaType := range mediaTypes {
+		if strings.Count(mediaType, "/") != 1 {
+			panic("muxie/Route#Accepts: invalid media type \"" + mediaType + "\" for \"" + r.pattern + "\"")
+		}
+	}
+
+	r.When()
+
+	r.mux.lock()
+	r.conditional.mediaTypes = append(r.conditional.mediaTypes, mediaTypes...)
+	r.mux.unlock()
+
+	return r
+}
+
+// notAcceptableHandler responds with 406 Not Acceptable, see `Route#Accepts`.
+var notAcceptableHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+})
+
+// ErrNotAcceptable is the error that the `Negotiate` returns when the request does not accept any of the offered content types,
+// the `ErrorStatus` of it is 406 Not Acceptable.
+var ErrNotAcceptable = errors.New("muxie: none of the offered content types is accepted")
+
+// defaultOffers are the content types of the `Negotiate` without offers.
+var defaultOffers = []string{"application/json", "application/xml", "text/html", "text/plain"}
+
+// Negotiate sends the "v" with the "code" status code through the encoder, see `Render`, of the offered content type
+// that the request accepts the most through its "Accept" header, i.e:
+// mux.HandleE("/users/:id", func(w http.ResponseWriter, r *http.Request) error {
+// return muxie.Negotiate(w, r, http.StatusOK, user)
+// })
+// mux.RegisterEncoder("text/html", muxie.HTMLTemplate(templates, "user.html"))
+//
+// The offers default to the "application/json", "application/xml", "text/html" and "text/plain",
+// the "text/html" is offered only when it has an encoder, i.e an `HTMLTemplate`.
+// The offer with the highest quality value ("q") of the "Accept" header is selected, on ties the most specific
+// media range wins and then the first offer, so the first one is selected for the requests without an "Accept" header.
+// The "Vary: Accept" header is added to the response. It sends nothing and it returns the `ErrNotAcceptable`
+// when the request does not accept any of the offers, which the `HandleE` routes respond with 406 Not Acceptable.
+func Negotiate(w http.ResponseWriter, r *http.Request, code int, v interface{}, offers ...string) error {
+	var mux *Mux
+	if pw := findParamsWriter(w); pw != nil {
+		mux = pw.mux
+	}
+
+	defaults := len(offers) == 0
+	if defaults {
+		offers = defaultOffers
+	}
+
+	ranges := parseAccept(r.Header.Get("Accept"))
+
+	var (
+		best     string
+		bestQ    float64
+		bestRank int
+	)
+	for _, offer := range offers {
+		contentType := mediaType(offer)
+		if strings.Count(contentType, "/") != 1 {
+			panic("muxie/Negotiate: invalid media type \"" + offer + "\"")
+		}
+
+		if defaults && mux.encoder(contentType) == nil {
+			continue
+		}
+
+		if q, rank := acceptQuality(ranges, contentType); q > bestQ || (q == bestQ && rank > bestRank) {
+			best, bestQ, bestRank = contentType, q, rank
+		}
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if best == "" {
+		return ErrNotAcceptable
+	}
+
+	return Render(w, code, best, v)
+}
+
+// acceptRange is a media range of the "Accept" header, i.e "text/*;q=0.8".
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept returns the media ranges of an "Accept" header, an empty header accepts everything.
+func parseAccept(header string) []acceptRange {
+	if strings.TrimSpace(header) == "" {
+		return []acceptRange{{typ: "*", subtype: "*", q: 1}}
+	}
+
+	var ranges []acceptRange
+	for _, s := range strings.Split(header, ",") {
+		params := strings.Split(s, ";")
+
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+		slashIdx := strings.IndexByte(mediaRange, '/')
+		if slashIdx <= 0 || slashIdx == len(mediaRange)-1 {
+			continue
+		}
+
+		ar := acceptRange{typ: mediaRange[:slashIdx], subtype: mediaRange[slashIdx+1:], q: 1}
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) > 2 && (param[0] == 'q' || param[0] == 'Q') && param[1] == '=' {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				ar.q = q
+			}
+		}
+
+		ranges = append(ranges, ar)
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the quality value of the most specific media range that matches the "mediaType"
+// and its specificity; 3 for exact, 2 for "type/*", 1 for "*/*" and 0 for no match.
+func acceptQuality(ranges []acceptRange, mediaType string) (q float64, rank int) {
+	mediaType = strings.ToLower(mediaType)
+	slashIdx := strings.IndexByte(mediaType, '/')
+	typ, subtype := mediaType[:slashIdx], mediaType[slashIdx+1:]
+
+	for _, ar := range ranges {
+		var r int
+		switch {
+		case ar.typ == typ && ar.subtype == subtype:
+			r = 3
+		case ar.typ == typ && ar.subtype == "*":
+			r = 2
+		case ar.typ == "*" && ar.subtype == "*":
+			r = 1
+		default:
+			continue
+		}
+
+		if r > rank {
+			q, rank = ar.q, r
+		}
+	}
+
+	if q == 0 {
+		return 0, 0
+	}
+
+	return q, rank
+}
@@ -0,0 +1,103 @@
+package muxie
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteAccepts(t *testing.T) {
+	writeReport := func(format string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s report", format)
+		}
+	}
+
+	mux := NewMux()
+	mux.HandleFunc("/report", writeReport("json")).Accepts("application/json")
+	mux.HandleFunc("/report", writeReport("csv")).Accepts("text/csv")
+	mux.HandleFunc("/report", writeReport("html")).Accepts("text/html", "application/xhtml+xml")
+
+	mux.HandleFunc("/feed", writeReport("plain"))
+	mux.HandleFunc("/feed", writeReport("atom")).Accepts("application/atom+xml")
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var tests = []struct {
+		path   string
+		accept string
+		status int
+		body   string
+	}{
+		{"/report", "", http.StatusOK, "json report"},
+		{"/report", "*/*", http.StatusOK, "json report"},
+		{"/report", "text/csv", http.StatusOK, "csv report"},
+		{"/report", "text/*", http.StatusOK, "csv report"},
+		{"/report", "application/json;q=0.5, text/csv;q=0.9", http.StatusOK, "csv report"},
+		{"/report", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", http.StatusOK, "html report"},
+		{"/report", "application/xhtml+xml", http.StatusOK, "html report"},
+		{"/report", "text/*;q=0.3, */*;q=0.1, text/html", http.StatusOK, "html report"},
+		{"/report", "*/*, text/csv", http.StatusOK, "csv report"},
+		{"/report", "image/png", http.StatusNotAcceptable, "Not Acceptable\n"},
+		{"/report", "text/csv;q=0, image/png", http.StatusNotAcceptable, "Not Acceptable\n"},
+		{"/feed", "application/atom+xml", http.StatusOK, "atom report"},
+		{"/feed", "image/png", http.StatusOK, "plain report"},
+	}
+
+	for _, tt := range tests {
+		expect(t, http.MethodGet, srv.URL+tt.path, withHeader("Accept", tt.accept)).
+			statusCode(tt.status).bodyEq(tt.body).headerEq("Vary", "Accept")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	type negotiatedUser struct {
+		Name string `json:"name" xml:"name,attr"`
+	}
+	user := negotiatedUser{Name: "kataras"}
+
+	mux := NewMux()
+	mux.HandleE("/user", func(w http.ResponseWriter, r *http.Request) error {
+		return Negotiate(w, r, http.StatusOK, user)
+	})
+	mux.HandleE("/csv", func(w http.ResponseWriter, r *http.Request) error {
+		return Negotiate(w, r, http.StatusOK, "name\nkataras", "text/csv", "text/plain")
+	})
+
+	html := mux.Of("/html")
+	html.RegisterEncoder("text/html", HTMLTemplate(template.Must(template.New("user").Parse(`<b>{{.Name}}</b>`)), "user"))
+	html.HandleE("/user", func(w http.ResponseWriter, r *http.Request) error {
+		return Negotiate(w, r, http.StatusOK, user)
+	})
+
+	negotiate := func(path, accept string) *testie {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		resp := rec.Result()
+		resp.Request = req
+		return &testie{t: t, resp: resp}
+	}
+
+	negotiate("/user", "").statusCode(http.StatusOK).headerEq("Vary", "Accept").
+		headerEq("Content-Type", "application/json; charset=utf-8").bodyEq(`{"name":"kataras"}`)
+	negotiate("/user", "text/xml;q=0.5, application/xml").headerEq("Content-Type", "text/xml; charset=utf-8").
+		bodyEq(`<negotiatedUser name="kataras"></negotiatedUser>`)
+	negotiate("/user", "text/*").headerEq("Content-Type", "text/plain; charset=utf-8").bodyEq("{kataras}")
+	// the html is not offered without an encoder.
+	negotiate("/user", "text/html").statusCode(http.StatusNotAcceptable)
+	negotiate("/html/user", "text/html, application/json;q=0.9").
+		headerEq("Content-Type", "text/html; charset=utf-8").bodyEq("<b>kataras</b>")
+	negotiate("/html/user", "application/json;q=0, */*;q=0.1").headerEq("Content-Type", "text/xml; charset=utf-8")
+
+	// explicit offers.
+	negotiate("/csv", "text/plain, text/csv;q=0.5").bodyEq("name\nkataras")
+	negotiate("/csv", "text/csv").statusCode(http.StatusInternalServerError)
+	negotiate("/csv", "image/png").statusCode(http.StatusNotAcceptable)
+}
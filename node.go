@@ -6,16 +6,26 @@ import (
 	"strings"
 )
 
-// Node is the trie's node which path patterns with their data like an HTTP handler are saved to.
-// See `Trie` too.
-type Node struct {
-	parent *Node
+// NodeOf is the trie's node which path patterns with their value of type T are saved to.
+// See `TrieOf` too.
+type NodeOf[T any] struct {
+	parent *NodeOf[T]
+	// the key of the node under its parent's children
+	// and the static path segments that the node is compressed with, if any,
+	// i.e "/v1/users" for a "/api/v1/users" chain of static nodes keyed as "api", see `TrieOf#compress`.
+	segment string
+	edge    string
 
-	children               map[string]*Node
+	children               map[string]*NodeOf[T]
 	hasDynamicChild        bool // does one of the children contains a parameter or wildcard?
 	childNamedParameter    bool // is the child a named parameter (single segmnet)
 	childWildcardParameter bool // or it is a wildcard (can be more than one path segments) ?
 
+	// the named parameter children in search order, the typed ones first and the untyped (":") last.
+	paramChildren []*NodeOf[T]
+	// if not nil then this is a typed named parameter node and the path segment should pass it.
+	paramValidator ParamValidator
+
 	paramKeys []string // the param keys without : or *.
 	end       bool     // it is a complete node, here we stop and we can say that the node is valid.
 	key       string   // if end == true then key is filled with the original value of the insertion's key.
@@ -23,23 +33,129 @@ type Node struct {
 	// we need it to track the static part for the closest-wildcard's parameter storage.
 	staticKey string
 
-	// insert main data relative to http and a tag for things like route names.
-	Handler http.Handler
+	// insert main data, the http.Handler of a `Node`, and a tag for things like route names.
+	Handler T
 	Tag     string
 
+	// the per HTTP method handlers, see `WithMethodHandler`.
+	methodHandlers    map[string]http.Handler // method:handler
+	methodsAllowed    []string                // in registration order.
+	methodsAllowedStr string                  // the "Allow" header's value.
+	// the priority of the node, see `WithPriority`, and the highest priority of the node and its children.
+	priority    int
+	maxPriority int
+	// the registration order of the end node, see `Precedence#FirstRegistered`.
+	seq int
+
+	// the handlers that serve only the requests that pass their matchers, newest last, see `Route#When`.
+	conditionals []*conditionalHandler
+
 	// other insert data.
 	Data interface{}
+	// the metadata of the path pattern, shared by the nodes of its optional named parameters, see `SetMeta`.
+	meta *nodeMeta
+}
+
+type nodeMeta struct {
+	values map[string]interface{}
 }
 
+// Node is the node of the `Trie`, the path patterns with their HTTP handlers are saved to.
+type Node = NodeOf[http.Handler]
+
 // NewNode returns a new, empty, Node.
 func NewNode() *Node {
 	n := new(Node)
 	return n
 }
 
-func (n *Node) addChild(s string, child *Node) {
+// Value returns the value of the node, its `Handler` field, see `WithValue`.
+func (n *NodeOf[T]) Value() T {
+	return n.Handler
+}
+
+// SetMeta attaches the "value" to the node's path pattern by its "key", i.e its docs, rate limits or owners,
+// so it can be retrieved from the `Search` results instead of a map keyed by the path patterns, i.e:
+// trie.Insert("/users/:id", muxie.WithHandler(users)).SetMeta("rate", 100)
+// [...]
+// n := trie.Search("/users/42", params)
+// rate, ok := n.Meta("rate")
+//
+// It should be called before the trie is searched, see `COWTrieOf#Update` for the ones that are modified at runtime.
+// A nil "value" removes the "key". The metadata is kept when the path pattern is inserted again and lost when it is deleted.
+// Returns the node itself for further calls.
+func (n *NodeOf[T]) SetMeta(key string, value interface{}) *NodeOf[T] {
+	if n.meta == nil {
+		n.meta = new(nodeMeta)
+	}
+
+	if value == nil {
+		delete(n.meta.values, key)
+		return n
+	}
+
+	if n.meta.values == nil {
+		n.meta.values = make(map[string]interface{})
+	}
+	n.meta.values[key] = value
+
+	return n
+}
+
+// Meta returns the metadata of the node's path pattern by its "key", see `SetMeta`,
+// and it reports whether it exists.
+func (n *NodeOf[T]) Meta(key string) (interface{}, bool) {
+	return n.meta.get(key)
+}
+
+func (m *nodeMeta) get(key string) (interface{}, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// all returns a copy of the metadata values, nil if there are not any.
+func (m *nodeMeta) all() map[string]interface{} {
+	if m == nil || len(m.values) == 0 {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(m.values))
+	for key, value := range m.values {
+		values[key] = value
+	}
+
+	return values
+}
+
+// clone returns a copy of the metadata, the metadata that the "clones" have copied are shared.
+func (m *nodeMeta) clone(clones map[*nodeMeta]*nodeMeta) *nodeMeta {
+	if m == nil {
+		return nil
+	}
+
+	if c, ok := clones[m]; ok {
+		return c
+	}
+
+	c := new(nodeMeta)
+	if m.values != nil {
+		c.values = make(map[string]interface{}, len(m.values))
+		for key, value := range m.values {
+			c.values[key] = value
+		}
+	}
+	clones[m] = c
+
+	return c
+}
+
+func (n *NodeOf[T]) addChild(s string, child *NodeOf[T]) {
 	if n.children == nil {
-		n.children = make(map[string]*Node)
+		n.children = make(map[string]*NodeOf[T])
 	}
 
 	if _, exists := n.children[s]; exists {
@@ -47,32 +163,221 @@ func (n *Node) addChild(s string, child *Node) {
 	}
 
 	child.parent = n
+	child.segment = s
 	n.children[s] = child
+
+	if s[0] == ParamStart[0] {
+		n.addParamChild(s, child)
+	}
 }
 
-func (n *Node) getChild(s string) *Node {
-	if n.children == nil {
-		return nil
+func (n *NodeOf[T]) addParamChild(s string, child *NodeOf[T]) {
+	if s != ParamStart {
+		// typed parameter, keep it before the untyped one (if any), which is always the last one.
+		if last := len(n.paramChildren) - 1; last >= 0 && n.paramChildren[last] == n.children[ParamStart] {
+			n.paramChildren = append(n.paramChildren[:last], child, n.paramChildren[last])
+			return
+		}
 	}
 
-	return n.children[s]
+	n.paramChildren = append(n.paramChildren, child)
 }
 
-func (n *Node) hasChild(s string) bool {
-	return n.getChild(s) != nil
+func (n *NodeOf[T]) removeChild(child *NodeOf[T]) {
+	s := child.segment
+	if n.children[s] != child {
+		return
+	}
+
+	delete(n.children, s)
+	child.parent = nil
+
+	switch s[0] {
+	case ParamStart[0]:
+		for i, c := range n.paramChildren {
+			if c == child {
+				n.paramChildren = append(n.paramChildren[:i], n.paramChildren[i+1:]...)
+				break
+			}
+		}
+		n.childNamedParameter = len(n.paramChildren) > 0
+	case WildcardParamStart[0]:
+		n.childWildcardParameter = false
+	}
+
+	n.hasDynamicChild = n.childNamedParameter || n.childWildcardParameter
 }
 
-func (n *Node) findClosestParentWildcardNode() *Node {
-	n = n.parent
-	for n != nil {
-		if n.childWildcardParameter {
-			return n.getChild(WildcardParamStart)
+// MethodHandler returns the handler that is registered for a specific HTTP method, if any, see `WithMethodHandler`.
+func (n *NodeOf[T]) MethodHandler(method string) (http.Handler, bool) {
+	handler, ok := n.methodHandlers[method]
+	return handler, ok
+}
+
+// AllowedMethods returns the HTTP methods that this node has handlers for, in registration order.
+// See `WithMethodHandler` too.
+func (n *NodeOf[T]) AllowedMethods() []string {
+	return n.methodsAllowed
+}
+
+func (n *NodeOf[T]) setMethodHandler(method string, handler http.Handler) {
+	if n.methodHandlers == nil {
+		n.methodHandlers = make(map[string]http.Handler)
+	}
+
+	if _, exists := n.methodHandlers[method]; !exists {
+		// a new slice, the previous one may be used by the callers of the `AllowedMethods`.
+		n.methodsAllowed = append(n.methodsAllowed[0:len(n.methodsAllowed):len(n.methodsAllowed)], method)
+		if n.methodsAllowedStr == "" {
+			n.methodsAllowedStr = method
+		} else {
+			n.methodsAllowedStr += ", " + method
 		}
+	}
 
-		n = n.parent
+	n.methodHandlers[method] = handler
+}
+
+// setPriority sets the node's priority and it raises the highest priority of its parents.
+func (n *NodeOf[T]) setPriority(priority int) {
+	n.priority = priority
+	for p := n; p != nil; p = p.parent {
+		if p.maxPriority < priority {
+			p.maxPriority = priority
+		}
+	}
+}
+
+func (n *NodeOf[T]) removeMethodHandler(method string) {
+	if _, exists := n.methodHandlers[method]; !exists {
+		return
+	}
+
+	delete(n.methodHandlers, method)
+
+	methodsAllowed := make([]string, 0, len(n.methodsAllowed)-1)
+	for _, m := range n.methodsAllowed {
+		if m != method {
+			methodsAllowed = append(methodsAllowed, m)
+		}
+	}
+
+	n.methodsAllowed = methodsAllowed
+	n.methodsAllowedStr = strings.Join(methodsAllowed, ", ")
+}
+
+func (n *NodeOf[T]) resetMethodHandlers() {
+	n.methodHandlers = nil
+	n.methodsAllowed = nil
+	n.methodsAllowedStr = ""
+}
+
+// handler returns the `Handler` or, if the node has per method handlers, a `MethodHandler` of them.
+func (n *NodeOf[T]) handler() http.Handler {
+	if len(n.methodsAllowed) == 0 {
+		handler, _ := interface{}(n.Handler).(http.Handler)
+		return handler
+	}
+
+	methodHandler := Methods()
+	for _, method := range n.methodsAllowed {
+		methodHandler.Handle(method, n.methodHandlers[method])
+	}
+
+	return methodHandler
+}
+
+// splitEdge splits the compressed "child" after the first "k" path segments of its edge,
+// it returns the new node which takes the child's place and the child is moved under it.
+func (n *NodeOf[T]) splitEdge(child *NodeOf[T], k int) *NodeOf[T] {
+	segments := strings.Split(child.edge[len(pathSep):], pathSep)
+
+	mid := &NodeOf[T]{
+		parent:      n,
+		segment:     child.segment,
+		edge:        joinEdge(segments[:k]),
+		maxPriority: child.maxPriority,
+	}
+	n.children[child.segment] = mid
+
+	child.edge = joinEdge(segments[k+1:])
+	mid.addChild(segments[k], child)
+	return mid
+}
+
+// joinEdge returns the edge of the static path "segments", i.e "/v1/users", or empty if there are not any.
+func joinEdge(segments []string) string {
+	if len(segments) == 0 {
+		return ""
+	}
+
+	return pathSep + strings.Join(segments, pathSep)
+}
+
+// edgeEnd returns the end of the node's edge at the path "q", which continues after its "start" offset,
+// or -1 if the path does not contain the edge's path segments there.
+func (n *NodeOf[T]) edgeEnd(q string, start int, caseInsensitive bool) int {
+	if n.edge == "" {
+		return start
+	}
+
+	end := start + len(n.edge)
+	if !caseInsensitive {
+		if !strings.HasPrefix(q[start:], n.edge) || (end < len(q) && q[end] != pathSepB) {
+			return -1
+		}
+		return end
+	}
+
+	// the edge is lower-cased, compare each path segment as the `TrieOf#search` does.
+	edge := n.edge
+	for edge != "" {
+		if start >= len(q) || q[start] != pathSepB {
+			return -1
+		}
+		start++
+		edge = edge[len(pathSep):]
+
+		segmentEnd := strings.IndexByte(q[start:], pathSepB)
+		if segmentEnd == -1 {
+			segmentEnd = len(q)
+		} else {
+			segmentEnd += start
+		}
+
+		edgeSegment := edge
+		if i := strings.IndexByte(edge, pathSepB); i != -1 {
+			edgeSegment = edge[:i]
+		}
+
+		if strings.ToLower(q[start:segmentEnd]) != edgeSegment {
+			return -1
+		}
+
+		start = segmentEnd
+		edge = edge[len(edgeSegment):]
 	}
 
-	return nil
+	return start
+}
+
+// compressible reports whether the node can be compressed with its only child,
+// both are static path segments and the node is not an end one.
+func (n *NodeOf[T]) compressible() bool {
+	return n.parent != nil && !n.end && len(n.children) == 1 && !n.hasDynamicChild &&
+		n.segment != pathSep && n.segment[0] != ParamStart[0] && n.segment[0] != WildcardParamStart[0]
+}
+
+func (n *NodeOf[T]) getChild(s string) *NodeOf[T] {
+	if n.children == nil {
+		return nil
+	}
+
+	return n.children[s]
+}
+
+func (n *NodeOf[T]) hasChild(s string) bool {
+	return n.getChild(s) != nil
 }
 
 // NodeKeysSorter is the type definition for the sorting logic
@@ -87,21 +392,21 @@ var DefaultKeysSorter = func(list []string) func(i, j int) bool {
 }
 
 // Keys returns this node's key (if it's a final path segment)
-// and its children's node's key. The "sorter" can be optionally used to sort the result.
-func (n *Node) Keys(sorter NodeKeysSorter) (list []string) {
+// and its children's node's key, once per key, i.e the optional parameters expansions
+// of a path pattern are listed once. The "sorter" can be optionally used to sort the result.
+func (n *NodeOf[T]) Keys(sorter NodeKeysSorter) (list []string) {
 	if n == nil {
 		return
 	}
 
-	if n.end {
-		list = append(list, n.key)
-	}
-
-	if n.children != nil {
-		for _, child := range n.children {
-			list = append(list, child.Keys(sorter)...)
+	visited := make(map[string]struct{})
+	n.walk(func(child *NodeOf[T]) bool {
+		if _, ok := visited[child.key]; !ok {
+			visited[child.key] = struct{}{}
+			list = append(list, child.key)
 		}
-	}
+		return true
+	})
 
 	if sorter != nil {
 		sort.Slice(list, sorter(list))
@@ -111,16 +416,16 @@ func (n *Node) Keys(sorter NodeKeysSorter) (list []string) {
 }
 
 // Parent returns the parent of that node, can return nil if this is the root node.
-func (n *Node) Parent() *Node {
+func (n *NodeOf[T]) Parent() *NodeOf[T] {
 	return n.parent
 }
 
 // String returns the key, which is the path pattern for the HTTP Mux.
-func (n *Node) String() string {
+func (n *NodeOf[T]) String() string {
 	return n.key
 }
 
 // IsEnd returns true if this Node is a final path, has a key.
-func (n *Node) IsEnd() bool {
+func (n *NodeOf[T]) IsEnd() bool {
 	return n.end
 }
@@ -0,0 +1,54 @@
+package muxie
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FoldCase returns the "s" with its letters case-folded, the letters that are equal under
+// the Unicode simple case folding, like the `strings.EqualFold` compares them, are mapped to the same lower-case letter,
+// i.e "Straße/ΣΟΦΙΑ" is folded to "straße/σοφια" and the Kelvin sign "K" to "k".
+//
+// It can be used as the, or as a part of the, `TrieOf#Normalize` so any casing of a path
+// matches the path patterns, including the named parameter values, unlike the `TrieOf#CaseInsensitive`.
+func FoldCase(s string) string {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= utf8.RuneSelf || ('A' <= c && c <= 'Z') {
+			return strings.Map(foldRune, s)
+		}
+	}
+
+	return s
+}
+
+// foldRune returns the lower-case of the upper-case of the "r",
+// so the letters of the same upper-case, i.e "σ" and "ς", are folded to the same one.
+func foldRune(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
+
+func (t *TrieOf[T]) normalize(s string) string {
+	if t.Normalize == nil {
+		return s
+	}
+
+	return t.Normalize(s)
+}
+
+// normalizePattern normalizes the static path segments of a path pattern,
+// the named parameters, their constraints, and the wildcards are kept as they are.
+func (t *TrieOf[T]) normalizePattern(pattern string) string {
+	if t.Normalize == nil {
+		return pattern
+	}
+
+	segments := strings.Split(pattern, pathSep)
+	for i, s := range segments {
+		if s != "" && s[0] != ParamStart[0] && s[0] != WildcardParamStart[0] {
+			segments[i] = t.Normalize(s)
+		}
+	}
+
+	return strings.Join(segments, pathSep)
+}
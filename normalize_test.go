@@ -0,0 +1,68 @@
+package muxie
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFoldCase(t *testing.T) {
+	for s, expected := range map[string]string{
+		"/users/42":     "/users/42",
+		"/Users/John":   "/users/john",
+		"/Straße/ΣΟΦΙΑ": "/straße/σοφια",
+		"/σοφίας":       "/σοφίασ",
+		"/K":            "/k",
+		"/ẞ":            "/ß",
+	} {
+		if got := FoldCase(s); expected != got {
+			t.Fatalf("%s: expected to be folded to: %s but got: %s", s, expected, got)
+		}
+	}
+}
+
+// composeAcute is a tiny NFC of the "e" with a combining acute accent, for the tests.
+func composeAcute(s string) string {
+	return strings.ReplaceAll(s, "e\u0301", "\u00e9")
+}
+
+func TestTrieNormalize(t *testing.T) {
+	tree := NewTrie()
+	tree.Normalize = composeAcute
+	tree.Insert("/caf\u00e9/:name([a-z\u00e9]+)", WithTag("cafe"))
+	tree.Insert("/cafe\u0301/menu", WithTag("menu"))
+
+	expectSearch(t, tree, "/cafe\u0301/creme", "cafe", []ParamEntry{{"name", "creme"}})
+	expectSearch(t, tree, "/caf\u00e9/menu", "menu", nil)
+	// the named parameter values are normalized too.
+	expectSearch(t, tree, "/caf\u00e9/cafe\u0301", "cafe", []ParamEntry{{"name", "caf\u00e9"}})
+
+	if n := tree.SearchPrefix("/caf\u00e9/menu"); n == nil || n.key != "/cafe\u0301/menu" {
+		t.Fatalf("expected the menu node by its normalized prefix")
+	}
+
+	if !tree.Delete("/caf\u00e9/:name([a-z\u00e9]+)") {
+		t.Fatalf("expected the path pattern to be deleted")
+	}
+	if n := tree.Search("/cafe\u0301/creme", nil); n != nil {
+		t.Fatalf("expected the deleted path pattern to be not found but got: %s", n.key)
+	}
+
+	tree = NewTrie()
+	tree.Normalize = func(s string) string { return composeAcute(FoldCase(s)) }
+	tree.Insert("/Caf\u00e9/:name", WithTag("cafe"))
+	expectSearch(t, tree, "/CAFE\u0301/John", "cafe", []ParamEntry{{"name", "john"}})
+}
+
+func TestMuxNormalize(t *testing.T) {
+	mux := NewMux()
+	mux.Routes.Normalize = composeAcute
+	mux.HandleFunc("/caf\u00e9/:name", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello " + GetParam(w, "name")))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/cafe%CC%81/Ren%C3%A9e").
+		statusCode(http.StatusOK).bodyEq("hello Ren\u00e9e")
+	testHandler(t, mux, http.MethodGet, "/caf%C3%A9/Rene%CC%81e").
+		statusCode(http.StatusOK).bodyEq("hello Ren\u00e9e")
+}
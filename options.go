@@ -0,0 +1,96 @@
+package muxie
+
+import (
+	"net/http"
+)
+
+// Option is the type of the `New` options, it configures a Mux.
+type Option func(*Mux)
+
+// New returns a new Mux configured by the "options", i.e:
+// mux := muxie.New(
+// muxie.WithRedirectTrailingSlash(),
+// muxie.WithCaseInsensitive(),
+// muxie.WithMethodNotAllowed(),
+// muxie.WithNotFound(notFoundHandler),
+// )
+//
+// It is the `NewMux` with its fields set by the options, in order, before any route registration.
+func New(options ...Option) *Mux {
+	m := NewMux()
+	for _, opt := range options {
+		opt(m)
+	}
+
+	return m
+}
+
+// WithTrailingSlash sets the policy for the requests with a trailing slash, see `Mux#TrailingSlash`.
+func WithTrailingSlash(policy TrailingSlashPolicy) Option {
+	return func(m *Mux) {
+		m.TrailingSlash = policy
+	}
+}
+
+// WithRedirectTrailingSlash redirects the requests with a trailing slash to their path without it,
+// see `TrailingSlashRedirect`.
+func WithRedirectTrailingSlash() Option {
+	return WithTrailingSlash(TrailingSlashRedirect)
+}
+
+// WithCleanPath redirects the requests with double slashes, "." or ".." path elements to their cleaned path,
+// see `Mux#CleanPath`.
+func WithCleanPath() Option {
+	return func(m *Mux) {
+		m.CleanPath = true
+	}
+}
+
+// WithCaseInsensitive matches the static path segments of the routes case-insensitively,
+// see `Trie#CaseInsensitive`.
+func WithCaseInsensitive() Option {
+	return func(m *Mux) {
+		m.Routes.CaseInsensitive = true
+	}
+}
+
+// WithMethodNotAllowed responds with 405 Method Not Allowed to the requests of the paths
+// that are registered only for different HTTP methods, see `Mux#MethodNotAllowed`.
+func WithMethodNotAllowed() Option {
+	return func(m *Mux) {
+		m.MethodNotAllowed = true
+	}
+}
+
+// WithAutoOptions responds to the OPTIONS requests of the paths that are registered through `HandleMethod` or `Methods`,
+// see `Mux#AutoOptions`.
+func WithAutoOptions() Option {
+	return func(m *Mux) {
+		m.AutoOptions = true
+	}
+}
+
+// WithMaxParams sets the maximum number of the path parameters that a path pattern can have, see `Mux#MaxParams`.
+func WithMaxParams(n int) Option {
+	return func(m *Mux) {
+		m.MaxParams = n
+	}
+}
+
+// WithNotFound sets the handler of the requests that are not matched by any route, see `Mux#NotFoundHandler`.
+func WithNotFound(handler http.Handler) Option {
+	if handler == nil {
+		panic("muxie/WithNotFound: empty handler")
+	}
+
+	return func(m *Mux) {
+		m.NotFoundHandler = handler
+	}
+}
+
+// WithThreadSafe allows routes to be registered and removed while the Mux serves requests, see `Mux#ThreadSafe`.
+func WithThreadSafe() Option {
+	return func(m *Mux) {
+		m.ThreadSafe = true
+	}
+}
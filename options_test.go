@@ -0,0 +1,44 @@
+package muxie
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	mux := New(
+		WithRedirectTrailingSlash(),
+		WithCaseInsensitive(),
+		WithMethodNotAllowed(),
+		WithMaxParams(1),
+		WithNotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("custom not found"))
+		})),
+	)
+
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user " + GetParam(w, "id")))
+	})
+	mux.HandleMethodFunc(http.MethodPost, "/posts", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("post created"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/Users/42").
+		statusCode(http.StatusOK).bodyEq("user 42")
+	testHandler(t, mux, http.MethodGet, "/users/42/").
+		statusCode(http.StatusMovedPermanently).headerEq("Location", "/users/42")
+	testHandler(t, mux, http.MethodGet, "/posts").
+		statusCode(http.StatusMethodNotAllowed).headerEq("Allow", "POST")
+	testHandler(t, mux, http.MethodGet, "/about").
+		statusCode(http.StatusNotFound).bodyEq("custom not found")
+
+	if _, err := mux.HandleErr("/users/:id/posts/:slug", http.NotFoundHandler()); err == nil {
+		t.Fatalf("expected an error for a path pattern with more path parameters than the max params")
+	}
+
+	mux = New(WithTrailingSlash(TrailingSlashMatch), WithCleanPath(), WithAutoOptions(), WithThreadSafe())
+	if !mux.CleanPath || !mux.AutoOptions || !mux.ThreadSafe || mux.TrailingSlash != TrailingSlashMatch {
+		t.Fatalf("expected the options to be applied")
+	}
+}
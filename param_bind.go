@@ -0,0 +1,103 @@
+package muxie
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ParamTag is the struct field tag that `BindParams` reads the parameter keys from.
+const ParamTag = "param"
+
+// ParamErrors is the error that `BindParams` returns, it holds the errors of all the fields that could not be bound.
+type ParamErrors []error
+
+func (errs ParamErrors) Error() string {
+	var b strings.Builder
+	for i, err := range errs {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(err.Error())
+	}
+
+	return b.String()
+}
+
+// BindParams binds the path parameters to the fields of the struct that "ptr" points to, by their `ParamTag`, i.e:
+// type postParams struct { ID int `param:"id,required"`; Slug string `param:"slug"` }
+// mux.HandleFunc("/users/:id/posts/:slug", func(w http.ResponseWriter, r *http.Request) {
+// var p postParams
+// err := muxie.BindParams(w, &p)
+// [...]
+//
+// The fields can be of the types that `Param` supports or pointers to them, the embedded structs are bound too.
+// A missing parameter leaves its field untouched, unless its tag has the "required" option.
+// It returns a `ParamErrors` which contains a `*ParamError` for each field that could not be bound,
+// the ones of the missing required parameters wrap the `ErrParamNotFound`.
+func BindParams(w http.ResponseWriter, ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		panic("muxie/BindParams: expected a pointer to a struct but got " + reflect.TypeOf(ptr).String())
+	}
+
+	var errs ParamErrors
+	bindParams(w, v.Elem(), &errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func bindParams(w http.ResponseWriter, v reflect.Value, errs *ParamErrors) {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, hasTag := field.Tag.Lookup(ParamTag)
+
+		if !hasTag {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				bindParams(w, v.Field(i), errs)
+			}
+			continue
+		}
+
+		key, opts := tag, ""
+		if idx := strings.IndexByte(tag, ','); idx != -1 {
+			key, opts = tag[:idx], tag[idx+1:]
+		}
+
+		if key == "-" || field.PkgPath != "" { // skipped or unexported.
+			continue
+		}
+
+		if key == "" {
+			key = field.Name
+		}
+
+		value := GetParam(w, key)
+		if value == "" {
+			if opts == "required" {
+				*errs = append(*errs, &ParamError{Key: key, Err: ErrParamNotFound})
+			}
+			continue
+		}
+
+		fieldValue := v.Field(i)
+		if fieldValue.Kind() == reflect.Ptr {
+			elem := reflect.New(fieldValue.Type().Elem())
+			if err := parseParam(elem.Interface(), value); err != nil {
+				*errs = append(*errs, paramError(key, value, err))
+				continue
+			}
+			fieldValue.Set(elem)
+			continue
+		}
+
+		if err := parseParam(fieldValue.Addr().Interface(), value); err != nil {
+			*errs = append(*errs, paramError(key, value, err))
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package muxie
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+type testPageParams struct {
+	Page *int `param:"page"`
+}
+
+type testPostParams struct {
+	testPageParams
+	UserID  uint64         `param:"id,required"`
+	Slug    string         `param:"slug"`
+	Level   testParamLevel `param:"level"`
+	Ignored string         `param:"-"`
+	Name    string
+}
+
+func TestBindParams(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/users/:id/posts/:slug/:level/:page/:Ignored", func(w http.ResponseWriter, r *http.Request) {
+		var p testPostParams
+		if err := BindParams(w, &p); err != nil {
+			fmt.Fprint(w, err)
+			return
+		}
+
+		fmt.Fprintf(w, "%d %s %d %d %q", p.UserID, p.Slug, p.Level, *p.Page, p.Ignored)
+	})
+	mux.HandleFunc("/posts/:slug", func(w http.ResponseWriter, r *http.Request) {
+		var p testPostParams
+		err := BindParams(w, &p)
+
+		var errs ParamErrors
+		if errors.As(err, &errs) && len(errs) == 1 && errors.Is(errs[0], ErrParamNotFound) {
+			fmt.Fprintf(w, "%s %v", p.Slug, err)
+		}
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42/posts/hello/high/2/value").
+		statusCode(http.StatusOK).bodyEq(`42 hello 2 2 ""`)
+
+	testHandler(t, mux, http.MethodGet, "/users/-1/posts/hello/max/two/value").
+		statusCode(http.StatusOK).bodyEq(`muxie: invalid value "two" of parameter "page": invalid syntax; ` +
+		`muxie: invalid value "-1" of parameter "id": invalid syntax; ` +
+		`muxie: invalid value "max" of parameter "level": unknown level`)
+
+	testHandler(t, mux, http.MethodGet, "/posts/hello").
+		statusCode(http.StatusOK).bodyEq(`hello muxie: parameter "id" not found`)
+}
+
+func TestBindParamsInvalidTarget(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a non-pointer target")
+		}
+	}()
+
+	BindParams(nil, testPostParams{})
+}
@@ -0,0 +1,216 @@
+package muxie
+
+import (
+	"encoding"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrParamNotFound is returned by the typed parameter getters, i.e `GetParamInt`,
+// when the request has not a parameter with the given key.
+var ErrParamNotFound = errors.New("muxie: parameter not found")
+
+// getParam returns the value of a parameter or `ErrParamNotFound`, an empty value is a missing one.
+func getParam(w http.ResponseWriter, key string) (string, error) {
+	value := GetParam(w, key)
+	if value == "" {
+		return "", ErrParamNotFound
+	}
+
+	return value, nil
+}
+
+// ParamError is the error that the typed parameter getters, i.e `GetParamInt`,
+// and the `BindParams` return when a parameter's value can not be parsed.
+type ParamError struct {
+	Key   string
+	Value string
+	// Err is the parse error, i.e `strconv.ErrSyntax`.
+	Err error
+}
+
+func (e *ParamError) Error() string {
+	if e.Err == ErrParamNotFound {
+		return "muxie: parameter \"" + e.Key + "\" not found"
+	}
+
+	return "muxie: invalid value \"" + e.Value + "\" of parameter \"" + e.Key + "\": " + e.Err.Error()
+}
+
+// Unwrap returns the parse error.
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+func paramError(key, value string, err error) error {
+	if numErr, ok := err.(*strconv.NumError); ok {
+		err = numErr.Err
+	}
+
+	return &ParamError{Key: key, Value: value, Err: err}
+}
+
+// GetParamInt returns the value of a path parameter as int, i.e "/users/:id:int".
+// It returns `ErrParamNotFound` if the parameter is missing or an error if its value is not a valid int.
+func GetParamInt(w http.ResponseWriter, key string) (int, error) {
+	value, err := getParam(w, key)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, paramError(key, value, err)
+	}
+
+	return v, nil
+}
+
+// GetParamInt64 returns the value of a path parameter as int64, see `GetParamInt`.
+func GetParamInt64(w http.ResponseWriter, key string) (int64, error) {
+	value, err := getParam(w, key)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, paramError(key, value, err)
+	}
+
+	return v, nil
+}
+
+// GetParamUint64 returns the value of a path parameter as uint64, see `GetParamInt`.
+func GetParamUint64(w http.ResponseWriter, key string) (uint64, error) {
+	value, err := getParam(w, key)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, paramError(key, value, err)
+	}
+
+	return v, nil
+}
+
+// GetParamFloat64 returns the value of a path parameter as float64, see `GetParamInt`.
+func GetParamFloat64(w http.ResponseWriter, key string) (float64, error) {
+	value, err := getParam(w, key)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, paramError(key, value, err)
+	}
+
+	return v, nil
+}
+
+// GetParamBool returns the value of a path parameter as bool, it accepts the values of the `strconv.ParseBool`,
+// i.e "1", "t", "true", "0", "f" and "false", see `GetParamInt` too.
+func GetParamBool(w http.ResponseWriter, key string) (bool, error) {
+	value, err := getParam(w, key)
+	if err != nil {
+		return false, err
+	}
+
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, paramError(key, value, err)
+	}
+
+	return v, nil
+}
+
+// GetParamUUID returns the value of a path parameter which should be a UUID, i.e "/users/:id:uuid",
+// in its canonical, lower-cased, form: "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx". See `GetParamInt` too.
+func GetParamUUID(w http.ResponseWriter, key string) (string, error) {
+	value, err := getParam(w, key)
+	if err != nil {
+		return "", err
+	}
+
+	if !isUUID(value) {
+		return "", paramError(key, value, errors.New("invalid UUID format"))
+	}
+
+	return strings.ToLower(value), nil
+}
+
+// Param returns the value of a path parameter as "T", i.e:
+// id, err := muxie.Param[int](w, "id")
+//
+// The supported types are the string, the integers, the floats, the bool, the time.Time, which is parsed as RFC3339,
+// and the types which their pointer implements the `encoding.TextUnmarshaler`.
+// It returns `ErrParamNotFound` if the parameter is missing or an error if its value can not be parsed as "T".
+func Param[T any](w http.ResponseWriter, key string) (T, error) {
+	var v T
+
+	value, err := getParam(w, key)
+	if err != nil {
+		return v, err
+	}
+
+	if err = parseParam(&v, value); err != nil {
+		return v, paramError(key, value, err)
+	}
+
+	return v, nil
+}
+
+// parseParam parses the "value" to the "ptr", see `Param`.
+func parseParam(ptr interface{}, value string) error {
+	if u, ok := ptr.(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	var err error
+	switch v := ptr.(type) {
+	case *string:
+		*v = value
+	case *bool:
+		*v, err = strconv.ParseBool(value)
+	case *time.Time:
+		*v, err = time.Parse(time.RFC3339, value)
+	default:
+		return parseNumber(reflect.ValueOf(ptr).Elem(), value)
+	}
+
+	return err
+}
+
+func parseNumber(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return errors.New("unsupported type " + v.Type().String())
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package muxie
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTypedParamGetters(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/int/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := GetParamInt(w, "v")
+		fmt.Fprintf(w, "%d %v", v, err)
+	})
+	mux.HandleFunc("/int64/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := GetParamInt64(w, "v")
+		fmt.Fprintf(w, "%d %v", v, err)
+	})
+	mux.HandleFunc("/uint64/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := GetParamUint64(w, "v")
+		fmt.Fprintf(w, "%d %v", v, err)
+	})
+	mux.HandleFunc("/float64/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := GetParamFloat64(w, "v")
+		fmt.Fprintf(w, "%g %v", v, err)
+	})
+	mux.HandleFunc("/bool/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := GetParamBool(w, "v")
+		fmt.Fprintf(w, "%t %v", v, err)
+	})
+	mux.HandleFunc("/uuid/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := GetParamUUID(w, "v")
+		fmt.Fprintf(w, "%s %v", v, err)
+	})
+	mux.HandleFunc("/users/:id:uuid", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s", GetParam(w, "id"))
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		_, err := GetParamInt(w, "v")
+		fmt.Fprintf(w, "%t", err == ErrParamNotFound)
+	})
+
+	var tests = []struct {
+		path string
+		body string
+	}{
+		{"/int/-42", "-42 <nil>"},
+		{"/int/42a", `0 muxie: invalid value "42a" of parameter "v": invalid syntax`},
+		{"/int64/9223372036854775807", "9223372036854775807 <nil>"},
+		{"/int64/9223372036854775808", `0 muxie: invalid value "9223372036854775808" of parameter "v": value out of range`},
+		{"/uint64/18446744073709551615", "18446744073709551615 <nil>"},
+		{"/uint64/-1", `0 muxie: invalid value "-1" of parameter "v": invalid syntax`},
+		{"/float64/3.14", "3.14 <nil>"},
+		{"/bool/true", "true <nil>"},
+		{"/bool/yes", `false muxie: invalid value "yes" of parameter "v": invalid syntax`},
+		{"/uuid/123E4567-E89B-12D3-A456-426614174000", "123e4567-e89b-12d3-a456-426614174000 <nil>"},
+		{"/uuid/123e4567e89b12d3a456426614174000", ` muxie: invalid value "123e4567e89b12d3a456426614174000" of parameter "v": invalid UUID format`},
+		{"/users/123e4567-e89b-12d3-a456-426614174000", "user 123e4567-e89b-12d3-a456-426614174000"},
+		{"/missing", "true"},
+	}
+
+	for _, tt := range tests {
+		testHandler(t, mux, http.MethodGet, tt.path).statusCode(http.StatusOK).bodyEq(tt.body)
+	}
+
+	testHandler(t, mux, http.MethodGet, "/users/42").statusCode(http.StatusNotFound)
+}
+
+type testParamLevel int
+
+func (l *testParamLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level")
+	}
+
+	return nil
+}
+
+func TestGenericParam(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/int8/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := Param[int8](w, "v")
+		fmt.Fprintf(w, "%d %v", v, err)
+	})
+	mux.HandleFunc("/uint/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := Param[uint](w, "v")
+		fmt.Fprintf(w, "%d %v", v, err)
+	})
+	mux.HandleFunc("/float32/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := Param[float32](w, "v")
+		fmt.Fprintf(w, "%g %v", v, err)
+	})
+	mux.HandleFunc("/bool/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := Param[bool](w, "v")
+		fmt.Fprintf(w, "%t %v", v, err)
+	})
+	mux.HandleFunc("/string/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := Param[string](w, "v")
+		fmt.Fprintf(w, "%s %v", v, err)
+	})
+	mux.HandleFunc("/time/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := Param[time.Time](w, "v")
+		fmt.Fprintf(w, "%d %v", v.Unix(), err)
+	})
+	mux.HandleFunc("/level/:v", func(w http.ResponseWriter, r *http.Request) {
+		v, err := Param[testParamLevel](w, "v")
+		fmt.Fprintf(w, "%d %v", v, err)
+	})
+	mux.HandleFunc("/slice/:v", func(w http.ResponseWriter, r *http.Request) {
+		_, err := Param[[]string](w, "v")
+		fmt.Fprintf(w, "%v", err)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		_, err := Param[int](w, "v")
+		fmt.Fprintf(w, "%t", err == ErrParamNotFound)
+	})
+
+	var tests = []struct {
+		path string
+		body string
+	}{
+		{"/int8/-128", "-128 <nil>"},
+		{"/int8/128", `0 muxie: invalid value "128" of parameter "v": value out of range`},
+		{"/uint/42", "42 <nil>"},
+		{"/float32/1.5", "1.5 <nil>"},
+		{"/bool/0", "false <nil>"},
+		{"/string/kataras", "kataras <nil>"},
+		{"/time/2024-01-02T03:04:05Z", "1704164645 <nil>"},
+		{"/level/high", "2 <nil>"},
+		{"/level/max", `0 muxie: invalid value "max" of parameter "v": unknown level`},
+		{"/slice/a", `muxie: invalid value "a" of parameter "v": unsupported type []string`},
+		{"/missing", "true"},
+	}
+
+	for _, tt := range tests {
+		testHandler(t, mux, http.MethodGet, tt.path).statusCode(http.StatusOK).bodyEq(tt.body)
+	}
+}
@@ -0,0 +1,50 @@
+package muxie
+
+// ParamsResetter can be implemented by a `ParamsSetter` so its previous parameters are discarded
+// before each `TrieOf#SearchInto`, the `Params` and the `MapParams` implement it.
+type ParamsResetter interface {
+	Reset()
+}
+
+// Params is a `ParamsSetter` which keeps the named path parameters in their path order,
+// see `TrieOf#SearchInto`.
+type Params []ParamEntry
+
+// Set implements the `ParamsSetter`, it adds a parameter.
+func (p *Params) Set(key, value string) {
+	*p = append(*p, ParamEntry{Key: key, Value: value})
+}
+
+// Reset implements the `ParamsResetter`, it removes the parameters and it keeps their storage.
+func (p *Params) Reset() {
+	*p = (*p)[:0]
+}
+
+// Get returns the value of a parameter, if any.
+func (p Params) Get(key string) string {
+	for _, entry := range p {
+		if entry.Key == key {
+			return entry.Value
+		}
+	}
+
+	return ""
+}
+
+// MapParams is a `ParamsSetter` which keeps the named path parameters by their keys, i.e:
+// params := make(muxie.MapParams)
+// trie.Search("/users/42", params)
+// params["id"] // "42"
+type MapParams map[string]string
+
+// Set implements the `ParamsSetter`, it sets a parameter.
+func (p MapParams) Set(key, value string) {
+	p[key] = value
+}
+
+// Reset implements the `ParamsResetter`, it removes the parameters.
+func (p MapParams) Reset() {
+	for key := range p {
+		delete(p, key)
+	}
+}
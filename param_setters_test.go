@@ -0,0 +1,57 @@
+package muxie
+
+import (
+	"testing"
+)
+
+func TestParamSetters(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/users/:id/files/*path", WithTag("file"))
+	tree.Insert("/about", WithTag("about"))
+
+	var params Params
+	if n := tree.SearchInto("/users/42/files/a/b.txt", &params); n == nil || n.Tag != "file" {
+		t.Fatalf("expected the path to be found")
+	}
+	if expected, got := (Params{{"id", "42"}, {"path", "a/b.txt"}}), params; len(got) != 2 || got[0] != expected[0] || got[1] != expected[1] {
+		t.Fatalf("expected params: %v but got: %v", expected, got)
+	}
+	if expected, got := "42", params.Get("id"); expected != got {
+		t.Fatalf("expected param: %s but got: %s", expected, got)
+	}
+
+	// the previous parameters are reset.
+	if n := tree.SearchInto("/about", &params); n == nil || len(params) != 0 || params.Get("id") != "" {
+		t.Fatalf("expected the params to be reset")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		tree.SearchInto("/users/42/files/a/b.txt", &params)
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations but got: %v", allocs)
+	}
+
+	mapParams := make(MapParams)
+	if n := tree.Search("/users/42/files/readme.md", mapParams); n == nil || mapParams["id"] != "42" || mapParams["path"] != "readme.md" {
+		t.Fatalf("expected the params to be set but got: %v", mapParams)
+	}
+
+	// any storage can be reused, the ones that implement the ParamsResetter are reset.
+	if n := tree.SearchInto("/about", mapParams); n == nil || len(mapParams) != 0 {
+		t.Fatalf("expected the map params to be reset but got: %v", mapParams)
+	}
+
+	pw := new(paramsWriter)
+	if n := tree.SearchInto("/users/42/files/a/b.txt", pw); n == nil || len(pw.params) != 2 || pw.params[0].Value != "42" {
+		t.Fatalf("expected the params to be set but got: %v", pw.params)
+	}
+
+	if n := tree.SearchInto("/users/42/files/readme.md", nil); n == nil || n.Tag != "file" {
+		t.Fatalf("expected the path to be found without a params setter")
+	}
+
+	if n := tree.Search("/users/42/files/readme.md", nil); n == nil || n.Tag != "file" {
+		t.Fatalf("expected the path to be found without a params setter")
+	}
+}
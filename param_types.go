@@ -0,0 +1,153 @@
+package muxie
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+const (
+	// ParamTypeSep is the character, as a string, which separates a named parameter's name from its type,
+	// i.e "/users/:id:int".
+	ParamTypeSep = ":"
+	// ParamExprStart is the character which starts a named parameter's regular expression constraint,
+	// i.e "/articles/:slug([a-z0-9-]+)". The expression should match the whole path segment.
+	ParamExprStart = '('
+	// ParamExprEnd is the character which ends a named parameter's regular expression constraint.
+	ParamExprEnd = ')'
+)
+
+// ParamValidator reports whether a path segment's raw value is valid for a typed or regex-constrained named parameter.
+//
+// See `RegisterParamType`.
+type ParamValidator func(value string) bool
+
+var (
+	paramTypesMu sync.RWMutex
+	paramTypes   = map[string]ParamValidator{
+		"string":       func(string) bool { return true },
+		"int":          isIntBits(strconv.IntSize),
+		"int8":         isIntBits(8),
+		"int16":        isIntBits(16),
+		"int32":        isIntBits(32),
+		"int64":        isIntBits(64),
+		"uint":         isUintBits(strconv.IntSize),
+		"uint8":        isUintBits(8),
+		"uint16":       isUintBits(16),
+		"uint32":       isUintBits(32),
+		"uint64":       isUintBits(64),
+		"bool":         isBool,
+		"alphabetical": isAlphabetical,
+		"uuid":         isUUID,
+	}
+)
+
+// RegisterParamType registers a new, or overrides an existing, named parameter type
+// which can be used on path patterns like "/users/:name:mytype".
+// Should be called before the `Trie#Insert` (or `Mux#Handle/HandleFunc`) of the patterns that use it.
+//
+// Built-in types are: string, int, int8, int16, int32, int64,
+// uint, uint8, uint16, uint32, uint64, bool, alphabetical and uuid.
+func RegisterParamType(name string, validator ParamValidator) {
+	if name == "" {
+		panic("muxie/RegisterParamType: empty type name")
+	}
+
+	if validator == nil {
+		panic("muxie/RegisterParamType: empty validator")
+	}
+
+	paramTypesMu.Lock()
+	paramTypes[name] = validator
+	paramTypesMu.Unlock()
+}
+
+// LookupParamType returns the validator of a registered named parameter type
+// and reports whether that type is registered.
+func LookupParamType(name string) (ParamValidator, bool) {
+	paramTypesMu.RLock()
+	validator, ok := paramTypes[name]
+	paramTypesMu.RUnlock()
+	return validator, ok
+}
+
+// compileParamConstraint returns the validator of a named parameter's constraint,
+// which is a registered type's name or a regular expression wrapped by parenthesis.
+func compileParamConstraint(constraint string) (ParamValidator, error) {
+	if constraint[0] == ParamExprStart {
+		if constraint[len(constraint)-1] != ParamExprEnd {
+			return nil, errors.New("missing closing parenthesis on parameter expression \"" + constraint + "\"")
+		}
+
+		expr, err := regexp.Compile("^(?:" + constraint[1:len(constraint)-1] + ")$")
+		if err != nil {
+			return nil, err
+		}
+
+		return expr.MatchString, nil
+	}
+
+	validator, ok := LookupParamType(constraint)
+	if !ok {
+		return nil, errors.New("unknown parameter type \"" + constraint + "\"")
+	}
+
+	return validator, nil
+}
+
+func isIntBits(bitSize int) ParamValidator {
+	return func(value string) bool {
+		_, err := strconv.ParseInt(value, 10, bitSize)
+		return err == nil
+	}
+}
+
+func isUintBits(bitSize int) ParamValidator {
+	return func(value string) bool {
+		_, err := strconv.ParseUint(value, 10, bitSize)
+		return err == nil
+	}
+}
+
+func isBool(value string) bool {
+	_, err := strconv.ParseBool(value)
+	return err == nil
+}
+
+func isAlphabetical(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isUUID reports whether the value is a UUID in its canonical form, i.e "123e4567-e89b-12d3-a456-426614174000".
+func isUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if (c < '0' || c > '9') && (c < 'a' || c > 'f') && (c < 'A' || c > 'F') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
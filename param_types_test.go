@@ -0,0 +1,99 @@
+package muxie
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestTypedParams(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/users/:id:int", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "User by ID: %s", GetParam(w, "id"))
+	})
+	mux.HandleFunc("/users/:name", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "User by name: %s", GetParam(w, "name"))
+	})
+	mux.HandleFunc("/files/:size:uint64", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Files of size: %s", GetParam(w, "size"))
+	})
+	mux.HandleFunc("/files/:size:uint64/:kind:alphabetical", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Files of size: %s and kind: %s", GetParam(w, "size"), GetParam(w, "kind"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42").
+		statusCode(http.StatusOK).bodyEq("User by ID: 42")
+	testHandler(t, mux, http.MethodGet, "/users/kataras").
+		statusCode(http.StatusOK).bodyEq("User by name: kataras")
+	testHandler(t, mux, http.MethodGet, "/files/1024").
+		statusCode(http.StatusOK).bodyEq("Files of size: 1024")
+	testHandler(t, mux, http.MethodGet, "/files/1024/images").
+		statusCode(http.StatusOK).bodyEq("Files of size: 1024 and kind: images")
+	testHandler(t, mux, http.MethodGet, "/files/-1").
+		statusCode(http.StatusNotFound)
+	testHandler(t, mux, http.MethodGet, "/files/1024/images2").
+		statusCode(http.StatusNotFound)
+}
+
+func TestRegisterParamType(t *testing.T) {
+	RegisterParamType("even", func(value string) bool {
+		return len(value) > 0 && (value[len(value)-1]-'0')%2 == 0
+	})
+
+	mux := NewMux()
+	mux.HandleFunc("/numbers/:n:even", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Even: %s", GetParam(w, "n"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/numbers/42").
+		statusCode(http.StatusOK).bodyEq("Even: 42")
+	testHandler(t, mux, http.MethodGet, "/numbers/43").
+		statusCode(http.StatusNotFound)
+}
+
+func TestUnknownParamType(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for an unknown parameter type")
+		}
+	}()
+
+	NewMux().HandleFunc("/users/:id:integer", func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func TestRegexParams(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/articles/:slug([a-z0-9-]+)", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Article: %s", GetParam(w, "slug"))
+	})
+	mux.HandleFunc("/articles/:code(A-(?:[0-9]{3}))/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Comments of article: %s", GetParam(w, "code"))
+	})
+	mux.HandleFunc("/articles/*rest", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Other: %s", GetParam(w, "rest"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/articles/hello-world-42").
+		statusCode(http.StatusOK).bodyEq("Article: hello-world-42")
+	testHandler(t, mux, http.MethodGet, "/articles/A-123/comments").
+		statusCode(http.StatusOK).bodyEq("Comments of article: A-123")
+	// constraints are not matched, the sibling wildcard route should handle them.
+	testHandler(t, mux, http.MethodGet, "/articles/Hello_World").
+		statusCode(http.StatusOK).bodyEq("Other: Hello_World")
+	testHandler(t, mux, http.MethodGet, "/articles/A-1234/comments").
+		statusCode(http.StatusOK).bodyEq("Other: A-1234/comments")
+}
+
+func TestInvalidParamExpr(t *testing.T) {
+	for _, pattern := range []string{"/articles/:slug([a-z", "/articles/:slug([a-z)"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("%s: expected a panic for an invalid parameter expression", pattern)
+				}
+			}()
+
+			NewMux().HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {})
+		}()
+	}
+}
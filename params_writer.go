@@ -1,7 +1,14 @@
 package muxie
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strings"
 )
 
 // GetParam returns the path parameter value based on its key, i.e
@@ -11,31 +18,82 @@ import (
 // then the `GetParam("name")` will return the value of "kataras".
 // If not associated value with that key is found then it will return an empty string.
 //
-// The function will do its job only if the given "w" http.ResponseWriter interface is an `ResponseWriter`.
+// The function will do its job only if the given "w" http.ResponseWriter interface is an `ResponseWriter`
+// or it wraps one, see `Unwrapper`.
 func GetParam(w http.ResponseWriter, key string) string {
-	if store, ok := w.(ResponseWriter); ok {
+	if store, ok := paramsStore(w); ok {
 		return store.Get(key)
 	}
 
 	return ""
 }
 
+// GetParamSegments returns the path segments of a parameter's value, without the empty ones,
+// useful for the wildcards which their value can contain slashes, i.e
+// "/files/*filepath", the `GetParamSegments(w, "filepath")` for the "/files/docs/2024/report.pdf" path
+// will return []string{"docs", "2024", "report.pdf"}.
+func GetParamSegments(w http.ResponseWriter, key string) []string {
+	value := GetParam(w, key)
+	if value == "" {
+		return nil
+	}
+
+	segments := strings.Split(value, pathSep)
+
+	n := 0
+	for _, segment := range segments {
+		if segment != "" {
+			segments[n] = segment
+			n++
+		}
+	}
+
+	return segments[:n]
+}
+
 // GetParams returns all the available parameters based on the "w" http.ResponseWriter which should be a ResponseWriter.
 //
-// The function will do its job only if the given "w" http.ResponseWriter interface is an `ResponseWriter`.
+// The function will do its job only if the given "w" http.ResponseWriter interface is an `ResponseWriter`
+// or it wraps one, see `Unwrapper`.
 func GetParams(w http.ResponseWriter) []ParamEntry {
-	if store, ok := w.(ResponseWriter); ok {
+	if store, ok := paramsStore(w); ok {
 		return store.GetAll()
 	}
 
 	return nil
 }
 
-// SetParam sets manually a parameter to the "w" http.ResponseWriter which should be a ResponseWriter.
+// ParamsMap returns a new map of all the available parameters, their keys to their values,
+// see `GetParams` and `EachParam` too.
+func ParamsMap(w http.ResponseWriter) map[string]string {
+	params := GetParams(w)
+	if len(params) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(params))
+	for _, p := range params {
+		m[p.Key] = p.Value
+	}
+
+	return m
+}
+
+// EachParam calls the "visitor" for each one of the available parameters, by their order on the path,
+// until it returns false. It does not allocate, so it can be used by middlewares to inspect the parameters.
+func EachParam(w http.ResponseWriter, visitor func(key, value string) bool) {
+	for _, p := range GetParams(w) {
+		if !visitor(p.Key, p.Value) {
+			return
+		}
+	}
+}
+
+// SetParam sets manually a parameter to the "w" http.ResponseWriter which should be a ResponseWriter, or wrap one.
 // This is not commonly used by the end-developers,
 // unless sharing values(string messages only) between handlers is absolutely necessary.
 func SetParam(w http.ResponseWriter, key, value string) bool {
-	if store, ok := w.(ResponseWriter); ok {
+	if store, ok := paramsStore(w); ok {
 		store.Set(key, value)
 		return true
 	}
@@ -43,6 +101,131 @@ func SetParam(w http.ResponseWriter, key, value string) bool {
 	return false
 }
 
+// SetParams sets manually many parameters at once to the "w" http.ResponseWriter, like `SetParam` does,
+// i.e for middlewares that resolve synthetic parameters, like a tenant from the host.
+func SetParams(w http.ResponseWriter, params []ParamEntry) bool {
+	store, ok := paramsStore(w)
+	if !ok {
+		return false
+	}
+
+	for _, p := range params {
+		store.Set(p.Key, p.Value)
+	}
+
+	return true
+}
+
+// CopyParams returns a copy of all the available parameters, see `GetParams`.
+// The `GetParams` returns the storage of the request's parameters, which is reused after the request is served,
+// so the copy should be used instead when the parameters are passed to goroutines that outlive the handler.
+func CopyParams(w http.ResponseWriter) []ParamEntry {
+	params := GetParams(w)
+	if len(params) == 0 {
+		return nil
+	}
+
+	return append(make([]ParamEntry, 0, len(params)), params...)
+}
+
+// RoutePattern returns the path pattern of the route that serves the request, i.e "/users/:id",
+// useful for logging and metrics middlewares. It returns an empty string if the "w" is not a `ResponseWriter`
+// of the `Mux` or it does not wrap one.
+func RoutePattern(w http.ResponseWriter) string {
+	if pw := findParamsWriter(w); pw != nil {
+		return pw.route
+	}
+
+	return ""
+}
+
+// RouteMeta returns the metadata of the route that serves the request by its "key", see `Route#Meta`,
+// and it reports whether it exists, i.e for the rate limit of a route:
+// mux.HandleFunc("/search", searchHandler).Meta("rate", 10)
+// [...]
+// rate, ok := muxie.RouteMeta(w, "rate")
+func RouteMeta(w http.ResponseWriter, key string) (interface{}, bool) {
+	if pw := findParamsWriter(w); pw != nil {
+		return pw.meta.get(key)
+	}
+
+	return nil, false
+}
+
+// routeRecorder is implemented by the http.ResponseWriter wrappers of the `Mux#Wrap` middlewares,
+// which run outside of the `ResponseWriter`, to be notified about the path pattern of the matched route, see `Logger`.
+type routeRecorder interface {
+	recordRoute(pattern string)
+}
+
+// recordRoute notifies the `routeRecorder`s of the "w"'s `Unwrapper` chain, if any.
+func recordRoute(w http.ResponseWriter, pattern string) {
+	for w != nil {
+		if rec, ok := w.(routeRecorder); ok {
+			rec.recordRoute(pattern)
+		}
+
+		u, ok := w.(Unwrapper)
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
+
+// Unwrapper is the interface that the http.ResponseWriter wrappers, i.e of a gzip or a logging middleware,
+// can implement to give access to the http.ResponseWriter that they wrap,
+// so the `GetParam`, `GetParams` and `SetParam` can still find the muxie's `ResponseWriter`.
+// It is the same method that the `http.ResponseController` uses.
+type Unwrapper interface {
+	Unwrap() http.ResponseWriter
+}
+
+// paramsStore returns the "w" as a `ResponseWriter` or, if it is not, the first one of its `Unwrapper` chain.
+func paramsStore(w http.ResponseWriter) (ResponseWriter, bool) {
+	for w != nil {
+		if store, ok := w.(ResponseWriter); ok {
+			return store, true
+		}
+
+		u, ok := w.(Unwrapper)
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+
+	return nil, false
+}
+
+type paramsContextKey struct{}
+
+// withParams returns a shallow copy of the request with a copy of the "params" stored to its context.
+func withParams(r *http.Request, params []ParamEntry) *http.Request {
+	params = append([]ParamEntry(nil), params...)
+	return r.WithContext(context.WithValue(r.Context(), paramsContextKey{}, params))
+}
+
+// ParamsFromContext returns the path parameters that are stored to the request's context
+// by the `Mux` which its `ContextParams` field is true.
+func ParamsFromContext(r *http.Request) []ParamEntry {
+	params, _ := r.Context().Value(paramsContextKey{}).([]ParamEntry)
+	return params
+}
+
+// GetParamFromRequest returns the value of a path parameter that is stored to the request's context,
+// see the `Mux#ContextParams` field.
+// If not associated value with that key is found then it will return an empty string.
+func GetParamFromRequest(r *http.Request, key string) string {
+	for _, p := range ParamsFromContext(r) {
+		if p.Key == key {
+			return p.Value
+		}
+	}
+
+	return ""
+}
+
 // ParamEntry holds the Key and the Value of a named path parameter.
 type ParamEntry struct {
 	Key   string
@@ -62,9 +245,23 @@ type ResponseWriter interface {
 type paramsWriter struct {
 	http.ResponseWriter
 	params []ParamEntry
+	// the path pattern of the matched route, see `RoutePattern`, and its metadata, see `RouteMeta`.
+	route string
+	meta  *nodeMeta
+	// the status code and the body bytes of the response, see `Status` and `BytesWritten`.
+	status  int
+	written int64
+	// the hooks that run before the response header is sent, see `OnCommit`.
+	commitHooks []func(header http.Header, status int)
+	hijacked    bool
+	// the mux of the matched route, for its encoders, see `Render`.
+	mux *Mux
 }
 
-var _ ResponseWriter = (*paramsWriter)(nil)
+var (
+	_ ResponseWriter = (*paramsWriter)(nil)
+	_ Unwrapper      = (*paramsWriter)(nil)
+)
 
 // Set implements the `ParamsSetter` which `Trie#Search` needs to store the parameters, if any.
 // These are decoupled because end-developers may want to use the trie to design a new Mux of their own
@@ -101,9 +298,157 @@ func (pw *paramsWriter) GetAll() []ParamEntry {
 	return pw.params
 }
 
+// Unwrap returns the underline http.ResponseWriter, so the http.ResponseController can reach the server's one,
+// i.e for its SetReadDeadline, SetWriteDeadline and EnableFullDuplex.
+func (pw *paramsWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
+
 func (pw *paramsWriter) reset(w http.ResponseWriter) {
 	pw.ResponseWriter = w
 	pw.params = pw.params[0:0]
+	pw.route = ""
+	pw.meta = nil
+	pw.status = 0
+	pw.written = 0
+	clear(pw.commitHooks)
+	pw.commitHooks = pw.commitHooks[0:0]
+	pw.hijacked = false
+	pw.mux = nil
+}
+
+// ErrResponseReleased is the error that the writes of a `ResponseWriter` return after its request is served,
+// i.e by a goroutine of the handler that outlives it. The writers are reused by the next requests,
+// so a handler should not keep its http.ResponseWriter, or its parameters, after it returns, see `CopyParams`.
+var ErrResponseReleased = errors.New("muxie: the response writer is used after its request is served")
+
+// releasedWriter is the http.ResponseWriter of a released `paramsWriter`, until it is reused,
+// the late writes fail with the `ErrResponseReleased` instead of writing to a served response.
+type releasedWriter struct{}
+
+func (releasedWriter) Header() http.Header {
+	return make(http.Header)
+}
+
+func (releasedWriter) Write([]byte) (int, error) {
+	return 0, ErrResponseReleased
+}
+
+func (releasedWriter) WriteHeader(int) {}
+
+// maxPooledParams is the capacity of the parameters storage that a released `paramsWriter` can keep,
+// unless the `Mux#ParamsCapacity` is larger, the larger ones, of a few requests with too many parameters, are not reused.
+const maxPooledParams = 64
+
+// release clears the "pw" after its request is served and it reports whether it can be reused, see `ErrResponseReleased`.
+func (pw *paramsWriter) release(paramsCapacity int) bool {
+	pw.reset(releasedWriter{})
+	return cap(pw.params) <= max(maxPooledParams, paramsCapacity)
+}
+
+// commit records the status code of the response and it runs the `OnCommit` hooks, once.
+func (pw *paramsWriter) commit(statusCode int) {
+	if pw.status != 0 {
+		return
+	}
+
+	pw.status = statusCode
+	hooks := pw.commitHooks
+	for i, hook := range hooks {
+		hooks[i] = nil
+		hook(pw.Header(), statusCode)
+	}
+	pw.commitHooks = hooks[0:0]
+}
+
+// finish runs the `OnCommit` hooks of a response that the handler has not written,
+// the net/http sends it with the 200 OK status code after the handler returns.
+func (pw *paramsWriter) finish() {
+	if !pw.hijacked {
+		pw.commit(http.StatusOK)
+	}
+}
+
+// WriteHeader sends the response header with the status code and it records it, see `Status`.
+func (pw *paramsWriter) WriteHeader(statusCode int) {
+	// the informational responses, except the 101 Switching Protocols, can be followed by the final one.
+	if statusCode >= 200 || statusCode == http.StatusSwitchingProtocols {
+		pw.commit(statusCode)
+	}
+
+	pw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write writes the "b" to the response body and it counts its bytes, see `BytesWritten`.
+func (pw *paramsWriter) Write(b []byte) (int, error) {
+	pw.commit(http.StatusOK)
+
+	n, err := pw.ResponseWriter.Write(b)
+	pw.written += int64(n)
+	return n, err
+}
+
+// findParamsWriter returns the first *paramsWriter of the "w"'s `Unwrapper` chain, if any.
+func findParamsWriter(w http.ResponseWriter) *paramsWriter {
+	for w != nil {
+		if pw, ok := w.(*paramsWriter); ok {
+			return pw
+		}
+
+		u, ok := w.(Unwrapper)
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+
+	return nil
+}
+
+// Status returns the status code of the response that is sent so far, or 0 if none is sent yet,
+// useful for the logging and metrics middlewares of the `Mux#Use` and `Route#Use`, after they call the next handler, i.e:
+// next.ServeHTTP(w, r)
+// requests.WithLabelValues(muxie.RoutePattern(w), strconv.Itoa(muxie.Status(w))).Inc()
+//
+// It returns 0 if the "w" is not a `ResponseWriter` of the `Mux` or it does not wrap one,
+// i.e on the `Mux#Wrap` middlewares, which run outside of it, see `Mux#After` for them.
+func Status(w http.ResponseWriter) int {
+	if pw := findParamsWriter(w); pw != nil {
+		return pw.status
+	}
+
+	return 0
+}
+
+// BytesWritten returns the number of the response body bytes that are written so far, see `Status`.
+func BytesWritten(w http.ResponseWriter) int64 {
+	if pw := findParamsWriter(w); pw != nil {
+		return pw.written
+	}
+
+	return 0
+}
+
+// WroteHeader reports whether the response header is sent, so the status code can not be changed anymore, see `Status`.
+func WroteHeader(w http.ResponseWriter) bool {
+	return Status(w) != 0
+}
+
+// OnCommit registers a hook that runs once, right before the response header is sent, with the header and the status code,
+// so the middlewares can still set headers that depend on the response without buffering its body, i.e:
+// start := time.Now()
+// muxie.OnCommit(w, func(header http.Header, status int) { header.Set("Server-Timing", "app;dur="+ms(time.Since(start))) })
+//
+// The hooks run in the order they are registered, even when the handler does not write anything.
+// It returns false if the "w" is not a `ResponseWriter` of the `Mux`, or it does not wrap one, or the header is already sent.
+func OnCommit(w http.ResponseWriter, hook func(header http.Header, status int)) bool {
+	pw := findParamsWriter(w)
+	if pw == nil || pw.status != 0 {
+		return false
+	}
+
+	pw.commitHooks = append(pw.commitHooks, hook)
+	return true
 }
 
 // Flusher indicates if `Flush` is supported by the client.
@@ -121,9 +466,109 @@ func (pw *paramsWriter) Flusher() (http.Flusher, bool) {
 	return flusher, canFlush
 }
 
-// Flush sends any buffered data to the client.
+// Flush sends any buffered data to the client, if the underline http.ResponseWriter
+// or one of its `Unwrapper` chain supports it, so the streaming handlers can flush their responses, i.e:
+// flusher, ok := w.(http.Flusher)
+// or through the http.NewResponseController(w).Flush() which reports the `http.ErrNotSupported` too.
 func (pw *paramsWriter) Flush() {
-	if flusher, ok := pw.Flusher(); ok {
-		flusher.Flush()
+	pw.FlushError()
+}
+
+// FlushError is the `Flush` which returns its error, it is used by the http.ResponseController.
+func (pw *paramsWriter) FlushError() error {
+	pw.commit(http.StatusOK)
+	return flushWriter(pw.ResponseWriter)
+}
+
+// flushWriter flushes the "w" or the first http.Flusher of its `Unwrapper` chain,
+// it returns the http.ErrNotSupported if none of them supports it.
+func flushWriter(w http.ResponseWriter) error {
+	for w != nil {
+		switch flusher := w.(type) {
+		case interface{ FlushError() error }:
+			return flusher.FlushError()
+		case http.Flusher:
+			flusher.Flush()
+			return nil
+		}
+
+		u, ok := w.(Unwrapper)
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
 	}
+
+	return http.ErrNotSupported
+}
+
+// errHijackNotSupported is returned by the `paramsWriter#Hijack` when none of the wrapped http.ResponseWriters supports it.
+var errHijackNotSupported = fmt.Errorf("muxie: the response writer does not support hijacking: %w", http.ErrNotSupported)
+
+// Hijack lets the handler take over the connection, i.e for the WebSocket upgrades,
+// if the underline http.ResponseWriter or one of its `Unwrapper` chain supports it,
+// otherwise it returns an error which wraps the `http.ErrNotSupported`.
+func (pw *paramsWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w := pw.ResponseWriter
+	for w != nil {
+		if hijacker, ok := w.(http.Hijacker); ok {
+			conn, buf, err := hijacker.Hijack()
+			pw.hijacked = err == nil
+			return conn, buf, err
+		}
+
+		u, ok := w.(Unwrapper)
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+
+	return nil, nil, errHijackNotSupported
+}
+
+// Push initiates an HTTP/2 server push, see `Push`.
+func (pw *paramsWriter) Push(target string, opts *http.PushOptions) error {
+	return Push(pw.ResponseWriter, target, opts)
+}
+
+// Push initiates an HTTP/2 server push of the "target", i.e "/static/app.css", through the "w"
+// or the first http.Pusher of its `Unwrapper` chain, i.e:
+// muxie.Push(w, "/static/app.css", nil)
+//
+// It returns the `http.ErrNotSupported` if none of them supports it, i.e on HTTP/1.x connections,
+// or the error of the push. Note that most of the browsers have dropped the support of the server push.
+func Push(w http.ResponseWriter, target string, opts *http.PushOptions) error {
+	for w != nil {
+		if pusher, ok := w.(http.Pusher); ok {
+			return pusher.Push(target, opts)
+		}
+
+		u, ok := w.(Unwrapper)
+		if !ok {
+			break
+		}
+		w = u.Unwrap()
+	}
+
+	return http.ErrNotSupported
+}
+
+// ReadFrom copies the "src" to the response through the io.ReaderFrom of the underline http.ResponseWriter, if it supports it,
+// so the `http.ServeContent` and the io.Copy of the files can use the sendfile system call instead of copying them in userspace.
+func (pw *paramsWriter) ReadFrom(src io.Reader) (int64, error) {
+	pw.commit(http.StatusOK)
+
+	var (
+		n   int64
+		err error
+	)
+	if rf, ok := pw.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(pw.ResponseWriter, src)
+	}
+
+	pw.written += n
+	return n, err
 }
@@ -1,9 +1,16 @@
 package muxie
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetParam(t *testing.T) {
@@ -16,3 +23,473 @@ func TestGetParam(t *testing.T) {
 
 	testHandler(t, mux, http.MethodGet, "/hello/kataras").bodyEq("Hello kataras")
 }
+
+func TestGetParamSegments(t *testing.T) {
+	mux := NewMux()
+
+	mux.HandleFunc("/files/*filepath", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %q", GetParam(w, "filepath"), GetParamSegments(w, "filepath"))
+	})
+	mux.HandleFunc("/raw/*", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %q", GetParam(w, WildcardParamKey), GetParamSegments(w, WildcardParamKey))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/files/docs/2024/report.pdf").
+		bodyEq(`docs/2024/report.pdf ["docs" "2024" "report.pdf"]`)
+	testHandler(t, mux, http.MethodGet, "/files/docs//report.pdf/").
+		bodyEq(`docs//report.pdf/ ["docs" "report.pdf"]`)
+	testHandler(t, mux, http.MethodGet, "/raw/a/b").
+		bodyEq(`a/b ["a" "b"]`)
+}
+
+func TestContextParams(t *testing.T) {
+	// a middleware which hides the muxie's ResponseWriter.
+	wrapWriter := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(struct{ http.ResponseWriter }{w}, r)
+		})
+	}
+
+	mux := NewMux()
+	mux.ContextParams = true
+	mux.Use(wrapWriter)
+	mux.HandleFunc("/users/:id/posts/:post", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %v [%s]", GetParamFromRequest(r, "id"), GetParamFromRequest(r, "post"), ParamsFromContext(r), GetParam(w, "id"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42/posts/7").
+		bodyEq("42 7 [{id 42} {post 7}] []")
+
+	mux.ContextParams = false
+	testHandler(t, mux, http.MethodGet, "/users/42/posts/7").
+		bodyEq("  [] []")
+}
+
+type unwrapWriter struct {
+	http.ResponseWriter
+}
+
+func (w *unwrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestGetParamUnwrap(t *testing.T) {
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&unwrapWriter{&unwrapWriter{w}}, r)
+		})
+	})
+	mux.HandleFunc("/hello/:name", func(w http.ResponseWriter, r *http.Request) {
+		SetParam(w, "greeting", "Hello")
+		fmt.Fprintf(w, "%s %s %d", GetParam(w, "greeting"), GetParam(w, "name"), len(GetParams(w)))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/hello/kataras").bodyEq("Hello kataras 2")
+}
+
+func TestParamsMapAndEachParam(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/users/:id/posts/:slug/*rest", func(w http.ResponseWriter, r *http.Request) {
+		m := ParamsMap(w)
+		fmt.Fprintf(w, "%d %s %s %s|", len(m), m["id"], m["slug"], m["rest"])
+
+		EachParam(w, func(key, value string) bool {
+			fmt.Fprintf(w, "%s=%s;", key, value)
+			return key != "slug"
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%t", ParamsMap(w) == nil)
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42/posts/hello/a/b").
+		statusCode(http.StatusOK).bodyEq("3 42 hello a/b|id=42;slug=hello;")
+	testHandler(t, mux, http.MethodGet, "/").
+		statusCode(http.StatusOK).bodyEq("true")
+
+	w := &paramsWriter{params: []ParamEntry{{Key: "id", Value: "42"}}}
+	if allocs := testing.AllocsPerRun(100, func() {
+		EachParam(w, func(key, value string) bool { return true })
+	}); allocs != 0 {
+		t.Fatalf("expected EachParam to not allocate but it allocates %v times", allocs)
+	}
+}
+
+func TestSetParamsAndCopyParams(t *testing.T) {
+	var snapshots [][]ParamEntry
+
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			SetParams(w, []ParamEntry{{Key: "tenant", Value: "acme"}, {Key: "region", Value: "eu"}})
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		snapshots = append(snapshots, CopyParams(w))
+		fmt.Fprintf(w, "%s %s %s", GetParam(w, "id"), GetParam(w, "tenant"), GetParam(w, "region"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42").bodyEq("42 acme eu")
+	testHandler(t, mux, http.MethodGet, "/users/43").bodyEq("43 acme eu")
+
+	// the parameters storage is reused, the snapshots should be kept as they were.
+	if expected, got := "[[{id 42} {tenant acme} {region eu}] [{id 43} {tenant acme} {region eu}]]", fmt.Sprint(snapshots); got != expected {
+		t.Fatalf("expected snapshots: %s but got: %s", expected, got)
+	}
+
+	w := httptest.NewRecorder()
+	if SetParams(w, []ParamEntry{{Key: "k", Value: "v"}}) {
+		t.Fatal("expected SetParams to fail for a http.ResponseWriter without parameters")
+	}
+
+	if CopyParams(w) != nil {
+		t.Fatal("expected CopyParams to return nil for a http.ResponseWriter without parameters")
+	}
+}
+
+func TestParamsWriterFlush(t *testing.T) {
+	mux := NewMux()
+	mux.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// a wrapper of the Wrap middlewares that does not implement the http.Flusher itself.
+			next.ServeHTTP(&unwrapWriter{w}, r)
+		})
+	})
+	mux.HandleMethodFunc(http.MethodGet, "/stream", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatalf("expected an http.Flusher")
+		}
+
+		w.Write([]byte("chunk"))
+		flusher.Flush()
+
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Fatalf("expected the response controller to flush but got: %v", err)
+		}
+	})
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, "/stream", nil))
+		if !rec.Flushed {
+			t.Fatalf("%s: expected the response to be flushed", method)
+		}
+	}
+
+	// not supported.
+	pw := new(paramsWriter)
+	pw.reset(struct{ http.ResponseWriter }{httptest.NewRecorder()})
+	if err := http.NewResponseController(pw).Flush(); err != http.ErrNotSupported {
+		t.Fatalf("expected the http.ErrNotSupported but got: %v", err)
+	}
+}
+
+func TestParamsWriterHijack(t *testing.T) {
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&unwrapWriter{w}, r)
+		})
+	})
+	mux.HandleFunc("/upgrade/:protocol", func(w http.ResponseWriter, r *http.Request) {
+		protocol := GetParam(w, "protocol")
+
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("expected the connection to be hijacked but got: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: " + protocol + "\r\nConnection: Upgrade\r\n\r\n")
+		buf.Flush()
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "GET /upgrade/echo HTTP/1.1\r\nHost: %s\r\nUpgrade: echo\r\nConnection: Upgrade\r\n\r\n", srv.Listener.Addr())
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Upgrade") != "echo" {
+		t.Fatalf("expected the upgrade response but got: %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// not supported, i.e by the httptest.ResponseRecorder.
+	pw := new(paramsWriter)
+	pw.reset(httptest.NewRecorder())
+	if _, _, err := pw.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+		t.Fatalf("expected the http.ErrNotSupported but got: %v", err)
+	}
+}
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (w *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	w.pushed = append(w.pushed, opts.Method+" "+target)
+	return nil
+}
+
+func TestPush(t *testing.T) {
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&unwrapWriter{w}, r)
+		})
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if err := Push(w, "/static/app.css", &http.PushOptions{Method: http.MethodGet}); err != nil {
+			t.Fatalf("expected the push to succeed but got: %v", err)
+		}
+
+		pusher, ok := w.(*unwrapWriter).ResponseWriter.(http.Pusher)
+		if !ok {
+			t.Fatalf("expected the params writer to be an http.Pusher")
+		}
+		pusher.Push("/static/app.js", &http.PushOptions{Method: http.MethodHead})
+	})
+
+	rec := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if expected, got := "GET /static/app.css, HEAD /static/app.js", strings.Join(rec.pushed, ", "); expected != got {
+		t.Fatalf("expected pushes: %s but got: %s", expected, got)
+	}
+
+	if err := Push(httptest.NewRecorder(), "/static/app.css", nil); err != http.ErrNotSupported {
+		t.Fatalf("expected the http.ErrNotSupported but got: %v", err)
+	}
+}
+
+type readFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom int64
+}
+
+func (w *readFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	n, err := io.Copy(w.ResponseRecorder, src)
+	w.readFrom += n
+	return n, err
+}
+
+func TestParamsWriterReadFrom(t *testing.T) {
+	content := strings.Repeat("muxie", 1000)
+
+	mux := NewMux()
+	mux.HandleFunc("/files/:name", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, GetParam(w, "name"), time.Time{}, strings.NewReader(content))
+	})
+
+	rec := &readFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/report.txt", nil))
+	if rec.Body.String() != content || rec.readFrom != int64(len(content)) {
+		t.Fatalf("expected the content to be copied through the io.ReaderFrom but got %d bytes of %d", rec.readFrom, rec.Body.Len())
+	}
+
+	// without an io.ReaderFrom.
+	plain := httptest.NewRecorder()
+	mux.ServeHTTP(struct{ http.ResponseWriter }{plain}, httptest.NewRequest(http.MethodGet, "/files/report.txt", nil))
+	if plain.Body.String() != content {
+		t.Fatalf("expected the content to be copied but got %d bytes", plain.Body.Len())
+	}
+}
+
+func TestParamsWriterResponseController(t *testing.T) {
+	mux := NewMux()
+	mux.Use(Logger(LoggerOutput(io.Discard)), ETag())
+	mux.HandleMethodFunc(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		if err := rc.SetReadDeadline(time.Now().Add(time.Minute)); err != nil {
+			t.Errorf("SetReadDeadline: %v", err)
+		}
+		if err := rc.SetWriteDeadline(time.Now().Add(time.Minute)); err != nil {
+			t.Errorf("SetWriteDeadline: %v", err)
+		}
+		if err := rc.EnableFullDuplex(); err != nil {
+			t.Errorf("EnableFullDuplex: %v", err)
+		}
+
+		w.Write([]byte(GetParam(w, "id")))
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL+"/users/42").statusCode(http.StatusOK).bodyEq("42")
+	expect(t, http.MethodHead, srv.URL+"/users/42").statusCode(http.StatusOK)
+}
+
+func TestStatusAndBytesWritten(t *testing.T) {
+	var got []string
+
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if WroteHeader(w) || Status(w) != 0 || BytesWritten(w) != 0 {
+				t.Fatalf("expected no response before the handler")
+			}
+
+			next.ServeHTTP(&unwrapWriter{w}, r)
+			got = append(got, fmt.Sprintf("%s %d %d %v", r.Method, Status(w), BytesWritten(w), WroteHeader(w)))
+		})
+	})
+	mux.HandleMethodFunc(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s", GetParam(w, "id"))
+	})
+	mux.HandleFunc("/created", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(http.StatusOK) // superfluous.
+	})
+	mux.HandleFunc("/files/:name", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, strings.NewReader("content"))
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+
+	testHandler(t, mux, http.MethodGet, "/users/42").bodyEq("user 42")
+	testHandler(t, mux, http.MethodHead, "/users/42").bodyEq("")
+	testHandler(t, mux, http.MethodPost, "/created").statusCode(http.StatusCreated)
+	testHandler(t, mux, http.MethodGet, "/files/report.txt").bodyEq("content")
+	testHandler(t, mux, http.MethodGet, "/empty")
+
+	expected := []string{
+		"GET 200 7 true",
+		"HEAD 200 0 true",
+		"POST 201 0 true",
+		"GET 200 7 true",
+		"GET 0 0 false",
+	}
+	if strings.Join(got, ", ") != strings.Join(expected, ", ") {
+		t.Fatalf("expected: %q but got: %q", expected, got)
+	}
+
+	if Status(httptest.NewRecorder()) != 0 || BytesWritten(httptest.NewRecorder()) != 0 {
+		t.Fatalf("expected zero values outside of the mux")
+	}
+
+	// the informational responses are not final.
+	pw := new(paramsWriter)
+	pw.reset(httptest.NewRecorder())
+	pw.WriteHeader(http.StatusEarlyHints)
+	if Status(pw) != 0 {
+		t.Fatalf("expected no status after an informational response but got: %d", Status(pw))
+	}
+	pw.WriteHeader(http.StatusAccepted)
+	if Status(pw) != http.StatusAccepted {
+		t.Fatalf("expected status code: %d but got: %d", http.StatusAccepted, Status(pw))
+	}
+}
+
+func TestOnCommit(t *testing.T) {
+	var calls []string
+
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			OnCommit(w, func(header http.Header, status int) {
+				calls = append(calls, fmt.Sprintf("%s %s %d", r.Method, r.URL.Path, status))
+				header.Set("X-Status", fmt.Sprint(status))
+			})
+			OnCommit(w, func(header http.Header, status int) {
+				calls = append(calls, "second")
+			})
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.HandleMethodFunc(http.MethodGet, "/write", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a"))
+		w.Write([]byte("b"))
+		if OnCommit(w, func(http.Header, int) { t.Fatalf("the late hook should not run") }) {
+			t.Fatalf("expected a late registration to fail")
+		}
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/buffered", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		ResetResponse(w)
+		w.WriteHeader(http.StatusConflict)
+	}).Use(Buffer())
+
+	testHandler(t, mux, http.MethodGet, "/write").bodyEq("ab").headerEq("X-Status", "200")
+	testHandler(t, mux, http.MethodHead, "/write").bodyEq("").headerEq("X-Status", "200")
+	testHandler(t, mux, http.MethodGet, "/status").statusCode(http.StatusTeapot).headerEq("X-Status", "418")
+	testHandler(t, mux, http.MethodGet, "/empty").statusCode(http.StatusOK).headerEq("X-Status", "200")
+	testHandler(t, mux, http.MethodGet, "/buffered").statusCode(http.StatusConflict).headerEq("X-Status", "409")
+
+	expected := "GET /write 200, second, HEAD /write 200, second, GET /status 418, second, " +
+		"GET /empty 200, second, GET /buffered 409, second"
+	if got := strings.Join(calls, ", "); expected != got {
+		t.Fatalf("expected calls: %s but got: %s", expected, got)
+	}
+
+	if OnCommit(httptest.NewRecorder(), func(http.Header, int) {}) {
+		t.Fatalf("expected the registration to fail outside of the mux")
+	}
+}
+
+func TestParamsWriterRelease(t *testing.T) {
+	var retained http.ResponseWriter
+
+	mux := NewMux()
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		retained = w
+		w.Write([]byte(GetParam(w, "id")))
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	if GetParam(retained, "id") != "" || RoutePattern(retained) != "" || Status(retained) != 0 {
+		t.Fatalf("expected the released writer to be cleared")
+	}
+
+	// used after the request is served, i.e by a goroutine of the handler.
+	if _, err := retained.Write([]byte("late")); err != ErrResponseReleased {
+		t.Fatalf("expected the ErrResponseReleased but got: %v", err)
+	}
+	retained.Header().Set("X-Late", "1")
+	retained.WriteHeader(http.StatusTeapot)
+
+	if rec.Body.String() != "42" || rec.Code != http.StatusOK || rec.Header().Get("X-Late") != "" {
+		t.Fatalf("expected the served response to not be modified but got: %d %s %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+
+	// too large to be reused.
+	pw := &paramsWriter{params: make([]ParamEntry, 0, maxPooledParams+1)}
+	if pw.release(0) || !pw.release(maxPooledParams+1) {
+		t.Fatalf("expected the storage to be reused only up to the params capacity")
+	}
+}
+
+func TestServeHTTPAllocs(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		if GetParam(w, "id") != "42" {
+			t.Fatalf("unexpected parameter")
+		}
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	mux.ServeHTTP(w, r) // warm up the pool.
+
+	if allocs := testing.AllocsPerRun(100, func() { mux.ServeHTTP(w, r) }); allocs > 0 {
+		t.Fatalf("expected no allocations per request but got: %v", allocs)
+	}
+}
@@ -0,0 +1,157 @@
+package muxie
+
+import "strings"
+
+// PatternSyntax is the type of the `Mux#PatternSyntax` field,
+// it declares how the named parameters of the path patterns are written.
+type PatternSyntax uint8
+
+const (
+	// ColonSyntax is the muxie's path pattern syntax, i.e "/users/:id", "/users/:id:int" and "/users/:id([0-9]+)".
+	ColonSyntax PatternSyntax = iota
+	// BraceSyntax is the path pattern syntax of the gorilla/mux and the go-chi/chi routers, i.e "/users/{id}"
+	// and "/users/{id:[0-9]+}", so their routes can be registered without rewriting them.
+	// The "{name}" is translated to the ":name" and the "{name:regex}" to the ":name(regex)",
+	// a named parameter should be a whole path segment. The wildcards are written as before, i.e "/files/*".
+	BraceSyntax
+	// ServeMuxSyntax is the path pattern syntax of the net/http.ServeMux since Go 1.22,
+	// i.e "GET /users/{id}", "/files/{path...}", "/static/" and "/{$}", so its routes can be registered without rewriting them.
+	// The method, if any, is registered like the `Mux#HandleMethod` does, the "{name}" is translated to the ":name",
+	// the "{name...}" to the "*name" and a trailing slash to the "*" wildcard, which matches the whole subtree.
+	// The "{$}" is removed, the "/{$}" matches only the root path and the trailing slashes of the rest paths
+	// are handled by the `Mux#TrailingSlash` policy. The host patterns are not supported, see `Mux#Host` instead.
+	ServeMuxSyntax
+)
+
+// pattern returns the path pattern that the "pattern" is registered as, under this Mux' root,
+// translated from the `PatternSyntax` of the Mux.
+func (m *Mux) pattern(pattern string) string {
+	return m.root + m.origin().PatternSyntax.translate(pattern)
+}
+
+// splitMethod returns the method and the path of a `ServeMuxSyntax` path pattern, i.e "GET" and "/users/{id}".
+// The method is empty for the rest syntaxes and for the path patterns without a method.
+func (syntax PatternSyntax) splitMethod(pattern string) (string, string) {
+	if syntax != ServeMuxSyntax {
+		return "", pattern
+	}
+
+	i := strings.IndexAny(pattern, " \t")
+	if i == -1 {
+		return "", pattern
+	}
+
+	return pattern[:i], strings.TrimLeft(pattern[i:], " \t")
+}
+
+func (syntax PatternSyntax) translate(pattern string) string {
+	switch syntax {
+	case BraceSyntax:
+		return translateBraces(pattern, false)
+	case ServeMuxSyntax:
+		_, pattern = syntax.splitMethod(pattern)
+		if pattern != "" && pattern[0] != pathSepB {
+			panic("muxie/Mux#Handle: host patterns are not supported, use the Mux#Host instead of \"" + pattern + "\"")
+		}
+
+		exact := strings.HasSuffix(pattern, "/{$}")
+		if exact {
+			pattern = pattern[:len(pattern)-len("{$}")]
+		}
+
+		pattern = translateBraces(pattern, true)
+		if pattern != "" && pattern[len(pattern)-1] == pathSepB {
+			if !exact {
+				// the "/" is a catch-all, like the rest subtrees.
+				pattern += WildcardParamStart
+			} else if len(pattern) > 1 {
+				pattern = pattern[:len(pattern)-1]
+			}
+		}
+
+		return pattern
+	default:
+		return pattern
+	}
+}
+
+// translateBraces translates the "{name}" and "{name:regex}" named parameters of a path pattern to the muxie's syntax,
+// the "{name...}" is translated to the "*name" wildcard when "wildcards" is true.
+func translateBraces(pattern string, wildcards bool) string {
+	if strings.IndexAny(pattern, "{}") == -1 {
+		return pattern
+	}
+
+	var b strings.Builder
+	b.Grow(len(pattern))
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c == '}' {
+			panic("muxie/Mux#Handle: unexpected '}' in \"" + pattern + "\"")
+		}
+
+		if c != '{' {
+			b.WriteByte(c)
+			continue
+		}
+
+		if i > 0 && pattern[i-1] != pathSepB {
+			panic("muxie/Mux#Handle: a named parameter should be a whole path segment in \"" + pattern + "\"")
+		}
+
+		// find the closing brace, the regular expressions can contain braces too, i.e "{code:[0-9]{3}}".
+		end, depth := -1, 0
+		for j := i + 1; j < len(pattern) && end == -1; j++ {
+			switch pattern[j] {
+			case '{':
+				depth++
+			case '}':
+				if depth == 0 {
+					end = j
+				}
+				depth--
+			}
+		}
+
+		if end == -1 {
+			panic("muxie/Mux#Handle: missing '}' in \"" + pattern + "\"")
+		}
+
+		if end+1 < len(pattern) && pattern[end+1] != pathSepB {
+			panic("muxie/Mux#Handle: a named parameter should be a whole path segment in \"" + pattern + "\"")
+		}
+
+		name, expr := pattern[i+1:end], ""
+		if idx := strings.IndexByte(name, ':'); idx != -1 {
+			name, expr = name[:idx], name[idx+1:]
+		}
+
+		if wildcards && strings.HasSuffix(name, "...") {
+			if name = name[:len(name)-len("...")]; name == "" || end+1 != len(pattern) {
+				panic("muxie/Mux#Handle: a \"{name...}\" wildcard should be the last path segment of \"" + pattern + "\"")
+			}
+
+			b.WriteString(WildcardParamStart)
+			b.WriteString(name)
+			i = end
+			continue
+		}
+
+		if name == "" {
+			panic("muxie/Mux#Handle: empty parameter name in \"" + pattern + "\"")
+		}
+
+		b.WriteString(ParamStart)
+		b.WriteString(name)
+		if expr != "" {
+			b.WriteByte(ParamExprStart)
+			b.WriteString(expr)
+			b.WriteByte(ParamExprEnd)
+		}
+
+		i = end
+	}
+
+	return b.String()
+}
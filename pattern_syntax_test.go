@@ -0,0 +1,137 @@
+package muxie
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestMuxBraceSyntax(t *testing.T) {
+	mux := NewMux()
+	mux.PatternSyntax = BraceSyntax
+
+	mux.HandleFunc("/users/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s", GetParam(w, "id"))
+	})
+	mux.HandleFunc("/users/{name}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user named %s", GetParam(w, "name"))
+	})
+	mux.HandleFunc("/codes/{code:[0-9]{3}}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "code %s", GetParam(w, "code"))
+	})
+	mux.HandleFunc("/files/*", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "file %s", GetParam(w, WildcardParamKey))
+	})
+	mux.Of("/orgs/{org}").HandleFunc("/repos/{repo}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "repo %s/%s", GetParam(w, "org"), GetParam(w, "repo"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42").bodyEq("user 42")
+	testHandler(t, mux, http.MethodGet, "/users/kataras").bodyEq("user named kataras")
+	testHandler(t, mux, http.MethodGet, "/codes/404").bodyEq("code 404")
+	testHandler(t, mux, http.MethodGet, "/codes/4040").statusCode(http.StatusNotFound)
+	testHandler(t, mux, http.MethodGet, "/files/a/b.txt").bodyEq("file a/b.txt")
+	testHandler(t, mux, http.MethodGet, "/orgs/kataras/repos/muxie").bodyEq("repo kataras/muxie")
+
+	if !mux.Unhandle("/users/{name}") {
+		t.Fatal("expected the \"/users/{name}\" to be removed")
+	}
+	testHandler(t, mux, http.MethodGet, "/users/kataras").statusCode(http.StatusNotFound)
+
+	for _, pattern := range []string{"/users/{id", "/users/id}", "/users/{}", "/users/{id}.json", "/users/v{id}"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected a panic for the invalid \"%s\" path pattern", pattern)
+				}
+			}()
+
+			mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {})
+		}()
+	}
+}
+
+func TestPatternSyntaxTranslate(t *testing.T) {
+	var tests = []struct {
+		pattern  string
+		expected string
+	}{
+		{"/users/{id}", "/users/:id"},
+		{"/users/{id:[0-9]+}/posts/{slug}", "/users/:id([0-9]+)/posts/:slug"},
+		{"/{lang:(?:en|el)}/about", "/:lang((?:en|el))/about"},
+		{"/users/:id", "/users/:id"},
+	}
+
+	for _, tt := range tests {
+		if got := BraceSyntax.translate(tt.pattern); got != tt.expected {
+			t.Fatalf("expected \"%s\" to be translated to \"%s\" but got \"%s\"", tt.pattern, tt.expected, got)
+		}
+	}
+
+	if got := ColonSyntax.translate("/users/{id}"); got != "/users/{id}" {
+		t.Fatalf("expected the ColonSyntax to not translate but got \"%s\"", got)
+	}
+}
+
+func TestMuxServeMuxSyntax(t *testing.T) {
+	mux := NewMux()
+	mux.PatternSyntax = ServeMuxSyntax
+	mux.MethodNotAllowed = true
+
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "get user %s", GetParam(w, "id"))
+	})
+	mux.HandleFunc("DELETE  /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "delete user %s", GetParam(w, "id"))
+	})
+	mux.HandleFunc("/files/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "file %s", GetParam(w, "path"))
+	})
+	mux.HandleFunc("/static/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "static %s", GetParam(w, WildcardParamKey))
+	})
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "index")
+	})
+	mux.HandleFunc("/about/{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "about")
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42").bodyEq("get user 42")
+	testHandler(t, mux, http.MethodHead, "/users/42").statusCode(http.StatusOK).bodyEq("")
+	testHandler(t, mux, http.MethodDelete, "/users/42").bodyEq("delete user 42")
+	testHandler(t, mux, http.MethodPost, "/users/42").
+		statusCode(http.StatusMethodNotAllowed).headerEq("Allow", "GET, DELETE, HEAD")
+	testHandler(t, mux, http.MethodGet, "/files/a/b.txt").bodyEq("file a/b.txt")
+	testHandler(t, mux, http.MethodGet, "/static/css/app.css").bodyEq("static css/app.css")
+	testHandler(t, mux, http.MethodGet, "/static/").bodyEq("static ")
+	testHandler(t, mux, http.MethodGet, "/").bodyEq("index")
+	testHandler(t, mux, http.MethodGet, "/x").statusCode(http.StatusNotFound)
+	testHandler(t, mux, http.MethodGet, "/about").bodyEq("about")
+
+	// the "/" matches all the paths, the "/{$}" only the root one.
+	catchAll := NewMux()
+	catchAll.PatternSyntax = ServeMuxSyntax
+	catchAll.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "catch-all %s", GetParam(w, WildcardParamKey))
+	})
+	testHandler(t, catchAll, http.MethodGet, "/").statusCode(http.StatusOK).bodyEq("catch-all ")
+	testHandler(t, catchAll, http.MethodGet, "/a/b").statusCode(http.StatusOK).bodyEq("catch-all a/b")
+	catchAll.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "index")
+	})
+	testHandler(t, catchAll, http.MethodGet, "/").bodyEq("index")
+	testHandler(t, catchAll, http.MethodGet, "/a/b").bodyEq("catch-all a/b")
+
+	for _, pattern := range []string{"GET example.com/users", "/files/{path...}/meta", "/files/{...}"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected a panic for the invalid \"%s\" path pattern", pattern)
+				}
+			}()
+
+			mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {})
+		}()
+	}
+}
@@ -0,0 +1,88 @@
+package muxie
+
+// SegmentKind is the kind of a path pattern's segment, see `Precedence`.
+type SegmentKind uint8
+
+const (
+	// SegmentStatic is a static path segment, i.e "users".
+	SegmentStatic SegmentKind = iota
+	// SegmentParam is a named parameter, i.e ":id" and ":id uint64".
+	SegmentParam
+	// SegmentWildcard is a wildcard, i.e "*path".
+	SegmentWildcard
+)
+
+func (k SegmentKind) String() string {
+	switch k {
+	case SegmentStatic:
+		return "static"
+	case SegmentParam:
+		return "param"
+	case SegmentWildcard:
+		return "wildcard"
+	default:
+		return "unknown"
+	}
+}
+
+// Precedence is the rules that select the path pattern of a path which many path patterns can match,
+// see `TrieOf#SetPrecedence`.
+type Precedence struct {
+	// Order is the search order of the sibling path segments, the first kind is checked first
+	// and the next one is checked only if the first one cannot lead to an end node.
+	Order []SegmentKind
+	// FirstRegistered, if true, selects the path pattern which was inserted first among the ones that match the path,
+	// whatever kind their path segments are, instead of the first one that the Order finds.
+	// All the path patterns of a path are checked then, the Order selects only on the `WithPriority` ties.
+	FirstRegistered bool
+}
+
+// DefaultPrecedence is the precedence of a new trie:
+// static path segments, named parameters, the typed ones before the untyped one, and then wildcards.
+var DefaultPrecedence = Precedence{
+	Order: []SegmentKind{SegmentStatic, SegmentParam, SegmentWildcard},
+}
+
+// defaultOrder is the search order of a trie without a custom one, the `DefaultPrecedence` can be modified.
+var defaultOrder = []SegmentKind{SegmentStatic, SegmentParam, SegmentWildcard}
+
+// SetPrecedence sets the rules that select the path pattern of a path which many path patterns can match,
+// i.e to keep the behavior of another router that a project is migrated from:
+// mux.Routes.SetPrecedence(muxie.Precedence{
+// Order: []muxie.SegmentKind{muxie.SegmentStatic, muxie.SegmentWildcard, muxie.SegmentParam},
+// })
+// mux.Routes.SetPrecedence(muxie.Precedence{Order: muxie.DefaultPrecedence.Order, FirstRegistered: true})
+//
+// The `WithPriority` priorities win over the precedence.
+// It should be set before any search, like the `CaseInsensitive` field.
+// It panics if the "p.Order" does not contain each `SegmentKind` once.
+func (t *TrieOf[T]) SetPrecedence(p Precedence) {
+	if len(p.Order) != len(defaultOrder) {
+		panic("muxie/Trie#SetPrecedence: order should contain the static, param and wildcard kinds once")
+	}
+
+	var seen [3]bool
+	for _, kind := range p.Order {
+		if int(kind) >= len(seen) || seen[kind] {
+			panic("muxie/Trie#SetPrecedence: order should contain the static, param and wildcard kinds once")
+		}
+		seen[kind] = true
+	}
+
+	t.order = append([]SegmentKind(nil), p.Order...)
+	t.firstRegistered = p.FirstRegistered
+}
+
+// Precedence returns the current rules that select the path pattern of a path
+// which many path patterns can match, see `SetPrecedence`.
+func (t *TrieOf[T]) Precedence() Precedence {
+	order := t.order
+	if order == nil {
+		order = defaultOrder
+	}
+
+	return Precedence{
+		Order:           append([]SegmentKind(nil), order...),
+		FirstRegistered: t.firstRegistered,
+	}
+}
@@ -0,0 +1,94 @@
+package muxie
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTriePrecedence(t *testing.T) {
+	tree := NewTrie()
+	if expected, got := DefaultPrecedence, tree.Precedence(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected precedence to be: %v but got: %v", expected, got)
+	}
+
+	tree.Insert("/files/:name", WithTag("param"))
+	tree.Insert("/files/*path", WithTag("wildcard"))
+	tree.Insert("/files/readme", WithTag("static"))
+	tree.Insert("/files/:name/meta", WithTag("param meta"))
+
+	expectSearch(t, tree, "/files/readme", "static", nil)
+	expectSearch(t, tree, "/files/a", "param", []ParamEntry{{"name", "a"}})
+	expectSearch(t, tree, "/files/a/b", "wildcard", []ParamEntry{{"path", "a/b"}})
+
+	// wildcard over param.
+	order := []SegmentKind{SegmentStatic, SegmentWildcard, SegmentParam}
+	tree.SetPrecedence(Precedence{Order: order})
+	order[0] = SegmentParam // it is copied.
+
+	if expected, got := (Precedence{Order: []SegmentKind{SegmentStatic, SegmentWildcard, SegmentParam}}), tree.Precedence(); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected precedence to be: %v but got: %v", expected, got)
+	}
+
+	expectSearch(t, tree, "/files/readme", "static", nil)
+	expectSearch(t, tree, "/files/a", "wildcard", []ParamEntry{{"path", "a"}})
+	expectSearch(t, tree, "/files/a/meta", "wildcard", []ParamEntry{{"path", "a/meta"}})
+
+	// param over static.
+	tree.SetPrecedence(Precedence{Order: []SegmentKind{SegmentParam, SegmentStatic, SegmentWildcard}})
+	expectSearch(t, tree, "/files/readme", "param", []ParamEntry{{"name", "readme"}})
+	expectSearch(t, tree, "/files/a/b", "wildcard", []ParamEntry{{"path", "a/b"}})
+
+	tree.SetPrecedence(DefaultPrecedence)
+	expectSearch(t, tree, "/files/readme", "static", nil)
+	expectSearch(t, tree, "/files/a", "param", []ParamEntry{{"name", "a"}})
+}
+
+func TestTriePrecedenceFirstRegistered(t *testing.T) {
+	tree := NewTrie()
+	tree.SetPrecedence(Precedence{Order: DefaultPrecedence.Order, FirstRegistered: true})
+	if !tree.Precedence().FirstRegistered {
+		t.Fatalf("expected first registered precedence")
+	}
+
+	tree.Insert("/users/:id", WithTag("param"))
+	tree.Insert("/users/new", WithTag("static"))
+	tree.Insert("/assets/*file", WithTag("wildcard"))
+	tree.Insert("/assets/:name", WithTag("param"))
+	tree.Insert("/assets/logo.png", WithTag("static"))
+
+	expectSearch(t, tree, "/users/new", "param", []ParamEntry{{"id", "new"}})
+	expectSearch(t, tree, "/users/42", "param", []ParamEntry{{"id", "42"}})
+	expectSearch(t, tree, "/assets/logo.png", "wildcard", []ParamEntry{{"file", "logo.png"}})
+
+	// the priorities win.
+	tree.Insert("/users/new", WithTag("static"), WithPriority(1))
+	expectSearch(t, tree, "/users/new", "static", nil)
+
+	// a re-inserted path pattern is registered again.
+	tree.Delete("/assets/*file")
+	tree.Insert("/assets/*file", WithTag("wildcard"))
+	expectSearch(t, tree, "/assets/logo.png", "param", []ParamEntry{{"name", "logo.png"}})
+	expectSearch(t, tree, "/assets/css/main.css", "wildcard", []ParamEntry{{"file", "css/main.css"}})
+
+	tree.SetPrecedence(DefaultPrecedence)
+	expectSearch(t, tree, "/assets/logo.png", "static", nil)
+}
+
+func TestTrieSetPrecedenceInvalidOrder(t *testing.T) {
+	for _, order := range [][]SegmentKind{
+		nil,
+		{SegmentStatic, SegmentParam},
+		{SegmentStatic, SegmentParam, SegmentParam},
+		{SegmentStatic, SegmentParam, SegmentKind(3)},
+	} {
+		func() {
+			defer func() {
+				if expected, got := "muxie/Trie#SetPrecedence: order should contain the static, param and wildcard kinds once", recover(); expected != got {
+					t.Fatalf("%v: expected panic: %v but got: %v", order, expected, got)
+				}
+			}()
+
+			NewTrie().SetPrecedence(Precedence{Order: order})
+		}()
+	}
+}
@@ -0,0 +1,172 @@
+package muxie
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// ProblemContentType is the content type of the `Problem` responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details error response, i.e:
+// return muxie.NewProblem(http.StatusForbidden, "insufficient credit").With("balance", 30)
+//
+// It can be returned by the `HandlerE`s, it is sent as it is by the `ProblemErrorMapper`, or written by the `WriteProblem`.
+type Problem struct {
+	// Type is a URI which identifies the problem type, empty means "about:blank".
+	Type string
+	// Title is a short summary of the problem type, it defaults to the status text.
+	Title string
+	// Status is the status code of the response. Defaults to 500 Internal Server Error.
+	Status int
+	// Detail is an explanation of this occurrence of the problem.
+	Detail string
+	// Instance is a URI which identifies this occurrence of the problem.
+	Instance string
+	// Extensions are the additional members of the problem, see `With`.
+	// They can not override the standard members.
+	Extensions map[string]interface{}
+	// Err is the underline error, if any, it is not sent to the client.
+	Err error
+}
+
+// NewProblem returns a new `Problem` of the given status code and detail.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{Status: status, Detail: detail}
+}
+
+// With sets an extension member of the problem, i.e "balance" or "errors".
+// Returns this Problem for further calls.
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+
+	p.Extensions[key] = value
+	return p
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+
+	if p.Err != nil {
+		return p.Err.Error()
+	}
+
+	return p.title()
+}
+
+// Unwrap returns the underline error.
+func (p *Problem) Unwrap() error {
+	return p.Err
+}
+
+// StatusCode returns the status code of the problem's response.
+func (p *Problem) StatusCode() int {
+	if p.Status == 0 {
+		return http.StatusInternalServerError
+	}
+
+	return p.Status
+}
+
+func (p *Problem) title() string {
+	if p.Title != "" {
+		return p.Title
+	}
+
+	return http.StatusText(p.StatusCode())
+}
+
+// MarshalJSON returns the JSON body of the problem, with its extension members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	if p.Type != "" {
+		members["type"] = p.Type
+	} else {
+		delete(members, "type")
+	}
+
+	members["title"] = p.title()
+	members["status"] = p.StatusCode()
+
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	} else {
+		delete(members, "detail")
+	}
+
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	} else {
+		delete(members, "instance")
+	}
+
+	return json.Marshal(members)
+}
+
+// WriteProblem sends the "problem" as an "application/problem+json" response.
+func WriteProblem(w http.ResponseWriter, r *http.Request, problem *Problem) {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		// i.e a not JSON extension member.
+		problem = &Problem{Status: http.StatusInternalServerError}
+		body, _ = json.Marshal(problem)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", ProblemContentType)
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Del("Content-Length")
+	w.WriteHeader(problem.StatusCode())
+	w.Write(body)
+}
+
+// ProblemErrorMapper is an `ErrorMapper` which responds to the errors with `Problem`s,
+// a returned `*Problem` is sent as it is, the rest errors are converted by their `ErrorStatus`,
+// the message of an `*HTTPError` or of a 400 Bad Request error is sent as the problem's detail. See `Mux#Problems`.
+var ProblemErrorMapper ErrorMapper = func(w http.ResponseWriter, r *http.Request, err error) {
+	var problem *Problem
+	if !errors.As(err, &problem) {
+		status := ErrorStatus(err)
+		problem = &Problem{Status: status, Detail: errorMessage(err, status), Err: err}
+	}
+
+	WriteProblem(w, r, problem)
+}
+
+// ProblemHandler returns a handler which responds with the `Problem` of the "status", i.e for the timed out responses:
+// mux.Wrap(muxie.Timeout(30*time.Second, muxie.TimeoutResponse(muxie.ProblemHandler(http.StatusServiceUnavailable))))
+func ProblemHandler(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteProblem(w, r, &Problem{Status: status})
+	})
+}
+
+// ProblemRecoverHandler responds to the recovered panics with a 500 Internal Server Error `Problem`, i.e:
+// mux.Use(muxie.Recover(muxie.RecoverHandler(muxie.ProblemRecoverHandler)))
+func ProblemRecoverHandler(w http.ResponseWriter, r *http.Request, err interface{}) {
+	WriteProblem(w, r, &Problem{Status: http.StatusInternalServerError})
+}
+
+// Problems makes this Mux and its sub muxes respond with `Problem`s to the not found requests,
+// the requests of not allowed methods, the too large request bodies and the errors of the `HandleE` routes
+// through the `NotFoundHandler`, the `MethodNotAllowedHandler` and the `ErrorMapper` fields, i.e:
+// api := mux.Of("/api")
+// api.Problems()
+//
+// The panics and the timeouts are responded by the `ProblemRecoverHandler` and the `ProblemHandler`.
+func (m *Mux) Problems() {
+	m.lock()
+	m.NotFoundHandler = ProblemHandler(http.StatusNotFound)
+	m.MethodNotAllowedHandler = ProblemHandler(http.StatusMethodNotAllowed)
+	m.ErrorMapper = ProblemErrorMapper
+	m.unlock()
+}
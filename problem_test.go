@@ -0,0 +1,112 @@
+package muxie
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProblem(t *testing.T) {
+	problem := NewProblem(http.StatusForbidden, "insufficient credit").With("balance", 30).With("status", 200)
+	problem.Type = "https://example.com/probs/out-of-credit"
+	problem.Instance = "/account/12345/msgs/abc"
+
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, httptest.NewRequest(http.MethodGet, "/", nil), problem)
+
+	if expected, got := http.StatusForbidden, rec.Code; expected != got {
+		t.Fatalf("expected status code: %d but got: %d", expected, got)
+	}
+
+	if expected, got := ProblemContentType, rec.Header().Get("Content-Type"); expected != got {
+		t.Fatalf("expected content type: %s but got: %s", expected, got)
+	}
+
+	expected := `{"balance":30,"detail":"insufficient credit","instance":"/account/12345/msgs/abc","status":403,` +
+		`"title":"Forbidden","type":"https://example.com/probs/out-of-credit"}`
+	if got := rec.Body.String(); expected != got {
+		t.Fatalf("expected body: %s but got: %s", expected, got)
+	}
+
+	if expected, got := "insufficient credit", problem.Error(); expected != got {
+		t.Fatalf("expected error: %s but got: %s", expected, got)
+	}
+
+	// a not JSON extension member.
+	rec = httptest.NewRecorder()
+	WriteProblem(rec, httptest.NewRequest(http.MethodGet, "/", nil), NewProblem(http.StatusBadRequest, "").With("fn", func() {}))
+	if expected, got := `{"status":500,"title":"Internal Server Error"}`, rec.Body.String(); rec.Code != 500 || expected != got {
+		t.Fatalf("expected 500 body: %s but got: %d %s", expected, rec.Code, got)
+	}
+}
+
+func TestMuxProblems(t *testing.T) {
+	mux := NewMux()
+	mux.MethodNotAllowed = true
+	mux.HandleFunc("/html", func(w http.ResponseWriter, r *http.Request) {})
+
+	api := mux.Of("/api")
+	api.Problems()
+	api.Use(Recover(RecoverLogger(nil), RecoverHandler(ProblemRecoverHandler)))
+	api.HandleMethodFunc(http.MethodGet, "/users", func(w http.ResponseWriter, r *http.Request) {})
+	api.HandleE("/users/:id", func(w http.ResponseWriter, r *http.Request) error {
+		if _, err := GetParamInt(w, "id"); err != nil {
+			return err
+		}
+
+		return NewProblem(http.StatusPaymentRequired, "no credit").With("balance", 0)
+	})
+	api.HandleE("/conflict", func(w http.ResponseWriter, r *http.Request) error {
+		return fmt.Errorf("saving: %w", NewHTTPError(http.StatusConflict, "already exists"))
+	})
+	api.HandleE("/internal", func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("secret")
+	})
+	api.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	api.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}).Use(Timeout(time.Millisecond, TimeoutResponse(ProblemHandler(http.StatusServiceUnavailable))))
+	api.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+	}).MaxBodySize(4)
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{http.MethodGet, "/api/missing", 404, `{"status":404,"title":"Not Found"}`},
+		{http.MethodPost, "/api/users", 405, `{"status":405,"title":"Method Not Allowed"}`},
+		{http.MethodGet, "/api/users/1", 402, `{"balance":0,"detail":"no credit","status":402,"title":"Payment Required"}`},
+		{http.MethodGet, "/api/users/abc", 400, `{"detail":"muxie: invalid value \"abc\" of parameter \"id\": invalid syntax","status":400,"title":"Bad Request"}`},
+		{http.MethodGet, "/api/conflict", 409, `{"detail":"already exists","status":409,"title":"Conflict"}`},
+		{http.MethodGet, "/api/internal", 500, `{"status":500,"title":"Internal Server Error"}`},
+		{http.MethodGet, "/api/panic", 500, `{"status":500,"title":"Internal Server Error"}`},
+		{http.MethodGet, "/api/slow", 503, `{"status":503,"title":"Service Unavailable"}`},
+		{http.MethodPost, "/api/upload", 413, `{"status":413,"title":"Request Entity Too Large"}`},
+	}
+
+	for i, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader("too large"))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Fatalf("[%d] %s %s: expected: %d %s but got: %d %s", i, tt.method, tt.path, tt.status, tt.body, rec.Code, rec.Body.String())
+		}
+
+		if expected, got := ProblemContentType, rec.Header().Get("Content-Type"); expected != got {
+			t.Fatalf("[%d] expected content type: %s but got: %s", i, expected, got)
+		}
+	}
+
+	// the rest of the mux is not affected.
+	testHandler(t, mux, http.MethodGet, "/missing").statusCode(http.StatusNotFound).bodyEq("404 page not found\n")
+}
@@ -0,0 +1,259 @@
+package muxie
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket limit, a client can send up to "Requests" requests at once
+// and its bucket is refilled by "Requests" tokens per "Per" duration, i.e RateLimit{Requests: 100, Per: time.Minute}.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// RateLimitResult is the result of a `RateStore#Take`.
+type RateLimitResult struct {
+	// Allowed reports whether a token was taken.
+	Allowed bool
+	// Remaining is the number of the tokens that are left on the bucket.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available, when the request is not allowed.
+	RetryAfter time.Duration
+}
+
+// RateStore is the interface that the storages of the token buckets of the `RateLimiter` implement,
+// the default one keeps them in memory, see `NewRateStore`, a distributed backend, i.e Redis,
+// can implement it to share the limits between many instances of a server.
+type RateStore interface {
+	// Take takes a token from the bucket of the "key", if any.
+	Take(key string, limit RateLimit) RateLimitResult
+}
+
+// RateLimitKey is the type of the functions that return the key of a request's token bucket,
+// see `RateLimitByIP`, `RateLimitByHeader` and `RateLimitByParam`.
+type RateLimitKey func(w http.ResponseWriter, r *http.Request) string
+
+// RateLimitByIP returns a `RateLimitKey` which limits the requests by the client's IP, it is the default one.
+func RateLimitByIP() RateLimitKey {
+	return func(w http.ResponseWriter, r *http.Request) string {
+		return remoteHost(r)
+	}
+}
+
+// RateLimitByHeader returns a `RateLimitKey` which limits the requests by the value of a request header, i.e "X-API-Key".
+func RateLimitByHeader(key string) RateLimitKey {
+	if key == "" {
+		panic("muxie/RateLimitByHeader: empty header key")
+	}
+
+	key = http.CanonicalHeaderKey(key)
+	return func(w http.ResponseWriter, r *http.Request) string {
+		return r.Header.Get(key)
+	}
+}
+
+// RateLimitByParam returns a `RateLimitKey` which limits the requests by the value of a path parameter, i.e "tenant",
+// the `RateLimiter` should be registered through the `Mux#Use`, `Mux#With` or `Route#Use` so the parameters are available.
+func RateLimitByParam(key string) RateLimitKey {
+	if key == "" {
+		panic("muxie/RateLimitByParam: empty parameter key")
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) string {
+		return GetParam(w, key)
+	}
+}
+
+// RateLimitOption is the type of the options that `RateLimiter` accepts.
+type RateLimitOption func(*rateLimiter)
+
+// RateLimitBy is a `RateLimitOption` which sets the key of the requests' token buckets,
+// the requests with an empty key are limited by the client's IP. Defaults to the `RateLimitByIP`.
+func RateLimitBy(key RateLimitKey) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.key = key
+	}
+}
+
+// RateLimitStore is a `RateLimitOption` which sets the storage of the token buckets. Defaults to a new `NewRateStore`.
+func RateLimitStore(store RateStore) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.store = store
+	}
+}
+
+// RateLimitPerRoute is a `RateLimitOption` which gives each route its own token buckets,
+// instead of sharing them between all the routes that the `RateLimiter` wraps.
+func RateLimitPerRoute() RateLimitOption {
+	return func(l *rateLimiter) {
+		l.perRoute = true
+	}
+}
+
+// RateLimitHandler is a `RateLimitOption` which customizes the response of the limited requests,
+// the rate limit headers are already set when it is called.
+// Defaults to a plain text 429 Too Many Requests response.
+func RateLimitHandler(handler http.Handler) RateLimitOption {
+	return func(l *rateLimiter) {
+		l.handler = handler
+	}
+}
+
+type rateLimiter struct {
+	limit RateLimit
+	// the limit of a request, if any, it overrides the "limit", see `RateLimitByAPIKey`.
+	limitOf  func(r *http.Request) (RateLimit, bool)
+	key      RateLimitKey
+	store    RateStore
+	perRoute bool
+	handler  http.Handler
+}
+
+// RateLimiter returns a middleware which limits the requests by token buckets, i.e:
+// mux.Wrap(muxie.RateLimiter(muxie.RateLimit{Requests: 1000, Per: time.Minute}))
+// mux.HandleFunc("/login", login).Use(muxie.RateLimiter(muxie.RateLimit{Requests: 5, Per: time.Minute}))
+// mux.With(muxie.RateLimiter(limit, muxie.RateLimitBy(muxie.RateLimitByParam("tenant")), muxie.RateLimitPerRoute()))
+//
+// The responses have the "RateLimit-Limit", "RateLimit-Remaining" and "RateLimit-Reset" headers
+// and the limited ones the "Retry-After" header too, the durations are in seconds.
+func RateLimiter(limit RateLimit, options ...RateLimitOption) Wrapper {
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		panic("muxie/RateLimiter: the limit should have positive requests and duration")
+	}
+
+	l := &rateLimiter{limit: limit}
+	for _, opt := range options {
+		opt(l)
+	}
+
+	if l.key == nil {
+		l.key = RateLimitByIP()
+	}
+
+	if l.store == nil {
+		l.store = NewRateStore()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := l.key(w, r)
+			if key == "" {
+				key = remoteHost(r)
+			}
+
+			if l.perRoute {
+				key = RoutePattern(w) + " " + key
+			}
+
+			limit := l.limit
+			if l.limitOf != nil {
+				if custom, ok := l.limitOf(r); ok && custom.Requests > 0 && custom.Per > 0 {
+					limit = custom
+				}
+			}
+
+			result := l.store.Take(key, limit)
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			h.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("RateLimit-Reset", seconds(result.Reset))
+
+			if result.Allowed {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Set("Retry-After", seconds(result.RetryAfter))
+			if l.handler != nil {
+				l.handler.ServeHTTP(w, r)
+				return
+			}
+
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		})
+	}
+}
+
+// seconds returns the "d" in seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// NewRateStore returns a new `RateStore` which keeps the token buckets in memory,
+// the full buckets are removed periodically.
+func NewRateStore() RateStore {
+	return &memoryRateStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	// the time that the bucket is full, see `memoryRateStore#sweep`.
+	full time.Time
+}
+
+type memoryRateStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func (s *memoryRateStore) Take(key string, limit RateLimit) RateLimitResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) > limit.Per {
+		s.sweep(now)
+	}
+
+	capacity := float64(limit.Requests)
+	rate := capacity / float64(limit.Per) // tokens per nanosecond.
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+
+	if b.tokens = b.tokens + float64(now.Sub(b.last))*rate; b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.last = now
+
+	var result RateLimitResult
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / rate)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) / rate)
+	b.full = now.Add(result.Reset)
+
+	return result
+}
+
+// sweep removes the buckets that are full, they are the same as the missing ones.
+func (s *memoryRateStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !b.full.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
@@ -0,0 +1,118 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestRateStore(now *time.Time) RateStore {
+	store := NewRateStore().(*memoryRateStore)
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := RateLimit{Requests: 2, Per: 10 * time.Second}
+
+	mux := NewMux()
+	mux.Use(RateLimiter(limit, RateLimitStore(newTestRateStore(&now))))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/").statusCode(http.StatusOK).bodyEq("ok").
+		headerEq("RateLimit-Limit", "2").headerEq("RateLimit-Remaining", "1").headerEq("RateLimit-Reset", "5")
+	testHandler(t, mux, http.MethodGet, "/").statusCode(http.StatusOK).
+		headerEq("RateLimit-Remaining", "0").headerEq("RateLimit-Reset", "10")
+	testHandler(t, mux, http.MethodGet, "/").statusCode(http.StatusTooManyRequests).
+		bodyEq("Too Many Requests\n").headerEq("RateLimit-Remaining", "0").headerEq("Retry-After", "5")
+
+	// a token is refilled every 5 seconds.
+	now = now.Add(5 * time.Second)
+	testHandler(t, mux, http.MethodGet, "/").statusCode(http.StatusOK)
+	testHandler(t, mux, http.MethodGet, "/").statusCode(http.StatusTooManyRequests)
+
+	// other clients have their own buckets.
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "192.0.2.2:1234"
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code: %d but got: %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestRateLimiterKeys(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := RateLimit{Requests: 1, Per: time.Minute}
+
+	mux := NewMux()
+	mux.Use(RateLimiter(limit, RateLimitStore(newTestRateStore(&now)),
+		RateLimitBy(RateLimitByParam("tenant")), RateLimitPerRoute(),
+		RateLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("slow down " + GetParam(w, "tenant")))
+		}))))
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+	mux.HandleFunc("/:tenant/users", handler)
+	mux.HandleFunc("/:tenant/orders", handler)
+
+	testHandler(t, mux, http.MethodGet, "/acme/users").statusCode(http.StatusOK)
+	testHandler(t, mux, http.MethodGet, "/acme/users").statusCode(http.StatusTooManyRequests).
+		bodyEq("slow down acme").headerEq("Retry-After", "60")
+	testHandler(t, mux, http.MethodGet, "/globex/users").statusCode(http.StatusOK)
+	testHandler(t, mux, http.MethodGet, "/acme/orders").statusCode(http.StatusOK)
+
+	header := NewMux()
+	header.Use(RateLimiter(limit, RateLimitStore(newTestRateStore(&now)), RateLimitBy(RateLimitByHeader("X-API-Key"))))
+	header.HandleFunc("/", handler)
+
+	for _, tt := range []struct {
+		apiKey string
+		status int
+	}{
+		{"a", http.StatusOK},
+		{"a", http.StatusTooManyRequests},
+		{"b", http.StatusOK},
+		// falls back to the client's IP.
+		{"", http.StatusOK},
+		{"", http.StatusTooManyRequests},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.apiKey != "" {
+			r.Header.Set("X-API-Key", tt.apiKey)
+		}
+		header.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Fatalf("[%s]: expected status code: %d but got: %d", tt.apiKey, tt.status, w.Code)
+		}
+	}
+}
+
+func TestRateStoreSweep(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := newTestRateStore(&now).(*memoryRateStore)
+	limit := RateLimit{Requests: 10, Per: time.Second}
+
+	store.Take("a", limit)
+	now = now.Add(500 * time.Millisecond)
+	for i := 0; i < limit.Requests; i++ {
+		store.Take("b", limit)
+	}
+
+	now = now.Add(700 * time.Millisecond)
+	store.Take("c", limit)
+
+	if _, ok := store.buckets["a"]; ok {
+		t.Fatalf("expected the full bucket to be removed")
+	}
+
+	if len(store.buckets) != 2 {
+		t.Fatalf("expected 2 buckets but got: %d", len(store.buckets))
+	}
+}
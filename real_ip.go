@@ -0,0 +1,214 @@
+package muxie
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIPOption is the type of the options that `RealIP` accepts.
+type RealIPOption func(*realIP)
+
+// RealIPTrustedProxies is a `RealIPOption` which sets the proxies that the client IP headers are trusted from,
+// by their CIDRs or IPs, i.e "10.0.0.0/8", "2001:db8::/32" or "192.0.2.10".
+// It panics if one of them is not valid.
+func RealIPTrustedProxies(proxies ...string) RealIPOption {
+	return func(ip *realIP) {
+		for _, proxy := range proxies {
+			prefix, ok := parsePrefix(proxy)
+			if !ok {
+				panic("muxie/RealIPTrustedProxies: invalid proxy \"" + proxy + "\"")
+			}
+
+			ip.trusted = append(ip.trusted, prefix)
+		}
+	}
+}
+
+// RealIPHeaders is a `RealIPOption` which sets the headers that the client IP is resolved from, the first one that
+// resolves it wins, the supported ones are the "Forwarded", "X-Forwarded-For" and "X-Real-IP".
+// Defaults to all of them, in that order.
+func RealIPHeaders(headers ...string) RealIPOption {
+	return func(ip *realIP) {
+		ip.headers = ip.headers[0:0]
+		for _, header := range headers {
+			header = http.CanonicalHeaderKey(header)
+			switch header {
+			case "Forwarded", "X-Forwarded-For", "X-Real-Ip":
+				ip.headers = append(ip.headers, header)
+			default:
+				panic("muxie/RealIPHeaders: unsupported header \"" + header + "\"")
+			}
+		}
+	}
+}
+
+type realIP struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+// RealIP returns a middleware which resolves the IP of the client from the "Forwarded", "X-Forwarded-For" or "X-Real-IP"
+// headers, only if the request comes from one of the `RealIPTrustedProxies`, i.e:
+// mux.Wrap(muxie.RealIP(muxie.RealIPTrustedProxies("10.0.0.0/8")), muxie.Logger())
+//
+// The list headers are read from right to left, their trusted proxies are skipped and the first untrusted IP
+// is the client's one. The resolved IP is retrieved by the `ClientIP` and it replaces the host of the request's RemoteAddr,
+// so the following middlewares, i.e the `Logger` and the `RateLimiter`, use it too.
+func RealIP(options ...RealIPOption) Wrapper {
+	ip := &realIP{headers: []string{"Forwarded", "X-Forwarded-For", "X-Real-Ip"}}
+	for _, opt := range options {
+		opt(ip)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if addr, ok := ip.resolve(r); ok {
+				client := addr.String()
+				r = r.WithContext(context.WithValue(r.Context(), clientIPContextKey{}, client))
+
+				_, port, err := net.SplitHostPort(r.RemoteAddr)
+				if err != nil {
+					port = "0"
+				}
+				r.RemoteAddr = net.JoinHostPort(client, port)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (ip *realIP) isTrusted(addr netip.Addr) bool {
+	return containsAddr(ip.trusted, addr.Unmap())
+}
+
+// resolve returns the client IP of the request's headers, if the request comes from a trusted proxy.
+func (ip *realIP) resolve(r *http.Request) (netip.Addr, bool) {
+	peer, ok := parseIP(r.RemoteAddr)
+	if !ok || !ip.isTrusted(peer) {
+		return netip.Addr{}, false
+	}
+
+	for _, header := range ip.headers {
+		values := r.Header.Values(header)
+		if len(values) == 0 {
+			continue
+		}
+
+		var hops []string
+		switch header {
+		case "Forwarded":
+			hops = forwardedFor(values)
+		case "X-Forwarded-For":
+			for _, v := range values {
+				hops = append(hops, strings.Split(v, ",")...)
+			}
+		default: // "X-Real-Ip".
+			hops = values[len(values)-1:]
+		}
+
+		if addr, ok := ip.client(hops); ok {
+			return addr, true
+		}
+	}
+
+	return netip.Addr{}, false
+}
+
+// client returns the rightmost untrusted IP of the "hops", or the leftmost one if all of them are trusted.
+// It stops on an invalid hop, i.e "unknown", because the hops before it can not be trusted.
+func (ip *realIP) client(hops []string) (netip.Addr, bool) {
+	var addr netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseIP(hops[i])
+		if !ok {
+			return netip.Addr{}, false
+		}
+
+		if addr = hop; !ip.isTrusted(hop) {
+			return addr, true
+		}
+	}
+
+	return addr, addr.IsValid()
+}
+
+// forwardedFor returns the "for" parameters of the "Forwarded" header values, i.e
+// `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			hop := "unknown"
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hop = strings.Trim(value, "\"")
+				}
+			}
+
+			hops = append(hops, hop)
+		}
+	}
+
+	return hops
+}
+
+// parseIP parses an IP, with or without a port, i.e "192.0.2.60", "192.0.2.60:8080" or "[2001:db8::17]:4711".
+func parseIP(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.Unmap(), true
+	}
+
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		if addr, err := netip.ParseAddr(s[1 : len(s)-1]); err == nil {
+			return addr.Unmap(), true
+		}
+	}
+
+	return netip.Addr{}, false
+}
+
+// parsePrefix parses a CIDR or an IP, as a single address prefix, i.e "10.0.0.0/8", "2001:db8::/32" or "192.0.2.10".
+func parsePrefix(s string) (netip.Prefix, bool) {
+	s = strings.TrimSpace(s)
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		if addr := prefix.Addr(); addr.Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(addr.Unmap(), prefix.Bits()-96)
+		}
+
+		return prefix.Masked(), true
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
+
+type clientIPContextKey struct{}
+
+// ClientIP returns the IP of the client that the `RealIP` resolved for the request,
+// or the IP of the request's RemoteAddr if it did not resolve one.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPContextKey{}).(string); ok {
+		return ip
+	}
+
+	if addr, ok := parseIP(r.RemoteAddr); ok {
+		return addr.String()
+	}
+
+	return r.RemoteAddr
+}
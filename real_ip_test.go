@@ -0,0 +1,67 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRealIP(t *testing.T) {
+	mux := NewMux()
+	mux.Wrap(RealIP(RealIPTrustedProxies("10.0.0.0/8", "2001:db8::/32", "192.0.2.10")))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ClientIP(r) + " " + r.RemoteAddr))
+	})
+
+	var tests = []struct {
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		// untrusted peers are not resolved.
+		{"203.0.113.7:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.7 203.0.113.7:1234"},
+		{"10.0.0.1:1234", nil, "10.0.0.1 10.0.0.1:1234"},
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1 198.51.100.1:1234"},
+		// the spoofed leftmost hops are ignored.
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1, 10.0.0.2"}, "198.51.100.1 198.51.100.1:1234"},
+		// all trusted, the leftmost one.
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3 10.0.0.3:1234"},
+		{"10.0.0.1:1234", map[string]string{"X-Forwarded-For": "198.51.100.1, unknown"}, "10.0.0.1 10.0.0.1:1234"},
+		{"192.0.2.10:80", map[string]string{"X-Real-IP": "198.51.100.2"}, "198.51.100.2 198.51.100.2:80"},
+		{"[2001:db8::1]:443", map[string]string{"Forwarded": `for=198.51.100.3;proto=https, for="[2001:db8:cafe::17]:4711"`},
+			"198.51.100.3 198.51.100.3:443"},
+		{"10.0.0.1:1234", map[string]string{"Forwarded": `for="[2001:db9::17]:4711"`, "X-Forwarded-For": "198.51.100.1"},
+			"2001:db9::17 [2001:db9::17]:1234"},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = tt.remoteAddr
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		mux.ServeHTTP(w, r)
+
+		if got := w.Body.String(); got != tt.expected {
+			t.Fatalf("%s %v: expected: '%s' but got: '%s'", tt.remoteAddr, tt.headers, tt.expected, got)
+		}
+	}
+
+	headers := NewMux()
+	headers.Wrap(RealIP(RealIPTrustedProxies("10.0.0.1"), RealIPHeaders("X-Real-IP")))
+	headers.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ClientIP(r)))
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	r.Header.Set("X-Real-IP", "198.51.100.2")
+	headers.ServeHTTP(w, r)
+
+	if got := w.Body.String(); got != "198.51.100.2" {
+		t.Fatalf("expected the X-Real-IP header to be used only but got: '%s'", got)
+	}
+}
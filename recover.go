@@ -0,0 +1,83 @@
+package muxie
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// RecoverOption is the type of the options that `Recover` accepts.
+type RecoverOption func(*recoverer)
+
+// RecoverLogger is a `RecoverOption` which logs the recovered panics and their stack traces to the "logger",
+// a nil "logger" disables the logging. Defaults to the standard logger of the log package.
+func RecoverLogger(logger *log.Logger) RecoverOption {
+	return func(rec *recoverer) {
+		rec.logger = logger
+		rec.noLogger = logger == nil
+	}
+}
+
+// RecoverHandler is a `RecoverOption` which customizes the response of a panicking request,
+// the "handler" receives the recovered value, i.e to render an error page.
+// Defaults to a plain text 500 Internal Server Error response.
+func RecoverHandler(handler func(w http.ResponseWriter, r *http.Request, err interface{})) RecoverOption {
+	return func(rec *recoverer) {
+		rec.handler = handler
+	}
+}
+
+type recoverer struct {
+	logger   *log.Logger
+	noLogger bool
+	handler  func(w http.ResponseWriter, r *http.Request, err interface{})
+}
+
+// Recover returns a middleware which recovers the panics of the handlers that it wraps,
+// it logs their stack traces and it responds with 500 Internal Server Error, i.e:
+// mux.Use(muxie.Recover())
+// mux.Use(muxie.Recover(muxie.RecoverLogger(myLogger), muxie.RecoverHandler(myErrorHandler)))
+//
+// The `http.ErrAbortHandler` panics are not recovered, they abort the response as the net/http expects.
+// Register it through the `Mux#Use`, so the parameters storage of the panicking requests is reused safely,
+// or through the `Mux#Wrap` to recover the panics of the rest middlewares too.
+func Recover(options ...RecoverOption) Wrapper {
+	rec := new(recoverer)
+	for _, opt := range options {
+		opt(rec)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				if !rec.noLogger {
+					msg := fmt.Sprintf("muxie: panic serving %s %s: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+					if rec.logger != nil {
+						rec.logger.Print(msg)
+					} else {
+						log.Print(msg)
+					}
+				}
+
+				if rec.handler != nil {
+					rec.handler(w, r, err)
+					return
+				}
+
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package muxie
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var logs bytes.Buffer
+
+	mux := NewMux()
+	mux.Use(Recover(RecoverLogger(log.New(&logs, "", 0))))
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		if id := GetParam(w, "id"); id == "0" {
+			panic("invalid user")
+		}
+
+		fmt.Fprintf(w, "user %s", GetParam(w, "id"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/0").
+		statusCode(http.StatusInternalServerError).bodyEq("Internal Server Error\n")
+
+	if got := logs.String(); !strings.HasPrefix(got, "muxie: panic serving GET /users/0: invalid user\n") ||
+		!strings.Contains(got, "runtime/debug.Stack") {
+		t.Fatalf("expected the panic and its stack trace to be logged but got: %s", got)
+	}
+
+	// the reused parameters storage should not keep the parameters of the panicking request.
+	for i := 1; i <= 3; i++ {
+		testHandler(t, mux, http.MethodGet, fmt.Sprintf("/users/%d", i)).
+			statusCode(http.StatusOK).bodyEq(fmt.Sprintf("user %d", i))
+	}
+
+	custom := NewMux()
+	custom.Use(Recover(RecoverLogger(nil), RecoverHandler(func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "recovered: %v", err)
+	})))
+	custom.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		panic("oops")
+	})
+
+	testHandler(t, custom, http.MethodGet, "/").
+		statusCode(http.StatusServiceUnavailable).bodyEq("recovered: oops")
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	mux := NewMux()
+	mux.Use(Recover(RecoverLogger(nil)))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			t.Fatalf("expected the http.ErrAbortHandler to not be recovered but got: %v", err)
+		}
+	}()
+
+	testHandler(t, mux, http.MethodGet, "/")
+}
@@ -0,0 +1,581 @@
+package muxie
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	// YAML implements the `Dispatcher` interface.
+	// It is responsible to dispatch YAML results to the client, with "Indent" spaces per level, at least 2.
+	// The values are encoded through their JSON encoding, so their `json` struct tags and json.Marshaler are respected.
+	//
+	// Usage:
+	// muxie.WriteYAML(w, http.StatusOK, mySendDataValue)
+	YAML = &yamlDispatcher{Indent: 2}
+
+	// MsgPack implements the `Dispatcher` interface.
+	// It is responsible to dispatch MessagePack results to the client.
+	// The values are encoded through their JSON encoding, like the `YAML` ones.
+	//
+	// Usage:
+	// muxie.WriteMsgPack(w, http.StatusOK, mySendDataValue)
+	MsgPack = &msgpackDispatcher{}
+
+	// Text implements the `Dispatcher` interface.
+	// It is responsible to dispatch plain text results to the client, the strings, the []byte,
+	// the fmt.Stringer and the error values are sent as they are, the rest ones through the fmt.Sprint.
+	//
+	// Usage:
+	// muxie.Render(w, http.StatusOK, "text/plain", mySendDataValue)
+	Text = &textDispatcher{}
+)
+
+// DispatcherFunc is an adapter to use a function as a `Dispatcher`, i.e for the `Mux#RegisterEncoder`.
+type DispatcherFunc func(w http.ResponseWriter, v interface{}) error
+
+// Dispatch calls f(w, v).
+func (f DispatcherFunc) Dispatch(w http.ResponseWriter, v interface{}) error {
+	return f(w, v)
+}
+
+// defaultEncoders are the encoders of the `Render` for the content types that no mux has registered one.
+var defaultEncoders = map[string]Dispatcher{
+	"application/json":    JSON,
+	"application/xml":     XML,
+	"text/xml":            XML,
+	"application/yaml":    YAML,
+	"application/msgpack": MsgPack,
+	"text/plain":          Text,
+}
+
+// RegisterEncoder registers the encoder of the responses of a content type, i.e "text/csv", for the `Render`
+// of this Mux' routes and of its sub muxes' ones, or it replaces a built-in one, i.e:
+// mux.RegisterEncoder("text/csv", muxie.DispatcherFunc(writeCSV))
+// mux.RegisterEncoder("application/json", myFasterJSON)
+//
+// The built-in encoders are the `JSON` for the "application/json", the `XML` for the "application/xml" and "text/xml",
+// the `YAML` for the "application/yaml", the `MsgPack` for the "application/msgpack" and the `Text` for the "text/plain" content types,
+// the "text/html" has not a built-in one, see `HTMLTemplate`. The encoder should set the Content-Type header of the response.
+func (m *Mux) RegisterEncoder(contentType string, encoder Dispatcher) {
+	if encoder == nil {
+		panic("muxie/Mux#RegisterEncoder: empty encoder for \"" + contentType + "\"")
+	}
+
+	contentType = mediaType(contentType)
+	if strings.Count(contentType, "/") != 1 {
+		panic("muxie/Mux#RegisterEncoder: invalid content type \"" + contentType + "\"")
+	}
+
+	m.lock()
+	if m.encoders == nil {
+		m.encoders = make(map[string]Dispatcher)
+	}
+	m.encoders[contentType] = encoder
+	m.unlock()
+}
+
+// encoder returns the encoder of the "contentType" of this mux or of its nearest parent, or the built-in one, if any.
+func (m *Mux) encoder(contentType string) Dispatcher {
+	if m != nil {
+		m.rlock()
+		defer m.runlock()
+
+		for mux := m; mux != nil; mux = mux.parent {
+			if encoder, ok := mux.encoders[contentType]; ok {
+				return encoder
+			}
+		}
+	}
+
+	return defaultEncoders[contentType]
+}
+
+// mediaType returns the lowercase "contentType" without its parameters, i.e "text/html" for "text/html; charset=utf-8".
+func mediaType(contentType string) string {
+	if idx := strings.IndexByte(contentType, ';'); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// ErrNoEncoder is the error that the `Render` returns when there is no encoder for the content type of the response.
+var ErrNoEncoder = errors.New("muxie: no encoder is registered for the content type")
+
+// Render sends the "v" with the "code" status code, through the encoder of the "contentType",
+// the one that the route's mux has registered through the `Mux#RegisterEncoder` or the built-in one, i.e:
+// muxie.Render(w, http.StatusOK, "application/yaml", order)
+//
+// A zero "code" sends the 200 status code. Nothing is sent if the encoding fails,
+// so the handler can still send an error response. It returns an error which wraps the `ErrNoEncoder`
+// if there is no encoder for the "contentType". See `WriteJSON`, `WriteXML`, `WriteYAML`, `WriteMsgPack` and `WriteJSONP` too.
+func Render(w http.ResponseWriter, code int, contentType string, v interface{}) error {
+	var mux *Mux
+	if pw := findParamsWriter(w); pw != nil {
+		mux = pw.mux
+	}
+
+	encoder := mux.encoder(mediaType(contentType))
+	if encoder == nil {
+		return fmt.Errorf("%w: %q", ErrNoEncoder, contentType)
+	}
+
+	if code > 0 {
+		w = &statusCodeWriter{ResponseWriter: w, code: code}
+	}
+
+	return encoder.Dispatch(w, v)
+}
+
+// WriteJSON sends the "v" as JSON with the "code" status code, see `Render`, i.e:
+// muxie.WriteJSON(w, http.StatusCreated, user)
+//
+// The indentation of the built-in encoder is set through the `JSON.Indent`.
+func WriteJSON(w http.ResponseWriter, code int, v interface{}) error {
+	return Render(w, code, "application/json", v)
+}
+
+// WriteXML sends the "v" as XML with the "code" status code, see `Render`.
+// The indentation of the built-in encoder is set through the `XML.Indent`.
+func WriteXML(w http.ResponseWriter, code int, v interface{}) error {
+	return Render(w, code, "application/xml", v)
+}
+
+// WriteYAML sends the "v" as YAML with the "code" status code, see `Render` and `YAML`.
+func WriteYAML(w http.ResponseWriter, code int, v interface{}) error {
+	return Render(w, code, "application/yaml", v)
+}
+
+// WriteMsgPack sends the "v" as MessagePack with the "code" status code, see `Render` and `MsgPack`.
+func WriteMsgPack(w http.ResponseWriter, code int, v interface{}) error {
+	return Render(w, code, "application/msgpack", v)
+}
+
+// ErrJSONPCallback is the error that the `WriteJSONP` returns for a callback which is not a JavaScript identifier.
+var ErrJSONPCallback = errors.New("muxie: invalid JSONP callback")
+
+// WriteJSONP sends the "v" as JSON, through the `JSON`, wrapped by a call to the "callback" function,
+// with the "code" status code, i.e:
+// muxie.WriteJSONP(w, http.StatusOK, r.URL.Query().Get("callback"), user)
+//
+// The "callback" should be a JavaScript identifier or a dot separated path of them, i.e "app.onUser",
+// otherwise, as it is usually given by the client, it returns the `ErrJSONPCallback` without sending anything.
+func WriteJSONP(w http.ResponseWriter, code int, callback string, v interface{}) error {
+	if !isJSONPCallback(callback) {
+		return ErrJSONPCallback
+	}
+
+	result, err := JSON.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", withCharset("application/javascript"))
+	h.Set("X-Content-Type-Options", "nosniff")
+	if code > 0 {
+		w.WriteHeader(code)
+	}
+
+	// the comment prevents the callbacks which start with a byte order mark or similar tricks.
+	_, err = w.Write([]byte("/**/" + callback + "(" + string(bytes.TrimSpace(result)) + ");"))
+	return err
+}
+
+func isJSONPCallback(callback string) bool {
+	if callback == "" || len(callback) > 128 {
+		return false
+	}
+
+	for _, name := range strings.Split(callback, ".") {
+		if name == "" {
+			return false
+		}
+
+		for i := 0; i < len(name); i++ {
+			c := name[i]
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$' || (i > 0 && c >= '0' && c <= '9')) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// statusCodeWriter sends its status code before the first write of an encoder, unless the encoder sends its own, see `Render`.
+type statusCodeWriter struct {
+	http.ResponseWriter
+	code int
+	sent bool
+}
+
+var _ Unwrapper = (*statusCodeWriter)(nil)
+
+func (w *statusCodeWriter) WriteHeader(statusCode int) {
+	w.sent = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusCodeWriter) Write(b []byte) (int, error) {
+	if !w.sent {
+		w.WriteHeader(w.code)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underline http.ResponseWriter.
+func (w *statusCodeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// HTMLTemplate returns a `Dispatcher` which renders the values through the "name" template of the "t",
+// for the "text/html" responses of the `Render` and the `Negotiate`, i.e:
+// mux.RegisterEncoder("text/html", muxie.HTMLTemplate(templates, "user.html"))
+//
+// Nothing is sent if the template fails.
+func HTMLTemplate(t *template.Template, name string) Dispatcher {
+	if t == nil || t.Lookup(name) == nil {
+		panic("muxie/HTMLTemplate: template \"" + name + "\" not found")
+	}
+
+	return DispatcherFunc(func(w http.ResponseWriter, v interface{}) error {
+		var b bytes.Buffer
+		if err := t.ExecuteTemplate(&b, name, v); err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", withCharset("text/html"))
+		_, err := w.Write(b.Bytes())
+		return err
+	})
+}
+
+type textDispatcher struct{}
+
+var _ Dispatcher = (*textDispatcher)(nil)
+
+func (p *textDispatcher) Dispatch(w http.ResponseWriter, v interface{}) error {
+	var result []byte
+	switch v := v.(type) {
+	case string:
+		result = []byte(v)
+	case []byte:
+		result = v
+	case fmt.Stringer:
+		result = []byte(v.String())
+	case error:
+		result = []byte(v.Error())
+	default:
+		result = []byte(fmt.Sprint(v))
+	}
+
+	w.Header().Set("Content-Type", withCharset("text/plain"))
+	_, err := w.Write(result)
+	return err
+}
+
+// jsonMember is a member of a JSON object, the objects keep the order of their members, see `jsonTree`.
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+type jsonObject []jsonMember
+
+// jsonTree returns the JSON encoding of the "v" decoded to nil, bool, json.Number, string, []interface{} and jsonObject values,
+// for the encoders of the other formats.
+func jsonTree(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return decodeJSONTree(dec)
+}
+
+func decodeJSONTree(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+
+	if delim == '[' {
+		list := []interface{}{}
+		for dec.More() {
+			item, err := decodeJSONTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+
+		_, err = dec.Token()
+		return list, err
+	}
+
+	object := jsonObject{}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := decodeJSONTree(dec)
+		if err != nil {
+			return nil, err
+		}
+		object = append(object, jsonMember{key: key.(string), value: value})
+	}
+
+	_, err = dec.Token()
+	return object, err
+}
+
+type yamlDispatcher struct {
+	Indent int
+}
+
+var _ Dispatcher = (*yamlDispatcher)(nil)
+
+func (p *yamlDispatcher) Dispatch(w http.ResponseWriter, v interface{}) error {
+	tree, err := jsonTree(v)
+	if err != nil {
+		return err
+	}
+
+	indent := p.Indent
+	if indent < 2 {
+		indent = 2
+	}
+
+	var b bytes.Buffer
+	encodeYAML(&b, tree, indent, 0, false)
+
+	w.Header().Set("Content-Type", withCharset("application/yaml"))
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// encodeYAML writes the "v" in block style at the "level", the "inline" reports whether
+// its first line continues the current one, i.e after the "- " of a list item.
+func encodeYAML(b *bytes.Buffer, v interface{}, indent, level int, inline bool) {
+	switch v := v.(type) {
+	case jsonObject:
+		if len(v) > 0 {
+			for i, member := range v {
+				if i > 0 || !inline {
+					b.WriteString(strings.Repeat(" ", indent*level))
+				}
+
+				b.WriteString(yamlScalar(member.key))
+				b.WriteByte(':')
+				if isYAMLBlock(member.value) {
+					b.WriteByte('\n')
+					encodeYAML(b, member.value, indent, level+1, false)
+					continue
+				}
+
+				b.WriteString(" " + yamlScalar(member.value) + "\n")
+			}
+			return
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			for i, item := range v {
+				if i > 0 || !inline {
+					b.WriteString(strings.Repeat(" ", indent*level))
+				}
+
+				b.WriteByte('-')
+				if isYAMLBlock(item) {
+					b.WriteString(strings.Repeat(" ", indent-1))
+					encodeYAML(b, item, indent, level+1, true)
+					continue
+				}
+
+				b.WriteString(" " + yamlScalar(item) + "\n")
+			}
+			return
+		}
+	}
+
+	b.WriteString(yamlScalar(v) + "\n")
+}
+
+// isYAMLBlock reports whether the "v" is a not empty object or list.
+func isYAMLBlock(v interface{}) bool {
+	switch v := v.(type) {
+	case jsonObject:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return false
+	}
+}
+
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if isPlainYAML(v) {
+			return v
+		}
+		// the escapes of the Go strings are valid in the YAML double quoted ones.
+		return strconv.Quote(v)
+	case jsonObject:
+		return "{}"
+	default:
+		return "[]"
+	}
+}
+
+// isPlainYAML reports whether the "s" can be written without quotes, it is conservative,
+// the rest of the strings are quoted.
+func isPlainYAML(s string) bool {
+	if s == "" || s[len(s)-1] == ' ' {
+		return false
+	}
+
+	switch strings.ToLower(s) {
+	case "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return false
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' {
+			continue
+		}
+
+		if i > 0 && (c >= '0' && c <= '9' || c == ' ' || c == '.' || c == '-' || c == '/') {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+type msgpackDispatcher struct{}
+
+var _ Dispatcher = (*msgpackDispatcher)(nil)
+
+func (p *msgpackDispatcher) Dispatch(w http.ResponseWriter, v interface{}) error {
+	tree, err := jsonTree(v)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/msgpack")
+	_, err = w.Write(appendMsgPack(nil, tree))
+	return err
+}
+
+func appendMsgPack(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return appendMsgPackInt(b, i)
+		}
+
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return binary.BigEndian.AppendUint64(append(b, 0xcf), u)
+		}
+
+		f, _ := v.Float64()
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f))
+	case string:
+		b = appendMsgPackLen(b, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		return append(b, v...)
+	case []interface{}:
+		b = appendMsgPackLen(b, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			b = appendMsgPack(b, item)
+		}
+		return b
+	case jsonObject:
+		b = appendMsgPackLen(b, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, member := range v {
+			b = appendMsgPack(b, member.key)
+			b = appendMsgPack(b, member.value)
+		}
+		return b
+	default:
+		return append(b, 0xc0)
+	}
+}
+
+func appendMsgPackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= -32 && i <= math.MaxInt8:
+		// positive and negative fixint.
+		return append(b, byte(i))
+	case i > 0 && i <= math.MaxUint8:
+		return append(b, 0xcc, byte(i))
+	case i > 0 && i <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(i))
+	case i > 0 && i <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(i))
+	case i > 0:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), uint64(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+	}
+}
+
+// appendMsgPackLen appends the header of a string, list or map of "n" length,
+// the "fix" type holds the lengths up to "fixMax"-1, a zero "code8" means that there is no 8-bit length type.
+func appendMsgPackLen(b []byte, n int, fix byte, fixMax int, code8, code16, code32 byte) []byte {
+	switch {
+	case n < fixMax:
+		return append(b, fix|byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		return append(b, code8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, code32), uint32(n))
+	}
+}
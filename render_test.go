@@ -0,0 +1,167 @@
+package muxie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type renderOrder struct {
+	ID     int64             `json:"id"`
+	Status string            `json:"status"`
+	Items  []renderOrderItem `json:"items"`
+	Tags   []string          `json:"tags"`
+	Meta   map[string]string `json:"meta"`
+	Note   *string           `json:"note"`
+}
+
+type renderOrderItem struct {
+	SKU      string  `json:"sku"`
+	Price    float64 `json:"price"`
+	Discount bool    `json:"discount"`
+}
+
+var testRenderOrder = renderOrder{
+	ID:     42,
+	Status: "paid",
+	Items:  []renderOrderItem{{SKU: "a-1", Price: 9.5}, {SKU: "123", Price: 1, Discount: true}},
+	Tags:   []string{},
+	Meta:   map[string]string{"note": "line\nbreak", "empty": ""},
+}
+
+func TestRender(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusCreated, map[string]int{"id": 42})
+	})
+	mux.HandleFunc("/xml", func(w http.ResponseWriter, r *http.Request) {
+		WriteXML(w, 0, person{Name: "kataras", Age: 25})
+	})
+	mux.HandleFunc("/yaml", func(w http.ResponseWriter, r *http.Request) {
+		WriteYAML(w, http.StatusOK, testRenderOrder)
+	})
+	mux.HandleFunc("/failed", func(w http.ResponseWriter, r *http.Request) {
+		if err := WriteJSON(w, http.StatusOK, make(chan int)); err != nil {
+			http.Error(w, "encoding failed", http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/unknown", func(w http.ResponseWriter, r *http.Request) {
+		if err := Render(w, http.StatusOK, "text/csv", nil); !errors.Is(err, ErrNoEncoder) {
+			t.Errorf("expected the ErrNoEncoder but got: %v", err)
+		}
+	})
+
+	reports := mux.Of("/reports")
+	reports.RegisterEncoder("text/csv; charset=utf-8", DispatcherFunc(func(w http.ResponseWriter, v interface{}) error {
+		w.Header().Set("Content-Type", "text/csv")
+		_, err := fmt.Fprintf(w, "id\n%v\n", v)
+		return err
+	}))
+	reports.HandleFunc("/csv", func(w http.ResponseWriter, r *http.Request) {
+		Render(w, http.StatusAccepted, "text/csv", 42)
+	})
+	reports.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, 42)
+	})
+
+	testHandler(t, mux, http.MethodGet, "/json").statusCode(http.StatusCreated).
+		headerEq("Content-Type", "application/json; charset=utf-8").bodyEq(`{"id":42}`)
+	testHandler(t, mux, http.MethodGet, "/xml").statusCode(http.StatusOK).
+		headerEq("Content-Type", "text/xml; charset=utf-8").bodyEq(`<person name="kataras" age="25"><description></description></person>`)
+	testHandler(t, mux, http.MethodGet, "/yaml").statusCode(http.StatusOK).
+		headerEq("Content-Type", "application/yaml; charset=utf-8").bodyEq(`id: 42
+status: paid
+items:
+  - sku: a-1
+    price: 9.5
+    discount: false
+  - sku: "123"
+    price: 1
+    discount: true
+tags: []
+meta:
+  empty: ""
+  note: "line\nbreak"
+note: null
+`)
+	testHandler(t, mux, http.MethodGet, "/failed").statusCode(http.StatusInternalServerError)
+	testHandler(t, mux, http.MethodGet, "/unknown").statusCode(http.StatusOK).bodyEq("")
+	testHandler(t, mux, http.MethodGet, "/reports/csv").statusCode(http.StatusAccepted).
+		headerEq("Content-Type", "text/csv").bodyEq("id\n42\n")
+	testHandler(t, mux, http.MethodGet, "/reports/json").bodyEq("42")
+
+	// replaces a built-in one.
+	mux.RegisterEncoder("application/json", DispatcherFunc(func(w http.ResponseWriter, v interface{}) error {
+		_, err := w.Write([]byte("custom"))
+		return err
+	}))
+	testHandler(t, mux, http.MethodGet, "/reports/json").bodyEq("custom")
+}
+
+func TestRenderYAMLIndent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	(&yamlDispatcher{Indent: 4}).Dispatch(rec, []interface{}{[]int{1, 2}, map[string][]string{"key": {"yes"}}})
+
+	if expected, got := `-   - 1
+    - 2
+-   key:
+        - "yes"
+`, rec.Body.String(); expected != got {
+		t.Fatalf("expected body:\n%s\nbut got:\n%s", expected, got)
+	}
+}
+
+func TestRenderMsgPack(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := WriteMsgPack(rec, http.StatusOK, struct {
+		A int     `json:"a"`
+		B []int64 `json:"b"`
+		C string  `json:"c"`
+		D float64 `json:"d"`
+		E bool    `json:"e"`
+		F *int    `json:"f"`
+	}{A: 1, B: []int64{-1, -33, 200, 70000, 1 << 40}, C: "hi", D: 0.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0x86,
+		0xa1, 'a', 0x01,
+		0xa1, 'b', 0x95, 0xff, 0xd0, 0xdf, 0xcc, 0xc8, 0xce, 0x00, 0x01, 0x11, 0x70, 0xcf, 0, 0, 0x01, 0, 0, 0, 0, 0,
+		0xa1, 'c', 0xa2, 'h', 'i',
+		0xa1, 'd', 0xcb, 0x3f, 0xe0, 0, 0, 0, 0, 0, 0,
+		0xa1, 'e', 0xc2,
+		0xa1, 'f', 0xc0,
+	}
+	if got := rec.Body.Bytes(); !bytes.Equal(expected, got) {
+		t.Fatalf("expected body: %x but got: %x", expected, got)
+	}
+
+	if expected, got := "application/msgpack", rec.Header().Get("Content-Type"); expected != got {
+		t.Fatalf("expected content type: %s but got: %s", expected, got)
+	}
+}
+
+func TestWriteJSONP(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := WriteJSONP(rec, http.StatusOK, "app.onUser", map[string]int{"id": 42}); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := `/**/app.onUser({"id":42});`, rec.Body.String(); expected != got {
+		t.Fatalf("expected body: %s but got: %s", expected, got)
+	}
+	if expected, got := "application/javascript; charset=utf-8", rec.Header().Get("Content-Type"); expected != got {
+		t.Fatalf("expected content type: %s but got: %s", expected, got)
+	}
+
+	for _, callback := range []string{"", "alert(1)//", "a..b", "1a", "a-b"} {
+		rec := httptest.NewRecorder()
+		if err := WriteJSONP(rec, http.StatusOK, callback, 42); err != ErrJSONPCallback || rec.Body.Len() > 0 {
+			t.Fatalf("[%s] expected the ErrJSONPCallback but got: %v", callback, err)
+		}
+	}
+}
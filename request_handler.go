@@ -49,3 +49,78 @@ func (h Host) Match(r *http.Request) bool {
 	s := string(h)
 	return r.Host == s || (s[0] == '.' && strings.HasSuffix(r.Host, s)) || s == WildcardParamStart
 }
+
+// SubdomainParamKey is the parameter key that the `Mux#Host` stores the wildcard subdomain of the request,
+// if the host pattern does not give a name to it, i.e "*.example.com".
+const SubdomainParamKey = "subdomain"
+
+// hostMatcher is the Matcher of the `Mux#Host` and `Mux#Subdomain`.
+type hostMatcher struct {
+	host string // the exact host or its static suffix when wildcard or its static prefix when subdomain.
+
+	wildcard  bool
+	subdomain bool
+	paramKey  string
+}
+
+// newHostMatcher returns a Matcher for a host pattern, i.e "api.example.com", "*.example.com" or "*tenant.example.com".
+func newHostMatcher(pattern string) *hostMatcher {
+	if pattern == "" {
+		panic("muxie/Mux#Host: empty host pattern")
+	}
+
+	h := &hostMatcher{host: pattern}
+
+	if pattern[0] == WildcardParamStart[0] {
+		dotIdx := strings.IndexByte(pattern, '.')
+		if dotIdx == -1 {
+			panic("muxie/Mux#Host: wildcard subdomain without a domain: \"" + pattern + "\"")
+		}
+
+		h.wildcard = true
+		h.host = pattern[dotIdx:]
+		h.paramKey = pattern[1:dotIdx]
+		if h.paramKey == "" {
+			h.paramKey = SubdomainParamKey
+		}
+	}
+
+	return h
+}
+
+// hostname returns the request's host,
+// without its port if the pattern does not contain a port.
+func (h *hostMatcher) hostname(r *http.Request) string {
+	host := r.Host
+	if !strings.Contains(h.host, ":") {
+		if portIdx := strings.LastIndexByte(host, ':'); portIdx != -1 && !strings.HasSuffix(host, "]") {
+			host = host[:portIdx]
+		}
+	}
+
+	return host
+}
+
+// Match validates the host, implementing the `Matcher` interface.
+func (h *hostMatcher) Match(r *http.Request) bool {
+	_, ok := h.capture(r)
+	return ok
+}
+
+// capture returns the wildcard subdomain of the request, if the pattern is a wildcard one,
+// and reports whether the request's host matches the pattern.
+func (h *hostMatcher) capture(r *http.Request) (string, bool) {
+	host := h.hostname(r)
+
+	switch {
+	case h.wildcard:
+		if len(host) > len(h.host) && strings.HasSuffix(host, h.host) {
+			return host[:len(host)-len(h.host)], true
+		}
+		return "", false
+	case h.subdomain:
+		return "", strings.HasPrefix(host, h.host)
+	default:
+		return "", host == h.host
+	}
+}
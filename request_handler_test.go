@@ -1,6 +1,7 @@
 package muxie
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 )
@@ -57,3 +58,80 @@ func TestRequestHandler(t *testing.T) {
 	testHandler(t, mux, customMethod, "http://"+domain).
 		statusCode(http.StatusOK).bodyEq(customMethod)
 }
+
+func TestMuxHost(t *testing.T) {
+	const domain = "example.com"
+
+	mux := NewMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("root domain"))
+	})
+
+	api := mux.Host("api." + domain)
+	api.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("api user " + GetParam(w, "id")))
+	})
+
+	admin := mux.Subdomain("admin.")
+	admin.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("admin of " + r.Host))
+	})
+
+	tenants := mux.Host("*." + domain)
+	tenants.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tenant " + GetParam(w, SubdomainParamKey)))
+	})
+
+	named := mux.Host("*shop.shops.localhost:8080")
+	named.Of("/v1").HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("shop " + GetParam(w, "shop")))
+	})
+
+	testHandler(t, mux, http.MethodGet, "http://"+domain).
+		statusCode(http.StatusOK).bodyEq("root domain")
+	testHandler(t, mux, http.MethodGet, "http://api."+domain+":8080/users/42").
+		statusCode(http.StatusOK).bodyEq("api user 42")
+	testHandler(t, mux, http.MethodGet, "http://api."+domain+"/").
+		statusCode(http.StatusNotFound)
+	testHandler(t, mux, http.MethodGet, "http://admin.mysite.com").
+		statusCode(http.StatusOK).bodyEq("admin of admin.mysite.com")
+	testHandler(t, mux, http.MethodGet, "http://acme."+domain).
+		statusCode(http.StatusOK).bodyEq("tenant acme")
+	testHandler(t, mux, http.MethodGet, "http://eu.acme."+domain).
+		statusCode(http.StatusOK).bodyEq("tenant eu.acme")
+	testHandler(t, mux, http.MethodGet, "http://mine.shops.localhost:8080/v1").
+		statusCode(http.StatusOK).bodyEq("shop mine")
+	testHandler(t, mux, http.MethodGet, "http://mine.shops.localhost/v1").
+		statusCode(http.StatusNotFound)
+}
+
+func TestMuxHostInheritedOptions(t *testing.T) {
+	mux := NewMux()
+	mux.MaxParams = 2
+	mux.ParamsCapacity = 4
+	mux.ThreadSafe = true
+
+	api := mux.Host("api.example.com")
+	api.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %d", GetParam(w, "id"), cap(GetParams(w)))
+	})
+
+	tenants := mux.Of("/v1").Host("*.example.com")
+	tenants.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %d", GetParam(w, SubdomainParamKey), GetParam(w, "id"), cap(GetParams(w)))
+	})
+
+	testHandler(t, mux, http.MethodGet, "http://api.example.com/users/42").
+		statusCode(http.StatusOK).bodyEq("42 4")
+	testHandler(t, mux, http.MethodGet, "http://acme.example.com/v1/users/42").
+		statusCode(http.StatusOK).bodyEq("acme 42 4")
+
+	_, err := api.(*Mux).HandleErr("/users/:id/posts/:slug/*rest", http.NotFoundHandler())
+	if expected := `muxie/Mux#Handle: "/users/:id/posts/:slug/*rest" has 3 path parameters, more than the maximum of 2`; err == nil || err.Error() != expected {
+		t.Fatalf("expected error: %s but got: %v", expected, err)
+	}
+
+	if host := api.(*Mux); host.MaxParams != 2 || host.ParamsCapacity != 4 || !host.ThreadSafe || host.mu != mux.mu {
+		t.Fatalf("expected the host mux to inherit the options of the main one")
+	}
+}
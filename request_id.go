@@ -0,0 +1,151 @@
+package muxie
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the default header of the request IDs, see `RequestIDs`.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDOption is the type of the options that `RequestIDs` accepts.
+type RequestIDOption func(*requestIDs)
+
+// RequestIDHeaderName is a `RequestIDOption` which sets the header that the request IDs are read from
+// and written to. Defaults to the `RequestIDHeader`.
+func RequestIDHeaderName(header string) RequestIDOption {
+	return func(ids *requestIDs) {
+		ids.header = http.CanonicalHeaderKey(header)
+	}
+}
+
+// RequestIDGenerator is a `RequestIDOption` which sets the generator of the request IDs.
+// Defaults to 16 random bytes, hex encoded.
+func RequestIDGenerator(generate func() string) RequestIDOption {
+	return func(ids *requestIDs) {
+		ids.generate = generate
+	}
+}
+
+// RequestIDIgnoreIncoming is a `RequestIDOption` which generates a new ID for every request,
+// instead of accepting the ID of the request's header, i.e for the public servers which are not behind a trusted proxy.
+func RequestIDIgnoreIncoming() RequestIDOption {
+	return func(ids *requestIDs) {
+		ids.ignoreIncoming = true
+	}
+}
+
+type requestIDs struct {
+	header         string
+	generate       func() string
+	ignoreIncoming bool
+}
+
+// RequestIDs returns a middleware which gives an ID to each request, the ID of the request's "X-Request-ID" header
+// or a new generated one, it stores it to the request's context and it sends it back on the same response header, i.e:
+// mux.Wrap(muxie.Logger(), muxie.RequestIDs())
+//
+// The ID is retrieved by the `RequestID` and it is logged by the `Logger`,
+// the outgoing requests to other services can carry it through the `PropagateRequestID`.
+// The incoming IDs which are longer than 200 characters or contain not printable characters are replaced.
+func RequestIDs(options ...RequestIDOption) Wrapper {
+	ids := &requestIDs{header: RequestIDHeader, generate: newRequestID}
+	for _, opt := range options {
+		opt(ids)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := ""
+			if !ids.ignoreIncoming {
+				if id = r.Header.Get(ids.header); !isValidRequestID(id) {
+					id = ""
+				}
+			}
+
+			if id == "" {
+				id = ids.generate()
+			}
+
+			w.Header().Set(ids.header, id)
+			recordRequestID(w, id)
+
+			ctx := context.WithValue(r.Context(), requestIDContextKey{}, requestIDValue{header: ids.header, id: id})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("muxie/RequestIDs: " + err.Error())
+	}
+
+	return hex.EncodeToString(b[:])
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 200 {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+type requestIDContextKey struct{}
+
+type requestIDValue struct {
+	header string
+	id     string
+}
+
+// RequestID returns the ID that the `RequestIDs` gave to the request, or empty.
+func RequestID(r *http.Request) string {
+	return RequestIDFromContext(r.Context())
+}
+
+// RequestIDFromContext returns the request ID of a context which is derived from a request's context, see `RequestID`.
+func RequestIDFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(requestIDContextKey{}).(requestIDValue)
+	return v.id
+}
+
+// PropagateRequestID sets the request ID of the "out" request's context, if any,
+// to its header, so the called service can correlate its logs with the caller's ones, i.e:
+// out, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, "http://users/42", nil)
+// muxie.PropagateRequestID(out)
+func PropagateRequestID(out *http.Request) {
+	if v, ok := out.Context().Value(requestIDContextKey{}).(requestIDValue); ok {
+		out.Header.Set(v.header, v.id)
+	}
+}
+
+// requestIDRecorder is implemented by the http.ResponseWriter wrappers of the middlewares that run before the `RequestIDs`,
+// to be notified about the request's ID, see `Logger`.
+type requestIDRecorder interface {
+	recordRequestID(id string)
+}
+
+// recordRequestID notifies the `requestIDRecorder`s of the "w"'s `Unwrapper` chain, if any.
+func recordRequestID(w http.ResponseWriter, id string) {
+	for w != nil {
+		if rec, ok := w.(requestIDRecorder); ok {
+			rec.recordRequestID(id)
+		}
+
+		u, ok := w.(Unwrapper)
+		if !ok {
+			return
+		}
+		w = u.Unwrap()
+	}
+}
@@ -0,0 +1,72 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDs(t *testing.T) {
+	var logged []string
+
+	mux := NewMux()
+	mux.Wrap(Logger(LoggerFormat(func(e *LogEntry) string {
+		logged = append(logged, e.RequestID)
+		return ""
+	}), LoggerOutput(new(strings.Builder))), RequestIDs())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		out, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, "http://users/42", nil)
+		PropagateRequestID(out)
+		w.Write([]byte(RequestID(r) + " " + out.Header.Get(RequestIDHeader)))
+	})
+
+	serve := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if id != "" {
+			r.Header.Set(RequestIDHeader, id)
+		}
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("")
+	id := w.Header().Get(RequestIDHeader)
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(id) {
+		t.Fatalf("expected a generated request ID but got: '%s'", id)
+	}
+
+	if expected := id + " " + id; w.Body.String() != expected {
+		t.Fatalf("expected body: '%s' but got: '%s'", expected, w.Body.String())
+	}
+
+	if w = serve("abc-123"); w.Header().Get(RequestIDHeader) != "abc-123" || w.Body.String() != "abc-123 abc-123" {
+		t.Fatalf("expected the incoming request ID to be kept but got: '%s'", w.Header().Get(RequestIDHeader))
+	}
+
+	if w = serve("bad id\t"); w.Header().Get(RequestIDHeader) == "bad id\t" {
+		t.Fatalf("expected the invalid incoming request ID to be replaced")
+	}
+
+	if len(logged) != 3 || logged[0] != id || logged[1] != "abc-123" {
+		t.Fatalf("expected the request IDs to be logged but got: %q", logged)
+	}
+
+	custom := NewMux()
+	custom.Use(RequestIDs(RequestIDHeaderName("X-Correlation-ID"), RequestIDIgnoreIncoming(),
+		RequestIDGenerator(func() string { return "generated" })))
+	custom.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RequestID(r)))
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Correlation-ID", "incoming")
+	w = httptest.NewRecorder()
+	custom.ServeHTTP(w, r)
+
+	if w.Body.String() != "generated" || w.Header().Get("X-Correlation-ID") != "generated" {
+		t.Fatalf("expected the generated request ID but got: '%s'", w.Body.String())
+	}
+}
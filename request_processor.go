@@ -47,6 +47,7 @@ type Binder interface {
 
 // Bind accepts the current request and any `Binder` to bind
 // the request data to the "ptrOut".
+// See `BindBody` to bind it by the Content-Type of the request.
 func Bind(r *http.Request, b Binder, ptrOut interface{}) error {
 	return b.Bind(r, ptrOut)
 }
@@ -106,6 +107,22 @@ func (p *jsonProcessor) Bind(r *http.Request, v interface{}) error {
 }
 
 func (p *jsonProcessor) Dispatch(w http.ResponseWriter, v interface{}) error {
+	result, err := p.marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if len(p.Prefix) > 0 {
+		result = append([]byte(p.Prefix), result...)
+	}
+
+	w.Header().Set("Content-Type", withCharset("application/json"))
+	_, err = w.Write(result)
+	return err
+}
+
+// marshal returns the JSON encoding of the "v", indented and unescaped by the processor's fields, without its Prefix.
+func (p *jsonProcessor) marshal(v interface{}) ([]byte, error) {
 	var (
 		result []byte
 		err    error
@@ -122,7 +139,7 @@ func (p *jsonProcessor) Dispatch(w http.ResponseWriter, v interface{}) error {
 	}
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if p.UnescapeHTML {
@@ -131,13 +148,7 @@ func (p *jsonProcessor) Dispatch(w http.ResponseWriter, v interface{}) error {
 		result = bytes.Replace(result, andHex, and, -1)
 	}
 
-	if len(p.Prefix) > 0 {
-		result = append([]byte(p.Prefix), result...)
-	}
-
-	w.Header().Set("Content-Type", withCharset("application/json"))
-	_, err = w.Write(result)
-	return err
+	return result, nil
 }
 
 type xmlProcessor struct {
@@ -0,0 +1,425 @@
+package muxie
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Route is the handle of a registered path pattern, it is returned by the `Mux#Handle/HandleFunc/HandleMethod/HandleMethodFunc`
+// and it can be used to give a name to the route, i.e:
+// mux.HandleFunc("/user/:id:int", showUserHandler).Name("user.show")
+//
+// See `Mux#URL` too.
+type Route struct {
+	mux     *Mux
+	pattern string
+	name    string
+
+	// the HTTP methods of the `Mux#HandleMethod` routes, empty for any method.
+	methods []string
+	handler *routeHandler
+	// the handlers that this route replaced at its registration, by node, see `Route#When`.
+	replaced map[*Node]replacedHandlers
+	// not nil when the route has matchers, see `Route#When`.
+	conditional *conditionalHandler
+}
+
+// routeHandler gives an identity to the handler of a registered route.
+type routeHandler struct {
+	// the route's main handler wrapped by its middlewares, it is replaced by the `Route#Use`
+	// while the requests are served, see `Mux#ThreadSafe`.
+	handler atomic.Pointer[http.Handler]
+	// the route's main handler and the middlewares that the "handler" wraps it with.
+	main     http.Handler
+	wrappers Wrappers
+	// the processors of the route's path parameters, they run before the handler, see `Route#MatrixParams`.
+	paramProcessors []func(params ResponseWriter)
+	// the route's limit of the request bodies, it overrides the `Mux#MaxBodySize` when "hasBodyLimit" is true.
+	mux          *Mux
+	bodyLimit    int64
+	hasBodyLimit bool
+	// true if the route is served while its mux is in maintenance, see `Route#IgnoreMaintenance`.
+	ignoreMaintenance bool
+}
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.ignoreMaintenance {
+		if maintenance := h.mux.underMaintenance(); maintenance != nil {
+			maintenance.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	limit := h.bodyLimit
+	if !h.hasBodyLimit {
+		limit = h.mux.origin().MaxBodySize
+	}
+
+	if limit > 0 && !limitBody(h.mux, w, r, limit) {
+		return
+	}
+
+	if pw := findParamsWriter(w); pw != nil {
+		pw.mux = h.mux
+	}
+
+	if len(h.paramProcessors) > 0 {
+		if store, ok := paramsStore(w); ok {
+			for _, process := range h.paramProcessors {
+				process(store)
+			}
+		}
+	}
+
+	(*h.handler.Load()).ServeHTTP(w, r)
+}
+
+// compose wraps the route's main handler by its middlewares.
+func (h *routeHandler) compose() {
+	handler := h.wrappers.For(h.main)
+	h.handler.Store(&handler)
+}
+
+type replacedHandlers struct {
+	handler        http.Handler
+	methods        []string
+	methodHandlers map[string]http.Handler
+}
+
+// newRoute returns a new Route of the full "pattern", the "handler" is wrapped by the Mux' middlewares.
+// It keeps the handlers of the existing nodes of the "pattern" that it is going to replace.
+func (m *Mux) newRoute(pattern string, methods []string, handler http.Handler) *Route {
+	if handler == nil {
+		panic("muxie/Mux#Handle: empty handler")
+	}
+
+	if err := m.checkMaxParams(pattern); err != nil {
+		panic(err.Error())
+	}
+
+	route := &Route{
+		mux:     m,
+		pattern: pattern,
+		methods: methods,
+		handler: &routeHandler{
+			main:              handler,
+			wrappers:          append(Wrappers(nil), m.beginHandlers...),
+			mux:               m,
+			ignoreMaintenance: isHealthHandler(handler),
+		},
+	}
+	route.handler.compose()
+
+	for _, n := range m.Routes.nodes(pattern) {
+		if route.replaced == nil {
+			route.replaced = make(map[*Node]replacedHandlers)
+		}
+
+		replaced := replacedHandlers{
+			handler:        n.Handler,
+			methods:        n.methodsAllowed,
+			methodHandlers: make(map[string]http.Handler, len(n.methodHandlers)),
+		}
+		for method, h := range n.methodHandlers {
+			replaced.methodHandlers[method] = h
+		}
+
+		route.replaced[n] = replaced
+	}
+
+	return route
+}
+
+// checkMaxParams returns an error if the "pattern" has more path parameters than the `Mux#MaxParams`.
+func (m *Mux) checkMaxParams(pattern string) error {
+	maxParams := m.origin().MaxParams
+	if maxParams <= 0 {
+		return nil
+	}
+
+	if n := countPatternParams(pattern); n > maxParams {
+		return errors.New("muxie/Mux#Handle: \"" + pattern + "\" has " + strconv.Itoa(n) +
+			" path parameters, more than the maximum of " + strconv.Itoa(maxParams))
+	}
+
+	return nil
+}
+
+// countPatternParams returns the number of the named parameters and wildcards of a path pattern.
+func countPatternParams(pattern string) int {
+	if pattern == "" {
+		return 0
+	}
+
+	n := 0
+	for _, s := range slowPathSplit(pattern) {
+		if s != "" && (s[0] == ParamStart[0] || s[0] == WildcardParamStart[0]) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// exprValidators caches the compiled regular expression constraints for the `Route#URL`, constraint:validator.
+var exprValidators sync.Map
+
+// Pattern returns the full path pattern of the route, including the prefix of its Mux.
+func (r *Route) Pattern() string {
+	return r.pattern
+}
+
+// GetName returns the name of the route, if any.
+func (r *Route) GetName() string {
+	return r.name
+}
+
+// Name gives a name to the route, the name can be used to build its URL later on, through the `Mux#URL`.
+// The name is stored to the `Node#Tag` field as well.
+// Returns this Route for further calls.
+func (r *Route) Name(name string) *Route {
+	if name == "" {
+		panic("muxie/Route#Name: empty name")
+	}
+
+	r.mux.lock()
+	defer r.mux.unlock()
+
+	if r.name != "" {
+		delete(r.mux.namedRoutes, r.name)
+	}
+
+	r.name = name
+	r.mux.namedRoutes[name] = r
+	r.tag()
+
+	return r
+}
+
+// Priority sets the priority of the route, the routes with higher priority
+// win over the rest ones that can match the same path, i.e:
+// mux.HandleFunc("/users/new", newUserHandler)
+// mux.HandleFunc("/users/:id", userHandler).Priority(1)
+// The "/users/new" path is served by the "userHandler".
+//
+// Defaults to 0 but it is lost when the path pattern is registered again by the `Mux#Handle`,
+// the `Mux#HandleMethod` registrations of its rest methods keep it, see `WithPriority` too.
+// Returns this Route for further calls.
+func (r *Route) Priority(priority int) *Route {
+	r.mux.lock()
+	defer r.mux.unlock()
+
+	for _, n := range r.mux.Routes.nodes(r.pattern) {
+		n.setPriority(priority)
+	}
+
+	if priority != 0 {
+		r.mux.Routes.hasPriority = true
+	}
+
+	return r
+}
+
+// Meta attaches the "value" to the route by its "key", i.e its docs, rate limits or owners,
+// the handlers and the middlewares can retrieve it through the `RouteMeta`, see `Node#SetMeta` too.
+// It is shared by the routes of the rest methods of the path pattern and it is kept when the path pattern is registered again.
+// Returns this Route for further calls.
+func (r *Route) Meta(key string, value interface{}) *Route {
+	r.mux.lock()
+	defer r.mux.unlock()
+
+	for _, n := range r.mux.Routes.nodes(r.pattern) {
+		n.SetMeta(key, value)
+	}
+
+	return r
+}
+
+// Use wraps the route's handler with the given "middlewares", after the ones of its Mux, i.e:
+// mux.HandleFunc("/admin", adminHandler).Use(authMiddleware, auditMiddleware)
+//
+// See `Mux#With` too.
+// Returns this Route for further calls.
+func (r *Route) Use(middlewares ...Wrapper) *Route {
+	r.mux.lock()
+	r.handler.wrappers = append(r.handler.wrappers, middlewares...)
+	r.handler.compose()
+	r.mux.unlock()
+
+	return r
+}
+
+// tag stores the route's name to its nodes.
+func (r *Route) tag() {
+	for _, n := range r.mux.Routes.nodes(r.pattern) {
+		n.Tag = r.name
+	}
+}
+
+// URL returns the path of the route with its named parameters and wildcards replaced
+// by the given values, the "pairs" should be key-value pairs, i.e "id", "42".
+// It returns an error if a required parameter is missing or its value is not valid for its type or regular expression.
+// The values are escaped, the trailing optional parameters without a value are omitted.
+func (r *Route) URL(pairs ...string) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", errors.New("muxie/Route#URL: odd number of key-value pairs for \"" + r.pattern + "\"")
+	}
+
+	if r.pattern == pathSep {
+		return pathSep, nil
+	}
+
+	values := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		values[pairs[i]] = pairs[i+1]
+	}
+
+	var b strings.Builder
+	for _, s := range slowPathSplit(r.pattern) {
+		if s == "" {
+			b.WriteString(pathSep)
+			continue
+		}
+
+		switch s[0] {
+		case ParamStart[0]:
+			segment := s[1:]
+			optional := strings.HasSuffix(segment, OptionalParamEnd)
+			if optional {
+				segment = segment[:len(segment)-len(OptionalParamEnd)]
+			}
+
+			name, constraint := splitParam(segment)
+			value, ok := values[name]
+			if !ok {
+				if optional {
+					return b.String(), nil
+				}
+
+				return "", errors.New("muxie/Route#URL: missing parameter \"" + name + "\" for \"" + r.pattern + "\"")
+			}
+
+			if constraint != "" {
+				validator, err := r.validator(constraint)
+				if err != nil {
+					return "", errors.New("muxie/Route#URL: " + err.Error() + " of \"" + r.pattern + "\"")
+				}
+
+				if !validator(value) {
+					return "", errors.New("muxie/Route#URL: invalid value \"" + value + "\" of parameter \"" + name + "\" for \"" + r.pattern + "\"")
+				}
+			}
+
+			b.WriteString(pathSep)
+			b.WriteString(url.PathEscape(value))
+		case WildcardParamStart[0]:
+			name := wildcardName(s)
+			value, ok := values[name]
+			if !ok {
+				return "", errors.New("muxie/Route#URL: missing wildcard parameter \"" + name + "\" for \"" + r.pattern + "\"")
+			}
+
+			for _, part := range strings.Split(strings.TrimPrefix(value, pathSep), pathSep) {
+				b.WriteString(pathSep)
+				b.WriteString(url.PathEscape(part))
+			}
+		default:
+			b.WriteString(pathSep)
+			b.WriteString(s)
+		}
+	}
+
+	return b.String(), nil
+}
+
+func (r *Route) validator(constraint string) (ParamValidator, error) {
+	if constraint[0] != ParamExprStart {
+		return compileParamConstraint(constraint)
+	}
+
+	if v, ok := exprValidators.Load(constraint); ok {
+		return v.(ParamValidator), nil
+	}
+
+	validator, err := compileParamConstraint(constraint)
+	if err != nil {
+		return nil, err
+	}
+
+	exprValidators.Store(constraint, validator)
+	return validator, nil
+}
+
+// URL returns the path of the route registered with the given "name",
+// with its named parameters and wildcards replaced by the given values, i.e:
+// mux.HandleFunc("/user/:id:int", showUserHandler).Name("user.show")
+// mux.URL("user.show", "id", "42") returns "/user/42".
+//
+// See `Route#URL` for more.
+func (m *Mux) URL(name string, pairs ...string) (string, error) {
+	route := m.GetRoute(name)
+	if route == nil {
+		return "", errors.New("muxie/Mux#URL: route with name \"" + name + "\" not found")
+	}
+
+	return route.URL(pairs...)
+}
+
+// GetRoute returns the route registered with the given "name" or nil.
+func (m *Mux) GetRoute(name string) *Route {
+	m.rlock()
+	route := m.namedRoutes[name]
+	m.runlock()
+	return route
+}
+
+// RouteInfo describes a registered route, see `Mux#ListRoutes`.
+type RouteInfo struct {
+	// Pattern is the full path pattern of the route.
+	Pattern string
+	// Methods are the HTTP methods that the route is registered for through the `Mux#HandleMethod`,
+	// empty if the route accepts any method.
+	Methods []string
+	// Name is the name of the route, if any, see `Route#Name`.
+	Name string
+	// Handler is the route's handler, for the `Mux#HandleMethod` routes it is a `MethodHandler` of their handlers.
+	Handler http.Handler
+	// Data is the optional data of the route's node, see `WithData`.
+	Data interface{}
+	// Meta is a copy of the metadata of the route, see `Route#Meta`.
+	Meta map[string]interface{}
+}
+
+// ListRoutes returns the information of all the registered routes of this Mux (and its sub muxes but host ones),
+// sorted by their path segments. Useful for generating docs and admin dashboards.
+//
+// See `Trie#Walk` too.
+func (m *Mux) ListRoutes() []RouteInfo {
+	m.rlock()
+	defer m.runlock()
+
+	var routes []RouteInfo
+	m.Routes.walk(func(n *Node) bool {
+		route := RouteInfo{
+			Pattern: n.key,
+			Name:    n.Tag,
+			Handler: n.handler(),
+			Data:    n.Data,
+			Meta:    n.meta.all(),
+		}
+
+		if methodHandler, ok := route.Handler.(*MethodHandler); ok {
+			route.Methods = methodHandler.AllowedMethods()
+		}
+
+		routes = append(routes, route)
+		return true
+	})
+
+	return routes
+}
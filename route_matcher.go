@@ -0,0 +1,298 @@
+package muxie
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// conditionalHandler is the handler of a route with matchers, see `Route#When`.
+type conditionalHandler struct {
+	methods  []string // empty for any method.
+	matchers []Matcher
+	handler  http.Handler
+	// the query keys that their values are stored as parameters, see `Route#QueryParam`.
+	queryParams []string
+	// the media types that the handler responds with, see `Route#Accepts`.
+	mediaTypes []string
+}
+
+func (c *conditionalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if len(c.queryParams) > 0 {
+		if store, ok := paramsStore(w); ok {
+			query := r.URL.Query()
+			for _, key := range c.queryParams {
+				store.Set(key, query.Get(key))
+			}
+		}
+	}
+
+	c.handler.ServeHTTP(w, r)
+}
+
+// match reports whether the request passes the handler's methods and matchers,
+// a HEAD request can be served by a GET handler, see `Mux#HandleMethod`.
+func (c *conditionalHandler) match(r *http.Request) bool {
+	if len(c.methods) > 0 {
+		found := false
+		for _, method := range c.methods {
+			if r.Method == method || (r.Method == http.MethodHead && method == http.MethodGet) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	for _, matcher := range c.matchers {
+		if !matcher.Match(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// conditionalHandler returns the handler of the newest route with matchers that the request passes, if any,
+// the routes with media types are negotiated after the rest ones, see `Route#Accepts`.
+// It reports whether the request's "Accept" header was negotiated as well.
+func (n *NodeOf[T]) conditionalHandler(r *http.Request) (http.Handler, bool) {
+	for i := len(n.conditionals) - 1; i >= 0; i-- {
+		if c := n.conditionals[i]; len(c.mediaTypes) == 0 && c.match(r) {
+			return c.forMethod(r.Method), false
+		}
+	}
+
+	var (
+		accept     []acceptRange
+		negotiated bool
+		best       *conditionalHandler
+		bestQ      float64
+		bestRank   int
+	)
+
+	// the oldest one wins on ties.
+	for _, c := range n.conditionals {
+		if len(c.mediaTypes) == 0 || !c.match(r) {
+			continue
+		}
+
+		if !negotiated {
+			negotiated = true
+			accept = parseAccept(r.Header.Get("Accept"))
+		}
+
+		for _, mediaType := range c.mediaTypes {
+			if q, rank := acceptQuality(accept, mediaType); q > bestQ || (q == bestQ && rank > bestRank) {
+				best, bestQ, bestRank = c, q, rank
+			}
+		}
+	}
+
+	if best == nil {
+		return nil, negotiated
+	}
+
+	return best.forMethod(r.Method), true
+}
+
+// forMethod returns the handler for a request's "method", a HEAD request is served by a GET handler without the response body.
+func (c *conditionalHandler) forMethod(method string) http.Handler {
+	if method == http.MethodHead && len(c.methods) > 0 && !containsMethod(c.methods, http.MethodHead) {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serveHead(c, w, r)
+		})
+	}
+
+	return c
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+// When adds matchers to the route, the route serves only the requests that pass all of them,
+// the rest requests of its path are served by the route, if any, which was registered for the same path before this one, i.e:
+// mux.HandleFunc("/users", usersV1)
+// mux.HandleFunc("/users", usersV2).Headers("X-API-Version", "2")
+//
+// The newest route with matchers has priority over the older ones of the same path.
+// Returns this Route for further calls.
+func (r *Route) When(matchers ...Matcher) *Route {
+	r.mux.lock()
+	r.when(matchers...)
+	r.mux.unlock()
+
+	return r
+}
+
+func (r *Route) when(matchers ...Matcher) {
+	if r.conditional == nil {
+		r.conditional = &conditionalHandler{methods: r.methods, handler: r.handler}
+
+		for _, n := range r.mux.Routes.nodes(r.pattern) {
+			n.conditionals = append(n.conditionals[0:len(n.conditionals):len(n.conditionals)], r.conditional)
+			r.restore(n)
+		}
+	}
+
+	r.conditional.matchers = append(r.conditional.matchers, matchers...)
+}
+
+// restore gives back the node's handlers that this route replaced, if the node still holds the route's handler.
+func (r *Route) restore(n *Node) {
+	replaced := r.replaced[n]
+
+	if len(r.methods) == 0 {
+		if n.Handler != r.handler {
+			return
+		}
+
+		n.Handler = replaced.handler
+		for _, method := range replaced.methods {
+			n.setMethodHandler(method, replaced.methodHandlers[method])
+		}
+		return
+	}
+
+	for _, method := range r.methods {
+		if n.methodHandlers[method] != r.handler {
+			continue
+		}
+
+		if h, ok := replaced.methodHandlers[method]; ok {
+			n.setMethodHandler(method, h)
+		} else {
+			n.removeMethodHandler(method)
+		}
+	}
+}
+
+// Headers adds matchers to the route, see `When`, which require the request headers of the given key-value pairs, i.e:
+// Headers("X-API-Version", "2", "X-Requested-With", "") requires the "X-API-Version" header to be "2"
+// and the "X-Requested-With" header to be present, with any value.
+// Returns this Route for further calls.
+func (r *Route) Headers(pairs ...string) *Route {
+	if len(pairs)%2 != 0 {
+		panic("muxie/Route#Headers: odd number of key-value pairs for \"" + r.pattern + "\"")
+	}
+
+	matchers := make([]Matcher, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		matchers = append(matchers, headerMatcher{key: http.CanonicalHeaderKey(pairs[i]), value: pairs[i+1]})
+	}
+
+	return r.When(matchers...)
+}
+
+// HeadersRegexp adds matchers to the route, see `When`, which require the request headers of the given key-value pairs
+// to match the regular expressions of the values, i.e:
+// HeadersRegexp("X-API-Version", `^2(\.[0-9]+)?$`)
+// Returns this Route for further calls.
+func (r *Route) HeadersRegexp(pairs ...string) *Route {
+	if len(pairs)%2 != 0 {
+		panic("muxie/Route#HeadersRegexp: odd number of key-value pairs for \"" + r.pattern + "\"")
+	}
+
+	matchers := make([]Matcher, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		expr, err := regexp.Compile(pairs[i+1])
+		if err != nil {
+			panic("muxie/Route#HeadersRegexp: " + err.Error() + " of \"" + r.pattern + "\"")
+		}
+
+		matchers = append(matchers, headerRegexpMatcher{key: http.CanonicalHeaderKey(pairs[i]), expr: expr})
+	}
+
+	return r.When(matchers...)
+}
+
+// Query adds a matcher to the route, see `When`, which requires the given query parameters to be present, i.e:
+// mux.HandleFunc("/search", searchHandler).Query("q")
+// The requests without them are served by the previous route of the same path, if any, or they are not found.
+// Returns this Route for further calls.
+func (r *Route) Query(keys ...string) *Route {
+	if len(keys) == 0 {
+		panic("muxie/Route#Query: empty keys for \"" + r.pattern + "\"")
+	}
+
+	return r.When(queryMatcher(keys))
+}
+
+// QueryParam adds a matcher to the route which requires the given query parameters to be present, like `Query` does,
+// and it stores their values as parameters which can be retrieved by the `GetParam`, i.e:
+// mux.HandleFunc("/search", searchHandler).QueryParam("q")
+// The "searchHandler" can retrieve the value through the `GetParam(w, "q")`.
+// Returns this Route for further calls.
+func (r *Route) QueryParam(keys ...string) *Route {
+	r.Query(keys...)
+
+	r.mux.lock()
+	r.conditional.queryParams = append(r.conditional.queryParams, keys...)
+	r.mux.unlock()
+
+	return r
+}
+
+// queryMatcher requires the query parameters of its keys to be present.
+type queryMatcher []string
+
+func (keys queryMatcher) Match(r *http.Request) bool {
+	query := r.URL.Query()
+	for _, key := range keys {
+		if _, ok := query[key]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// headerMatcher requires a request header to be present with a specific value, or with any value when empty.
+type headerMatcher struct {
+	key   string
+	value string
+}
+
+func (h headerMatcher) Match(r *http.Request) bool {
+	values, ok := r.Header[h.key]
+	if !ok {
+		return false
+	}
+
+	if h.value == "" {
+		return true
+	}
+
+	for _, v := range values {
+		if v == h.value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// headerRegexpMatcher requires a request header to match a regular expression.
+type headerRegexpMatcher struct {
+	key  string
+	expr *regexp.Regexp
+}
+
+func (h headerRegexpMatcher) Match(r *http.Request) bool {
+	for _, v := range r.Header[h.key] {
+		if h.expr.MatchString(v) {
+			return true
+		}
+	}
+
+	return false
+}
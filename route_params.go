@@ -0,0 +1,133 @@
+package muxie
+
+import "strings"
+
+// MatrixParamSep is the character which separates the matrix parameters of a path segment, i.e "/map/point;lat=50;lon=20".
+const MatrixParamSep = ";"
+
+// paramKeys returns the keys of the route's named parameters and, if "wildcards" is true, of its wildcards.
+func (r *Route) paramKeys(wildcards bool) []string {
+	var keys []string
+	for _, s := range slowPathSplit(r.pattern) {
+		if s == "" {
+			continue
+		}
+
+		switch s[0] {
+		case ParamStart[0]:
+			name, _ := splitParam(strings.TrimSuffix(s[1:], OptionalParamEnd))
+			keys = append(keys, name)
+		case WildcardParamStart[0]:
+			if wildcards {
+				keys = append(keys, wildcardName(s))
+			}
+		}
+	}
+
+	return keys
+}
+
+// processParams adds a processor of the route's path parameters, it runs before the route's handler and middlewares.
+func (r *Route) processParams(process func(params ResponseWriter)) {
+	r.mux.lock()
+	r.handler.paramProcessors = append(r.handler.paramProcessors, process)
+	r.mux.unlock()
+}
+
+// MatrixParams parses the matrix parameters of the route's named parameters, i.e:
+// mux.HandleFunc("/map/:point", mapHandler).MatrixParams()
+// The "/map/center;lat=50;lon=20" path gives the "center" to the "point" parameter,
+// and the "50" and "20" to the "lat" and "lon" ones, they can be retrieved by the `GetParam` as well.
+//
+// Only the values of the named parameters are parsed, the typed ones validate the whole path segment.
+// Returns this Route for further calls.
+func (r *Route) MatrixParams() *Route {
+	keys := r.paramKeys(false)
+	if len(keys) == 0 {
+		panic("muxie/Route#MatrixParams: \"" + r.pattern + "\" has not any named parameters")
+	}
+
+	r.processParams(func(params ResponseWriter) {
+		all := params.GetAll()
+		// the matrix parameters are appended, so only the path parameters are visited.
+		for i, n := 0, len(all); i < n; i++ {
+			p := all[i]
+			sepIdx := strings.Index(p.Value, MatrixParamSep)
+			if sepIdx == -1 || !containsKey(keys, p.Key) {
+				continue
+			}
+
+			all[i].Value = p.Value[:sepIdx]
+			for _, pair := range strings.Split(p.Value[sepIdx+1:], MatrixParamSep) {
+				key, value := pair, ""
+				if eqIdx := strings.IndexByte(pair, '='); eqIdx != -1 {
+					key, value = pair[:eqIdx], pair[eqIdx+1:]
+				}
+
+				if key != "" {
+					params.Set(key, value)
+				}
+			}
+
+			all = params.GetAll()
+		}
+	})
+
+	return r
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParamTransformer transforms a path parameter's value before the route's handler sees it, see `Route#Transform`.
+type ParamTransformer func(value string) string
+
+var (
+	// Lowercase is a `ParamTransformer` which returns the value in lower case.
+	Lowercase ParamTransformer = strings.ToLower
+	// Uppercase is a `ParamTransformer` which returns the value in upper case.
+	Uppercase ParamTransformer = strings.ToUpper
+	// TrimSpace is a `ParamTransformer` which removes the leading and trailing white space of the value.
+	TrimSpace ParamTransformer = strings.TrimSpace
+)
+
+// Transform registers transformers for the value of the route's "key" path parameter,
+// they run in order before the route's handler and middlewares, i.e:
+// mux.HandleFunc("/users/:username", userHandler).Transform("username", muxie.TrimSpace, muxie.Lowercase)
+// The "/users/%20Kataras" path gives the "kataras" to the "username" parameter.
+//
+// The typed named parameters are validated before their values are transformed.
+// Returns this Route for further calls.
+func (r *Route) Transform(key string, transformers ...ParamTransformer) *Route {
+	if !containsKey(r.paramKeys(true), key) {
+		panic("muxie/Route#Transform: \"" + r.pattern + "\" has not a \"" + key + "\" parameter")
+	}
+
+	for _, transformer := range transformers {
+		if transformer == nil {
+			panic("muxie/Route#Transform: empty transformer for \"" + key + "\" of \"" + r.pattern + "\"")
+		}
+	}
+
+	r.processParams(func(params ResponseWriter) {
+		all := params.GetAll()
+		for i := range all {
+			if all[i].Key != key {
+				continue
+			}
+
+			for _, transformer := range transformers {
+				all[i].Value = transformer(all[i].Value)
+			}
+		}
+	})
+
+	return r
+}
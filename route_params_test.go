@@ -0,0 +1,60 @@
+package muxie
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRouteMatrixParams(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/map/:point/:zoom?", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s lat=%s lon=%s zoom=%s:%s ", GetParam(w, "point"), GetParam(w, "lat"), GetParam(w, "lon"),
+			GetParam(w, "zoom"), GetParam(w, "level"))
+		EachParam(w, func(key, value string) bool {
+			fmt.Fprintf(w, "%s=%s;", key, value)
+			return true
+		})
+	}).MatrixParams()
+	mux.HandleFunc("/raw/:point", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, GetParam(w, "point"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/map/center;lat=50;lon=20").
+		bodyEq("center lat=50 lon=20 zoom=: point=center;lat=50;lon=20;")
+	testHandler(t, mux, http.MethodGet, "/map/center;lat=50/z;level=3;debug").
+		bodyEq("center lat=50 lon= zoom=z:3 point=center;zoom=z;lat=50;level=3;debug=;")
+	testHandler(t, mux, http.MethodGet, "/map/center").
+		bodyEq("center lat= lon= zoom=: point=center;")
+	testHandler(t, mux, http.MethodGet, "/raw/center;lat=50").
+		bodyEq("center;lat=50")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a route without named parameters")
+		}
+	}()
+
+	mux.HandleFunc("/files/*path", func(w http.ResponseWriter, r *http.Request) {}).MatrixParams()
+}
+
+func TestRouteTransform(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/users/:username/*path", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", GetParam(w, "username"), GetParam(w, "path"))
+	}).Transform("username", TrimSpace, Lowercase).Transform("path", func(value string) string {
+		return strings.TrimSuffix(value, ".json")
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/%20Kataras%20/posts/1.json").
+		bodyEq("kataras posts/1")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a missing parameter")
+		}
+	}()
+
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {}).Transform("username", Lowercase)
+}
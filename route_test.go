@@ -0,0 +1,281 @@
+package muxie
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMuxURL(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {}).Name("index")
+	users := mux.Of("/users")
+	users.HandleFunc("/:id:int", func(w http.ResponseWriter, r *http.Request) {}).Name("user.show")
+	users.HandleMethodFunc(http.MethodGet, "/:id:int/posts/:slug([a-z-]+)", func(w http.ResponseWriter, r *http.Request) {}).Name("user.post")
+	mux.HandleFunc("/archive/:year/:month?", func(w http.ResponseWriter, r *http.Request) {}).Name("archive")
+	mux.HandleFunc("/files/*filepath", func(w http.ResponseWriter, r *http.Request) {}).Name("files")
+
+	for _, tt := range []struct {
+		name     string
+		pairs    []string
+		expected string
+		ok       bool
+	}{
+		{"index", nil, "/", true},
+		{"user.show", []string{"id", "42"}, "/users/42", true},
+		{"user.show", []string{"id", "kataras"}, "", false},
+		{"user.show", nil, "", false},
+		{"user.show", []string{"id"}, "", false},
+		{"user.post", []string{"id", "42", "slug", "hello-world"}, "/users/42/posts/hello-world", true},
+		{"user.post", []string{"id", "42", "slug", "Hello"}, "", false},
+		{"archive", []string{"year", "2024"}, "/archive/2024", true},
+		{"archive", []string{"year", "2024", "month", "05"}, "/archive/2024/05", true},
+		{"files", []string{"filepath", "css/my main.css"}, "/files/css/my%20main.css", true},
+		{"unknown", nil, "", false},
+	} {
+		got, err := mux.URL(tt.name, tt.pairs...)
+		if tt.ok && err != nil {
+			t.Fatalf("%s%v: unexpected error: %v", tt.name, tt.pairs, err)
+		}
+
+		if !tt.ok && err == nil {
+			t.Fatalf("%s%v: expected an error but got URL: %s", tt.name, tt.pairs, got)
+		}
+
+		if expected := tt.expected; expected != got {
+			t.Fatalf("%s%v: expected URL to be: '%s' but got: '%s'", tt.name, tt.pairs, expected, got)
+		}
+	}
+
+	if expected, got := "user.show", mux.Routes.Search("/users/42", new(paramsWriter)).Tag; expected != got {
+		t.Fatalf("expected the node's tag to be: '%s' but got: '%s'", expected, got)
+	}
+}
+
+func TestMuxListRoutes(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {}).Name("index")
+	mux.HandleMethodFunc("GET, POST", "/users", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/users/:id/:tab?", func(w http.ResponseWriter, r *http.Request) {}).Name("user")
+	mux.Routes.Insert("/about", WithHandler(http.NotFoundHandler()), WithData("docs"))
+
+	routes := mux.ListRoutes()
+	expected := []RouteInfo{
+		{Pattern: "/", Name: "index"},
+		{Pattern: "/about", Data: "docs"},
+		{Pattern: "/users", Methods: []string{http.MethodGet, http.MethodPost}},
+		{Pattern: "/users/:id/:tab?", Name: "user"},
+	}
+
+	if len(expected) != len(routes) {
+		t.Fatalf("expected %d routes but got %d: %#+v", len(expected), len(routes), routes)
+	}
+
+	for i, route := range routes {
+		if e := expected[i]; e.Pattern != route.Pattern || e.Name != route.Name || e.Data != route.Data ||
+			strings.Join(e.Methods, ",") != strings.Join(route.Methods, ",") || route.Handler == nil {
+			t.Fatalf("[%d] expected route: %#+v but got: %#+v", i, e, route)
+		}
+	}
+}
+
+func TestRouteHeaders(t *testing.T) {
+	writeVersion := func(version string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s %s %s", r.Method, version, GetParam(w, "id"))
+		}
+	}
+
+	mux := NewMux()
+	mux.MethodNotAllowed = true
+	mux.HandleFunc("/users/:id", writeVersion("v1"))
+	mux.HandleFunc("/users/:id", writeVersion("v2")).Headers("X-API-Version", "2")
+	mux.HandleFunc("/users/:id", writeVersion("v3")).HeadersRegexp("X-API-Version", `^3(\.[0-9]+)?$`)
+	mux.HandleFunc("/users/:id", writeVersion("ajax")).Headers("X-Requested-With", "")
+
+	mux.HandleMethodFunc(http.MethodGet, "/posts", writeVersion("v1"))
+	mux.HandleMethodFunc(http.MethodGet, "/posts", writeVersion("v2")).Headers("X-API-Version", "2")
+	mux.HandleMethodFunc(http.MethodPost, "/posts", writeVersion("v1"))
+
+	mux.HandleMethodFunc(http.MethodGet, "/only-v2", writeVersion("v2")).Headers("X-API-Version", "2")
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL+"/users/42").
+		statusCode(http.StatusOK).bodyEq("GET v1 42")
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("X-API-Version", "2")).
+		statusCode(http.StatusOK).bodyEq("GET v2 42")
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("X-API-Version", "3.1")).
+		statusCode(http.StatusOK).bodyEq("GET v3 42")
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("X-API-Version", "4")).
+		statusCode(http.StatusOK).bodyEq("GET v1 42")
+	// the newest one has priority.
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("X-API-Version", "2"), withHeader("X-Requested-With", "XMLHttpRequest")).
+		statusCode(http.StatusOK).bodyEq("GET ajax 42")
+
+	expect(t, http.MethodGet, srv.URL+"/posts").
+		statusCode(http.StatusOK).bodyEq("GET v1 ")
+	expect(t, http.MethodGet, srv.URL+"/posts", withHeader("X-API-Version", "2")).
+		statusCode(http.StatusOK).bodyEq("GET v2 ")
+	expect(t, http.MethodPost, srv.URL+"/posts", withHeader("X-API-Version", "2")).
+		statusCode(http.StatusOK).bodyEq("POST v1 ")
+	expect(t, http.MethodHead, srv.URL+"/posts", withHeader("X-API-Version", "2")).
+		statusCode(http.StatusOK)
+
+	expect(t, http.MethodGet, srv.URL+"/only-v2", withHeader("X-API-Version", "2")).
+		statusCode(http.StatusOK).bodyEq("GET v2 ")
+	expect(t, http.MethodGet, srv.URL+"/only-v2").
+		statusCode(http.StatusNotFound)
+}
+
+func TestRouteQuery(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "search form")
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "results of %s", GetParam(w, "q"))
+	}).QueryParam("q")
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "results of %s, page %s", GetParam(w, "q"), r.URL.Query().Get("page"))
+	}).QueryParam("q").Query("page")
+	mux.HandleFunc("/export", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "export")
+	}).Query("format")
+
+	testHandler(t, mux, http.MethodGet, "/search").
+		statusCode(http.StatusOK).bodyEq("search form")
+	testHandler(t, mux, http.MethodGet, "/search?q=muxie").
+		statusCode(http.StatusOK).bodyEq("results of muxie")
+	testHandler(t, mux, http.MethodGet, "/search?q=muxie&page=2").
+		statusCode(http.StatusOK).bodyEq("results of muxie, page 2")
+	testHandler(t, mux, http.MethodGet, "/search?page=2").
+		statusCode(http.StatusOK).bodyEq("search form")
+	testHandler(t, mux, http.MethodGet, "/export?format=csv").
+		statusCode(http.StatusOK).bodyEq("export")
+	testHandler(t, mux, http.MethodGet, "/export").
+		statusCode(http.StatusNotFound)
+}
+
+func TestRoutePriority(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/users/new", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "new user")
+	})
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s", GetParam(w, "id"))
+	}).Priority(1)
+
+	testHandler(t, mux, http.MethodGet, "/users/new").bodyEq("user new")
+	testHandler(t, mux, http.MethodGet, "/users/42").bodyEq("user 42")
+
+	// the registrations of the rest methods keep the priority.
+	mux = NewMux()
+	mux.GET("/u/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "get user %s", GetParam(w, "id"))
+	})).Priority(10)
+	mux.GET("/u/new", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "new user form")
+	}))
+	mux.HandleMethodFunc(http.MethodPost, "/u/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "update user %s", GetParam(w, "id"))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/u/new").bodyEq("get user new")
+	testHandler(t, mux, http.MethodPost, "/u/new").bodyEq("update user new")
+
+	// a path pattern which is registered again by the Handle loses it.
+	mux.HandleFunc("/u/:id", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "user %s", GetParam(w, "id"))
+	})
+	testHandler(t, mux, http.MethodGet, "/u/new").bodyEq("new user form")
+}
+
+func TestRouteMeta(t *testing.T) {
+	mux := NewMux()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		rate, ok := RouteMeta(w, "rate")
+		fmt.Fprintf(w, "%v %v", rate, ok)
+	}
+	mux.HandleFunc("/search/:q?", handler).Meta("rate", 10)
+	mux.HandleFunc("/users", handler)
+
+	testHandler(t, mux, http.MethodGet, "/search").bodyEq("10 true")
+	testHandler(t, mux, http.MethodGet, "/search/muxie").bodyEq("10 true")
+	testHandler(t, mux, http.MethodGet, "/users").bodyEq("<nil> false")
+
+	// the routes of the rest methods share it.
+	mux.GET("/x", http.HandlerFunc(handler)).Meta("rate", 20)
+	mux.POST("/x", http.HandlerFunc(handler))
+	testHandler(t, mux, http.MethodGet, "/x").bodyEq("20 true")
+	testHandler(t, mux, http.MethodPost, "/x").bodyEq("20 true")
+
+	for _, route := range mux.ListRoutes() {
+		if route.Pattern == "/x" && route.Meta["rate"] != 20 {
+			t.Fatalf("expected the route info to have the rate metadata but got: %v", route.Meta)
+		}
+		if route.Pattern == "/users" && route.Meta != nil {
+			t.Fatalf("expected the route info to have no metadata but got: %v", route.Meta)
+		}
+	}
+
+	if _, ok := RouteMeta(httptest.NewRecorder(), "rate"); ok {
+		t.Fatalf("expected no metadata outside of the mux")
+	}
+}
+
+func TestMuxMaxParams(t *testing.T) {
+	mux := NewMux()
+	mux.MaxParams = 2
+	mux.ParamsCapacity = 2
+
+	mux.HandleFunc("/users/:id/posts/:slug?", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %d", GetParam(w, "id"), GetParam(w, "slug"), cap(GetParams(w)))
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42/posts/hello").
+		statusCode(http.StatusOK).bodyEq("42 hello 2")
+
+	_, err := mux.HandleErr("/users/:id/posts/:slug/*rest", http.NotFoundHandler())
+	if expected := `muxie/Mux#Handle: "/users/:id/posts/:slug/*rest" has 3 path parameters, more than the maximum of 2`; err == nil || err.Error() != expected {
+		t.Fatalf("expected error: %s but got: %v", expected, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for a path pattern with more path parameters than the MaxParams")
+		}
+	}()
+
+	mux.Of("/api/:version").HandleFunc("/users/:id/:tab", func(w http.ResponseWriter, r *http.Request) {})
+}
+
+func TestRouteMiddlewares(t *testing.T) {
+	withHeaderValue := func(value string) Wrapper {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Chain", value)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	printChain := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, w.Header()["X-Chain"])
+	}
+
+	mux := NewMux()
+	mux.Use(withHeaderValue("mux"))
+	mux.HandleFunc("/", printChain)
+	mux.HandleFunc("/admin", printChain).Use(withHeaderValue("auth")).Use(withHeaderValue("audit"))
+	mux.With(withHeaderValue("with")).HandleFunc("/users/:id", printChain)
+	mux.HandleFunc("/posts", printChain)
+
+	testHandler(t, mux, http.MethodGet, "/").bodyEq("[mux]")
+	testHandler(t, mux, http.MethodGet, "/admin").bodyEq("[mux auth audit]")
+	testHandler(t, mux, http.MethodGet, "/users/42").bodyEq("[mux with]")
+	testHandler(t, mux, http.MethodGet, "/posts").bodyEq("[mux]")
+}
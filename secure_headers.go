@@ -0,0 +1,134 @@
+package muxie
+
+import (
+	"net/http"
+)
+
+// SecureHeadersOption is the type of the options that `SecureHeaders` and `SecureHeadersOverride` accept,
+// an option with an empty value removes its header.
+type SecureHeadersOption func(*secureHeaders)
+
+// SecureHSTS is a `SecureHeadersOption` which sets the "Strict-Transport-Security" header, i.e "max-age=63072000; includeSubDomains; preload".
+// It is sent only to the HTTPS requests, directly or through a proxy which sets the "X-Forwarded-Proto" header.
+// Defaults to "max-age=31536000; includeSubDomains".
+func SecureHSTS(value string) SecureHeadersOption {
+	return func(s *secureHeaders) {
+		s.set("Strict-Transport-Security", value)
+	}
+}
+
+// SecureContentTypeOptions is a `SecureHeadersOption` which sets the "X-Content-Type-Options" header. Defaults to "nosniff".
+func SecureContentTypeOptions(value string) SecureHeadersOption {
+	return func(s *secureHeaders) {
+		s.set("X-Content-Type-Options", value)
+	}
+}
+
+// SecureFrameOptions is a `SecureHeadersOption` which sets the "X-Frame-Options" header, i.e "SAMEORIGIN". Defaults to "DENY".
+func SecureFrameOptions(value string) SecureHeadersOption {
+	return func(s *secureHeaders) {
+		s.set("X-Frame-Options", value)
+	}
+}
+
+// SecureReferrerPolicy is a `SecureHeadersOption` which sets the "Referrer-Policy" header, i.e "no-referrer".
+// Defaults to "strict-origin-when-cross-origin".
+func SecureReferrerPolicy(value string) SecureHeadersOption {
+	return func(s *secureHeaders) {
+		s.set("Referrer-Policy", value)
+	}
+}
+
+// SecureCSP is a `SecureHeadersOption` which sets the "Content-Security-Policy" header, i.e "default-src 'self'".
+// Defaults to none.
+func SecureCSP(policy string) SecureHeadersOption {
+	return func(s *secureHeaders) {
+		s.set("Content-Security-Policy", policy)
+	}
+}
+
+// SecureCSPReportOnly is a `SecureHeadersOption` which sets the "Content-Security-Policy-Report-Only" header,
+// the violations of its policy are reported, i.e to its "report-uri", but they are not blocked,
+// useful to test a policy before it is enforced through the `SecureCSP`. Defaults to none.
+func SecureCSPReportOnly(policy string) SecureHeadersOption {
+	return func(s *secureHeaders) {
+		s.set("Content-Security-Policy-Report-Only", policy)
+	}
+}
+
+// SecureHeader is a `SecureHeadersOption` which sets any other header, i.e "Cross-Origin-Opener-Policy".
+func SecureHeader(key, value string) SecureHeadersOption {
+	return func(s *secureHeaders) {
+		s.set(http.CanonicalHeaderKey(key), value)
+	}
+}
+
+type secureHeader struct {
+	key   string
+	value string
+}
+
+type secureHeaders struct {
+	headers []secureHeader
+}
+
+func (s *secureHeaders) set(key, value string) {
+	for i := range s.headers {
+		if s.headers[i].key == key {
+			s.headers[i].value = value
+			return
+		}
+	}
+
+	s.headers = append(s.headers, secureHeader{key: key, value: value})
+}
+
+// SecureHeaders returns a middleware which sets the security headers of the responses, i.e:
+// mux.Use(muxie.SecureHeaders(muxie.SecureCSP("default-src 'self'")))
+//
+// The defaults are "X-Content-Type-Options: nosniff", "X-Frame-Options: DENY",
+// "Referrer-Policy: strict-origin-when-cross-origin" and, for the HTTPS requests,
+// "Strict-Transport-Security: max-age=31536000; includeSubDomains". See `SecureHeadersOverride` for the per route changes.
+func SecureHeaders(options ...SecureHeadersOption) Wrapper {
+	s := &secureHeaders{headers: []secureHeader{
+		{"Strict-Transport-Security", "max-age=31536000; includeSubDomains"},
+		{"X-Content-Type-Options", "nosniff"},
+		{"X-Frame-Options", "DENY"},
+		{"Referrer-Policy", "strict-origin-when-cross-origin"},
+	}}
+
+	return s.wrapper(options)
+}
+
+// SecureHeadersOverride returns a middleware which changes only the given headers of the `SecureHeaders`, without its defaults,
+// it should be registered after it, i.e per route:
+// mux.HandleFunc("/embed", embed).Use(muxie.SecureHeadersOverride(muxie.SecureFrameOptions(""), muxie.SecureCSP("frame-ancestors *")))
+func SecureHeadersOverride(options ...SecureHeadersOption) Wrapper {
+	return new(secureHeaders).wrapper(options)
+}
+
+func (s *secureHeaders) wrapper(options []SecureHeadersOption) Wrapper {
+	for _, opt := range options {
+		opt(s)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			for _, header := range s.headers {
+				if header.value == "" || (header.key == "Strict-Transport-Security" && !isHTTPS(r)) {
+					h.Del(header.key)
+					continue
+				}
+
+				h.Set(header.key, header.value)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
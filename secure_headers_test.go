@@ -0,0 +1,60 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecureHeaders(t *testing.T) {
+	mux := NewMux()
+	mux.Use(SecureHeaders(SecureCSP("default-src 'self'"), SecureCSPReportOnly("script-src 'self'"),
+		SecureHeader("cross-origin-opener-policy", "same-origin")))
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+	mux.HandleFunc("/", handler)
+	mux.HandleFunc("/embed", handler).Use(SecureHeadersOverride(SecureFrameOptions(""), SecureCSP("frame-ancestors *")))
+
+	testHandler(t, mux, http.MethodGet, "/").
+		headerEq("X-Content-Type-Options", "nosniff").
+		headerEq("X-Frame-Options", "DENY").
+		headerEq("Referrer-Policy", "strict-origin-when-cross-origin").
+		headerEq("Content-Security-Policy", "default-src 'self'").
+		headerEq("Content-Security-Policy-Report-Only", "script-src 'self'").
+		headerEq("Cross-Origin-Opener-Policy", "same-origin").
+		// not an HTTPS request.
+		headerEq("Strict-Transport-Security", "")
+
+	testHandler(t, mux, http.MethodGet, "/embed").
+		headerEq("X-Content-Type-Options", "nosniff").
+		headerEq("X-Frame-Options", "").
+		headerEq("Content-Security-Policy", "frame-ancestors *")
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+	mux.ServeHTTP(w, r)
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=31536000; includeSubDomains" {
+		t.Fatalf("expected the HSTS header for the HTTPS requests but got: '%s'", got)
+	}
+
+	custom := NewMux()
+	custom.Use(SecureHeaders(SecureHSTS("max-age=63072000; includeSubDomains; preload"), SecureReferrerPolicy("no-referrer"),
+		SecureContentTypeOptions("")))
+	custom.HandleFunc("/", handler)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	custom.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=63072000; includeSubDomains; preload" {
+		t.Fatalf("expected the custom HSTS header but got: '%s'", got)
+	}
+
+	if got := w.Header().Get("Referrer-Policy"); got != "no-referrer" {
+		t.Fatalf("expected the custom Referrer-Policy but got: '%s'", got)
+	}
+
+	if _, ok := w.Header()["X-Content-Type-Options"]; ok {
+		t.Fatalf("expected the X-Content-Type-Options header to be removed")
+	}
+}
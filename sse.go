@@ -0,0 +1,243 @@
+package muxie
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSEOption is the type of the options that `NewSSE` accepts.
+type SSEOption func(*SSE)
+
+// SSEHeartbeat is an `SSEOption` which sets the interval of the heartbeat comments that keep the idle streams open
+// through the proxies and detect the disconnected clients, a zero "d" disables them. Defaults to 15 seconds.
+func SSEHeartbeat(d time.Duration) SSEOption {
+	return func(s *SSE) {
+		s.heartbeat = d
+	}
+}
+
+// SSERetry is an `SSEOption` which sends the reconnection time of the clients, the "retry" field,
+// when the stream is opened. Defaults to none, the browsers wait about 3 seconds.
+func SSERetry(d time.Duration) SSEOption {
+	return func(s *SSE) {
+		s.retry = d
+	}
+}
+
+// ErrSSEClosed is the error that the `SSE#Send` returns after the stream is closed.
+var ErrSSEClosed = errors.New("muxie: the event stream is closed")
+
+// SSE is the sender of a Server-Sent Events stream, see `NewSSE`.
+// Its methods can be called from any goroutine.
+type SSE struct {
+	w           http.ResponseWriter
+	r           *http.Request
+	heartbeat   time.Duration
+	retry       time.Duration
+	lastEventID string
+
+	mu   sync.Mutex
+	err  error
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSSE opens a Server-Sent Events stream on the response of the "r" request, it sets the "Content-Type: text/event-stream"
+// and the no caching headers, it sends the header and it flushes it, i.e:
+// mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+// sse, err := muxie.NewSSE(w, r)
+// if err != nil {
+// return
+// }
+// defer sse.Close()
+// sse.SendJSON("order", order)
+// [...]
+//
+// Each event is flushed when it is sent. The stream is done when the client disconnects, the request's context is canceled,
+// or a write fails, see `SSE#Done`. The `SSE#Close` should be called before the handler returns, it stops the heartbeats.
+// It returns an error which wraps the `http.ErrNotSupported` if the response can not be flushed, its header is already sent.
+func NewSSE(w http.ResponseWriter, r *http.Request, options ...SSEOption) (*SSE, error) {
+	s := &SSE{
+		w:           w,
+		r:           r,
+		heartbeat:   15 * time.Second,
+		lastEventID: r.Header.Get("Last-Event-ID"),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range options {
+		opt(s)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // disables the buffering of the nginx proxies.
+	h.Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+
+	if s.retry > 0 {
+		w.Write([]byte("retry: " + strconv.FormatInt(s.retry.Milliseconds(), 10) + "\n\n"))
+	}
+
+	if err := flushWriter(w); err != nil {
+		return nil, fmt.Errorf("muxie/NewSSE: the response can not be flushed: %w", err)
+	}
+
+	s.wg.Add(1)
+	go s.watch()
+	return s, nil
+}
+
+// watch sends the heartbeats and it closes the stream when the request is done.
+func (s *SSE) watch() {
+	defer s.wg.Done()
+
+	var tick <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.r.Context().Done():
+			s.mu.Lock()
+			s.closeLocked(s.r.Context().Err())
+			s.mu.Unlock()
+			return
+		case <-tick:
+			if s.write(": heartbeat\n\n") != nil {
+				return
+			}
+		}
+	}
+}
+
+// LastEventID returns the "Last-Event-ID" header of a reconnected client, the ID of the last event that it received, or empty,
+// so the handler can send the missed events.
+func (s *SSE) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done returns a channel which is closed when the stream is done, see `Err`.
+func (s *SSE) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason that the stream is done, i.e the context.Canceled of a disconnected client,
+// or nil if it is still open.
+func (s *SSE) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Send sends an event of the "event" type, or a "message" one if it is empty, with the "data", a multiline "data" is sent
+// as many "data" fields.
+func (s *SSE) Send(event, data string) error {
+	return s.SendEvent(SSEEvent{Event: event, Data: data})
+}
+
+// SendJSON sends an event of the "event" type with the JSON encoding of the "v" as its data.
+func (s *SSE) SendJSON(event string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return s.SendEvent(SSEEvent{Event: event, Data: string(b)})
+}
+
+// SSEEvent is an event of a Server-Sent Events stream, see `SSE#SendEvent`.
+type SSEEvent struct {
+	// ID is the ID of the event, which the client sends back through the "Last-Event-ID" header when it reconnects.
+	ID string
+	// Event is the type of the event, the clients receive the events without a type as "message" ones.
+	Event string
+	// Data is the data of the event.
+	Data string
+	// Retry, if not zero, changes the reconnection time of the client.
+	Retry time.Duration
+}
+
+// SendEvent sends the "e" event, the line breaks of its ID and type are removed.
+func (s *SSE) SendEvent(e SSEEvent) error {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + sseField(e.ID) + "\n")
+	}
+
+	if e.Event != "" {
+		b.WriteString("event: " + sseField(e.Event) + "\n")
+	}
+
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	data := strings.ReplaceAll(e.Data, "\r\n", "\n")
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	return s.write(b.String())
+}
+
+// sseField removes the line breaks of an event's field, they would end it.
+func sseField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// write writes and flushes the "s", the stream is closed on a failure.
+func (s *SSE) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	if err := s.r.Context().Err(); err != nil {
+		s.closeLocked(err)
+		return err
+	}
+
+	if _, err := s.w.Write([]byte(data)); err != nil {
+		s.closeLocked(err)
+		return err
+	}
+
+	if err := flushWriter(s.w); err != nil {
+		s.closeLocked(err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *SSE) closeLocked(err error) {
+	if s.err == nil {
+		s.err = err
+		close(s.done)
+	}
+}
+
+// Close closes the stream and it stops its heartbeats, the `Send` fails with the `ErrSSEClosed` after it.
+// It does not close the client's connection, which is closed when the handler returns.
+func (s *SSE) Close() {
+	s.mu.Lock()
+	s.closeLocked(ErrSSEClosed)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
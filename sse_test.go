@@ -0,0 +1,114 @@
+package muxie
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSE(t *testing.T) {
+	done := make(chan error, 1)
+
+	mux := NewMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		sse, err := NewSSE(w, r, SSEHeartbeat(10*time.Millisecond), SSERetry(2*time.Second))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer sse.Close()
+
+		lastID, _ := strconv.Atoi(sse.LastEventID())
+		sse.Send("", "hello\nworld")
+		sse.SendJSON("order", map[string]int{"id": lastID})
+		sse.SendEvent(SSEEvent{ID: "4\n2", Event: "done", Data: "bye"})
+
+		<-sse.Done()
+		done <- sse.Err()
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	req.Header.Set("Last-Event-ID", "41")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if expected, got := "text/event-stream", resp.Header.Get("Content-Type"); expected != got {
+		t.Fatalf("expected content type: %s but got: %s", expected, got)
+	}
+	if expected, got := "no-cache", resp.Header.Get("Cache-Control"); expected != got {
+		t.Fatalf("expected cache control: %s but got: %s", expected, got)
+	}
+
+	expected := []string{
+		"retry: 2000", "",
+		"data: hello", "data: world", "",
+		"event: order", `data: {"id":41}`, "",
+		"id: 42", "event: done", "data: bye", "",
+		": heartbeat", "",
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for i, line := range expected {
+		if !scanner.Scan() {
+			t.Fatalf("[%d] expected line: %q but the stream ended: %v", i, line, scanner.Err())
+		}
+		if got := scanner.Text(); line != got {
+			t.Fatalf("[%d] expected line: %q but got: %q", i, line, got)
+		}
+	}
+
+	// the client disconnects.
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the context.Canceled but got: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the disconnect to be detected")
+	}
+}
+
+func TestSSEClose(t *testing.T) {
+	rec := httptest.NewRecorder()
+	sse, err := NewSSE(rec, httptest.NewRequest(http.MethodGet, "/events", nil), SSEHeartbeat(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sse.Send("ping", "1")
+	sse.Close()
+
+	if err := sse.Send("ping", "2"); err != ErrSSEClosed {
+		t.Fatalf("expected the ErrSSEClosed but got: %v", err)
+	}
+
+	if expected, got := "event: ping\ndata: 1\n\n", rec.Body.String(); expected != got {
+		t.Fatalf("expected body: %q but got: %q", expected, got)
+	}
+}
+
+type notFlushableWriter struct {
+	http.ResponseWriter
+}
+
+func TestSSENotFlushable(t *testing.T) {
+	w := notFlushableWriter{httptest.NewRecorder()}
+	if _, err := NewSSE(w, httptest.NewRequest(http.MethodGet, "/events", nil)); !errors.Is(err, http.ErrNotSupported) ||
+		!strings.HasPrefix(err.Error(), "muxie/NewSSE: ") {
+		t.Fatalf("expected the http.ErrNotSupported but got: %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+package muxie
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// IndexFile is the file name that a directory is served through, see `Mux#Static`.
+const IndexFile = "index.html"
+
+// Static registers a handler which serves the files of the "fs" for the GET and HEAD requests of the "pattern",
+// the "pattern" should end with a wildcard which its value is the requested file's name, i.e:
+// mux.Static("/assets/*filepath", http.Dir("./public"))
+//
+// The requested file names are cleaned before they are opened, so they can not escape the "fs"' root.
+// Directories are served through their `IndexFile`, if any, they are never listed.
+// The files and directories that do not exist are served by the `NotFoundHandler`.
+func (m *Mux) Static(pattern string, fs http.FileSystem) *Route {
+	if fs == nil {
+		panic("muxie/Mux#Static: empty file system")
+	}
+
+	return m.HandleMethod(http.MethodGet+","+http.MethodHead, pattern, &fileServer{
+		mux:      m,
+		fs:       fs,
+		paramKey: wildcardParamKey("muxie/Mux#Static", pattern),
+	})
+}
+
+// wildcardParamKey returns the parameter key of the "pattern"'s last path segment, which should be a wildcard.
+func wildcardParamKey(caller, pattern string) string {
+	i := strings.LastIndexByte(pattern, pathSepB)
+	if i == -1 || !strings.HasPrefix(pattern[i+1:], WildcardParamStart) {
+		panic(caller + ": the pattern should end with a wildcard, i.e \"/assets/*filepath\", but \"" + pattern + "\" does not")
+	}
+
+	return wildcardName(pattern[i+1:])
+}
+
+// StaticOption is the type of the options that `Mux#StaticFS` accepts.
+type StaticOption func(*staticOptions)
+
+type staticOptions struct {
+	root  string
+	etags bool
+	gzip  bool
+}
+
+// StaticRoot is a `StaticOption` which serves the files of a sub-directory of the file system,
+// i.e the "public" of an embed.FS which is declared with "//go:embed public".
+func StaticRoot(dir string) StaticOption {
+	return func(opts *staticOptions) {
+		opts.root = dir
+	}
+}
+
+// StaticETags is a `StaticOption` which computes the "ETag" of each file once, when the route is registered,
+// from its contents. Useful for the embedded files which have not a modification time.
+func StaticETags() StaticOption {
+	return func(opts *staticOptions) {
+		opts.etags = true
+	}
+}
+
+// StaticGzip is a `StaticOption` which serves the gzip-precompressed sibling of a file, i.e "app.js.gz" for the "app.js",
+// to the clients that accept the gzip encoding, if it exists.
+func StaticGzip() StaticOption {
+	return func(opts *staticOptions) {
+		opts.gzip = true
+	}
+}
+
+// StaticFS registers a handler which serves the files of the "fsys", i.e an embed.FS, for the GET and HEAD requests of the "pattern",
+// like `Static` does, i.e:
+// //go:embed public
+// var public embed.FS
+// mux.StaticFS("/assets/*filepath", public, muxie.StaticRoot("public"), muxie.StaticETags(), muxie.StaticGzip())
+func (m *Mux) StaticFS(pattern string, fsys fs.FS, options ...StaticOption) *Route {
+	if fsys == nil {
+		panic("muxie/Mux#StaticFS: empty file system")
+	}
+
+	var opts staticOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	if opts.root != "" && opts.root != "." {
+		sub, err := fs.Sub(fsys, strings.Trim(opts.root, pathSep))
+		if err != nil {
+			panic("muxie/Mux#StaticFS: " + err.Error())
+		}
+		fsys = sub
+	}
+
+	s := &fileServer{
+		mux:      m,
+		fs:       http.FS(fsys),
+		paramKey: wildcardParamKey("muxie/Mux#StaticFS", pattern),
+		gzip:     opts.gzip,
+	}
+
+	if opts.etags {
+		etags, err := computeETags(fsys)
+		if err != nil {
+			panic("muxie/Mux#StaticFS: " + err.Error())
+		}
+		s.etags = etags
+	}
+
+	return m.HandleMethod(http.MethodGet+","+http.MethodHead, pattern, s)
+}
+
+// computeETags returns the strong "ETag"s of the files of the "fsys", by their name with a leading slash.
+func computeETags(fsys fs.FS) (map[string]string, error) {
+	etags := make(map[string]string)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err = io.Copy(h, f); err != nil {
+			return err
+		}
+
+		etags[pathSep+name] = "\"" + hex.EncodeToString(h.Sum(nil)[:16]) + "\""
+		return nil
+	})
+
+	return etags, err
+}
+
+// acceptsGzip reports whether the request's "Accept-Encoding" header accepts the gzip encoding.
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params := encoding, ""
+		if i := strings.IndexByte(encoding, ';'); i != -1 {
+			name, params = encoding[:i], encoding[i+1:]
+		}
+
+		if name = strings.TrimSpace(name); name != "gzip" && name != "*" {
+			continue
+		}
+
+		params = strings.ReplaceAll(params, " ", "")
+		return params != "q=0" && params != "q=0.0" && params != "q=0.00" && params != "q=0.000"
+	}
+
+	return false
+}
+
+// SPA registers a handler which serves a single page application from the "fs" for the GET and HEAD requests of the "pattern",
+// like `Static` does, but the requests of paths that are not files, i.e client-side routes like "/app/users/42",
+// are served by the "index" file, i.e:
+// mux.SPA("/app/*path", http.Dir("./dist"), "index.html")
+//
+// The requests of missing files, paths with an extension like "/app/missing.js", are still served by the `NotFoundHandler`.
+// The "index" file is never cached by the clients, the hashed assets, i.e "app.3f2a9c1b.js" or "index-BdF3kL9a.css",
+// are cached forever and the rest files are validated through their modification time.
+func (m *Mux) SPA(pattern string, fs http.FileSystem, index string) *Route {
+	if fs == nil {
+		panic("muxie/Mux#SPA: empty file system")
+	}
+
+	if index == "" {
+		index = IndexFile
+	}
+
+	return m.HandleMethod(http.MethodGet+","+http.MethodHead, pattern, &fileServer{
+		mux:      m,
+		fs:       fs,
+		paramKey: wildcardParamKey("muxie/Mux#SPA", pattern),
+		fallback: path.Clean(pathSep + index),
+	})
+}
+
+// hashedAsset matches the file names that may contain a content hash between the name and the extension.
+var hashedAsset = regexp.MustCompile(`[.-]([A-Za-z0-9_]{8,})\.[A-Za-z0-9]+$`)
+
+// isHashedAsset reports whether a file name contains a content hash,
+// which is at least 8 characters long with at least one digit, i.e "app.3f2a9c1b.js".
+func isHashedAsset(name string) bool {
+	m := hashedAsset.FindStringSubmatch(name)
+	return len(m) == 2 && strings.ContainsAny(m[1], "0123456789")
+}
+
+const (
+	noCache        = "no-cache"
+	immutableCache = "public, max-age=31536000, immutable"
+)
+
+type fileServer struct {
+	mux      *Mux
+	fs       http.FileSystem
+	paramKey string
+	// not empty for single page applications, see `Mux#SPA`.
+	fallback string
+	// see `StaticETags` and `StaticGzip`.
+	etags map[string]string
+	gzip  bool
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// the leading slash keeps the ".." path elements inside the root.
+	requested := path.Clean(pathSep + GetParam(w, s.paramKey))
+
+	f, name, info, err := s.open(requested)
+	if err != nil && s.fallback != "" && path.Ext(requested) == "" {
+		f, name, info, err = s.open(s.fallback)
+	}
+
+	if err != nil {
+		s.mux.notFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	contentName := info.Name()
+	if s.gzip {
+		if gz, gzName, gzInfo, err := s.open(name + ".gz"); err == nil {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if acceptsGzip(r) {
+				defer gz.Close()
+				w.Header().Set("Content-Encoding", "gzip")
+				f, name, info = gz, gzName, gzInfo
+			} else {
+				gz.Close()
+			}
+		}
+	}
+
+	if etag, ok := s.etags[name]; ok {
+		w.Header().Set("ETag", etag)
+	}
+
+	if s.fallback != "" {
+		if name == s.fallback || contentName == path.Base(s.fallback) {
+			w.Header().Set("Cache-Control", noCache)
+		} else if isHashedAsset(contentName) {
+			w.Header().Set("Cache-Control", immutableCache)
+		}
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		if typ := TypeByFilename(contentName); typ != "" {
+			w.Header().Set("Content-Type", typ)
+		}
+	}
+
+	http.ServeContent(w, r, contentName, info.ModTime(), f)
+}
+
+// open opens the file of the "name", a directory is resolved to its `IndexFile`.
+// It returns the name of the opened file as well.
+func (s *fileServer) open(name string) (http.File, string, os.FileInfo, error) {
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return nil, name, nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, name, nil, err
+	}
+
+	if !info.IsDir() {
+		return f, name, info, nil
+	}
+
+	f.Close()
+
+	name = path.Join(name, IndexFile)
+	f, err = s.fs.Open(name)
+	if err != nil {
+		return nil, name, nil, err
+	}
+
+	if info, err = f.Stat(); err != nil || info.IsDir() {
+		f.Close()
+		return nil, name, nil, os.ErrNotExist
+	}
+
+	return f, name, info, nil
+}
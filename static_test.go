@@ -0,0 +1,141 @@
+package muxie
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMuxStatic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "muxie-static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"index.html":     "<h1>Home</h1>",
+		"js/app.js":      "console.log('app')",
+		"css/style.css":  "body{}",
+		"docs/readme.md": "# Docs",
+	}
+	for name, contents := range files {
+		filename := filepath.Join(dir, "public", filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filename, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := NewMux()
+	mux.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("custom not found"))
+	})
+	mux.Static("/assets/*filepath", http.Dir(filepath.Join(dir, "public")))
+
+	testHandler(t, mux, http.MethodGet, "/assets/js/app.js").
+		statusCode(http.StatusOK).bodyEq("console.log('app')").headerEq("Content-Type", "application/javascript")
+	testHandler(t, mux, http.MethodGet, "/assets/css/style.css").
+		statusCode(http.StatusOK).bodyEq("body{}").headerEq("Content-Type", "text/css; charset=utf-8")
+	testHandler(t, mux, http.MethodGet, "/assets/index.html").
+		statusCode(http.StatusOK).bodyEq("<h1>Home</h1>")
+	testHandler(t, mux, http.MethodHead, "/assets/css/style.css").
+		statusCode(http.StatusOK).bodyEq("")
+	testHandler(t, mux, http.MethodGet, "/assets/missing.js").
+		statusCode(http.StatusNotFound).bodyEq("custom not found")
+	// directories without an index file are not listed.
+	testHandler(t, mux, http.MethodGet, "/assets/docs").
+		statusCode(http.StatusNotFound).bodyEq("custom not found")
+	testHandler(t, mux, http.MethodGet, "/assets/../secret.txt").
+		statusCode(http.StatusNotFound).bodyEq("custom not found")
+	testHandler(t, mux, http.MethodGet, "/assets/js/..%2F..%2F..%2Fsecret.txt").
+		statusCode(http.StatusNotFound).bodyEq("custom not found")
+	testHandler(t, mux, http.MethodPost, "/assets/js/app.js").
+		statusCode(http.StatusNotFound)
+}
+
+func TestMuxSPA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "muxie-spa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"index.html":               "<div id=\"app\"></div>",
+		"assets/index-BdF3kL9a.js": "app()",
+		"assets/my-component.css":  ".component{}",
+		"assets/logo.3f2a9c1b.png": "png",
+	}
+	for name, contents := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(filename, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mux := NewMux()
+	mux.SPA("/app/*path", http.Dir(dir), "index.html")
+
+	testHandler(t, mux, http.MethodGet, "/app/assets/index-BdF3kL9a.js").
+		statusCode(http.StatusOK).bodyEq("app()").headerEq("Cache-Control", immutableCache)
+	testHandler(t, mux, http.MethodGet, "/app/assets/logo.3f2a9c1b.png").
+		statusCode(http.StatusOK).headerEq("Cache-Control", immutableCache)
+	testHandler(t, mux, http.MethodGet, "/app/assets/my-component.css").
+		statusCode(http.StatusOK).bodyEq(".component{}").headerEq("Cache-Control", "")
+	// client-side routes.
+	testHandler(t, mux, http.MethodGet, "/app/users/42").
+		statusCode(http.StatusOK).bodyEq("<div id=\"app\"></div>").headerEq("Cache-Control", noCache)
+	testHandler(t, mux, http.MethodGet, "/app/index.html").
+		statusCode(http.StatusOK).bodyEq("<div id=\"app\"></div>").headerEq("Cache-Control", noCache)
+	testHandler(t, mux, http.MethodGet, "/app/../../etc").
+		statusCode(http.StatusOK).bodyEq("<div id=\"app\"></div>")
+	// missing files.
+	testHandler(t, mux, http.MethodGet, "/app/assets/missing.js").
+		statusCode(http.StatusNotFound)
+}
+
+func TestMuxStaticFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"public/index.html":    {Data: []byte("<h1>Home</h1>")},
+		"public/js/app.js":     {Data: []byte("app()")},
+		"public/js/app.js.gz":  {Data: []byte("gzipped app()")},
+		"public/css/style.css": {Data: []byte("body{}")},
+		"private/secret.txt":   {Data: []byte("secret")},
+	}
+
+	mux := NewMux()
+	mux.StaticFS("/assets/*filepath", fsys, StaticRoot("public"), StaticETags(), StaticGzip())
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL+"/assets/css/style.css").
+		statusCode(http.StatusOK).bodyEq("body{}").headerEq("Content-Type", "text/css; charset=utf-8").
+		headerEq("ETag", `"7c98040a541657584690ae2a1cc3b42a"`)
+	expect(t, http.MethodGet, srv.URL+"/assets/css/style.css",
+		withHeader("If-None-Match", `"7c98040a541657584690ae2a1cc3b42a"`)).
+		statusCode(http.StatusNotModified)
+	expect(t, http.MethodGet, srv.URL+"/assets/", withHeader("Accept-Encoding", "identity")).
+		statusCode(http.StatusOK).bodyEq("<h1>Home</h1>")
+	expect(t, http.MethodGet, srv.URL+"/assets/js/app.js", withHeader("Accept-Encoding", "gzip, deflate")).
+		statusCode(http.StatusOK).bodyEq("gzipped app()").headerEq("Content-Encoding", "gzip").
+		headerEq("Content-Type", "application/javascript").headerEq("Vary", "Accept-Encoding")
+	expect(t, http.MethodGet, srv.URL+"/assets/js/app.js", withHeader("Accept-Encoding", "gzip;q=0")).
+		statusCode(http.StatusOK).bodyEq("app()").headerEq("Content-Encoding", "").headerEq("Vary", "Accept-Encoding")
+	expect(t, http.MethodGet, srv.URL+"/assets/../private/secret.txt").
+		statusCode(http.StatusNotFound)
+}
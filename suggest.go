@@ -0,0 +1,175 @@
+package muxie
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// SuggestKeys returns at most "n" inserted path patterns which are the closest ones to the path "q",
+// i.e the "/users/:id" for a "/usres/42" path. The path patterns that continue the "q" path,
+// i.e the "/users/:id/posts" for a "/users/42" path, are suggested as well.
+// The named parameters and the wildcards of the path patterns match any path segment
+// and the path segments are compared case-insensitively.
+//
+// Useful for "did you mean" responses, see `Mux#Suggestions`.
+func (t *TrieOf[T]) SuggestKeys(q string, n int) []string {
+	if n <= 0 {
+		return nil
+	}
+
+	q = strings.ToLower(t.normalize(q))
+	if q == "" || q[0] != pathSepB {
+		q = pathSep + q
+	}
+
+	var (
+		segments    = slowPathSplit(q)
+		maxDistance = max(2, len(q)/5)
+		suggestions []suggestion
+	)
+
+	t.walk(func(node *NodeOf[T]) bool {
+		if strings.HasPrefix(node.key, pathSep+WildcardParamStart) && !strings.Contains(node.key[1:], pathSep) {
+			// the root wildcard matches all the paths.
+			return true
+		}
+
+		path := strings.ToLower(t.normalize(suggestionPath(node.key, segments)))
+		distance := editDistance(q, path)
+		if distance > maxDistance {
+			if q != pathSep && !strings.HasPrefix(path, strings.TrimSuffix(q, pathSep)+pathSep) {
+				return true
+			}
+			// the path pattern continues the "q" path.
+			distance = maxDistance
+		}
+
+		suggestions = append(suggestions, suggestion{key: node.key, distance: distance})
+		return true
+	})
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+
+		return len(suggestions[i].key) < len(suggestions[j].key)
+	})
+
+	keys := make([]string, 0, min(n, len(suggestions)))
+	for _, s := range suggestions[:min(n, len(suggestions))] {
+		keys = append(keys, s.key)
+	}
+
+	return keys
+}
+
+type suggestion struct {
+	key      string
+	distance int
+}
+
+// suggestionPath returns the "pattern" with its named parameters and wildcards
+// filled by the path "segments" of the same position, the ones without a path segment are kept as they are.
+func suggestionPath(pattern string, segments []string) string {
+	if pattern == pathSep {
+		return pattern
+	}
+
+	var (
+		b               strings.Builder
+		patternSegments = slowPathSplit(pattern)
+		j               int
+	)
+
+	for i, s := range patternSegments {
+		b.WriteString(pathSep)
+
+		switch {
+		case j >= len(segments) || (s[0] != ParamStart[0] && s[0] != WildcardParamStart[0]):
+			b.WriteString(s)
+			j++
+		case s[0] == ParamStart[0]:
+			b.WriteString(segments[j])
+			j++
+		default:
+			// a wildcard takes the path segments that the rest of the pattern does not take, one at least.
+			count := max(1, len(segments)-j-(len(patternSegments)-i-1))
+			b.WriteString(strings.Join(segments[j:min(j+count, len(segments))], pathSep))
+			j += count
+		}
+	}
+
+	return b.String()
+}
+
+// editDistance returns the Levenshtein distance of "a" and "b".
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// Suggestions makes this Mux and its sub muxes respond to the not found requests with at most "n" path patterns
+// that are close to the request path, see `Trie#SuggestKeys`, through the `NotFoundHandler`, i.e:
+// mux.Suggestions(3)
+// GET /usres/42 responds with 404 Not Found and:
+// 404 page not found
+//
+// Did you mean:
+// /users/:id
+// [...]
+//
+// The requests that accept JSON are responded with a `Problem` which has a "suggestions" member instead.
+// Path patterns are exposed to the clients, it is meant for APIs and development.
+func (m *Mux) Suggestions(n int) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.rlock()
+		keys := m.Routes.SuggestKeys(r.URL.Path, n)
+		m.runlock()
+
+		ranges := parseAccept(r.Header.Get("Accept"))
+		jsonQ, _ := acceptQuality(ranges, "application/json")
+		problemQ, _ := acceptQuality(ranges, ProblemContentType)
+		textQ, _ := acceptQuality(ranges, "text/plain")
+		if max(jsonQ, problemQ) > textQ {
+			if keys == nil {
+				keys = []string{}
+			}
+			WriteProblem(w, r, NewProblem(http.StatusNotFound, "").With("suggestions", keys))
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusNotFound)
+
+		body := "404 page not found\n"
+		if len(keys) > 0 {
+			body += "\nDid you mean:\n" + strings.Join(keys, "\n") + "\n"
+		}
+		w.Write([]byte(body))
+	})
+
+	m.lock()
+	m.NotFoundHandler = handler
+	m.unlock()
+}
@@ -0,0 +1,73 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTrieSuggestKeys(t *testing.T) {
+	tree := NewTrie()
+	for _, pattern := range []string{
+		"/",
+		"/users",
+		"/users/:id",
+		"/users/:id/posts",
+		"/users/:id/settings",
+		"/orders/:id:int",
+		"/files/*path",
+		"/*",
+	} {
+		tree.Insert(pattern)
+	}
+
+	tests := []struct {
+		path     string
+		n        int
+		expected []string
+	}{
+		{"/usres/42", 1, []string{"/users/:id"}},
+		{"/Users/42/post", 2, []string{"/users/:id/posts"}},
+		{"/users/42", 3, []string{"/users/:id", "/users/:id/posts", "/users/:id/settings"}},
+		{"/orders/abc", 3, []string{"/orders/:id:int"}},
+		{"/fils/a/b.txt", 3, []string{"/files/*path"}},
+		{"/completely/unrelated/path", 3, []string{}},
+		{"/users", 0, nil},
+	}
+
+	for _, tt := range tests {
+		got := tree.SuggestKeys(tt.path, tt.n)
+		if strings.Join(tt.expected, ",") != strings.Join(got, ",") || (tt.expected == nil) != (got == nil) {
+			t.Fatalf("%s: expected suggestions: %v but got: %v", tt.path, tt.expected, got)
+		}
+	}
+}
+
+func TestMuxSuggestions(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/users/:id/posts", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Suggestions(2)
+
+	testHandler(t, mux, http.MethodGet, "/usres/42").statusCode(http.StatusNotFound).
+		headerEq("Content-Type", "text/plain; charset=utf-8").
+		bodyEq("404 page not found\n\nDid you mean:\n/users/:id\n")
+	testHandler(t, mux, http.MethodGet, "/nothing/like/that/at/all").statusCode(http.StatusNotFound).
+		bodyEq("404 page not found\n")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/usres/42", nil)
+	req.Header.Set("Accept", "application/json")
+	mux.ServeHTTP(rec, req)
+
+	if expected, got := http.StatusNotFound, rec.Code; expected != got {
+		t.Fatalf("expected status: %d but got: %d", expected, got)
+	}
+	if expected, got := ProblemContentType, rec.Header().Get("Content-Type"); expected != got {
+		t.Fatalf("expected content type: %s but got: %s", expected, got)
+	}
+	if expected, got := `"suggestions":["/users/:id"]`, rec.Body.String(); !strings.Contains(got, expected) {
+		t.Fatalf("expected body to contain: %s but got: %s", expected, got)
+	}
+}
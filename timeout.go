@@ -0,0 +1,235 @@
+package muxie
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TimeoutOption is the type of the options that `Timeout` accepts.
+type TimeoutOption func(*timeouter)
+
+// TimeoutStatus is a `TimeoutOption` which sets the status code of the timed out responses,
+// i.e the http.StatusGatewayTimeout for the handlers that proxy the requests. Defaults to the http.StatusServiceUnavailable.
+func TimeoutStatus(statusCode int) TimeoutOption {
+	return func(t *timeouter) {
+		t.status = statusCode
+	}
+}
+
+// TimeoutResponse is a `TimeoutOption` which customizes the timed out responses,
+// it has priority over the `TimeoutStatus`. Defaults to a plain text response of the `TimeoutStatus`.
+func TimeoutResponse(handler http.Handler) TimeoutOption {
+	return func(t *timeouter) {
+		t.handler = handler
+	}
+}
+
+type timeouter struct {
+	timeout time.Duration
+	status  int
+	handler http.Handler
+}
+
+// Timeout returns a middleware which cancels the context of the requests that are not served in the "timeout" duration
+// and responds with 503 Service Unavailable, if the handler has not written its response yet, i.e:
+// mux.Wrap(muxie.Timeout(30 * time.Second))
+// mux.HandleFunc("/reports", reports).Use(muxie.Timeout(2*time.Minute, muxie.TimeoutStatus(http.StatusGatewayTimeout)))
+//
+// The writes of the handler after the timed out response fail with the `http.ErrHandlerTimeout`.
+// The handler should return when its request's context is done, the request is not completed before it does,
+// so its parameters storage is not reused while it is still running.
+func Timeout(timeout time.Duration, options ...TimeoutOption) Wrapper {
+	if timeout <= 0 {
+		panic("muxie/Timeout: the timeout should be positive")
+	}
+
+	t := &timeouter{timeout: timeout, status: http.StatusServiceUnavailable}
+	for _, opt := range options {
+		opt(t)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), t.timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{ResponseWriter: w, ctx: ctx, header: w.Header().Clone()}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+
+			go func() {
+				defer func() {
+					if err := recover(); err != nil {
+						panicked <- err
+						return
+					}
+					close(done)
+				}()
+
+				next.ServeHTTP(tw, r)
+			}()
+
+			finished := false
+			select {
+			case err := <-panicked:
+				panic(err)
+			case <-done:
+				finished = true
+			case <-ctx.Done():
+			}
+
+			// a finished handler always sends its response, even if the deadline has just passed.
+			if !finished && ctx.Err() == context.DeadlineExceeded && tw.expire() {
+				if t.handler != nil {
+					t.handler.ServeHTTP(w, r)
+				} else {
+					http.Error(w, http.StatusText(t.status), t.status)
+				}
+
+				if flusher, ok := w.(http.Flusher); ok {
+					flusher.Flush()
+				}
+			} else if finished {
+				tw.finish()
+			}
+
+			// waits for the handler, so its parameters storage is not reused while it is still running.
+			if !finished {
+				select {
+				case err := <-panicked:
+					panic(err)
+				case <-done:
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter guards the http.ResponseWriter of a `Timeout` against the writes of its handler
+// after its request's deadline. The handler's headers are kept apart until it writes its response.
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+
+	mu          sync.Mutex
+	header      http.Header
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(statusCode int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.isTimedOut() {
+		return
+	}
+
+	w.writeHeader(statusCode)
+}
+
+// writeHeader sends the handler's headers and the "statusCode", the "mu" should be locked.
+func (w *timeoutWriter) writeHeader(statusCode int) {
+	if statusCode >= 100 && statusCode < 200 {
+		w.commitHeader()
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+	w.commitHeader()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// commitHeader replaces the headers of the wrapped http.ResponseWriter with the handler's ones.
+func (w *timeoutWriter) commitHeader() {
+	h := w.ResponseWriter.Header()
+	for key := range h {
+		if _, ok := w.header[key]; !ok {
+			delete(h, key)
+		}
+	}
+
+	for key, values := range w.header {
+		h[key] = append([]string(nil), values...)
+	}
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.isTimedOut() {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if !w.wroteHeader {
+		w.writeHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.isTimedOut() {
+		return
+	}
+
+	if !w.wroteHeader {
+		w.writeHeader(http.StatusOK)
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// isTimedOut reports whether the handler can not write its response anymore, the "mu" should be locked.
+// A response which is not started yet can not be started after the deadline,
+// even if the timed out response is not written yet.
+func (w *timeoutWriter) isTimedOut() bool {
+	if !w.timedOut && !w.wroteHeader && w.ctx.Err() == context.DeadlineExceeded {
+		w.timedOut = true
+	}
+
+	return w.timedOut
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, see `Unwrapper`.
+func (w *timeoutWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// expire marks the response as timed out, it reports whether the timed out response can be written,
+// that is when the handler has not written its response yet.
+func (w *timeoutWriter) expire() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.timedOut = true
+	return !w.wroteHeader
+}
+
+// finish sends the handler's headers if it returned without writing anything.
+func (w *timeoutWriter) finish() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.wroteHeader {
+		w.commitHeader()
+	}
+}
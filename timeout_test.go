@@ -0,0 +1,75 @@
+package muxie
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	lateWrite := make(chan error, 1)
+
+	mux := NewMux()
+	mux.Use(Timeout(20 * time.Millisecond))
+	mux.HandleFunc("/fast/:name", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Name", GetParam(w, "name"))
+		w.Write([]byte("fast"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Slow", "true")
+		<-r.Context().Done()
+		_, err := w.Write([]byte("slow"))
+		lateWrite <- err
+	})
+	mux.HandleFunc("/streaming", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("started"))
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Empty", "true")
+	})
+
+	testHandler(t, mux, http.MethodGet, "/fast/kataras").
+		statusCode(http.StatusOK).bodyEq("fast").headerEq("X-Name", "kataras")
+
+	testHandler(t, mux, http.MethodGet, "/slow").
+		statusCode(http.StatusServiceUnavailable).bodyEq("Service Unavailable\n").headerEq("X-Slow", "")
+	if err := <-lateWrite; err != http.ErrHandlerTimeout {
+		t.Fatalf("expected the write after the timeout to fail with: %v but got: %v", http.ErrHandlerTimeout, err)
+	}
+
+	testHandler(t, mux, http.MethodGet, "/streaming").statusCode(http.StatusOK).bodyEq("started")
+	testHandler(t, mux, http.MethodGet, "/empty").statusCode(http.StatusOK).headerEq("X-Empty", "true")
+
+	// a route's shorter timeout wins over the mux one, the gap keeps it independent of the scheduling.
+	proxy := NewMux()
+	proxy.Use(Timeout(time.Second))
+	proxy.HandleFunc("/proxy", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}).Use(Timeout(10*time.Millisecond, TimeoutStatus(http.StatusGatewayTimeout)))
+
+	testHandler(t, proxy, http.MethodGet, "/proxy").statusCode(http.StatusGatewayTimeout)
+}
+
+func TestTimeoutResponse(t *testing.T) {
+	mux := NewMux()
+	mux.Use(Timeout(10*time.Millisecond, TimeoutResponse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestTimeout)
+		w.Write([]byte("timed out " + GetParam(w, "id")))
+	}))))
+	mux.HandleFunc("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	testHandler(t, mux, http.MethodGet, "/users/42").statusCode(http.StatusRequestTimeout).bodyEq("timed out 42")
+}
+
+func TestTimeoutPanic(t *testing.T) {
+	mux := NewMux()
+	mux.Use(Recover(RecoverLogger(nil)), Timeout(time.Second))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		panic("oops")
+	})
+
+	testHandler(t, mux, http.MethodGet, "/").statusCode(http.StatusInternalServerError)
+}
@@ -1,7 +1,10 @@
 package muxie
 
 import (
+	"errors"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -12,41 +15,110 @@ const (
 	// It allows everything else after that path prefix
 	// but the Trie checks for static paths and named parameters before that in order to support everything that other implementations do not,
 	// and if nothing else found then it tries to find the closest wildcard path(super and unique).
+	// Wildcards can be placed in the middle of a path pattern too, i.e "/files/*path/meta",
+	// and a path pattern can contain more than one wildcard, i.e "/*tenant/files/*path".
 	WildcardParamStart = "*"
+	// WildcardParamKey is the parameter key of the wildcards without a name, i.e "/files/*",
+	// their value can be retrieved by `GetParam(w, "*")`.
+	WildcardParamKey = "*"
+	// OptionalParamEnd is the character, as a string, which a named parameter ends with to be declared as optional,
+	// i.e "/posts/:year/:month?/:day?" matches the "/posts/2024", "/posts/2024/05" and "/posts/2024/05/09".
+	// Only the trailing path segments can be optional.
+	OptionalParamEnd = "?"
 )
 
-// Trie contains the main logic for adding and searching nodes for path segments.
+// TrieOf contains the main logic for adding and searching nodes for path segments, with their values of type T.
 // It supports wildcard and named path parameters.
 // Trie supports very coblex and useful path patterns for routes.
 // The Trie checks for static paths(path without : or *) and named parameters before that in order to support everything that other implementations do not,
 // and if nothing else found then it tries to find the closest wildcard path(super and unique).
-type Trie struct {
-	root *Node
+//
+// See `Trie` for the one that the HTTP `Mux` uses and `NewTrieOf` to store any other type of values.
+type TrieOf[T any] struct {
+	// CaseInsensitive, if true, matches the static path segments case-insensitively,
+	// i.e "/Users/42" matches the "/users/:id", the parameter values keep their original casing.
+	// It should be set before any `Insert`.
+	CaseInsensitive bool
+	// MaxPathLength, if not zero, is the maximum length, in bytes, of the searched paths
+	// and MaxPathDepth, if not zero, is the maximum number of their path segments, i.e 3 for the "/users/42/posts".
+	// The longer or deeper paths are rejected before any work, so adversarial paths of thousands of slashes
+	// can not be expensive to search, see `SearchErr`.
+	// Defaults to 0, no limit.
+	MaxPathLength int
+	MaxPathDepth  int
+	// Normalize, if not nil, normalizes the inserted path patterns and the searched paths
+	// so the paths with non-ASCII path segments match regardless of the client's normalization form,
+	// i.e the NFC of the golang.org/x/text/unicode/norm package:
+	// trie.Normalize = norm.NFC.String
+	// or with case folding as well, see `FoldCase`:
+	// trie.Normalize = func(s string) string { return muxie.FoldCase(norm.NFC.String(s)) }
+	//
+	// The named parameter values are normalized too, the keys of the nodes keep the original path patterns.
+	// It should be set before any `Insert`.
+	Normalize func(string) string
+
+	root *NodeOf[T]
 
 	// if true then it will handle any path if not other parent wildcard exists,
 	// so even 404 (on http services) is up to it, see Trie#Insert.
 	hasRootWildcard bool
 
 	hasRootSlash bool
+
+	// if true then at least one node has a priority, see `WithPriority`.
+	hasPriority bool
+
+	// the search order of the sibling path segments, nil for the default one,
+	// and the tie rule of the path patterns that match the same path, see `SetPrecedence`.
+	order           []SegmentKind
+	firstRegistered bool
+	// the registration order of the last inserted path pattern, see `NodeOf#seq`.
+	seq int
 }
 
+// Trie is the `TrieOf` the HTTP handlers, the `Mux#Routes`.
+type Trie = TrieOf[http.Handler]
+
 // NewTrie returns a new, empty Trie.
 // It is only useful for end-developers that want to design their own mux/router based on my trie implementation.
 //
 // See `Trie`
 func NewTrie() *Trie {
-	return &Trie{
-		root:            NewNode(),
+	return NewTrieOf[http.Handler]()
+}
+
+// NewTrieOf returns a new, empty Trie which stores values of type T, i.e:
+// trie := muxie.NewTrieOf[*User]()
+// trie.Insert("/users/:id", muxie.WithValue(user))
+// n := trie.Search("/users/42", params)
+// n.Value() // *User, no type assertion needed.
+func NewTrieOf[T any]() *TrieOf[T] {
+	return &TrieOf[T]{
+		root:            new(NodeOf[T]),
 		hasRootWildcard: false,
 	}
 }
 
-// InsertOption is just a function which accepts a pointer to a Node which can alt its `Handler`, `Tag` and `Data`  fields.
+// InsertOptionOf is just a function which accepts a pointer to a Node which can alt its `Handler`, `Tag` and `Data`  fields.
+//
+// See `WithValue`.
+type InsertOptionOf[T any] func(*NodeOf[T])
+
+// InsertOption is the `InsertOptionOf` the `Trie`.
 //
 // See `WithHandler`, `WithTag` and `WithData`.
-type InsertOption func(*Node)
+type InsertOption = InsertOptionOf[http.Handler]
 
-// WithHandler sets the node's `Handler` field (useful for HTTP).
+// WithValue sets the node's value, its `Handler` field, of a `TrieOf` any type.
+// The value can be replaced.
+func WithValue[T any](value T) InsertOptionOf[T] {
+	return func(n *NodeOf[T]) {
+		n.Handler = value
+	}
+}
+
+// WithHandler sets the node's `Handler` field (useful for HTTP),
+// it removes any handlers that are registered by the `WithMethodHandler`.
 func WithHandler(handler http.Handler) InsertOption {
 	if handler == nil {
 		panic("muxie/WithHandler: empty handler")
@@ -55,6 +127,30 @@ func WithHandler(handler http.Handler) InsertOption {
 	return func(n *Node) {
 		if n.Handler == nil {
 			n.Handler = handler
+			n.resetMethodHandlers()
+		}
+	}
+}
+
+// WithMethodHandler adds a handler which is responsible only for the given HTTP method(s) to the node,
+// the "method" can accept many methods separated by comma, i.e "POST, PUT".
+// The node keeps the handlers of the previous insertions for the rest methods,
+// so the same path pattern can be inserted many times for different methods.
+//
+// See `Node#MethodHandler` and `Node#AllowedMethods` too.
+func WithMethodHandler(method string, handler http.Handler) InsertOption {
+	if handler == nil {
+		panic("muxie/WithMethodHandler: empty handler")
+	}
+
+	methods := parseMethods(method)
+	if len(methods) == 0 {
+		panic("muxie/WithMethodHandler: empty method")
+	}
+
+	return func(n *Node) {
+		for _, method := range methods {
+			n.setMethodHandler(method, handler)
 		}
 	}
 }
@@ -68,6 +164,16 @@ func WithTag(tag string) InsertOption {
 	}
 }
 
+// WithPriority sets the priority of the node, the path patterns with higher priority win over the rest ones
+// that can match the same path, i.e a "/users/:id" with priority 1 serves the "/users/new" path
+// even if the "/users/new" is inserted too. Defaults to 0, the default search order applies on ties:
+// static path segments, named parameters and then wildcards.
+func WithPriority(priority int) InsertOption {
+	return func(n *Node) {
+		n.setPriority(priority)
+	}
+}
+
 // WithData sets the node's optionally `Data` field.
 func WithData(data interface{}) InsertOption {
 	return func(n *Node) {
@@ -76,16 +182,188 @@ func WithData(data interface{}) InsertOption {
 	}
 }
 
-// Insert adds a node to the trie.
-func (t *Trie) Insert(pattern string, options ...InsertOption) {
+// parseMethods returns the upper-cased HTTP methods of a comma or space separated list, i.e "POST, PUT".
+func parseMethods(method string) []string {
+	return strings.FieldsFunc(strings.ToUpper(method), func(c rune) bool {
+		return c == ',' || c == ' '
+	})
+}
+
+// Insert adds a node to the trie and it returns it,
+// the node can hold metadata of the path pattern which the `Search` results carry, see `NodeOf#SetMeta`.
+//
+// A pattern with optional trailing named parameters, i.e "/posts/:year/:month?/:day?",
+// adds a node for each one of its forms, all of them keep the original pattern as their key and share its metadata,
+// the node of the longest form is returned. The metadata of an already inserted path pattern is kept.
+//
+// It panics if the pattern is not valid, see `InsertErr`.
+func (t *TrieOf[T]) Insert(pattern string, options ...InsertOptionOf[T]) *NodeOf[T] {
+	if err := validatePattern(pattern); err != nil {
+		panic(err.Error())
+	}
+
+	return t.insertPattern(pattern, options)
+}
+
+// InsertErr adds a node to the trie, like `Insert` does,
+// but it returns a `*PatternError` instead of panicking when the pattern is not valid.
+func (t *TrieOf[T]) InsertErr(pattern string, options ...InsertOptionOf[T]) error {
+	if err := validatePattern(pattern); err != nil {
+		return err
+	}
+
+	t.insertPattern(pattern, options)
+	return nil
+}
+
+func (t *TrieOf[T]) insertPattern(pattern string, options []InsertOptionOf[T]) (n *NodeOf[T]) {
+	// the metadata of an inserted path pattern is kept, i.e for the handlers of its rest methods.
+	var meta *nodeMeta
+	if nodes := t.nodes(pattern); len(nodes) > 0 {
+		meta = nodes[0].meta
+	}
+	if meta == nil {
+		meta = new(nodeMeta)
+	}
+
+	for _, p := range expandOptionalParams(pattern) {
+		var zero T
+		n = t.insert(t.normalizePattern(p), "", nil, zero)
+		n.key = pattern
+		n.meta = meta
+		for _, opt := range options {
+			opt(n)
+		}
+
+		if n.priority != 0 {
+			t.hasPriority = true
+		}
+	}
+
+	return
+}
+
+// PatternError is the error that `Trie#InsertErr` returns for a malformed path pattern.
+type PatternError struct {
+	Pattern string
+	// Position is the byte offset of the path pattern where the error is found.
+	Position int
+	Reason   string
+}
+
+func (e *PatternError) Error() string {
+	return "muxie/trie#Insert: " + e.Reason + " at position " + strconv.Itoa(e.Position) + " of \"" + e.Pattern + "\""
+}
+
+// validatePattern returns a `*PatternError` if the "pattern" is malformed, i.e it has an empty or a duplicated parameter name.
+func validatePattern(pattern string) error {
 	if pattern == "" {
-		panic("muxie/trie#Insert: empty pattern")
+		return &PatternError{Pattern: pattern, Reason: "empty pattern"}
+	}
+
+	if pattern[0] != pathSepB {
+		return &PatternError{Pattern: pattern, Reason: "the pattern should start with a slash"}
+	}
+
+	var (
+		names    = make(map[string]struct{})
+		optional bool
+	)
+
+	for start := 1; start <= len(pattern); {
+		end := strings.IndexByte(pattern[start:], pathSepB)
+		if end == -1 {
+			end = len(pattern)
+		} else {
+			end += start
+		}
+
+		s := pattern[start:end]
+		errAt := func(offset int, reason string) error {
+			return &PatternError{Pattern: pattern, Position: start + offset, Reason: reason}
+		}
+
+		isOptional := s != "" && s[0] == ParamStart[0] && strings.HasSuffix(s, OptionalParamEnd)
+		if optional && !isOptional && (s != "" || end != len(pattern)) {
+			return errAt(0, "only trailing named parameters can be optional")
+		}
+		optional = optional || isOptional
+
+		var name string
+		switch {
+		case s != "" && s[0] == ParamStart[0]:
+			var constraint string
+			name, constraint = splitParam(strings.TrimSuffix(s[1:], OptionalParamEnd))
+			if name == "" {
+				return errAt(1, "empty parameter name")
+			}
+
+			if constraint != "" {
+				if _, err := compileParamConstraint(constraint); err != nil {
+					return errAt(1+len(name), err.Error())
+				}
+			}
+		case s != "" && s[0] == WildcardParamStart[0]:
+			if strings.HasSuffix(s, OptionalParamEnd) {
+				return errAt(len(s)-1, "a wildcard can not be optional")
+			}
+			name = wildcardName(s)
+		default:
+			start = end + 1
+			continue
+		}
+
+		if _, ok := names[name]; ok {
+			return errAt(0, "duplicate parameter name \""+name+"\"")
+		}
+		names[name] = struct{}{}
+
+		start = end + 1
+	}
+
+	return nil
+}
+
+// expandOptionalParams returns the patterns that a pattern with optional trailing named parameters can be resolved to,
+// from the shortest to the longest one. It returns the "pattern" itself if it has not optional parameters.
+func expandOptionalParams(pattern string) []string {
+	if !strings.Contains(pattern, OptionalParamEnd) {
+		return []string{pattern}
+	}
+
+	var (
+		patterns []string
+		prefix   string
+		optional bool
+	)
+
+	for _, s := range slowPathSplit(pattern) {
+		if s != "" && s[0] == ParamStart[0] && strings.HasSuffix(s, OptionalParamEnd) {
+			if !optional {
+				optional = true
+				if prefix == "" {
+					patterns = append(patterns, pathSep)
+				} else {
+					patterns = append(patterns, prefix)
+				}
+			}
+
+			s = s[:len(s)-len(OptionalParamEnd)]
+		} else if optional {
+			panic("muxie/trie#Insert: only trailing named parameters can be optional, \"" + s + "\" of \"" + pattern + "\" is not")
+		}
+
+		prefix += pathSep + s
+		if optional {
+			patterns = append(patterns, prefix)
+		}
 	}
 
-	n := t.insert(pattern, "", nil, nil)
-	for _, opt := range options {
-		opt(n)
+	if !optional {
+		return []string{pattern}
 	}
+
+	return patterns
 }
 
 const (
@@ -118,7 +396,75 @@ func resolveStaticPart(key string) string {
 	return key[:i]
 }
 
-func (t *Trie) insert(key, tag string, optionalData interface{}, handler http.Handler) *Node {
+// fillPattern returns the path of a "pattern" with its dynamic path segments replaced by the "values", in order.
+// The optional named parameters without a value are omitted.
+func fillPattern(pattern string, values []string) string {
+	if pattern == pathSep {
+		return pattern
+	}
+
+	var b strings.Builder
+	for _, s := range slowPathSplit(pattern) {
+		if s != "" && (s[0] == ParamStart[0] || s[0] == WildcardParamStart[0]) {
+			if len(values) == 0 {
+				break
+			}
+
+			s = values[0]
+			values = values[1:]
+		}
+
+		b.WriteString(pathSep)
+		b.WriteString(s)
+	}
+
+	return b.String()
+}
+
+// wildcardName returns the parameter key of a wildcard's path segment, i.e "*filepath" returns "filepath"
+// and "*" returns the `WildcardParamKey`.
+func wildcardName(s string) string {
+	if name := s[len(WildcardParamStart):]; name != "" {
+		return name
+	}
+
+	return WildcardParamKey
+}
+
+// splitParam separates the name and the constraint of a named parameter's path segment, without the ":",
+// i.e "id:int" returns "id" and "int" and "slug([a-z0-9-]+)" returns "slug" and "([a-z0-9-]+)".
+// The constraint is empty for untyped named parameters.
+func splitParam(s string) (name, constraint string) {
+	typeIdx := strings.Index(s, ParamTypeSep)
+	exprIdx := strings.IndexByte(s, ParamExprStart)
+
+	if exprIdx != -1 && (typeIdx == -1 || exprIdx < typeIdx) {
+		return s[:exprIdx], s[exprIdx:]
+	}
+
+	if typeIdx != -1 {
+		return s[:typeIdx], s[typeIdx+1:]
+	}
+
+	return s, ""
+}
+
+// childKey returns the key that a path pattern's segment is stored under its parent's children,
+// i.e ":" for untyped named parameters, ":int" for typed named parameters,
+// ":([a-z]+)" for regex-constrained named parameters and "*" for wildcards.
+func childKey(s string) string {
+	switch s[0] {
+	case ParamStart[0]:
+		_, constraint := splitParam(s[1:])
+		return ParamStart + constraint
+	case WildcardParamStart[0]:
+		return WildcardParamStart
+	default:
+		return s
+	}
+}
+
+func (t *TrieOf[T]) insert(key, tag string, optionalData interface{}, handler T) *NodeOf[T] {
 	input := slowPathSplit(key)
 
 	n := t.root
@@ -128,68 +474,262 @@ func (t *Trie) insert(key, tag string, optionalData interface{}, handler http.Ha
 
 	var paramKeys []string
 
-	for _, s := range input {
+	for i := 0; i < len(input); i++ {
+		s := input[i]
 		c := s[0]
 
+		var validator ParamValidator
+
 		if isParam, isWildcard := c == ParamStart[0], c == WildcardParamStart[0]; isParam || isWildcard {
 			n.hasDynamicChild = true
-			paramKeys = append(paramKeys, s[1:]) // without : or *.
 
 			// if node has already a wildcard, don't force a value, check for true only.
 			if isParam {
+				name, constraint := splitParam(s[1:])
+				if constraint != "" && !n.hasChild(ParamStart+constraint) {
+					// compile it once, nodes with the same constraint are shared.
+					v, err := compileParamConstraint(constraint)
+					if err != nil {
+						panic("muxie/trie#Insert: " + err.Error() + " of \"" + key + "\"")
+					}
+					validator = v
+				}
+
+				paramKeys = append(paramKeys, name) // without : and the constraint.
 				n.childNamedParameter = true
-				s = ParamStart
 			}
 
 			if isWildcard {
+				paramKeys = append(paramKeys, wildcardName(s)) // without *.
 				n.childWildcardParameter = true
-				s = WildcardParamStart
 				if t.root == n {
 					t.hasRootWildcard = true
 				}
 			}
+
+			s = childKey(s)
+		} else if t.CaseInsensitive {
+			s = strings.ToLower(s)
 		}
 
 		if !n.hasChild(s) {
-			child := NewNode()
+			child := new(NodeOf[T])
+			child.paramValidator = validator
 			n.addChild(s, child)
 		}
 
 		n = n.getChild(s)
+		if n.edge != "" {
+			// a compressed node, split it where the rest path segments stop to match its edge, if they do.
+			segments := strings.Split(n.edge[len(pathSep):], pathSep)
+			k := 0
+			for ; k < len(segments) && i+1+k < len(input); k++ {
+				next := input[i+1+k]
+				if t.CaseInsensitive {
+					next = strings.ToLower(next)
+				}
+
+				if next != segments[k] {
+					break
+				}
+			}
+
+			if k < len(segments) {
+				n = n.parent.splitEdge(n, k)
+			}
+			i += k
+		}
 	}
 
 	n.Tag = tag
 	n.Handler = handler
 	n.Data = optionalData
+	n.priority = 0
 
 	n.paramKeys = paramKeys
 	n.key = key
 	n.staticKey = resolveStaticPart(key)
+	if !n.end {
+		t.seq++
+		n.seq = t.seq
+	}
 	n.end = true
 
+	t.compress(n.parent)
 	return n
 }
 
+// compress merges each node of the path from the "n", included, up to the root, which is not an end one
+// and has a single static child, with that child. The child takes its place with a multi-segment edge,
+// i.e a "/api/v1/users" chain of static path segments is searched through one node instead of three.
+// The end nodes are never replaced, the `Node` pointers that the callers keep stay valid.
+func (t *TrieOf[T]) compress(n *NodeOf[T]) {
+	for p := n; p != nil; {
+		parent := p.parent
+		if p.compressible() {
+			var child *NodeOf[T]
+			for _, child = range p.children {
+			}
+
+			child.edge = p.edge + pathSep + child.segment + child.edge
+			child.segment = p.segment
+			child.parent = parent
+			parent.children[p.segment] = child
+			p.parent, p.children = nil, nil
+		}
+
+		p = parent
+	}
+}
+
 // SearchPrefix returns the last node which holds the key which starts with "prefix".
-func (t *Trie) SearchPrefix(prefix string) *Node {
-	input := slowPathSplit(prefix)
+// It keeps its exact-prefix meaning, the "prefix" is a prefix of the inserted path patterns and not a path,
+// see `LongestPrefix` to find the inserted path pattern which is the longest prefix of a path.
+func (t *TrieOf[T]) SearchPrefix(prefix string) *NodeOf[T] {
+	return t.searchPrefix(prefix, false)
+}
+
+// searchPrefix returns the node of the "prefix", if "exact" is true then a "prefix"
+// which stops in the middle of a compressed node's path segments is not found.
+func (t *TrieOf[T]) searchPrefix(prefix string, exact bool) *NodeOf[T] {
+	input := slowPathSplit(t.normalizePattern(prefix))
 	n := t.root
 
 	for i := 0; i < len(input); i++ {
 		s := input[i]
-		if child := n.getChild(s); child != nil {
-			n = child
-			continue
+		if s != "" && (s[0] == ParamStart[0] || s[0] == WildcardParamStart[0]) {
+			s = childKey(s)
+		} else if t.CaseInsensitive {
+			s = strings.ToLower(s)
 		}
 
-		return nil
+		child := n.getChild(s)
+		if child == nil {
+			return nil
+		}
+
+		// the path segments of a compressed node, the "prefix" may stop in the middle of them.
+		if child.edge != "" {
+			segments := strings.Split(child.edge[len(pathSep):], pathSep)
+			k := 0
+			for ; k < len(segments) && i+1 < len(input); k++ {
+				next := input[i+1]
+				if t.CaseInsensitive {
+					next = strings.ToLower(next)
+				}
+
+				if next != segments[k] {
+					return nil
+				}
+				i++
+			}
+
+			if exact && k < len(segments) {
+				return nil
+			}
+		}
+
+		n = child
 	}
 
 	return n
 }
 
+// Delete removes an inserted path pattern and its data from the trie,
+// the nodes that are left without data and children are removed as well
+// and the chains of static nodes that are left are compressed again, see `TrieOf#compress`.
+// It reports whether the "pattern" was found.
+func (t *TrieOf[T]) Delete(pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+
+	nodes := t.nodes(pattern)
+	for _, n := range nodes {
+		n.end = false
+		n.key = ""
+		n.staticKey = ""
+		n.seq = 0
+		n.paramKeys = nil
+		var zero T
+		n.Handler = zero
+		n.resetMethodHandlers()
+		n.conditionals = nil
+		n.Tag = ""
+		n.Data = nil
+		n.meta = nil
+
+		// prune and merge the compressed nodes back together, i.e the "/users/:id/posts" is left
+		// as one node after the deletion of the "/users/:id".
+		for parent := n.parent; parent != nil && !n.end && len(n.children) == 0; n, parent = parent, parent.parent {
+			parent.removeChild(n)
+		}
+		t.compress(n)
+	}
+
+	if n := t.root.getChild(pathSep); n == nil {
+		t.hasRootSlash = false
+	}
+
+	t.hasRootWildcard = t.root.childWildcardParameter
+
+	return len(nodes) > 0
+}
+
+// Merge inserts the path patterns of the "other" trie, with their data, to this trie,
+// i.e to combine the routes that plugins contribute to the main ones.
+// The "onConflict" is called for each path pattern of the "other" which this trie has already,
+// i.e the "/users/:name" for "/users/:id", it reports whether its "incoming" data should replace the "existing" ones.
+// A nil "onConflict" keeps the existing ones. The "other" is not modified.
+func (t *TrieOf[T]) Merge(other *TrieOf[T], onConflict func(existing, incoming *NodeOf[T]) bool) {
+	other.walk(func(incoming *NodeOf[T]) bool {
+		for _, p := range expandOptionalParams(incoming.key) {
+			if existing := t.searchPrefix(p, true); existing != nil && existing.end {
+				if onConflict == nil || !onConflict(existing, incoming) {
+					return true
+				}
+				break
+			}
+		}
+
+		t.insertPattern(incoming.key, []InsertOptionOf[T]{func(n *NodeOf[T]) {
+			n.Handler = incoming.Handler
+			n.Tag = incoming.Tag
+			n.Data = incoming.Data
+			if incoming.priority != 0 {
+				n.setPriority(incoming.priority)
+			}
+
+			n.resetMethodHandlers()
+			for _, method := range incoming.methodsAllowed {
+				n.setMethodHandler(method, incoming.methodHandlers[method])
+			}
+			n.conditionals = append([]*conditionalHandler(nil), incoming.conditionals...)
+			if incoming.meta != nil {
+				for key, value := range incoming.meta.values {
+					n.SetMeta(key, value)
+				}
+			}
+		}})
+
+		return true
+	})
+}
+
+// nodes returns the end nodes of an inserted "pattern",
+// more than one if the pattern contains optional named parameters.
+func (t *TrieOf[T]) nodes(pattern string) (nodes []*NodeOf[T]) {
+	for _, p := range expandOptionalParams(pattern) {
+		if n := t.SearchPrefix(p); n != nil && n.end && n.key == pattern {
+			nodes = append(nodes, n)
+		}
+	}
+
+	return
+}
+
 // Parents returns the list of nodes that a node with "prefix" key belongs to.
-func (t *Trie) Parents(prefix string) (parents []*Node) {
+func (t *TrieOf[T]) Parents(prefix string) (parents []*NodeOf[T]) {
 	n := t.SearchPrefix(prefix)
 	if n != nil {
 		// without this node.
@@ -211,13 +751,13 @@ func (t *Trie) Parents(prefix string) (parents []*Node) {
 }
 
 // HasPrefix returns true if "prefix" is found inside the registered nodes.
-func (t *Trie) HasPrefix(prefix string) bool {
+func (t *TrieOf[T]) HasPrefix(prefix string) bool {
 	return t.SearchPrefix(prefix) != nil
 }
 
 // Autocomplete returns the keys that starts with "prefix",
 // this is useful for custom search-engines built on top of my trie implementation.
-func (t *Trie) Autocomplete(prefix string, sorter NodeKeysSorter) (list []string) {
+func (t *TrieOf[T]) Autocomplete(prefix string, sorter NodeKeysSorter) (list []string) {
 	n := t.SearchPrefix(prefix)
 	if n != nil {
 		list = n.Keys(sorter)
@@ -225,8 +765,61 @@ func (t *Trie) Autocomplete(prefix string, sorter NodeKeysSorter) (list []string
 	return
 }
 
+// WalkFunc is the type of the function that `Trie#Walk` calls for each inserted path pattern,
+// the "methods" are not empty when the handler is a `MethodHandler` or the node has per method handlers, i.e `Mux#HandleMethod`.
+// Return false to stop the walk.
+type WalkFunc func(pattern string, methods []string, handler http.Handler) bool
+
+// Walk calls the "fn" for each inserted path pattern, sorted by their path segments.
+// A path pattern with optional named parameters is visited once.
+func (t *TrieOf[T]) Walk(fn WalkFunc) {
+	t.walk(func(n *NodeOf[T]) bool {
+		handler := n.handler()
+
+		var methods []string
+		if methodHandler, ok := handler.(*MethodHandler); ok {
+			methods = methodHandler.AllowedMethods()
+		}
+
+		return fn(n.key, methods, handler)
+	})
+}
+
+// walk calls the "fn" for each end node, once per key, until it returns false.
+func (t *TrieOf[T]) walk(fn func(*NodeOf[T]) bool) {
+	visited := make(map[string]struct{})
+	t.root.walk(func(n *NodeOf[T]) bool {
+		if _, ok := visited[n.key]; ok {
+			return true
+		}
+		visited[n.key] = struct{}{}
+		return fn(n)
+	})
+}
+
+func (n *NodeOf[T]) walk(fn func(*NodeOf[T]) bool) bool {
+	if n.end && !fn(n) {
+		return false
+	}
+
+	keys := make([]string, 0, len(n.children))
+	for s := range n.children {
+		keys = append(keys, s)
+	}
+	sort.Strings(keys)
+
+	for _, s := range keys {
+		if !n.children[s].walk(fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // ParamsSetter is the interface which should be implemented by the
 // params writer for `Search` in order to store the found named path parameters, if any.
+// A nil ParamsSetter discards them. See `Params` and `MapParams` for the non-HTTP uses of the trie.
 type ParamsSetter interface {
 	Set(string, string)
 }
@@ -238,11 +831,61 @@ type ParamsSetter interface {
 // named parameters or wildcards.
 // Priority as:
 // 1. static paths
-// 2. named parameters with ":"
-// 3. wildcards
+// 2. named parameters with ":", the typed (e.g. ":id:int") and regex-constrained (e.g. ":slug([a-z0-9-]+)") ones first,
+// in the order they were inserted
+// 3. wildcards, a mid-path wildcard (e.g. "/files/*path/meta") accepts the fewest path segments
+// that the rest of its pattern can be matched against and it has priority over a trailing one (e.g. "/files/*path")
 // 4. closest wildcard if not found, if any
 // 5. root wildcard
-func (t *Trie) Search(q string, params ParamsSetter) *Node {
+//
+// A path segment which does not pass a typed or regex-constrained named parameter's validation
+// continues to the next candidate, so that route never shadows its siblings.
+// The order of the first three ones can be changed, see `SetPrecedence`.
+//
+// The paths which exceed the `MaxPathLength` or the `MaxPathDepth` are not found, see `SearchErr`.
+func (t *TrieOf[T]) Search(q string, params ParamsSetter) *NodeOf[T] {
+	if t.checkPath(q) != nil {
+		return nil
+	}
+
+	return t.searchPath(q, params)
+}
+
+var (
+	// ErrPathTooLong is the error that the `TrieOf#SearchErr` returns for a path longer than the `TrieOf#MaxPathLength`,
+	// the `Mux` responds with 414 URI Too Long.
+	ErrPathTooLong = errors.New("muxie: path too long")
+	// ErrPathTooDeep is the error that the `TrieOf#SearchErr` returns for a path with more path segments
+	// than the `TrieOf#MaxPathDepth`, the `Mux` responds with 400 Bad Request.
+	ErrPathTooDeep = errors.New("muxie: path too deep")
+)
+
+// SearchErr searches for the node of the "q" like `Search` does but it returns
+// the `ErrPathTooLong` or the `ErrPathTooDeep` for the paths which exceed the `MaxPathLength` or the `MaxPathDepth`,
+// so they can be told apart from the not found ones.
+func (t *TrieOf[T]) SearchErr(q string, params ParamsSetter) (*NodeOf[T], error) {
+	if err := t.checkPath(q); err != nil {
+		return nil, err
+	}
+
+	return t.searchPath(q, params), nil
+}
+
+// checkPath returns an error if the path "q" exceeds the search budget of the trie, i.e the `MaxPathLength`.
+func (t *TrieOf[T]) checkPath(q string) error {
+	if t.MaxPathLength > 0 && len(q) > t.MaxPathLength {
+		return ErrPathTooLong
+	}
+
+	if t.MaxPathDepth > 0 && strings.Count(q, pathSep) > t.MaxPathDepth {
+		return ErrPathTooDeep
+	}
+
+	return nil
+}
+
+func (t *TrieOf[T]) searchPath(q string, params ParamsSetter) *NodeOf[T] {
+	q = t.normalize(q)
 	end := len(q)
 
 	if end == 0 || (end == 1 && q[0] == pathSepB) {
@@ -251,101 +894,326 @@ func (t *Trie) Search(q string, params ParamsSetter) *Node {
 			return t.root.getChild(pathSep)
 		} else if t.hasRootWildcard {
 			// no need to going through setting parameters, this one has not but it is wildcard.
-			return t.root.getChild(WildcardParamStart)
+			if n := t.root.getChild(WildcardParamStart); n.end {
+				return n
+			}
 		}
 
 		return nil
 	}
 
-	n := t.root
-	start := 1
-	i := 1
-	var paramValues []string
-
-	for {
-		if i == end || q[i] == pathSepB {
-			if child := n.getChild(q[start:i]); child != nil {
-				n = child
-			} else if n.childNamedParameter { // && n.childWildcardParameter == false {
-				n = n.getChild(ParamStart)
-				if ln := len(paramValues); cap(paramValues) > ln {
-					paramValues = paramValues[:ln+1]
-					paramValues[ln] = q[start:i]
-				} else {
-					paramValues = append(paramValues, q[start:i])
+	var (
+		buf         [8]string
+		n           *NodeOf[T]
+		paramValues []string
+	)
+
+	if t.hasPriority || t.firstRegistered {
+		// all the end nodes of the path are checked, see `WithPriority` and `Precedence#FirstRegistered`.
+		var best [8]string
+		ps := &prioritySearch[T]{values: best[:0], firstRegistered: t.firstRegistered}
+		t.search(t.root, q, 1, buf[:0], ps)
+		n, paramValues = ps.node, ps.values
+	} else {
+		n, paramValues = t.search(t.root, q, 1, buf[:0], nil)
+	}
+
+	if n == nil {
+		return nil
+	}
+
+	if params == nil {
+		return n
+	}
+
+	for i, paramValue := range paramValues {
+		if len(n.paramKeys) > i {
+			params.Set(n.paramKeys[i], paramValue)
+		}
+	}
+
+	return n
+}
+
+// SearchInto searches for the node of the "q" like `Search` does and it stores its named path parameters
+// to the "params", which are reset first if they implement the `ParamsResetter`,
+// so the same `Params`, `MapParams` or custom storage can be reused by many searches, i.e:
+// var params muxie.Params
+// n := trie.SearchInto("/users/42", &params)
+// params.Get("id") // "42"
+func (t *TrieOf[T]) SearchInto(q string, params ParamsSetter) *NodeOf[T] {
+	if resetter, ok := params.(ParamsResetter); ok {
+		resetter.Reset()
+	}
+
+	return t.Search(q, params)
+}
+
+// search returns the end node which is responsible for the "q[start:]" path segments, starting from "n" children,
+// and the collected parameter values in order.
+// The children are checked by the `Search` priority and
+// if a child cannot lead to an end node then the next one is checked instead, this is how:
+//
+// /hello/*p
+// /hello/:p1/static/:p2
+// req: http://localhost:8080/hello/dsadsa/static/dsadsa => found
+// req: http://localhost:8080/hello/dsadsa => found by the closest wildcard
+// and
+// /second/wild/*p
+// /second/wild/static/otherstatic/
+// req: /second/wild/static/otherstatic/random => found by the closest wildcard.
+func (t *TrieOf[T]) search(n *NodeOf[T], q string, start int, paramValues []string, ps *prioritySearch[T]) (*NodeOf[T], []string) {
+	end := strings.IndexByte(q[start:], pathSepB)
+	if end == -1 {
+		end = len(q)
+	} else {
+		end += start
+	}
+
+	segment := q[start:end]
+	last := end == len(q)
+
+	// the sibling path segments in the search order, see `SetPrecedence`.
+	for i, kind := range defaultOrder {
+		if t.order != nil {
+			kind = t.order[i]
+		}
+
+		switch kind {
+		case SegmentStatic:
+			// static paths, a request path segment which starts with ":" or "*" cannot be matched against the dynamic ones.
+			if segment != "" && (segment[0] == ParamStart[0] || segment[0] == WildcardParamStart[0]) {
+				continue
+			}
+
+			staticSegment := segment
+			if t.CaseInsensitive {
+				staticSegment = strings.ToLower(staticSegment)
+			}
+
+			if child := n.getChild(staticSegment); child != nil {
+				if childEnd := child.edgeEnd(q, end, t.CaseInsensitive); childEnd == len(q) {
+					if child.end {
+						if ps == nil {
+							return child, paramValues
+						}
+						ps.offer(child, paramValues)
+					}
+				} else if childEnd != -1 && ps.canImprove(child) {
+					if found, values := t.search(child, q, childEnd+1, paramValues, ps); found != nil {
+						return found, values
+					}
 				}
-			} else if n.childWildcardParameter {
-				n = n.getChild(WildcardParamStart)
-				if ln := len(paramValues); cap(paramValues) > ln {
-					paramValues = paramValues[:ln+1]
-					paramValues[ln] = q[start:]
-				} else {
-					paramValues = append(paramValues, q[start:])
+			}
+		case SegmentParam:
+			// named parameters.
+			for _, child := range n.paramChildren {
+				if child.paramValidator != nil && !child.paramValidator(segment) {
+					continue
 				}
-				break
-			} else {
-				n = n.findClosestParentWildcardNode()
-				if n != nil {
-					// means that it has :param/static and *wildcard, we go trhough the :param
-					// but the next path segment is not the /static, so go back to *wildcard
-					// instead of not found.
-					//
-					// Fixes:
-					// /hello/*p
-					// /hello/:p1/static/:p2
-					// req: http://localhost:8080/hello/dsadsa/static/dsadsa => found
-					// req: http://localhost:8080/hello/dsadsa => but not found!
-					// and
-					// /second/wild/*p
-					// /second/wild/static/otherstatic/
-					// req: /second/wild/static/otherstatic/random => but not found!
-					params.Set(n.paramKeys[0], q[len(n.staticKey):])
-					return n
+
+				values := append(paramValues, segment)
+				if last {
+					if child.end {
+						if ps == nil {
+							return child, values
+						}
+						ps.offer(child, values)
+					}
+				} else if ps.canImprove(child) {
+					if found, values := t.search(child, q, end+1, values, ps); found != nil {
+						return found, values
+					}
 				}
+			}
+		case SegmentWildcard:
+			// wildcards, which can be the closest wildcard of a path that was not found on the above.
+			if !n.childWildcardParameter {
+				continue
+			}
 
-				return nil
+			child := n.getChild(WildcardParamStart)
+
+			// a mid-path wildcard consumes the fewest path segments (one at least)
+			// that the rest of its path pattern can be matched against.
+			if !last && len(child.children) > 0 && ps.canImprove(child) {
+				for i := end; ; {
+					if found, values := t.search(child, q, i+1, append(paramValues, q[start:i]), ps); found != nil {
+						return found, values
+					}
+
+					next := strings.IndexByte(q[i+1:], pathSepB)
+					if next == -1 {
+						break
+					}
+					i += next + 1
+				}
 			}
 
-			if i == end {
-				break
+			// a trailing wildcard consumes all the rest path segments.
+			if child.end {
+				if ps == nil {
+					return child, append(paramValues, q[start:])
+				}
+				ps.offer(child, append(paramValues, q[start:]))
 			}
+		}
+	}
 
-			i++
-			start = i
-			continue
+	return nil, nil
+}
+
+// prioritySearch collects the end node with the highest priority, see `WithPriority`.
+// On ties, the first found wins, it is the one that the search order selects,
+// or the first registered one if "firstRegistered" is true, see `Precedence`.
+type prioritySearch[T any] struct {
+	node            *NodeOf[T]
+	values          []string
+	firstRegistered bool
+}
+
+func (ps *prioritySearch[T]) offer(n *NodeOf[T], values []string) {
+	if ps.node == nil || n.priority > ps.node.priority ||
+		(ps.firstRegistered && n.priority == ps.node.priority && n.seq < ps.node.seq) {
+		ps.node = n
+		// the "values" backing array is reused by the rest of the search.
+		ps.values = append(ps.values[:0], values...)
+	}
+}
+
+// canImprove reports whether the node or its children can have a higher priority than the collected end node.
+func (ps *prioritySearch[T]) canImprove(n *NodeOf[T]) bool {
+	return ps == nil || ps.node == nil || n.maxPriority > ps.node.priority ||
+		(ps.firstRegistered && n.maxPriority == ps.node.priority)
+}
+
+// LongestPrefix returns the end node of the longest inserted path pattern which matches the start of the path "q",
+// on whole path segments, i.e "/api/v1" for the "/api/v1/users/42" when the "/api" and "/api/v1" are inserted,
+// and it stores its named path parameters, if any. The `Search` order applies on ties and the priorities are ignored.
+// It returns nil if none of the inserted path patterns is a prefix of "q".
+//
+// Useful for gateways, access control lists and fallback handlers.
+// The `SearchPrefix` returns the node of an inserted path pattern's prefix instead.
+func (t *TrieOf[T]) LongestPrefix(q string, params ParamsSetter) *NodeOf[T] {
+	q = t.normalize(q)
+	if q == "" || q[0] != pathSepB {
+		q = pathSep + q
+	}
+
+	var (
+		buf [8]string
+		lp  = &longestPrefix[T]{values: buf[:0]}
+	)
+
+	if t.hasRootSlash {
+		lp.offer(t.root.getChild(pathSep), 1, nil)
+	}
+
+	if len(q) > 1 {
+		t.longestPrefix(t.root, q, 1, nil, lp)
+	} else if !t.hasRootSlash && t.hasRootWildcard {
+		if n := t.root.getChild(WildcardParamStart); n.end {
+			return n
 		}
+	}
 
-		i++
+	if lp.node == nil {
+		return nil
 	}
 
-	if n == nil || !n.end {
-		if n != nil { // we need it on both places, on last segment (below) or on the first unnknown (above).
-			if n = n.findClosestParentWildcardNode(); n != nil {
-				params.Set(n.paramKeys[0], q[len(n.staticKey):])
-				return n
+	if params == nil {
+		return lp.node
+	}
+
+	for i, paramValue := range lp.values {
+		if len(lp.node.paramKeys) > i {
+			params.Set(lp.node.paramKeys[i], paramValue)
+		}
+	}
+
+	return lp.node
+}
+
+// longestPrefix offers the end nodes of the "q[start:]" path segments, starting from "n" children,
+// to the "lp", in the `Search` order.
+func (t *TrieOf[T]) longestPrefix(n *NodeOf[T], q string, start int, paramValues []string, lp *longestPrefix[T]) {
+	end := strings.IndexByte(q[start:], pathSepB)
+	if end == -1 {
+		end = len(q)
+	} else {
+		end += start
+	}
+
+	segment := q[start:end]
+
+	if segment == "" || (segment[0] != ParamStart[0] && segment[0] != WildcardParamStart[0]) {
+		staticSegment := segment
+		if t.CaseInsensitive {
+			staticSegment = strings.ToLower(staticSegment)
+		}
+
+		if child := n.getChild(staticSegment); child != nil {
+			if childEnd := child.edgeEnd(q, end, t.CaseInsensitive); childEnd != -1 {
+				if child.end {
+					lp.offer(child, childEnd, paramValues)
+				}
+
+				if childEnd < len(q) {
+					t.longestPrefix(child, q, childEnd+1, paramValues, lp)
+				}
 			}
 		}
+	}
+
+	for _, child := range n.paramChildren {
+		if child.paramValidator != nil && !child.paramValidator(segment) {
+			continue
+		}
 
-		if t.hasRootWildcard {
-			// that's the case for root wildcard, tests are passing
-			// even without it but stick with it for reference.
-			// Note ote that something like:
-			// Routes: /other2/*myparam and /other2/static
-			// Reqs: /other2/staticed will be handled
-			// by the /other2/*myparam and not the root wildcard (see above), which is what we want.
-			n = t.root.getChild(WildcardParamStart)
-			params.Set(n.paramKeys[0], q[1:])
-			return n
+		values := append(paramValues, segment)
+		if child.end {
+			lp.offer(child, end, values)
 		}
 
-		return nil
+		if end < len(q) {
+			t.longestPrefix(child, q, end+1, values, lp)
+		}
 	}
 
-	for i, paramValue := range paramValues {
-		if len(n.paramKeys) > i {
-			params.Set(n.paramKeys[i], paramValue)
+	if n.childWildcardParameter {
+		child := n.getChild(WildcardParamStart)
+
+		if len(child.children) > 0 {
+			for i := end; i < len(q); {
+				t.longestPrefix(child, q, i+1, append(paramValues, q[start:i]), lp)
+
+				next := strings.IndexByte(q[i+1:], pathSepB)
+				if next == -1 {
+					break
+				}
+				i += next + 1
+			}
+		}
+
+		if child.end {
+			lp.offer(child, len(q), append(paramValues, q[start:]))
 		}
 	}
+}
 
-	return n
+// longestPrefix collects the end node which matches the most bytes of the path, see `TrieOf#LongestPrefix`.
+// On ties, the first found wins, it is the one that the default search order selects.
+type longestPrefix[T any] struct {
+	node   *NodeOf[T]
+	length int
+	values []string
+}
+
+func (lp *longestPrefix[T]) offer(n *NodeOf[T], length int, values []string) {
+	if lp.node == nil || length > lp.length {
+		lp.node = n
+		lp.length = length
+		// the "values" backing array is reused by the rest of the search.
+		lp.values = append(lp.values[:0], values...)
+	}
 }
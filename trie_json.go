@@ -0,0 +1,266 @@
+package muxie
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// ErrHandlerNotRegistered is the error that the `TrieOf#MarshalJSON`, `TrieOf#UnmarshalJSON`
+// and `TrieOf#InsertMany` return for the handlers that are not registered by name, see `RegisterHandler`.
+var ErrHandlerNotRegistered = errors.New("muxie: the handler is not registered")
+
+var (
+	namedHandlersMu sync.RWMutex
+	namedHandlers   = make(map[string]*namedHandler)
+)
+
+type namedHandler struct {
+	name string
+	http.Handler
+}
+
+// RegisterHandler registers a new, or overrides an existing, handler by its name
+// so the path patterns of a `Trie` can be persisted and loaded with their handlers, see `TrieOf#MarshalJSON`.
+// It returns the handler which should be inserted to the trie, i.e:
+// users := muxie.RegisterHandler("users.list", http.HandlerFunc(listUsers))
+// mux.Handle("/users", users)
+// data, err := json.Marshal(mux.Routes)
+// [...]
+// routes := muxie.NewTrie()
+// err = json.Unmarshal(data, routes)
+func RegisterHandler(name string, handler http.Handler) http.Handler {
+	if name == "" {
+		panic("muxie/RegisterHandler: empty handler name")
+	}
+
+	if handler == nil {
+		panic("muxie/RegisterHandler: empty handler")
+	}
+
+	named := &namedHandler{name: name, Handler: handler}
+
+	namedHandlersMu.Lock()
+	namedHandlers[name] = named
+	namedHandlersMu.Unlock()
+	return named
+}
+
+// LookupHandler returns the handler that is registered by that name, see `RegisterHandler`,
+// and reports whether it is registered.
+func LookupHandler(name string) (http.Handler, bool) {
+	namedHandlersMu.RLock()
+	handler, ok := namedHandlers[name]
+	namedHandlersMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	return handler, true
+}
+
+// handlerName returns the registered name of a handler, the `Mux` route handlers are resolved to their main handler.
+func handlerName(handler http.Handler) (string, bool) {
+	if h, ok := handler.(*routeHandler); ok {
+		handler = h.main
+	}
+
+	if h, ok := handler.(*namedHandler); ok {
+		return h.name, true
+	}
+
+	return "", false
+}
+
+// TrieEntry is an inserted path pattern with its data,
+// the JSON form of a `TrieOf` is a list of them, see `TrieOf#InsertMany` too.
+// The `Node#Data` is not included.
+type TrieEntry struct {
+	Pattern  string `json:"pattern"`
+	Tag      string `json:"tag,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+	// Handler is the registered name of the handler, see `RegisterHandler`.
+	Handler string `json:"handler,omitempty"`
+	// Methods are the registered names of the per HTTP method handlers, see `WithMethodHandler`.
+	Methods map[string]string `json:"methods,omitempty"`
+	// Value is the JSON value of the node for a `TrieOf` any other type than http.Handler.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Entries returns the inserted path patterns, sorted by their path segments, with their data.
+// It returns an error which wraps the `ErrHandlerNotRegistered` if a handler is not registered by name.
+func (t *TrieOf[T]) Entries() ([]TrieEntry, error) {
+	var (
+		entries []TrieEntry
+		err     error
+	)
+
+	t.walk(func(n *NodeOf[T]) bool {
+		entry := TrieEntry{Pattern: n.key, Tag: n.Tag, Priority: n.priority}
+
+		if len(n.methodsAllowed) > 0 {
+			entry.Methods = make(map[string]string, len(n.methodsAllowed))
+			for _, method := range n.methodsAllowed {
+				name, ok := handlerName(n.methodHandlers[method])
+				if !ok {
+					err = fmt.Errorf("%w: %s %q", ErrHandlerNotRegistered, method, n.key)
+					return false
+				}
+				entry.Methods[method] = name
+			}
+		} else if value := interface{}(n.Handler); value != nil {
+			if handler, ok := value.(http.Handler); ok {
+				name, ok := handlerName(handler)
+				if !ok {
+					err = fmt.Errorf("%w: %q", ErrHandlerNotRegistered, n.key)
+					return false
+				}
+				entry.Handler = name
+			} else if entry.Value, err = json.Marshal(value); err != nil {
+				return false
+			}
+		}
+
+		entries = append(entries, entry)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// MarshalJSON returns the `Entries` of the trie as a JSON list.
+// The handlers are referenced by their registered names, see `RegisterHandler`.
+func (t *TrieOf[T]) MarshalJSON() ([]byte, error) {
+	entries, err := t.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	if entries == nil {
+		entries = []TrieEntry{}
+	}
+
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON inserts the path patterns of a JSON list of `TrieEntry`, see `InsertMany`.
+func (t *TrieOf[T]) UnmarshalJSON(data []byte) error {
+	var entries []TrieEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+
+	if t.root == nil {
+		t.root = new(NodeOf[T])
+	}
+
+	return t.InsertMany(entries)
+}
+
+// InsertMany inserts the path patterns of the "entries", i.e the ones that are loaded from a configuration file.
+// The handlers are resolved by their registered names, see `RegisterHandler`.
+//
+// It returns a `*PatternError` for a malformed path pattern, an error which wraps the `ErrHandlerNotRegistered`
+// for an unknown handler name or the JSON error of an invalid `TrieEntry#Value`,
+// none of the entries is inserted then.
+func (t *TrieOf[T]) InsertMany(entries []TrieEntry) error {
+	options := make([][]InsertOptionOf[T], len(entries))
+	for i, entry := range entries {
+		if err := validatePattern(entry.Pattern); err != nil {
+			return err
+		}
+
+		opts, err := entryOptions[T](entry)
+		if err != nil {
+			return err
+		}
+		options[i] = opts
+	}
+
+	for i, entry := range entries {
+		t.insertPattern(entry.Pattern, options[i])
+	}
+
+	return nil
+}
+
+func entryOptions[T any](entry TrieEntry) ([]InsertOptionOf[T], error) {
+	var options []InsertOptionOf[T]
+
+	lookup := func(name string) (http.Handler, error) {
+		handler, ok := LookupHandler(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q of %q", ErrHandlerNotRegistered, name, entry.Pattern)
+		}
+
+		return handler, nil
+	}
+
+	switch {
+	case len(entry.Methods) > 0:
+		methods := make([]string, 0, len(entry.Methods))
+		for method := range entry.Methods {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			handler, err := lookup(entry.Methods[method])
+			if err != nil {
+				return nil, err
+			}
+
+			methods := parseMethods(method)
+			options = append(options, func(n *NodeOf[T]) {
+				for _, method := range methods {
+					n.setMethodHandler(method, handler)
+				}
+			})
+		}
+	case entry.Handler != "":
+		handler, err := lookup(entry.Handler)
+		if err != nil {
+			return nil, err
+		}
+
+		value, ok := handler.(T)
+		if !ok {
+			return nil, fmt.Errorf("muxie: the handler %q of %q can not be a value of the trie", entry.Handler, entry.Pattern)
+		}
+
+		options = append(options, func(n *NodeOf[T]) {
+			n.Handler = value
+			n.resetMethodHandlers()
+		})
+	case len(entry.Value) > 0:
+		var value T
+		if err := json.Unmarshal(entry.Value, &value); err != nil {
+			return nil, err
+		}
+
+		options = append(options, WithValue(value))
+	}
+
+	if entry.Tag != "" {
+		tag := entry.Tag
+		options = append(options, func(n *NodeOf[T]) {
+			n.Tag = tag
+		})
+	}
+
+	if entry.Priority != 0 {
+		priority := entry.Priority
+		options = append(options, func(n *NodeOf[T]) {
+			n.setPriority(priority)
+		})
+	}
+
+	return options, nil
+}
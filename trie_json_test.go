@@ -0,0 +1,118 @@
+package muxie
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestTrieJSON(t *testing.T) {
+	users := RegisterHandler("test.users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	}))
+	createUser := RegisterHandler("test.users.create", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("create user"))
+	}))
+
+	mux := NewMux()
+	mux.Handle("/users", users).Name("users")
+	mux.HandleMethod("POST", "/users/:id", createUser)
+	mux.HandleMethod("GET", "/users/:id", users)
+
+	data, err := json.Marshal(mux.Routes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := `[{"pattern":"/users","tag":"users","handler":"test.users"},`+
+		`{"pattern":"/users/:id","methods":{"GET":"test.users","POST":"test.users.create"}}]`, string(data); expected != got {
+		t.Fatalf("expected json:\n%s\nbut got:\n%s", expected, got)
+	}
+
+	var routes Trie
+	if err = json.Unmarshal(data, &routes); err != nil {
+		t.Fatal(err)
+	}
+
+	n := expectSearch(t, &routes, "/users", "users", nil)
+	if n.Handler != users {
+		t.Fatalf("expected the registered handler")
+	}
+	n = expectSearch(t, &routes, "/users/42", "", []ParamEntry{{"id", "42"}})
+	if h, ok := n.MethodHandler(http.MethodPost); !ok || h != createUser {
+		t.Fatalf("expected the registered POST handler")
+	}
+
+	if again, err := json.Marshal(&routes); err != nil || string(again) != string(data) {
+		t.Fatalf("expected the same json but got: %s (%v)", again, err)
+	}
+
+	mux.HandleFunc("/unnamed", func(w http.ResponseWriter, r *http.Request) {})
+	if _, err = json.Marshal(mux.Routes); !errors.Is(err, ErrHandlerNotRegistered) {
+		t.Fatalf("expected the ErrHandlerNotRegistered but got: %v", err)
+	}
+}
+
+func TestTrieInsertMany(t *testing.T) {
+	tree := NewTrie()
+	err := tree.InsertMany([]TrieEntry{
+		{Pattern: "/files/*path", Handler: "test.files"},
+		{Pattern: "/about", Tag: "about"},
+	})
+	if !errors.Is(err, ErrHandlerNotRegistered) {
+		t.Fatalf("expected the ErrHandlerNotRegistered but got: %v", err)
+	}
+
+	err = tree.InsertMany([]TrieEntry{
+		{Pattern: "/about", Tag: "about"},
+		{Pattern: "/users/:id/:id"},
+	})
+	if _, ok := err.(*PatternError); !ok {
+		t.Fatalf("expected a *PatternError but got: %v", err)
+	}
+
+	if len(tree.root.children) != 0 {
+		t.Fatalf("expected none of the entries to be inserted")
+	}
+
+	if err = tree.InsertMany([]TrieEntry{
+		{Pattern: "/about", Tag: "about"},
+		{Pattern: "/users/new", Tag: "new"},
+		{Pattern: "/users/:id", Tag: "user", Priority: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectSearch(t, tree, "/about", "about", nil)
+	expectSearch(t, tree, "/users/new", "user", []ParamEntry{{"id", "new"}})
+}
+
+func TestTrieOfJSON(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	tree := NewTrieOf[*user]()
+	tree.Insert("/users/:id", WithValue(&user{Name: "kataras"}))
+	tree.Insert("/users/:id/posts")
+
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected, got := `[{"pattern":"/users/:id","value":{"name":"kataras"}},`+
+		`{"pattern":"/users/:id/posts","value":null}]`, string(data); expected != got {
+		t.Fatalf("expected json:\n%s\nbut got:\n%s", expected, got)
+	}
+
+	loaded := NewTrieOf[*user]()
+	if err = json.Unmarshal(data, loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := loaded.Search("/users/42", new(paramsWriter)); n == nil || n.Value() == nil || n.Value().Name != "kataras" {
+		t.Fatalf("expected the value to be loaded")
+	}
+}
@@ -0,0 +1,99 @@
+package muxie
+
+import (
+	"unsafe"
+)
+
+// TrieStats is the shape of a trie, see `TrieOf#Stats`.
+type TrieStats struct {
+	// Patterns is the number of the inserted path patterns.
+	Patterns int
+	// Nodes is the number of the nodes, without the root one,
+	// the Static, Params and Wildcards are the numbers of the nodes of each path segment kind.
+	Nodes     int
+	Static    int
+	Params    int
+	Wildcards int
+	// MaxDepth is the number of the nodes of the longest path from the root,
+	// the compressed static path segments are counted once, see `Trie`.
+	MaxDepth int
+	// Memory is an approximation of the bytes that the nodes use,
+	// without the handlers, the values and the data of the nodes.
+	Memory int
+}
+
+// the approximate bytes of each map entry and each map, the Go maps keep extra space for their growth.
+const (
+	mapEntrySize = 48
+	mapSize      = 48
+)
+
+// Stats returns the number of the nodes, by their kind, the max depth
+// and the approximate memory of the trie, i.e to monitor the growth of a routing table
+// which its routes are registered at runtime.
+func (t *TrieOf[T]) Stats() TrieStats {
+	var stats TrieStats
+
+	visited := make(map[string]struct{})
+	t.root.stats(0, &stats, visited)
+	stats.Memory += int(unsafe.Sizeof(*t))
+
+	return stats
+}
+
+func (n *NodeOf[T]) stats(depth int, stats *TrieStats, visited map[string]struct{}) {
+	if depth > 0 {
+		stats.Nodes++
+
+		switch n.segment[0] {
+		case ParamStart[0]:
+			stats.Params++
+		case WildcardParamStart[0]:
+			stats.Wildcards++
+		default:
+			stats.Static++
+		}
+	}
+
+	if depth > stats.MaxDepth {
+		stats.MaxDepth = depth
+	}
+
+	if n.end {
+		// a path pattern with optional named parameters has many nodes.
+		if _, ok := visited[n.key]; !ok {
+			visited[n.key] = struct{}{}
+			stats.Patterns++
+		}
+	}
+
+	stats.Memory += n.memory()
+	for _, child := range n.children {
+		child.stats(depth+1, stats, visited)
+	}
+}
+
+// memory returns the approximate memory of the node, its fields and its maps.
+func (n *NodeOf[T]) memory() int {
+	size := int(unsafe.Sizeof(*n))
+	size += len(n.segment) + len(n.edge) + len(n.Tag)
+	if n.end {
+		// the static key is a part of the key.
+		size += len(n.key)
+	}
+
+	if n.children != nil {
+		size += mapSize + len(n.children)*mapEntrySize
+	}
+	size += cap(n.paramChildren) * int(unsafe.Sizeof(n))
+	// the param keys are parts of the key.
+	size += cap(n.paramKeys) * int(unsafe.Sizeof(""))
+
+	if n.methodHandlers != nil {
+		size += mapSize + len(n.methodHandlers)*mapEntrySize
+	}
+	size += cap(n.methodsAllowed)*int(unsafe.Sizeof("")) + len(n.methodsAllowedStr)
+	size += cap(n.conditionals) * int(unsafe.Sizeof(n))
+
+	return size
+}
@@ -0,0 +1,30 @@
+package muxie
+
+import (
+	"testing"
+)
+
+func TestTrieStats(t *testing.T) {
+	tree := NewTrie()
+	if stats := tree.Stats(); stats.Nodes != 0 || stats.Patterns != 0 || stats.MaxDepth != 0 || stats.Memory == 0 {
+		t.Fatalf("unexpected stats of an empty trie: %#+v", stats)
+	}
+
+	tree.Insert("/")
+	tree.Insert("/api/v1/users")
+	tree.Insert("/api/v1/users/:id")
+	tree.Insert("/api/v1/users/:id/posts/:slug?")
+	tree.Insert("/files/*path")
+
+	// "/", "api/v1/users" (compressed), ":id", "posts", ":slug", "files" and "*path".
+	stats := tree.Stats()
+	if expected := (TrieStats{Patterns: 5, Nodes: 7, Static: 4, Params: 2, Wildcards: 1, MaxDepth: 4, Memory: stats.Memory}); expected != stats {
+		t.Fatalf("expected stats:\n%#+v\nbut got:\n%#+v", expected, stats)
+	}
+
+	before := stats.Memory
+	tree.Insert("/api/v1/orders/:id")
+	if stats := tree.Stats(); stats.Memory <= before || stats.Nodes != 10 {
+		t.Fatalf("expected the stats to grow but got: %#+v", stats)
+	}
+}
@@ -1,6 +1,8 @@
 package muxie
 
 import (
+	"net/http"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -190,3 +192,608 @@ func TestTrie(t *testing.T) {
 	t.Logf("Test node one by one\n")
 	testTrie(t, true)
 }
+
+// expectSearch fails if the "path" is not found or its node's tag and parameters are not the expected ones.
+func expectSearch(t *testing.T, tree *Trie, path, tag string, params []ParamEntry) *Node {
+	t.Helper()
+
+	pw := new(paramsWriter)
+	n := tree.Search(path, pw)
+	if n == nil {
+		t.Fatalf("%s: expected to be found", path)
+	}
+
+	if expected, got := tag, n.Tag; expected != got {
+		t.Fatalf("%s: expected tag to be: '%s' but got: '%s'", path, expected, got)
+	}
+
+	if expected, got := len(params), len(pw.params); expected != got {
+		t.Fatalf("%s: expected %d params but got %d", path, expected, got)
+	}
+
+	for i, p := range params {
+		if got := pw.params[i]; p != got {
+			t.Fatalf("%s: expected param %v but got %v", path, p, got)
+		}
+	}
+
+	return n
+}
+
+func TestTrieOptionalParams(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/posts/:year/:month?/:day?", WithTag("posts"))
+	tree.Insert("/:lang?", WithTag("index"))
+
+	for _, tt := range []struct {
+		path   string
+		tag    string
+		params []ParamEntry
+	}{
+		{"/posts/2024", "posts", []ParamEntry{{"year", "2024"}}},
+		{"/posts/2024/05", "posts", []ParamEntry{{"year", "2024"}, {"month", "05"}}},
+		{"/posts/2024/05/09", "posts", []ParamEntry{{"year", "2024"}, {"month", "05"}, {"day", "09"}}},
+		{"/", "index", nil},
+		{"/en", "index", []ParamEntry{{"lang", "en"}}},
+	} {
+		expectSearch(t, tree, tt.path, tt.tag, tt.params)
+	}
+
+	if n := tree.Search("/posts/2024/05/09/more", new(paramsWriter)); n != nil {
+		t.Fatalf("expected to not be found but got: %s", n.String())
+	}
+
+	if expected, got := "/posts/:year/:month?/:day?", tree.Search("/posts/2024", new(paramsWriter)).String(); expected != got {
+		t.Fatalf("expected key to be: '%s' but got: '%s'", expected, got)
+	}
+
+	if expected, got := []string{"/posts/:year/:month?/:day?"}, tree.Autocomplete("/posts", nil); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected the expansions to be autocompleted once: %v but got: %v", expected, got)
+	}
+}
+
+func TestTrieOptionalParamsNotTrailing(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic, optional named parameters can only be trailing")
+		}
+	}()
+
+	NewTrie().Insert("/posts/:year?/archive")
+}
+
+func TestTrieMidPathWildcards(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/files/*path/meta", WithTag("meta"))
+	tree.Insert("/files/*path", WithTag("files"))
+	tree.Insert("/*tenant/docs/*path", WithTag("docs"))
+
+	for _, tt := range []struct {
+		path   string
+		tag    string
+		params []ParamEntry
+	}{
+		{"/files/a/meta", "meta", []ParamEntry{{"path", "a"}}},
+		{"/files/a/b/c/meta", "meta", []ParamEntry{{"path", "a/b/c"}}},
+		{"/files/a/meta/meta", "meta", []ParamEntry{{"path", "a/meta"}}},
+		{"/files/a/b", "files", []ParamEntry{{"path", "a/b"}}},
+		{"/files/meta", "files", []ParamEntry{{"path", "meta"}}},
+		{"/acme/docs/readme.md", "docs", []ParamEntry{{"tenant", "acme"}, {"path", "readme.md"}}},
+		{"/acme/eu/docs/docs/a/b", "docs", []ParamEntry{{"tenant", "acme/eu"}, {"path", "docs/a/b"}}},
+	} {
+		expectSearch(t, tree, tt.path, tt.tag, tt.params)
+	}
+
+	for _, path := range []string{"/", "/acme", "/acme/docs"} {
+		if n := tree.Search(path, new(paramsWriter)); n != nil {
+			t.Fatalf("%s: expected to not be found but got: %s", path, n.String())
+		}
+	}
+}
+
+func TestTrieWalk(t *testing.T) {
+	tree := NewTrie()
+	initTree(tree)
+
+	var patterns []string
+	tree.Walk(func(pattern string, methods []string, handler http.Handler) bool {
+		patterns = append(patterns, pattern)
+		return true
+	})
+
+	if expected, got := len(tests), len(patterns); expected != got {
+		t.Fatalf("expected %d patterns but got %d", expected, got)
+	}
+
+	for i := 1; i < len(patterns); i++ {
+		if patterns[i-1] == patterns[i] {
+			t.Fatalf("pattern %s visited twice", patterns[i])
+		}
+	}
+
+	var visited int
+	tree.Walk(func(pattern string, methods []string, handler http.Handler) bool {
+		visited++
+		return visited < 3
+	})
+
+	if expected, got := 3, visited; expected != got {
+		t.Fatalf("expected walk to be stopped after %d patterns but got %d", expected, got)
+	}
+}
+
+func TestTrieDelete(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/", WithTag("index"))
+	tree.Insert("/users/:id/posts/:post", WithTag("post"))
+	tree.Insert("/users/:id", WithTag("user"))
+	tree.Insert("/files/*path", WithTag("files"))
+	tree.Insert("/posts/:year/:month?", WithTag("posts"))
+
+	if tree.Delete("/users/:name") {
+		t.Fatalf("expected a not inserted pattern to not be deleted")
+	}
+
+	if !tree.Delete("/users/:id/posts/:post") {
+		t.Fatalf("expected pattern to be deleted")
+	}
+
+	if n := tree.Search("/users/42/posts/1", new(paramsWriter)); n != nil {
+		t.Fatalf("expected deleted pattern to not be found but got: %s", n.String())
+	}
+
+	if n := tree.SearchPrefix("/users/:id"); n == nil || len(n.children) != 0 {
+		t.Fatalf("expected the empty nodes of the deleted pattern to be removed")
+	}
+
+	expectSearch(t, tree, "/users/42", "user", []ParamEntry{{"id", "42"}})
+
+	for _, pattern := range []string{"/", "/files/*path", "/posts/:year/:month?", "/users/:id"} {
+		if !tree.Delete(pattern) {
+			t.Fatalf("expected pattern %s to be deleted", pattern)
+		}
+	}
+
+	if expected, got := 0, len(tree.root.children); expected != got {
+		t.Fatalf("expected an empty trie but got %d root children", got)
+	}
+
+	for _, path := range []string{"/", "/files/a", "/posts/2024", "/posts/2024/05", "/users/42"} {
+		if n := tree.Search(path, new(paramsWriter)); n != nil {
+			t.Fatalf("%s: expected to not be found but got: %s", path, n.String())
+		}
+	}
+}
+
+func TestTrieDeleteCompression(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/api/v1/users", WithTag("users"))
+	tree.Insert("/api/v1/users/:id/posts", WithTag("posts"))
+	tree.Insert("/api/v1/users/:id", WithTag("user"))
+	tree.Insert("/api/v1/orders/list", WithTag("orders"))
+
+	if !tree.Delete("/api/v1/orders/list") {
+		t.Fatalf("expected pattern to be deleted")
+	}
+
+	// "/api/v1" and "/users" merged back together.
+	users := tree.root.getChild("api")
+	if users == nil || users.edge != "/v1/users" || users.key != "/api/v1/users" || len(tree.root.children) != 1 {
+		t.Fatalf("expected the nodes to be merged back together")
+	}
+
+	if !tree.Delete("/api/v1/users/:id") {
+		t.Fatalf("expected pattern to be deleted")
+	}
+
+	// the named parameters are not merged with their static children.
+	if id := users.getChild(ParamStart); id == nil || id.end || id.edge != "" || id.getChild("posts") == nil {
+		t.Fatalf("expected the named parameter node to be kept without its data")
+	}
+
+	expectSearch(t, tree, "/api/v1/users/42/posts", "posts", []ParamEntry{{"id", "42"}})
+	if n := tree.Search("/api/v1/users/42", new(paramsWriter)); n != nil {
+		t.Fatalf("expected deleted pattern to not be found but got: %s", n.String())
+	}
+
+	if !tree.Delete("/api/v1/users") {
+		t.Fatalf("expected pattern to be deleted")
+	}
+
+	expectSearch(t, tree, "/api/v1/users/42/posts", "posts", []ParamEntry{{"id", "42"}})
+	if n := tree.Search("/api/v1/users", new(paramsWriter)); n != nil {
+		t.Fatalf("expected deleted pattern to not be found but got: %s", n.String())
+	}
+
+	tree.Insert("/api/v1/users", WithTag("users"))
+	expectSearch(t, tree, "/api/v1/users", "users", nil)
+	expectSearch(t, tree, "/api/v1/users/42/posts", "posts", []ParamEntry{{"id", "42"}})
+
+	// a deleted node is merged with its only static child.
+	tree.Insert("/docs", WithTag("docs"))
+	tree.Insert("/docs/guide/intro", WithTag("intro"))
+	if !tree.Delete("/docs") {
+		t.Fatalf("expected pattern to be deleted")
+	}
+
+	if n := tree.root.getChild("docs"); n == nil || n.edge != "/guide/intro" || n.key != "/docs/guide/intro" {
+		t.Fatalf("expected the deleted node to be merged with its child")
+	}
+	expectSearch(t, tree, "/docs/guide/intro", "intro", nil)
+}
+
+func TestTrieMethodHandlers(t *testing.T) {
+	tree := NewTrie()
+	getHandler, postHandler := http.NotFoundHandler(), http.RedirectHandler("/", http.StatusFound)
+	tree.Insert("/users/:id", WithMethodHandler(http.MethodGet, getHandler))
+	tree.Insert("/users/:id", WithMethodHandler("post, put", postHandler))
+
+	n := tree.Search("/users/42", new(paramsWriter))
+	if n == nil {
+		t.Fatalf("expected a node for /users/42")
+	}
+
+	if expected, got := "GET,POST,PUT", strings.Join(n.AllowedMethods(), ","); expected != got {
+		t.Fatalf("expected allowed methods: %s but got: %s", expected, got)
+	}
+
+	if handler, ok := n.MethodHandler(http.MethodPut); !ok || handler != postHandler {
+		t.Fatalf("expected the POST handler for the PUT method")
+	}
+
+	if _, ok := n.MethodHandler(http.MethodDelete); ok {
+		t.Fatalf("expected no handler for the DELETE method")
+	}
+
+	// a handler for any method removes the per method ones.
+	tree.Insert("/users/:id", WithHandler(getHandler))
+	if n = tree.Search("/users/42", new(paramsWriter)); len(n.AllowedMethods()) != 0 || n.Handler == nil {
+		t.Fatalf("expected the per method handlers to be replaced by the handler")
+	}
+}
+
+func TestTriePriority(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/users/new", WithTag("new"))
+	tree.Insert("/users/:id", WithTag("user"), WithPriority(1))
+	tree.Insert("/users/:id/posts", WithTag("posts"))
+	tree.Insert("/users/*rest", WithTag("rest"), WithPriority(2))
+	tree.Insert("/files/:name", WithTag("file"), WithPriority(-1))
+	tree.Insert("/files/*path", WithTag("files"))
+	tree.Insert("/about", WithTag("about"))
+
+	expectSearch(t, tree, "/users/new", "rest", []ParamEntry{{"rest", "new"}})
+	expectSearch(t, tree, "/users/42/posts", "rest", []ParamEntry{{"rest", "42/posts"}})
+	expectSearch(t, tree, "/files/readme.md", "files", []ParamEntry{{"path", "readme.md"}})
+	expectSearch(t, tree, "/about", "about", nil)
+
+	tree.Insert("/users/*rest", WithTag("rest"))
+	expectSearch(t, tree, "/users/new", "user", []ParamEntry{{"id", "new"}})
+	expectSearch(t, tree, "/users/42/posts", "posts", []ParamEntry{{"id", "42"}})
+}
+
+func TestTrieInsertErr(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		err     string
+	}{
+		{"/users/:id/posts/:slug?", ""},
+		{"/files/*", ""},
+		{"/v1/items:batchGet", ""},
+		{"", `muxie/trie#Insert: empty pattern at position 0 of ""`},
+		{"users", `muxie/trie#Insert: the pattern should start with a slash at position 0 of "users"`},
+		{"/users/:", `muxie/trie#Insert: empty parameter name at position 8 of "/users/:"`},
+		{"/users/:int", ""},
+		{"/users/::int", `muxie/trie#Insert: empty parameter name at position 8 of "/users/::int"`},
+		{"/users/:id/posts/:id", `muxie/trie#Insert: duplicate parameter name "id" at position 17 of "/users/:id/posts/:id"`},
+		{"/files/*path/meta/:path", `muxie/trie#Insert: duplicate parameter name "path" at position 18 of "/files/*path/meta/:path"`},
+		{"/files/a*path", ""}, // a static segment, only a starting "*" is a wildcard.
+		{"/files/*path?", `muxie/trie#Insert: a wildcard can not be optional at position 12 of "/files/*path?"`},
+		{"/posts/:year?/archive", `muxie/trie#Insert: only trailing named parameters can be optional at position 14 of "/posts/:year?/archive"`},
+		{"/users/:id:unknown", `muxie/trie#Insert: unknown parameter type "unknown" at position 10 of "/users/:id:unknown"`},
+	}
+
+	for _, tt := range tests {
+		err := NewTrie().InsertErr(tt.pattern)
+		if tt.err == "" {
+			if err != nil {
+				t.Fatalf("expected \"%s\" to be inserted but got: %v", tt.pattern, err)
+			}
+			continue
+		}
+
+		if _, ok := err.(*PatternError); !ok || err.Error() != tt.err {
+			t.Fatalf("expected error: %s but got: %v", tt.err, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Insert to panic for a malformed pattern")
+		}
+	}()
+
+	NewTrie().Insert("/users/:id/:id")
+}
+
+func TestTrieStaticAsterisk(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/files/a*b", WithTag("static"))
+	tree.Insert("/files/:name", WithTag("param"))
+
+	expectSearch(t, tree, "/files/a*b", "static", nil)
+	expectSearch(t, tree, "/files/ab", "param", []ParamEntry{{"name", "ab"}})
+}
+
+func TestTrieSearchErr(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/users/:id/posts", WithTag("posts"))
+	tree.Insert("/*path", WithTag("root wildcard"))
+
+	n, err := tree.SearchErr("/users/42/posts", nil)
+	if err != nil || n == nil || n.Tag != "posts" {
+		t.Fatalf("expected the posts node but got: %v, %v", n, err)
+	}
+
+	tree.MaxPathDepth = 3
+	expectSearch(t, tree, "/users/42/posts", "posts", []ParamEntry{{"id", "42"}})
+	if _, err = tree.SearchErr("/users/42/posts/1", nil); err != ErrPathTooDeep {
+		t.Fatalf("expected error: %v but got: %v", ErrPathTooDeep, err)
+	}
+	if n = tree.Search(strings.Repeat("/", 10000), nil); n != nil {
+		t.Fatalf("expected a deep path to be rejected but got: %s", n.key)
+	}
+
+	tree.MaxPathLength = 10
+	if _, err = tree.SearchErr("/users/42/posts", nil); err != ErrPathTooLong {
+		t.Fatalf("expected error: %v but got: %v", ErrPathTooLong, err)
+	}
+	expectSearch(t, tree, "/users/42", "root wildcard", []ParamEntry{{"path", "users/42"}})
+}
+
+func TestTrieInsertMeta(t *testing.T) {
+	tree := NewTrie()
+	n := tree.Insert("/posts/:year/:month?", WithTag("posts")).SetMeta("docs", "the posts").SetMeta("rate", 100)
+	if expected, got := "/posts/:year/:month?", n.key; expected != got {
+		t.Fatalf("expected the inserted node of: %s but got: %s", expected, got)
+	}
+	tree.Insert("/users/:id")
+
+	// the nodes of the optional named parameters share the metadata.
+	for _, path := range []string{"/posts/2024", "/posts/2024/05"} {
+		n = tree.Search(path, nil)
+		if docs, ok := n.Meta("docs"); !ok || docs != "the posts" {
+			t.Fatalf("%s: expected the docs metadata but got: %v", path, docs)
+		}
+	}
+
+	n.SetMeta("rate", nil)
+	if _, ok := tree.Search("/posts/2024", nil).Meta("rate"); ok {
+		t.Fatalf("expected the rate metadata to be removed")
+	}
+
+	if _, ok := tree.Search("/users/42", nil).Meta("docs"); ok {
+		t.Fatalf("expected no metadata")
+	}
+
+	// the clones have their own metadata.
+	clone := tree.Clone()
+	clone.Search("/posts/2024/05", nil).SetMeta("docs", "the cloned posts")
+	if docs, _ := clone.Search("/posts/2024", nil).Meta("docs"); docs != "the cloned posts" {
+		t.Fatalf("expected the cloned docs metadata but got: %v", docs)
+	}
+	if docs, _ := tree.Search("/posts/2024", nil).Meta("docs"); docs != "the posts" {
+		t.Fatalf("expected the docs metadata but got: %v", docs)
+	}
+
+	merged := NewTrie()
+	merged.Merge(tree, nil)
+	if docs, _ := merged.Search("/posts/2024", nil).Meta("docs"); docs != "the posts" {
+		t.Fatalf("expected the merged docs metadata but got: %v", docs)
+	}
+
+	// a path pattern which is inserted again keeps its metadata.
+	tree.Insert("/posts/:year/:month?")
+	if docs, _ := tree.Search("/posts/2024", nil).Meta("docs"); docs != "the posts" {
+		t.Fatalf("expected the docs metadata to be kept but got: %v", docs)
+	}
+
+	// a deleted one loses it.
+	tree.Delete("/posts/:year/:month?")
+	if _, ok := tree.Insert("/posts/:year/:month?").Meta("docs"); ok {
+		t.Fatalf("expected the metadata to be lost")
+	}
+}
+
+func TestTrieOf(t *testing.T) {
+	type user struct {
+		name string
+	}
+
+	tree := NewTrieOf[*user]()
+	tree.Insert("/users/:id", WithValue(&user{name: "kataras"}))
+	tree.Insert("/users/:id/posts/:slug?", WithValue(&user{name: "posts"}))
+	tree.Insert("/files/*path")
+
+	params := new(paramsWriter)
+	n := tree.Search("/users/42", params)
+	if n == nil || n.Value().name != "kataras" {
+		t.Fatalf("expected the \"/users/42\" to be found")
+	}
+	if expected, got := "42", params.Get("id"); expected != got {
+		t.Fatalf("expected param: %s but got: %s", expected, got)
+	}
+
+	if n := tree.Search("/users/42/posts", new(paramsWriter)); n == nil || n.Value().name != "posts" {
+		t.Fatalf("expected the \"/users/42/posts\" to be found")
+	}
+
+	if n := tree.Search("/files/readme.md", new(paramsWriter)); n == nil || n.Value() != nil {
+		t.Fatalf("expected the \"/files/readme.md\" to be found without a value")
+	}
+
+	if !tree.Delete("/users/:id") || tree.Search("/users/42", new(paramsWriter)) != nil {
+		t.Fatalf("expected the \"/users/:id\" to be deleted")
+	}
+}
+
+func TestTrieCompression(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/api/v1/users/list", WithTag("list"))
+
+	n := tree.root.getChild("api")
+	if n == nil || n.edge != "/v1/users/list" || !n.end || len(tree.root.children) != 1 {
+		t.Fatalf("expected the static path segments to be compressed into one node")
+	}
+
+	tree.Insert("/api/v1/users/:id", WithTag("user"))
+	tree.Insert("/api/v1/posts", WithTag("posts"))
+	tree.Insert("/api/v1", WithTag("v1"))
+	tree.Insert("/api/v2/users/list", WithTag("list2"))
+
+	if api := tree.root.getChild("api"); api == nil || api.edge != "" || len(api.children) != 2 {
+		t.Fatalf("expected the compressed node to be split")
+	}
+	if v1 := tree.SearchPrefix("/api/v1"); v1 == nil || !v1.end || v1.edge != "" || v1.getChild("users").getChild("list") != n {
+		t.Fatalf("expected the end nodes to be kept")
+	}
+	if v2 := tree.SearchPrefix("/api/v2"); v2 == nil || v2.edge != "/users/list" {
+		t.Fatalf("expected the new path to be compressed")
+	}
+
+	expectSearch(t, tree, "/api/v1/users/list", "list", nil)
+	expectSearch(t, tree, "/api/v1/users/42", "user", []ParamEntry{{"id", "42"}})
+	expectSearch(t, tree, "/api/v1/posts", "posts", nil)
+	expectSearch(t, tree, "/api/v1", "v1", nil)
+	expectSearch(t, tree, "/api/v2/users/list", "list2", nil)
+
+	for _, path := range []string{"/api", "/api/v2", "/api/v2/users", "/api/v2/users/lis", "/api/v2/users/list/more", "/api/v2/users/listing"} {
+		if n := tree.Search(path, new(paramsWriter)); n != nil {
+			t.Fatalf("%s: expected to not be found but got: %s", path, n.String())
+		}
+	}
+
+	if n := tree.SearchPrefix("/api/v2/users"); n == nil || n.key != "/api/v2/users/list" {
+		t.Fatalf("expected the prefix to be found inside a compressed node")
+	}
+	if expected, got := []string{"/api/v2/users/list"}, tree.Autocomplete("/api/v2/users", nil); len(got) != 1 || got[0] != expected[0] {
+		t.Fatalf("expected autocomplete: %v but got: %v", expected, got)
+	}
+
+	insensitive := NewTrie()
+	insensitive.CaseInsensitive = true
+	insensitive.Insert("/Docs/Guide/Intro", WithTag("intro"))
+	insensitive.Insert("/docs/guide", WithTag("guide"))
+	expectSearch(t, insensitive, "/DOCS/guide/INTRO", "intro", nil)
+	expectSearch(t, insensitive, "/docs/Guide", "guide", nil)
+	if n := insensitive.Search("/docs/guide/intr", new(paramsWriter)); n != nil {
+		t.Fatalf("expected to not be found but got: %s", n.String())
+	}
+}
+
+func TestTrieLongestPrefix(t *testing.T) {
+	tree := NewTrie()
+	tree.Insert("/api", WithTag("api"))
+	tree.Insert("/api/v1", WithTag("v1"))
+	tree.Insert("/api/v1/users/:id", WithTag("user"))
+	tree.Insert("/api/v1/users/new/form", WithTag("form"))
+	tree.Insert("/static/*file", WithTag("static"))
+	tree.Insert("/tenants/:tenant/*path/meta", WithTag("meta"))
+
+	tests := []struct {
+		path   string
+		tag    string
+		params []ParamEntry
+	}{
+		{"/api", "api", nil},
+		{"/api/", "api", nil},
+		{"/api/v2/users", "api", nil},
+		{"/api/v1/users", "v1", nil},
+		{"/api/v1/users/42/posts/1", "user", []ParamEntry{{"id", "42"}}},
+		{"/api/v1/users/new/form/extra", "form", nil},
+		{"/api/v1/users/new/other", "user", []ParamEntry{{"id", "new"}}},
+		{"/static/css/app.css", "static", []ParamEntry{{"file", "css/app.css"}}},
+		{"/tenants/acme/a/b/meta/more", "meta", []ParamEntry{{"tenant", "acme"}, {"path", "a/b"}}},
+	}
+
+	for _, tt := range tests {
+		pw := new(paramsWriter)
+		n := tree.LongestPrefix(tt.path, pw)
+		if n == nil {
+			t.Fatalf("%s: expected a prefix to be found", tt.path)
+		}
+
+		if expected, got := tt.tag, n.Tag; expected != got {
+			t.Fatalf("%s: expected tag: %s but got: %s", tt.path, expected, got)
+		}
+
+		if expected, got := len(tt.params), len(pw.params); expected != got {
+			t.Fatalf("%s: expected %d params but got %d", tt.path, expected, got)
+		}
+		for i, p := range tt.params {
+			if got := pw.params[i]; p != got {
+				t.Fatalf("%s: expected param %v but got %v", tt.path, p, got)
+			}
+		}
+	}
+
+	// a nil ParamsSetter discards the parameters.
+	if n := tree.LongestPrefix("/api/v1/users/42", nil); n == nil || n.Tag != "user" {
+		t.Fatalf("expected the user node without params")
+	}
+
+	for _, path := range []string{"/", "/apix", "/users", "/tenants/acme"} {
+		if n := tree.LongestPrefix(path, new(paramsWriter)); n != nil {
+			t.Fatalf("%s: expected to not be found but got: %s", path, n.String())
+		}
+	}
+
+	tree.Insert("/", WithTag("index"))
+	if n := tree.LongestPrefix("/users", new(paramsWriter)); n == nil || n.Tag != "index" {
+		t.Fatalf("expected the root to be the longest prefix")
+	}
+}
+
+func TestTrieMerge(t *testing.T) {
+	main := NewTrie()
+	main.Insert("/", WithTag("index"))
+	main.Insert("/users/:id", WithTag("user"))
+	main.Insert("/files/*path", WithTag("files"))
+
+	plugin := NewTrie()
+	plugin.Insert("/users/:name", WithTag("plugin user"))
+	plugin.Insert("/files/*path", WithTag("plugin files"))
+	plugin.Insert("/plugins/:plugin/settings/:section?", WithTag("settings"), WithData(42))
+	plugin.Insert("/health", WithMethodHandler("GET", http.NotFoundHandler()), WithPriority(1))
+
+	var conflicts []string
+	main.Merge(plugin, func(existing, incoming *Node) bool {
+		conflicts = append(conflicts, existing.String()+" "+incoming.String())
+		return incoming.Tag == "plugin files"
+	})
+
+	if expected, got := "/files/*path /files/*path,/users/:id /users/:name", strings.Join(conflicts, ","); expected != got {
+		t.Fatalf("expected conflicts: %s but got: %s", expected, got)
+	}
+
+	expectSearch(t, main, "/", "index", nil)
+	expectSearch(t, main, "/users/42", "user", []ParamEntry{{"id", "42"}})
+	expectSearch(t, main, "/files/a.txt", "plugin files", []ParamEntry{{"path", "a.txt"}})
+	expectSearch(t, main, "/plugins/auth/settings", "settings", []ParamEntry{{"plugin", "auth"}})
+	n := expectSearch(t, main, "/plugins/auth/settings/keys", "settings", []ParamEntry{{"plugin", "auth"}, {"section", "keys"}})
+	if n.Data != 42 {
+		t.Fatalf("expected the data to be merged")
+	}
+
+	n = expectSearch(t, main, "/health", "", nil)
+	if _, ok := n.MethodHandler(http.MethodGet); !ok || n.priority != 1 || !main.hasPriority {
+		t.Fatalf("expected the method handlers and the priority to be merged")
+	}
+
+	// the other trie is not modified.
+	expectSearch(t, plugin, "/users/42", "plugin user", []ParamEntry{{"name", "42"}})
+
+	// the existing ones are kept without a callback.
+	main.Merge(plugin, nil)
+	expectSearch(t, main, "/users/42", "user", []ParamEntry{{"id", "42"}})
+}
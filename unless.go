@@ -0,0 +1,49 @@
+package muxie
+
+import (
+	"net/http"
+)
+
+// Unless returns a middleware which runs the "middleware" only for the requests that the "matcher" does not match,
+// the rest ones are served by the next handler directly, i.e:
+// mux.Use(muxie.Unless(muxie.Paths("/health", "/assets/*filepath"), authMiddleware))
+// mux.Use(muxie.Unless(muxie.MatcherFunc(isInternal), muxie.RateLimiter(limit)))
+//
+// See `Wrapper#Skip` too.
+func Unless(matcher Matcher, middleware Wrapper) Wrapper {
+	if matcher == nil || middleware == nil {
+		panic("muxie/Unless: empty matcher or middleware")
+	}
+
+	return func(next http.Handler) http.Handler {
+		wrapped := middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matcher.Match(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Skip returns a middleware which runs this one except for the requests of the given path patterns, see `Paths`, i.e:
+// mux.Use(muxie.BasicAuth(validator).Skip("/health", "/assets/*filepath"))
+func (w Wrapper) Skip(patterns ...string) Wrapper {
+	return Unless(Paths(patterns...), w)
+}
+
+// Paths returns a Matcher which matches the requests that their path matches one of the path patterns,
+// they can have named parameters and wildcards, i.e "/health", "/users/:id/avatar" or "/assets/*filepath".
+// It panics if a pattern is not valid, see `Trie#Insert`.
+func Paths(patterns ...string) Matcher {
+	t := NewTrie()
+	for _, pattern := range patterns {
+		t.Insert(pattern)
+	}
+
+	return MatcherFunc(func(r *http.Request) bool {
+		return t.Search(r.URL.Path, discardParams{}) != nil
+	})
+}
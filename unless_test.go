@@ -0,0 +1,57 @@
+package muxie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUnless(t *testing.T) {
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		})
+	}
+
+	mux := NewMux()
+	mux.Use(Wrapper(deny).Skip("/health", "/assets/*filepath", "/users/:id/avatar"))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}
+	mux.HandleFunc("/health", handler)
+	mux.HandleFunc("/assets/*filepath", handler)
+	mux.HandleFunc("/users/:id/avatar", handler)
+	mux.HandleFunc("/users/:id", handler)
+	mux.HandleFunc("/admin", handler)
+
+	var tests = []struct {
+		path   string
+		status int
+	}{
+		{"/health", http.StatusOK},
+		{"/assets/js/app.js", http.StatusOK},
+		{"/users/42/avatar", http.StatusOK},
+		{"/users/42", http.StatusForbidden},
+		{"/admin", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		testHandler(t, mux, http.MethodGet, tt.path).statusCode(tt.status)
+	}
+
+	internal := NewMux()
+	internal.Use(Unless(MatcherFunc(func(r *http.Request) bool {
+		return r.Header.Get("X-Internal") == "true"
+	}), deny))
+	internal.HandleFunc("/", handler)
+
+	testHandler(t, internal, http.MethodGet, "/").statusCode(http.StatusForbidden)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Internal", "true")
+	internal.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the matched request to skip the middleware but got: %d", w.Code)
+	}
+}
@@ -0,0 +1,157 @@
+package muxie
+
+import (
+	"net/http"
+	"strings"
+)
+
+// VersionResolver is the type of the `Mux#VersionResolver` field,
+// it returns the API version of a request or empty if the request does not specify one.
+type VersionResolver func(r *http.Request) string
+
+// HeaderVersion returns a `VersionResolver` which reads the API version from a request header, i.e "X-API-Version: 2".
+func HeaderVersion(key string) VersionResolver {
+	if key == "" {
+		panic("muxie/HeaderVersion: empty header key")
+	}
+
+	key = http.CanonicalHeaderKey(key)
+	return func(r *http.Request) string {
+		return r.Header.Get(key)
+	}
+}
+
+// AcceptVersion returns a `VersionResolver` which reads the API version from the vendor media types
+// of the "Accept" header, i.e "Accept: application/vnd.api.v2+json" for the "vnd.api" vendor.
+func AcceptVersion(vendor string) VersionResolver {
+	if vendor == "" {
+		panic("muxie/AcceptVersion: empty vendor")
+	}
+
+	prefix := strings.ToLower(vendor) + ".v"
+	return func(r *http.Request) string {
+		for _, mediaType := range strings.Split(r.Header.Get("Accept"), ",") {
+			slashIdx := strings.IndexByte(mediaType, '/')
+			if slashIdx == -1 {
+				continue
+			}
+
+			subtype := strings.ToLower(strings.TrimSpace(mediaType[slashIdx+1:]))
+			if !strings.HasPrefix(subtype, prefix) {
+				continue
+			}
+
+			version := subtype[len(prefix):]
+			if end := strings.IndexAny(version, "+;"); end != -1 {
+				version = version[:end]
+			}
+
+			if version = strings.TrimSpace(version); version != "" {
+				return version
+			}
+		}
+
+		return ""
+	}
+}
+
+// FirstVersion returns a `VersionResolver` which returns the first non-empty version of the given resolvers, i.e:
+// mux.VersionResolver = muxie.FirstVersion(muxie.HeaderVersion("X-API-Version"), muxie.AcceptVersion("vnd.api"))
+func FirstVersion(resolvers ...VersionResolver) VersionResolver {
+	return func(r *http.Request) string {
+		for _, resolver := range resolvers {
+			if version := resolver(r); version != "" {
+				return version
+			}
+		}
+
+		return ""
+	}
+}
+
+// VersionOption is the type of the options that `Mux#Version` accepts.
+type VersionOption func(*versionMatcher)
+
+// DefaultVersion is a `VersionOption` which makes the version's routes to serve the requests that do not specify a version.
+// It has not effect when the versions are resolved by their path prefix, see `Mux#VersionResolver`.
+func DefaultVersion() VersionOption {
+	return func(v *versionMatcher) {
+		v.isDefault = true
+	}
+}
+
+// Deprecated is a `VersionOption` which marks the version as deprecated,
+// the "hook" is executed before the handlers of the version's routes, i.e to add a "Sunset" header or to log the request.
+// The "Deprecation: true" response header is set before the "hook", which can be nil.
+func Deprecated(hook func(w http.ResponseWriter, r *http.Request)) VersionOption {
+	return func(v *versionMatcher) {
+		v.deprecated = true
+		v.deprecationHook = hook
+	}
+}
+
+// versionMatcher matches the requests of a specific API version, see `Mux#Version`.
+type versionMatcher struct {
+	mux     *Mux
+	version string
+
+	isDefault       bool
+	deprecated      bool
+	deprecationHook func(w http.ResponseWriter, r *http.Request)
+}
+
+func (v *versionMatcher) Match(r *http.Request) bool {
+	version := normalizeVersion(v.mux.origin().VersionResolver(r))
+	return version == v.version || (version == "" && v.isDefault)
+}
+
+// normalizeVersion removes the "v" prefix of a version, i.e "v2" to "2".
+func normalizeVersion(version string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+}
+
+// Version returns a new Mux which its routes are matched only by the requests of the given API version, i.e:
+// mux := NewMux()
+// mux.VersionResolver = muxie.HeaderVersion("X-API-Version")
+// v1 := mux.Version("1", muxie.Deprecated(nil))
+// v1.HandleFunc("/users", usersV1)
+// v2 := mux.Version("2", muxie.DefaultVersion())
+// v2.HandleFunc("/users", usersV2)
+//
+// The versions are resolved by the `VersionResolver` once per Mux.
+// When it is nil the routes are registered under the version's path prefix instead, i.e "/v2/users".
+// A route of a version and an unversioned route of the same path can live together,
+// the unversioned one serves the requests of unknown versions, see `Route#When` too.
+func (m *Mux) Version(version string, options ...VersionOption) SubMux {
+	if version = normalizeVersion(version); version == "" {
+		panic("muxie/Mux#Version: empty version")
+	}
+
+	v := &versionMatcher{mux: m, version: version}
+	for _, opt := range options {
+		opt(v)
+	}
+
+	var versionMux *Mux
+	if m.origin().VersionResolver == nil {
+		versionMux = m.child(m.root + pathSep + "v" + version)
+	} else {
+		versionMux = m.child(m.root)
+		versionMux.version = v
+	}
+
+	if v.deprecated {
+		versionMux.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Deprecation", "true")
+				if v.deprecationHook != nil {
+					v.deprecationHook(w, r)
+				}
+
+				next.ServeHTTP(w, r)
+			})
+		})
+	}
+
+	return versionMux
+}
@@ -0,0 +1,67 @@
+package muxie
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func writeAPIVersion(version string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", version, GetParam(w, "id"))
+	}
+}
+
+func TestMuxVersion(t *testing.T) {
+	mux := NewMux()
+	mux.VersionResolver = FirstVersion(HeaderVersion("X-API-Version"), AcceptVersion("vnd.api"))
+
+	var deprecatedRequests int
+	v1 := mux.Version("v1", Deprecated(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Sunset", "Wed, 11 Nov 2026 23:59:59 GMT")
+		deprecatedRequests++
+	}))
+	v1.HandleFunc("/users/:id", writeAPIVersion("v1"))
+
+	v2 := mux.Version("2", DefaultVersion())
+	v2.HandleFunc("/users/:id", writeAPIVersion("v2"))
+	v2.Of("/admin").HandleMethodFunc(http.MethodGet, "/users/:id", writeAPIVersion("v2 admin"))
+
+	mux.HandleFunc("/users/:id", writeAPIVersion("unversioned"))
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("X-API-Version", "1")).
+		statusCode(http.StatusOK).bodyEq("v1 42").headerEq("Deprecation", "true").headerEq("Sunset", "Wed, 11 Nov 2026 23:59:59 GMT")
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("Accept", "application/vnd.api.v1+json")).
+		statusCode(http.StatusOK).bodyEq("v1 42").headerEq("Deprecation", "true")
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("Accept", "application/vnd.api.v2+json")).
+		statusCode(http.StatusOK).bodyEq("v2 42").headerEq("Deprecation", "")
+	expect(t, http.MethodGet, srv.URL+"/users/42").
+		statusCode(http.StatusOK).bodyEq("v2 42")
+	expect(t, http.MethodGet, srv.URL+"/users/42", withHeader("X-API-Version", "3")).
+		statusCode(http.StatusOK).bodyEq("unversioned 42")
+	expect(t, http.MethodGet, srv.URL+"/admin/users/42", withHeader("X-API-Version", "v2")).
+		statusCode(http.StatusOK).bodyEq("v2 admin 42")
+	expect(t, http.MethodGet, srv.URL+"/admin/users/42", withHeader("X-API-Version", "1")).
+		statusCode(http.StatusNotFound)
+
+	if expected, got := 2, deprecatedRequests; expected != got {
+		t.Fatalf("expected the deprecation hook to be executed %d times but %d", expected, got)
+	}
+}
+
+func TestMuxVersionPathPrefix(t *testing.T) {
+	mux := NewMux()
+	mux.Version("1", Deprecated(nil)).HandleFunc("/users/:id", writeAPIVersion("v1"))
+	mux.Version("2").HandleFunc("/users/:id", writeAPIVersion("v2"))
+
+	testHandler(t, mux, http.MethodGet, "/v1/users/42").
+		statusCode(http.StatusOK).bodyEq("v1 42").headerEq("Deprecation", "true")
+	testHandler(t, mux, http.MethodGet, "/v2/users/42").
+		statusCode(http.StatusOK).bodyEq("v2 42")
+	testHandler(t, mux, http.MethodGet, "/users/42").
+		statusCode(http.StatusNotFound)
+}